@@ -1,40 +1,55 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
+	"net"
 	"net/http"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
 
+	"github.com/bank-service/internal/config"
+	"github.com/bank-service/internal/envelope"
+	"github.com/bank-service/internal/exchange"
 	"github.com/bank-service/internal/handlers"
+	"github.com/bank-service/internal/lifecycle"
 	"github.com/bank-service/internal/middleware"
+	"github.com/bank-service/internal/migrator"
+	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/pan"
+	"github.com/bank-service/internal/policy"
 	"github.com/bank-service/internal/repositories"
 	"github.com/bank-service/internal/services"
-	"github.com/gorilla/mux"
+	"github.com/bank-service/migrations"
 	_ "github.com/lib/pq"
 	"github.com/sirupsen/logrus"
 )
 
-const (
-	dbHost     = "localhost"
-	dbPort     = 5436
-	dbUser     = "test"
-	dbPassword = "test"
-	dbName     = "bank_service"
-	jwtSecret  = "your_jwt_secret"
-	hmacSecret = "your_hmac_secret"
-)
+// tokenCleanupInterval — период удаления истёкших сессий и записей об отозванных токенах
+const tokenCleanupInterval = time.Hour
 
 func main() {
+	configPath := flag.String("config", "", "путь к YAML-файлу конфигурации (по умолчанию BANK_CONFIG_FILE)")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		logrus.Fatal("Failed to load configuration: ", err)
+	}
+
 	// Инициализация логгера
-	logger := logrus.New()
-	logger.SetFormatter(&logrus.JSONFormatter{})
-	logger.SetLevel(logrus.DebugLevel)
+	logger := cfg.NewLogger()
+	logger.Info("Starting with profile ", cfg.Profile)
+	logger.Debug("Configuration:\n", cfg)
 
 	// Подключение к базе данных с указанием search_path
-	connStr := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable search_path=bank",
-		dbHost, dbPort, dbUser, dbPassword, dbName)
-	logger.Debug("Connecting to database with connection string: ", connStr)
-	db, err := sql.Open("postgres", connStr)
+	logger.Debug("Connecting to database with connection string: ", cfg.RedactedDSN())
+	db, err := sql.Open("postgres", cfg.DSN())
 	if err != nil {
 		logger.Fatal("Failed to connect to database: ", err)
 	}
@@ -47,11 +62,32 @@ func main() {
 	}
 	logger.Info("Database connection established")
 
+	schemaMigrator, err := migrator.New(db, migrations.FS, logger)
+	if err != nil {
+		logger.Fatal("Failed to load migrations: ", err)
+	}
+
+	// Подкоманда migrate управляет схемой и не запускает сервер
+	if args := flag.Args(); len(args) > 0 && args[0] == "migrate" {
+		if err := runMigrateCommand(context.Background(), schemaMigrator, args[1:]); err != nil {
+			logger.Fatal("Migration command failed: ", err)
+		}
+		return
+	}
+
+	// Соединение с базой закрывается последним, после остановки сервера и воркеров
+	manager := lifecycle.NewManager(logger)
+	manager.OnStop("database", func(ctx context.Context) error {
+		return db.Close()
+	})
+
 	// Выполнение миграций
 	logger.Debug("Running migrations")
-	if err := runMigrations(db, logger); err != nil {
+	applied, err := schemaMigrator.Up(context.Background())
+	if err != nil {
 		logger.Fatal("Failed to run migrations: ", err)
 	}
+	logger.Info("Database migrations completed successfully, applied: ", applied)
 
 	// Инициализация репозиториев
 	userRepo := repositories.NewUserRepository(db)
@@ -59,154 +95,352 @@ func main() {
 	transactionRepo := repositories.NewTransactionRepository(db)
 	cardRepo := repositories.NewCardRepository(db)
 	creditRepo := repositories.NewCreditRepository(db)
+	ledgerRepo := repositories.NewLedgerRepository(db)
+	idempotencyRepo := repositories.NewIdempotencyRepository(db)
+	holdRepo := repositories.NewHoldRepository(db)
+	tokenRepo := repositories.NewTokenRepository(db)
+	adminActionRepo := repositories.NewAdminActionRepository(db)
+	cardAuthorizationRepo := repositories.NewCardAuthorizationRepository(db)
+	limitRepo := repositories.NewLimitRepository(db)
 
 	// Инициализация сервисов
-	userService := services.NewUserService(userRepo, jwtSecret)
-	accountService := services.NewAccountService(accountRepo, userRepo, transactionRepo, db)
-	cardService := services.NewCardService(cardRepo, accountRepo, hmacSecret)
-	creditService := services.NewCreditService(creditRepo, userRepo)
+	userService := services.NewUserService(userRepo, tokenRepo, cfg.Security.JWTSecret.Value(), cfg.Security.AccessTokenTTL, cfg.Security.RefreshTokenTTL, db)
+	var rateProvider exchange.Provider = exchange.NewCBRProvider(cfg.Exchange.CBRURL, nil)
+	if cfg.Exchange.RatesFile != "" {
+		staticProvider, err := exchange.LoadStaticProvider(cfg.Exchange.RatesFile)
+		if err != nil {
+			logger.Fatal("Failed to load exchange rates: ", err)
+		}
+		rateProvider = staticProvider
+	}
+	// Ключи шифрования реквизитов карт; в dev-профиле файл создаётся автоматически
+	if cfg.Profile == config.ProfileDev {
+		if err := envelope.GenerateKeyFile(cfg.Security.CardKeyFile, "dev"); err == nil {
+			logger.Warn("Generated development card key file ", cfg.Security.CardKeyFile)
+		} else if !errors.Is(err, os.ErrExist) {
+			logger.Fatal("Failed to generate card key file: ", err)
+		}
+	}
+	cardKeys, err := envelope.LoadKeyRing(cfg.Security.CardKeyFile)
+	if err != nil {
+		logger.Fatal("Failed to load card key file: ", err)
+	}
+
+	ownership := policy.New(accountRepo, cardRepo, creditRepo, holdRepo)
+	ledgerService := services.NewLedgerService(ledgerRepo, accountRepo)
+	defaultLimits, err := newDefaultLimits(cfg.Limits)
+	if err != nil {
+		logger.Fatal("Invalid default limits: ", err)
+	}
+	limitService := services.NewLimitService(limitRepo, cardRepo, accountRepo, userRepo, ownership, defaultLimits, db)
+	accountService := services.NewAccountService(accountRepo, userRepo, transactionRepo, holdRepo, ledgerService, limitService, rateProvider, ownership, db)
+	holdService := services.NewHoldService(holdRepo, accountRepo, transactionRepo, ledgerService, limitService, db)
+	cardProducts, err := newCardProducts(cfg.Cards)
+	if err != nil {
+		logger.Fatal("Invalid card products: ", err)
+	}
+	cardService := services.NewCardService(cardRepo, ownership, holdService, cardKeys, cardProducts, cfg.Cards.DefaultProduct, cfg.Security.HMACSecret.Value(), db)
+	cardProcessingService := services.NewCardProcessingService(cardAuthorizationRepo, cardRepo, accountRepo, holdRepo, ledgerRepo, transactionRepo, ledgerService, limitService, cfg.Security.HMACSecret.Value(), db)
+	overduePolicy, err := newOverduePolicy(cfg.Credits)
+	if err != nil {
+		logger.Fatal("Invalid credit penalty settings: ", err)
+	}
+	creditService := services.NewCreditService(creditRepo, userRepo, accountService, ownership, overduePolicy, db)
+	paymentService := services.NewPaymentService(accountService, ownership)
+	adminService := services.NewAdminService(userRepo, accountRepo, transactionRepo, holdRepo, ledgerRepo, tokenRepo, adminActionRepo, accountService, cardService, limitService, ledgerService, db)
+
+	// Подкоманда role назначает роль пользователю; нужна, чтобы завести первого администратора
+	if args := flag.Args(); len(args) > 0 && args[0] == "role" {
+		if err := runRoleCommand(context.Background(), userRepo, adminService, args[1:]); err != nil {
+			logger.Fatal("Role command failed: ", err)
+		}
+		return
+	}
+
+	// Сверка журнала двойной записи с остатками счетов
+	logger.Debug("Verifying ledger")
+	if err := ledgerService.VerifyBooks(context.Background()); err != nil {
+		logger.Error("Ledger verification failed: ", err)
+	} else {
+		logger.Info("Ledger is balanced")
+	}
+
+	// Карты, выпущенные до перехода на шифрование, шифруются при первом запуске
+	encrypted, err := cardService.EncryptLegacyCards(context.Background())
+	if err != nil {
+		logger.Fatal("Failed to encrypt card data: ", err)
+	}
+	if encrypted > 0 {
+		logger.Info("Encrypted legacy cards: ", encrypted)
+	}
+	// HMAC номера нужен для проверки уникальности; у ранее заведённых карт он пересчитывается
+	rehashed, duplicates, err := cardService.RehashLegacyCards(context.Background())
+	if err != nil {
+		logger.Fatal("Failed to rehash card numbers: ", err)
+	}
+	if rehashed > 0 {
+		logger.Info("Rehashed legacy cards: ", rehashed)
+	}
+	if duplicates > 0 {
+		logger.Warn("Legacy cards with duplicate numbers left without HMAC: ", duplicates)
+	}
 
 	// Инициализация обработчиков
 	userHandler := handlers.NewUserHandler(userService, logger)
 	accountHandler := handlers.NewAccountHandler(accountService, logger)
 	cardHandler := handlers.NewCardHandler(cardService, logger)
 	creditHandler := handlers.NewCreditHandler(creditService, logger)
+	holdHandler := handlers.NewHoldHandler(holdService, ownership, logger)
+	paymentHandler := handlers.NewPaymentHandler(paymentService, logger)
+	adminHandler := handlers.NewAdminHandler(adminService, logger)
+	limitHandler := handlers.NewLimitHandler(limitService, logger)
+
+	// Фоновое снятие просроченных холдов
+	manager.Every("hold expiry", cfg.Holds.ExpiryInterval, func(ctx context.Context) error {
+		expired, err := holdService.ExpireHolds(ctx)
+		if err != nil {
+			return err
+		}
+		if expired > 0 {
+			logger.Info("Expired holds: ", expired)
+		}
+		return nil
+	})
+
+	// Перевод карт с истёкшим сроком действия в статус expired
+	manager.Every("card expiry", cfg.Cards.ExpiryInterval, func(ctx context.Context) error {
+		expired, err := cardService.ExpireCards(ctx)
+		if err != nil {
+			return err
+		}
+		if expired > 0 {
+			logger.Info("Expired cards: ", expired)
+		}
+		return nil
+	})
+
+	// Автосписание наступивших платежей по кредитам, затем начисление неустойки
+	// и смена статусов по платежам, которые списать не удалось. Неустойка начисляется,
+	// даже если часть списаний завершилась ошибкой.
+	manager.Every("credit repayment", cfg.Credits.RepaymentInterval, func(ctx context.Context) error {
+		collected, collectErr := creditService.CollectDuePayments(ctx)
+		if collected > 0 {
+			logger.Info("Collected credit payments: ", collected)
+		}
+		if collectErr != nil && ctx.Err() == nil {
+			logger.Error("Failed to collect some credit payments: ", collectErr)
+		}
+		accrued, err := creditService.ProcessOverdue(ctx)
+		if accrued > 0 {
+			logger.Info("Accrued credit penalties: ", accrued)
+		}
+		return err
+	})
+
+	// Очистка истёкших сессий и отозванных токенов
+	manager.Every("token cleanup", tokenCleanupInterval, func(ctx context.Context) error {
+		deleted, err := userService.PurgeExpiredTokens(ctx)
+		if err != nil {
+			return err
+		}
+		if deleted > 0 {
+			logger.Info("Purged expired tokens: ", deleted)
+		}
+		return nil
+	})
 
-	// Создание маршрутизатора
-	router := mux.NewRouter()
-
-	// Публичные эндпоинты
-	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte("OK"))
-	}).Methods("GET")
-	router.HandleFunc("/register", userHandler.Register).Methods("POST")
-	router.HandleFunc("/login", userHandler.Login).Methods("POST")
-
-	// Защищенные эндпоинты
-	protected := router.PathPrefix("/").Subrouter()
-	protected.Use(middleware.AuthMiddleware(jwtSecret, logger))
-	protected.HandleFunc("/profile", userHandler.Profile).Methods("GET")
-	protected.HandleFunc("/accounts", accountHandler.CreateAccount).Methods("POST")
-	protected.HandleFunc("/accounts", accountHandler.GetAccounts).Methods("GET")
-	protected.HandleFunc("/accounts/{id}/deposit", accountHandler.Deposit).Methods("POST")
-	protected.HandleFunc("/accounts/{id}/withdraw", accountHandler.Withdraw).Methods("POST")
-	protected.HandleFunc("/accounts/{id}/transactions", accountHandler.GetTransactions).Methods("GET")
-	protected.HandleFunc("/transfer", accountHandler.Transfer).Methods("POST")
-	protected.HandleFunc("/cards", cardHandler.CreateCard).Methods("POST")
-	protected.HandleFunc("/accounts/{account_id}/cards", cardHandler.GetCards).Methods("GET")
-	protected.HandleFunc("/credits", creditHandler.CreateCredit).Methods("POST")
-	protected.HandleFunc("/credits", creditHandler.GetCredits).Methods("GET")
-	protected.HandleFunc("/credits/{credit_id}/payment-schedules", creditHandler.GetPaymentSchedules).Methods("GET")
-
-	// Настройка сервера
+	// Удаление истёкших ключей идемпотентности и сохранённых ответов
+	manager.Every("idempotency key cleanup", cfg.Idempotency.CleanupInterval, func(ctx context.Context) error {
+		deleted, err := idempotencyRepo.DeleteExpired(ctx, time.Now())
+		if err != nil {
+			return err
+		}
+		if deleted > 0 {
+			logger.Info("Purged expired idempotency keys: ", deleted)
+		}
+		return nil
+	})
+
+	// Создание маршрутизатора. Операции с деньгами защищены от повторного выполнения
+	// при повторе запроса клиентом
+	auth := middleware.AuthMiddleware(cfg.Security.JWTSecret.Value(), tokenRepo, logger)
+	idempotent := middleware.IdempotencyMiddleware(idempotencyRepo, cfg.Idempotency.KeyTTL, cfg.Idempotency.ProcessingTimeout, logger)
+	router := newRouter(apiHandlers{
+		user:    userHandler,
+		account: accountHandler,
+		card:    cardHandler,
+		credit:  creditHandler,
+		hold:    holdHandler,
+		payment: paymentHandler,
+		admin:   adminHandler,
+		limit:   limitHandler,
+	}, auth, idempotent, logger)
+
+	// Настройка сервера. Контекст запросов не отменяется при остановке:
+	// начатые переводы дорабатывают до конца в пределах ShutdownTimeout.
 	server := &http.Server{
-		Addr:    ":8080",
-		Handler: router,
+		Addr:              cfg.Server.Addr,
+		Handler:           router,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
 	}
 
-	logger.Info("Starting server on :8080")
-	if err := server.ListenAndServe(); err != nil {
-		logger.Fatal("Server failed to start: ", err)
+	// Симулятор процессинга принимает сообщения ISO 8583 от тестовых терминалов
+	if cfg.ISO8583.Addr != "" {
+		listener, err := net.Listen("tcp", cfg.ISO8583.Addr)
+		if err != nil {
+			logger.Fatal("Failed to start ISO 8583 simulator: ", err)
+		}
+		iso8583Handler := handlers.NewISO8583Handler(cardProcessingService, logger)
+		logger.Info("Starting ISO 8583 simulator on ", cfg.ISO8583.Addr)
+		manager.Go("iso8583 simulator", func(ctx context.Context) error {
+			return iso8583Handler.Serve(ctx, listener)
+		})
 	}
+
+	logger.Info("Starting server on ", cfg.Server.Addr)
+	manager.Go("http listener", func(ctx context.Context) error {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	})
+	// Сервер перестаёт принимать соединения и ждёт завершения обрабатываемых запросов;
+	// если они не уложились в срок, оставшиеся соединения закрываются принудительно
+	manager.OnStop("http server", func(ctx context.Context) error {
+		if err := server.Shutdown(ctx); err != nil {
+			_ = server.Close()
+			return err
+		}
+		return nil
+	})
+
+	if err := manager.Wait(cfg.Server.ShutdownTimeout); err != nil {
+		logger.Fatal("Server failed: ", err)
+	}
+	logger.Info("Server stopped")
 }
 
-func runMigrations(db *sql.DB, logger *logrus.Logger) error {
-	logger.Debug("Creating schema bank")
-	_, err := db.Exec("CREATE SCHEMA IF NOT EXISTS bank")
-	if err != nil {
-		return fmt.Errorf("failed to create schema bank: %w", err)
-	}
-
-	logger.Debug("Creating table bank.users")
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS bank.users (
-			id BIGSERIAL PRIMARY KEY,
-			username VARCHAR(50) UNIQUE NOT NULL,
-			email VARCHAR(255) UNIQUE NOT NULL,
-			password TEXT NOT NULL,
-			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
-		)`)
-	if err != nil {
-		return fmt.Errorf("failed to create bank.users table: %w", err)
-	}
-
-	logger.Debug("Creating table bank.accounts")
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS bank.accounts (
-			id BIGSERIAL PRIMARY KEY,
-			user_id BIGINT REFERENCES bank.users(id) ON DELETE CASCADE,
-			balance NUMERIC(15, 2) DEFAULT 0.0,
-			currency VARCHAR(3) DEFAULT 'RUB',
-			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
-		)`)
-	if err != nil {
-		return fmt.Errorf("failed to create bank.accounts table: %w", err)
-	}
-
-	logger.Debug("Creating table bank.cards")
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS bank.cards (
-			id BIGSERIAL PRIMARY KEY,
-			account_id BIGINT REFERENCES bank.accounts(id) ON DELETE CASCADE,
-			card_number TEXT NOT NULL,
-			expiry_date TEXT NOT NULL,
-			cvv TEXT NOT NULL,
-			hmac TEXT NOT NULL,
-			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
-		)`)
+// runMigrateCommand выполняет подкоманду migrate up|down [N]|status
+func runMigrateCommand(ctx context.Context, m *migrator.Migrator, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: migrate up|down [N]|status")
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := m.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Applied %d migration(s)\n", applied)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				return errors.New("number of steps must be a positive integer")
+			}
+			steps = n
+		}
+		reverted, err := m.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Reverted %d migration(s)\n", reverted)
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, status := range statuses {
+			state, appliedAt := "pending", ""
+			if status.AppliedAt != nil {
+				state, appliedAt = "applied", status.AppliedAt.Format(time.RFC3339)
+			}
+			if status.Modified {
+				state = "modified"
+			}
+			if status.Missing {
+				state = "missing"
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+	return nil
+}
+
+// runRoleCommand выполняет подкоманду role <email> <role>
+func runRoleCommand(ctx context.Context, userRepo repositories.UserRepository, adminService services.AdminService, args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: role <email> customer|operator|admin|auditor")
+	}
+
+	user, err := userRepo.FindByEmail(ctx, args[0])
 	if err != nil {
-		return fmt.Errorf("failed to create bank.cards table: %w", err)
-	}
-
-	logger.Debug("Creating table bank.transactions")
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS bank.transactions (
-			id BIGSERIAL PRIMARY KEY,
-			account_id BIGINT REFERENCES bank.accounts(id) ON DELETE CASCADE,
-			amount NUMERIC(15, 2) NOT NULL,
-			type VARCHAR(50) NOT NULL,
-			description TEXT,
-			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
-		)`)
+		return err
+	}
+	if user == nil {
+		return fmt.Errorf("user %q not found", args[0])
+	}
+	user, err = adminService.SetUserRole(ctx, 0, user.ID, args[1])
 	if err != nil {
-		return fmt.Errorf("failed to create bank.transactions table: %w", err)
-	}
-
-	logger.Debug("Creating table bank.credits")
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS bank.credits (
-			id BIGSERIAL PRIMARY KEY,
-			user_id BIGINT REFERENCES bank.users(id) ON DELETE CASCADE,
-			amount NUMERIC(15, 2) NOT NULL,
-			interest_rate NUMERIC(5, 2) NOT NULL,
-			term_months INTEGER NOT NULL,
-			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
-		)`)
+		return err
+	}
+	fmt.Printf("User %s (id %d) now has role %s\n", user.Email, user.ID, user.Role)
+	return nil
+}
+
+// newCardProducts разбирает карточные продукты из конфигурации
+func newCardProducts(cfg config.CardsConfig) (map[string]services.CardProduct, error) {
+	products := make(map[string]services.CardProduct, len(cfg.Products))
+	for name, productCfg := range cfg.Products {
+		product := services.CardProduct{ValidityMonths: productCfg.ValidityMonths}
+		for _, binRange := range productCfg.BINRanges {
+			r, err := pan.ParseRange(binRange)
+			if err != nil {
+				return nil, fmt.Errorf("product %s: %w", name, err)
+			}
+			product.BINRanges = append(product.BINRanges, r)
+		}
+		products[name] = product
+	}
+	return products, nil
+}
+
+// newOverduePolicy переводит настройки неустойки из конфигурации в условия обслуживания просрочки
+func newOverduePolicy(cfg config.CreditsConfig) (services.OverduePolicy, error) {
+	fee, err := cfg.Penalty.Fee()
 	if err != nil {
-		return fmt.Errorf("failed to create bank.credits table: %w", err)
-	}
-
-	logger.Debug("Creating table bank.payment_schedules")
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS bank.payment_schedules (
-			id BIGSERIAL PRIMARY KEY,
-			credit_id BIGINT REFERENCES bank.credits(id) ON DELETE CASCADE,
-			payment_date DATE NOT NULL,
-			amount NUMERIC(15, 2) NOT NULL,
-			paid BOOLEAN DEFAULT FALSE,
-			penalty NUMERIC(15, 2) DEFAULT 0.0,
-			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
-		)`)
+		return services.OverduePolicy{}, fmt.Errorf("fixed_fee: %w", err)
+	}
+	rate, err := cfg.Penalty.Rate()
 	if err != nil {
-		return fmt.Errorf("failed to create bank.payment_schedules table: %w", err)
+		return services.OverduePolicy{}, fmt.Errorf("daily_rate: %w", err)
 	}
+	return services.OverduePolicy{
+		PenaltyFee:       fee,
+		PenaltyDailyRate: rate,
+		DefaultAfterDays: cfg.DefaultAfterDays,
+	}, nil
+}
 
-	logger.Info("Database migrations completed successfully")
-	return nil
+// newDefaultLimits переводит лимиты по умолчанию из конфигурации в модели
+func newDefaultLimits(cfg config.LimitsConfig) ([]*models.SpendingLimit, error) {
+	limits := make([]*models.SpendingLimit, 0, len(cfg.Defaults))
+	for i, limitCfg := range cfg.Defaults {
+		limit, err := limitCfg.Limit()
+		if err != nil {
+			return nil, fmt.Errorf("limit %d: %w", i, err)
+		}
+		limits = append(limits, limit)
+	}
+	return limits, nil
 }
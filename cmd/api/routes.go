@@ -0,0 +1,102 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/bank-service/internal/handlers"
+	"github.com/bank-service/internal/middleware"
+	"github.com/bank-service/internal/models"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// apiHandlers — обработчики, из которых собирается HTTP API
+type apiHandlers struct {
+	user    *handlers.UserHandler
+	account *handlers.AccountHandler
+	card    *handlers.CardHandler
+	credit  *handlers.CreditHandler
+	hold    *handlers.HoldHandler
+	payment *handlers.PaymentHandler
+	admin   *handlers.AdminHandler
+	limit   *handlers.LimitHandler
+}
+
+// newRouter регистрирует маршруты API. Все маршруты, кроме публичных, проходят через auth,
+// операции с деньгами — через idempotent. Владение счетами, картами, кредитами и холдами
+// проверяют сервисы и HoldHandler через пакет policy, доступ к /admin — роль сотрудника.
+func newRouter(h apiHandlers, auth, idempotent func(http.Handler) http.Handler, logger *logrus.Logger) *mux.Router {
+	router := mux.NewRouter()
+
+	// Публичные эндпоинты
+	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("OK"))
+	}).Methods("GET")
+	router.HandleFunc("/register", h.user.Register).Methods("POST")
+	router.HandleFunc("/login", h.user.Login).Methods("POST")
+	router.HandleFunc("/token/refresh", h.user.RefreshToken).Methods("POST")
+
+	// Защищенные эндпоинты
+	protected := router.PathPrefix("/").Subrouter()
+	protected.Use(auth)
+
+	protected.HandleFunc("/profile", h.user.Profile).Methods("GET")
+	protected.HandleFunc("/logout", h.user.Logout).Methods("POST")
+	protected.HandleFunc("/accounts", h.account.CreateAccount).Methods("POST")
+	protected.HandleFunc("/accounts", h.account.GetAccounts).Methods("GET")
+	protected.Handle("/accounts/{id}/deposit", idempotent(http.HandlerFunc(h.account.Deposit))).Methods("POST")
+	protected.Handle("/accounts/{id}/withdraw", idempotent(http.HandlerFunc(h.account.Withdraw))).Methods("POST")
+	protected.HandleFunc("/accounts/{id}/transactions", h.account.GetTransactions).Methods("GET")
+	protected.HandleFunc("/accounts/{id}/statement", h.account.GetStatement).Methods("GET")
+	protected.HandleFunc("/accounts/{id}/statement/camt053", h.payment.ExportCamt053).Methods("GET")
+	protected.Handle("/accounts/{id}/holds", idempotent(http.HandlerFunc(h.hold.PlaceHold))).Methods("POST")
+	protected.Handle("/holds/{hold_id}/capture", idempotent(http.HandlerFunc(h.hold.CaptureHold))).Methods("POST")
+	protected.HandleFunc("/holds/{hold_id}/release", h.hold.ReleaseHold).Methods("POST")
+	protected.Handle("/transfer", idempotent(http.HandlerFunc(h.account.Transfer))).Methods("POST")
+	protected.Handle("/payments/pain001", idempotent(http.HandlerFunc(h.payment.ImportPain001))).Methods("POST")
+	protected.HandleFunc("/cards", h.card.CreateCard).Methods("POST")
+	protected.HandleFunc("/accounts/{account_id}/cards", h.card.GetCards).Methods("GET")
+	protected.Handle("/cards/{card_id}/authorizations", idempotent(http.HandlerFunc(h.card.Authorize))).Methods("POST")
+	protected.HandleFunc("/cards/{card_id}/block", h.card.BlockCard).Methods("POST")
+	protected.HandleFunc("/cards/{card_id}/unblock", h.card.UnblockCard).Methods("POST")
+	protected.HandleFunc("/cards/{card_id}/lost", h.card.ReportLost).Methods("POST")
+	protected.HandleFunc("/cards/{card_id}/close", h.card.CloseCard).Methods("POST")
+	protected.Handle("/cards/{card_id}/reissue", idempotent(http.HandlerFunc(h.card.ReissueCard))).Methods("POST")
+	protected.HandleFunc("/cards/{card_id}/history", h.card.GetStatusHistory).Methods("GET")
+	protected.HandleFunc("/cards/{card_id}/limits", h.limit.GetCardLimits).Methods("GET")
+	protected.HandleFunc("/cards/{card_id}/limits", h.limit.SetCardLimit).Methods("PUT")
+	protected.HandleFunc("/cards/{card_id}/limits/{operation}/{period}", h.limit.RemoveCardLimit).Methods("DELETE")
+	protected.HandleFunc("/accounts/{id}/limits", h.limit.GetAccountLimits).Methods("GET")
+	protected.Handle("/credits", idempotent(http.HandlerFunc(h.credit.CreateCredit))).Methods("POST")
+	protected.HandleFunc("/credits", h.credit.GetCredits).Methods("GET")
+	protected.HandleFunc("/credits/{credit_id}/payment-schedules", h.credit.GetPaymentSchedules).Methods("GET")
+	protected.HandleFunc("/credits/{credit_id}/summary", h.credit.GetSummary).Methods("GET")
+	protected.Handle("/credits/{credit_id}/repayments", idempotent(http.HandlerFunc(h.credit.Repay))).Methods("POST")
+	protected.HandleFunc("/credits/{credit_id}/repayments", h.credit.GetPayments).Methods("GET")
+	protected.HandleFunc("/credits/{credit_id}/payoff", h.credit.GetPayoffQuote).Methods("GET")
+	protected.Handle("/credits/{credit_id}/payoff", idempotent(http.HandlerFunc(h.credit.PayOff))).Methods("POST")
+	protected.Handle("/credits/{credit_id}/prepayments", idempotent(http.HandlerFunc(h.credit.Prepay))).Methods("POST")
+	protected.HandleFunc("/credits/{credit_id}/penalties", h.credit.GetPenalties).Methods("GET")
+	protected.HandleFunc("/credits/{credit_id}/history", h.credit.GetStatusHistory).Methods("GET")
+
+	// Эндпоинты сотрудников банка: просматривать могут все сотрудники,
+	// изменять — операционисты и администраторы, назначать роли — только администраторы
+	admin := protected.PathPrefix("/admin").Subrouter()
+	admin.Use(middleware.RequireRole(logger, models.RoleOperator, models.RoleAdmin, models.RoleAuditor))
+	operator := middleware.RequireRole(logger, models.RoleOperator, models.RoleAdmin)
+	admin.HandleFunc("/users", h.admin.SearchUsers).Methods("GET")
+	admin.HandleFunc("/users/{user_id}", h.admin.GetUser).Methods("GET")
+	admin.Handle("/users/{user_id}/role", middleware.RequireRole(logger, models.RoleAdmin)(http.HandlerFunc(h.admin.SetUserRole))).Methods("PUT")
+	admin.HandleFunc("/accounts/{id}", h.admin.GetAccount).Methods("GET")
+	admin.HandleFunc("/accounts/{id}/transactions", h.admin.GetAccountTransactions).Methods("GET")
+	admin.Handle("/accounts/{id}/freeze", operator(http.HandlerFunc(h.admin.FreezeAccount))).Methods("POST")
+	admin.Handle("/accounts/{id}/unfreeze", operator(http.HandlerFunc(h.admin.UnfreezeAccount))).Methods("POST")
+	admin.Handle("/cards/{card_id}/reveal", operator(http.HandlerFunc(h.admin.RevealCard))).Methods("POST")
+	admin.HandleFunc("/limits/{scope}/{scope_id}", h.admin.GetLimits).Methods("GET")
+	admin.Handle("/limits/{scope}/{scope_id}", operator(http.HandlerFunc(h.admin.SetLimit))).Methods("PUT")
+	admin.Handle("/limits/{scope}/{scope_id}/{operation}/{period}", operator(http.HandlerFunc(h.admin.RemoveLimit))).Methods("DELETE")
+	admin.Handle("/transactions/{transaction_id}/reverse", operator(idempotent(http.HandlerFunc(h.admin.ReverseTransaction)))).Methods("POST")
+
+	return router
+}
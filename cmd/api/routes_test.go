@@ -0,0 +1,366 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bank-service/internal/handlers"
+	"github.com/bank-service/internal/iso20022"
+	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/money"
+	"github.com/bank-service/internal/policy"
+	"github.com/bank-service/internal/repositories"
+	"github.com/bank-service/internal/services"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// Ресурсы владельца ownerID; вызывающий customerID владеет только счётом customerAccountID
+const (
+	customerID        = 1
+	ownerID           = 2
+	adminID           = 3
+	customerAccountID = 10
+	ownerAccountID    = 20
+	ownerCardID       = 21
+	ownerCreditID     = 22
+	ownerHoldID       = 23
+)
+
+type fakeAccountRepository struct {
+	repositories.AccountRepository
+	accounts map[int64]*models.Account
+}
+
+func (r *fakeAccountRepository) FindByID(_ context.Context, id int64) (*models.Account, error) {
+	return r.accounts[id], nil
+}
+
+type fakeCardRepository struct {
+	repositories.CardRepository
+	cards map[int64]*models.Card
+}
+
+func (r *fakeCardRepository) FindByID(_ context.Context, id int64) (*models.Card, error) {
+	return r.cards[id], nil
+}
+
+type fakeCreditRepository struct {
+	repositories.CreditRepository
+	credits map[int64]*models.Credit
+}
+
+func (r *fakeCreditRepository) FindByID(_ context.Context, id int64) (*models.Credit, error) {
+	return r.credits[id], nil
+}
+
+type fakeHoldRepository struct {
+	repositories.HoldRepository
+	holds map[int64]*models.Hold
+}
+
+func (r *fakeHoldRepository) FindByID(_ context.Context, id int64) (*models.Hold, error) {
+	return r.holds[id], nil
+}
+
+type fakeUserRepository struct {
+	repositories.UserRepository
+}
+
+func (r *fakeUserRepository) FindByID(_ context.Context, id int64) (*models.User, error) {
+	return &models.User{ID: id, Role: models.RoleCustomer}, nil
+}
+
+// fakeHoldService отмечает, что запрос дошёл до сервиса холдов; HoldService сам
+// владельца не проверяет, поэтому до него доходят только проверенные запросы
+type fakeHoldService struct {
+	services.HoldService
+	calls int
+}
+
+func (s *fakeHoldService) PlaceHold(_ context.Context, accountID, cardID int64, amount money.Amount, description string, ttl time.Duration) (*models.Hold, error) {
+	s.calls++
+	return &models.Hold{ID: ownerHoldID, AccountID: accountID, Amount: amount, Status: models.HoldStatusActive}, nil
+}
+
+func (s *fakeHoldService) CaptureHold(_ context.Context, holdID int64, amount money.Amount) (*models.Hold, error) {
+	s.calls++
+	return &models.Hold{ID: holdID, AccountID: ownerAccountID, Status: models.HoldStatusCaptured}, nil
+}
+
+func (s *fakeHoldService) ReleaseHold(_ context.Context, holdID int64) (*models.Hold, error) {
+	s.calls++
+	return &models.Hold{ID: holdID, AccountID: ownerAccountID, Status: models.HoldStatusReleased}, nil
+}
+
+type fakeAdminService struct {
+	services.AdminService
+	accounts map[int64]*models.Account
+}
+
+func (s *fakeAdminService) GetAccount(_ context.Context, accountID int64) (*models.Account, error) {
+	return s.accounts[accountID], nil
+}
+
+type routeFixture struct {
+	router *mux.Router
+	holds  *fakeHoldService
+}
+
+// newRouteFixture собирает маршрутизатор API на настоящих обработчиках, сервисах и политике
+// доступа. Репозитории отвечают только на поиск по идентификатору, остальные зависимости
+// сервисов не заданы: запрос, прошедший мимо проверки владельца, упадёт на них.
+func newRouteFixture() *routeFixture {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	accounts := map[int64]*models.Account{
+		customerAccountID: {ID: customerAccountID, UserID: customerID, Currency: money.RUB, Status: models.AccountStatusActive},
+		ownerAccountID:    {ID: ownerAccountID, UserID: ownerID, Currency: money.RUB, Status: models.AccountStatusActive},
+	}
+	accountRepo := &fakeAccountRepository{accounts: accounts}
+	cardRepo := &fakeCardRepository{cards: map[int64]*models.Card{
+		ownerCardID: {ID: ownerCardID, AccountID: ownerAccountID, Status: models.CardStatusActive},
+	}}
+	creditRepo := &fakeCreditRepository{credits: map[int64]*models.Credit{
+		ownerCreditID: {ID: ownerCreditID, UserID: ownerID, AccountID: ownerAccountID},
+	}}
+	holdRepo := &fakeHoldRepository{holds: map[int64]*models.Hold{
+		ownerHoldID: {ID: ownerHoldID, AccountID: ownerAccountID},
+	}}
+	userRepo := &fakeUserRepository{}
+	ownership := policy.New(accountRepo, cardRepo, creditRepo, holdRepo)
+
+	accountService := services.NewAccountService(accountRepo, userRepo, nil, holdRepo, nil, nil, nil, ownership, nil)
+	cardService := services.NewCardService(cardRepo, ownership, nil, nil, nil, "", "", nil)
+	creditService := services.NewCreditService(creditRepo, userRepo, accountService, ownership, services.OverduePolicy{}, nil)
+	limitService := services.NewLimitService(nil, cardRepo, accountRepo, userRepo, ownership, nil, nil)
+	paymentService := services.NewPaymentService(accountService, ownership)
+	holdService := &fakeHoldService{}
+
+	h := apiHandlers{
+		user:    handlers.NewUserHandler(nil, logger),
+		account: handlers.NewAccountHandler(accountService, logger),
+		card:    handlers.NewCardHandler(cardService, logger),
+		credit:  handlers.NewCreditHandler(creditService, logger),
+		hold:    handlers.NewHoldHandler(holdService, ownership, logger),
+		payment: handlers.NewPaymentHandler(paymentService, logger),
+		admin:   handlers.NewAdminHandler(&fakeAdminService{accounts: accounts}, logger),
+		limit:   handlers.NewLimitHandler(limitService, logger),
+	}
+	return &routeFixture{
+		router: newRouter(h, testAuth, func(next http.Handler) http.Handler { return next }, logger),
+		holds:  holdService,
+	}
+}
+
+// testAuth заменяет AuthMiddleware: пользователь и роль берутся из заголовков запроса
+func testAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, err := strconv.ParseInt(r.Header.Get("X-Test-User"), 10, 64)
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		ctx := context.WithValue(r.Context(), "user_id", userID)
+		ctx = context.WithValue(ctx, "role", r.Header.Get("X-Test-Role"))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (f *routeFixture) serve(userID int64, role, method, path, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body == "" {
+		r.ContentLength = 0
+	}
+	r.Header.Set("X-Test-User", strconv.FormatInt(userID, 10))
+	r.Header.Set("X-Test-Role", role)
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, r)
+	return w
+}
+
+type routeCase struct {
+	method string
+	path   string
+	body   string
+}
+
+// ownedRoutes — все маршруты клиента, обращающиеся к счёту, карте, кредиту или холду
+var ownedRoutes = []routeCase{
+	{"POST", "/accounts/20/deposit", `{"amount":"10.00"}`},
+	{"POST", "/accounts/20/withdraw", `{"amount":"10.00"}`},
+	{"POST", "/transfer", `{"from_account_id":20,"to_account_id":10,"amount":"10.00"}`},
+	{"GET", "/accounts/20/transactions", ""},
+	{"GET", "/accounts/20/statement?from=2026-01-01", ""},
+	{"GET", "/accounts/20/statement/camt053?from=2026-01-01", ""},
+	{"GET", "/accounts/20/limits", ""},
+	{"POST", "/accounts/20/holds", `{"amount":"10.00","description":"test"}`},
+	{"POST", "/holds/23/capture", ""},
+	{"POST", "/holds/23/release", ""},
+	{"POST", "/cards", `{"account_id":20}`},
+	{"GET", "/accounts/20/cards", ""},
+	{"POST", "/cards/21/authorizations", `{"amount":"10.00","merchant":"shop"}`},
+	{"POST", "/cards/21/block", ""},
+	{"POST", "/cards/21/unblock", ""},
+	{"POST", "/cards/21/lost", `{"stolen":true}`},
+	{"POST", "/cards/21/close", ""},
+	{"POST", "/cards/21/reissue", ""},
+	{"GET", "/cards/21/history", ""},
+	{"GET", "/cards/21/limits", ""},
+	{"PUT", "/cards/21/limits", `{"operation":"card_purchase","period":"daily","max_amount":"100.00"}`},
+	{"DELETE", "/cards/21/limits/card_purchase/daily", ""},
+	{"POST", "/credits", `{"account_id":20,"amount":"1000.00","interest_rate":12,"term_months":12}`},
+	{"GET", "/credits/22/payment-schedules", ""},
+	{"GET", "/credits/22/summary", ""},
+	{"POST", "/credits/22/repayments", ""},
+	{"GET", "/credits/22/repayments", ""},
+	{"GET", "/credits/22/payoff", ""},
+	{"POST", "/credits/22/payoff", ""},
+	{"POST", "/credits/22/prepayments", `{"amount":"100.00","mode":"reduce_term"}`},
+	{"GET", "/credits/22/penalties", ""},
+	{"GET", "/credits/22/history", ""},
+}
+
+func TestRoutesHideOtherUsersResources(t *testing.T) {
+	callers := []struct {
+		name   string
+		userID int64
+		role   string
+	}{
+		{"customer", customerID, models.RoleCustomer},
+		// Роль сотрудника не даёт доступа к чужим ресурсам через клиентские маршруты
+		{"admin", adminID, models.RoleAdmin},
+	}
+	for _, caller := range callers {
+		for _, route := range ownedRoutes {
+			t.Run(caller.name+" "+route.method+" "+route.path, func(t *testing.T) {
+				f := newRouteFixture()
+				w := f.serve(caller.userID, caller.role, route.method, route.path, route.body)
+				if w.Code != http.StatusNotFound {
+					t.Errorf("status = %d (%s), want %d", w.Code, strings.TrimSpace(w.Body.String()), http.StatusNotFound)
+				}
+				if f.holds.calls != 0 {
+					t.Error("request reached the hold service")
+				}
+			})
+		}
+	}
+}
+
+func TestRoutesReportMissingResources(t *testing.T) {
+	routes := []routeCase{
+		{"POST", "/accounts/999/deposit", `{"amount":"10.00"}`},
+		{"GET", "/cards/999/history", ""},
+		{"GET", "/credits/999/summary", ""},
+		{"POST", "/holds/999/release", ""},
+	}
+	for _, route := range routes {
+		t.Run(route.method+" "+route.path, func(t *testing.T) {
+			w := newRouteFixture().serve(ownerID, models.RoleCustomer, route.method, route.path, route.body)
+			if w.Code != http.StatusNotFound {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+			}
+		})
+	}
+}
+
+func TestHoldRoutesServeOwner(t *testing.T) {
+	routes := []routeCase{
+		{"POST", "/accounts/20/holds", `{"amount":"10.00","description":"test"}`},
+		{"POST", "/holds/23/capture", ""},
+		{"POST", "/holds/23/release", ""},
+	}
+	for _, route := range routes {
+		t.Run(route.method+" "+route.path, func(t *testing.T) {
+			f := newRouteFixture()
+			w := f.serve(ownerID, models.RoleCustomer, route.method, route.path, route.body)
+			if w.Code >= http.StatusBadRequest {
+				t.Errorf("status = %d (%s), want success", w.Code, strings.TrimSpace(w.Body.String()))
+			}
+			if f.holds.calls != 1 {
+				t.Errorf("hold service called %d times, want 1", f.holds.calls)
+			}
+		})
+	}
+}
+
+func TestAdminRoutesRequireStaffRole(t *testing.T) {
+	tests := []struct {
+		name   string
+		role   string
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"customer reads account", models.RoleCustomer, "GET", "/admin/accounts/20", "", http.StatusForbidden},
+		{"admin reads any account", models.RoleAdmin, "GET", "/admin/accounts/20", "", http.StatusOK},
+		{"auditor reads any account", models.RoleAuditor, "GET", "/admin/accounts/20", "", http.StatusOK},
+		{"customer freezes account", models.RoleCustomer, "POST", "/admin/accounts/20/freeze", `{"reason":"test"}`, http.StatusForbidden},
+		{"auditor freezes account", models.RoleAuditor, "POST", "/admin/accounts/20/freeze", `{"reason":"test"}`, http.StatusForbidden},
+		{"operator sets role", models.RoleOperator, "PUT", "/admin/users/2/role", `{"role":"admin"}`, http.StatusForbidden},
+		{"customer reveals card", models.RoleCustomer, "POST", "/admin/cards/21/reveal", `{"reason":"test"}`, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newRouteFixture().serve(adminID, tt.role, tt.method, tt.path, tt.body)
+			if w.Code != tt.want {
+				t.Errorf("status = %d (%s), want %d", w.Code, strings.TrimSpace(w.Body.String()), tt.want)
+			}
+		})
+	}
+}
+
+// pain001Document — поручение на перевод со счёта debtorID на счёт creditorID, исполняемое сегодня
+func pain001Document(debtorID, creditorID int64) string {
+	today := time.Now().Format("2006-01-02")
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.09">
+  <CstmrCdtTrfInitn>
+    <GrpHdr>
+      <MsgId>MSG-1</MsgId>
+      <CreDtTm>` + today + `T10:00:00</CreDtTm>
+      <NbOfTxs>1</NbOfTxs>
+      <CtrlSum>10.00</CtrlSum>
+      <InitgPty><Nm>Test</Nm></InitgPty>
+    </GrpHdr>
+    <PmtInf>
+      <PmtInfId>PMT-1</PmtInfId>
+      <PmtMtd>TRF</PmtMtd>
+      <NbOfTxs>1</NbOfTxs>
+      <CtrlSum>10.00</CtrlSum>
+      <ReqdExctnDt><Dt>` + today + `</Dt></ReqdExctnDt>
+      <Dbtr><Nm>Test</Nm></Dbtr>
+      <DbtrAcct><Id><Othr><Id>` + strconv.FormatInt(debtorID, 10) + `</Id></Othr></Id></DbtrAcct>
+      <CdtTrfTxInf>
+        <PmtId><EndToEndId>E2E-1</EndToEndId></PmtId>
+        <Amt><InstdAmt Ccy="RUB">10.00</InstdAmt></Amt>
+        <Cdtr><Nm>Test</Nm></Cdtr>
+        <CdtrAcct><Id><Othr><Id>` + strconv.FormatInt(creditorID, 10) + `</Id></Othr></Id></CdtrAcct>
+      </CdtTrfTxInf>
+    </PmtInf>
+  </CstmrCdtTrfInitn>
+</Document>`
+}
+
+// Импорт pain.001 исполняет поручения через AccountService.Transfer и не может
+// списать средства с чужого счёта
+func TestPain001RejectsOtherUsersDebtorAccount(t *testing.T) {
+	f := newRouteFixture()
+	w := f.serve(customerID, models.RoleCustomer, "POST", "/payments/pain001", pain001Document(ownerAccountID, customerAccountID))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d (%s), want %d", w.Code, strings.TrimSpace(w.Body.String()), http.StatusOK)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "RJCT") || !strings.Contains(body, iso20022.ReasonInvalidDebtorAccount) {
+		t.Errorf("pain.002 does not reject the transfer with %s:\n%s", iso20022.ReasonInvalidDebtorAccount, body)
+	}
+}
@@ -0,0 +1,37 @@
+// cbr-stub локально отдаёт ежедневную выгрузку курсов в формате Банка России
+// (XML_daily.asp, кодировка windows-1251), чтобы разработка и тесты не зависели от cbr.ru.
+//
+// Запуск: go run ./cmd/cbr-stub -addr :8090
+// Адрес для CBRProvider: http://localhost:8090/scripts/XML_daily.asp
+package main
+
+import (
+	_ "embed"
+	"flag"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+)
+
+//go:embed XML_daily.xml
+var dailyRates []byte
+
+func main() {
+	addr := flag.String("addr", ":8090", "адрес, на котором слушает заглушка")
+	flag.Parse()
+
+	logger := logrus.New()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/scripts/XML_daily.asp", func(w http.ResponseWriter, r *http.Request) {
+		// Параметр date_req поддерживается ЦБ, но заглушка всегда отдаёт одну и ту же выгрузку
+		logger.Debug("Serving daily rates, date_req=", r.URL.Query().Get("date_req"))
+		w.Header().Set("Content-Type", "application/xml; charset=windows-1251")
+		_, _ = w.Write(dailyRates)
+	})
+
+	logger.Info("Starting CBR stub on ", *addr)
+	if err := http.ListenAndServe(*addr, mux); err != nil {
+		logger.Fatal("CBR stub failed: ", err)
+	}
+}
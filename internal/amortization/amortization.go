@@ -0,0 +1,150 @@
+package amortization
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/money"
+)
+
+var ErrUnknownType = errors.New("unknown schedule type")
+
+// Generator строит график платежей определённого типа
+type Generator interface {
+	// Generate раскладывает долг balance на платежи в даты dates; первый процентный период
+	// начинается в start. Последний платёж гасит весь оставшийся долг и поглощает ошибки округления.
+	Generate(balance money.Amount, terms Terms, start time.Time, dates []time.Time) []*models.PaymentSchedule
+	// Installment возвращает регулярный платёж по долгу balance на count периодов: для аннуитета —
+	// весь платёж, для остальных графиков — его часть в счёт основного долга
+	Installment(balance money.Amount, terms Terms, count int) money.Amount
+}
+
+// Terms — условия начисления процентов: годовая ставка долей и конвенция подсчёта дней.
+// Размер аннуитетного платежа рассчитывается по номинальной месячной ставке Rate/12,
+// проценты каждого периода — по фактической доле года.
+type Terms struct {
+	Rate     *big.Rat
+	DayCount string
+}
+
+// NewTerms составляет условия из годовой ставки в процентах и конвенции подсчёта дней
+func NewTerms(interestRate float64, dayCount string) Terms {
+	return Terms{Rate: rateFromPercent(interestRate), DayCount: dayCount}
+}
+
+// Interest начисляет проценты на долг balance за период с from по to
+func (t Terms) Interest(balance money.Amount, from, to time.Time) money.Amount {
+	return balance.MulRat(new(big.Rat).Mul(t.Rate, YearFraction(t.DayCount, from, to)), money.HalfUp)
+}
+
+func (t Terms) monthlyRate() *big.Rat {
+	return new(big.Rat).Quo(t.Rate, big.NewRat(12, 1))
+}
+
+// GeneratorFor возвращает построитель графика типа annuity, differentiated, interest_only или zero_interest
+func GeneratorFor(scheduleType string) (Generator, error) {
+	switch scheduleType {
+	case models.ScheduleTypeAnnuity:
+		return annuity{}, nil
+	case models.ScheduleTypeDifferentiated:
+		return differentiated{}, nil
+	case models.ScheduleTypeInterestOnly:
+		return interestOnly{}, nil
+	case models.ScheduleTypeZeroInterest:
+		return zeroInterest{}, nil
+	}
+	return nil, ErrUnknownType
+}
+
+// MonthlyDates возвращает даты count ежемесячных платежей, первый — через месяц после start.
+// Если в месяце платежа нет числа start, платёж приходится на последний день месяца.
+func MonthlyDates(start time.Time, count int) []time.Time {
+	dates := make([]time.Time, count)
+	for i := range dates {
+		dates[i] = addMonthsClamped(start, i+1)
+	}
+	return dates
+}
+
+// addMonthsClamped сдвигает t на n месяцев, не перенося дату в следующий месяц:
+// 31 января плюс месяц — 28 (29) февраля, а не 3 (2) марта
+func addMonthsClamped(t time.Time, n int) time.Time {
+	year, month, day := t.Date()
+	first := time.Date(year, month+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	return first.AddDate(0, 0, min(day, daysIn(first.Year(), first.Month()))-1)
+}
+
+// daysIn возвращает число дней в месяце month года year
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// rateFromPercent переводит процентную ставку (например, 12.5) в точную долю (0.125).
+// Ставка хранится в NUMERIC(5, 2), поэтому её десятичная запись конечна.
+func rateFromPercent(percent float64) *big.Rat {
+	rate, ok := new(big.Rat).SetString(strconv.FormatFloat(percent, 'f', -1, 64))
+	if !ok {
+		return new(big.Rat)
+	}
+	return rate.Quo(rate, big.NewRat(100, 1))
+}
+
+// build раскладывает долг balance по датам dates. Проценты каждого периода начисляются на остаток
+// долга, часть основного долга в платеже возвращает principal; она не бывает отрицательной
+// и оставляет на каждый следующий платёж хотя бы одну копейку долга, поэтому график всегда
+// содержит len(dates) платежей. Последний платёж гасит весь оставшийся долг.
+func build(balance money.Amount, terms Terms, start time.Time, dates []time.Time, principal func(balance, interest money.Amount) money.Amount) []*models.PaymentSchedule {
+	schedules := make([]*models.PaymentSchedule, 0, len(dates))
+	periodStart := start
+	for i, date := range dates {
+		interest := terms.Interest(balance, periodStart, date)
+		part := principal(balance, interest)
+		if limit := balance.Sub(money.FromMinor(int64(len(dates) - 1 - i))); part.Cmp(limit) > 0 {
+			part = limit
+		}
+		if part.IsNegative() {
+			part = 0
+		}
+		if i == len(dates)-1 {
+			part = balance
+		}
+		balance = balance.Sub(part)
+
+		schedules = append(schedules, &models.PaymentSchedule{
+			PeriodStart:        periodStart,
+			PaymentDate:        date,
+			Amount:             part.Add(interest),
+			Principal:          part,
+			Interest:           interest,
+			RemainingPrincipal: balance,
+			Paid:               false,
+			Penalty:            0,
+			CreatedAt:          time.Now(),
+			UpdatedAt:          time.Now(),
+		})
+		periodStart = date
+	}
+	return schedules
+}
+
+// equalPart делит долг balance на count равных частей с округлением вниз до копейки:
+// сумма первых count-1 частей не превышает долга, а остаток округления поглощает
+// последний платёж
+func equalPart(balance money.Amount, count int) money.Amount {
+	return balance.MulRat(big.NewRat(1, int64(count)), money.Down)
+}
+
+// days возвращает число календарных дней между датами from и to. Даты переносятся в UTC
+// без времени суток, поэтому переход на летнее время не теряет и не добавляет день.
+func days(from, to time.Time) int {
+	return int(civilDate(to).Sub(civilDate(from)).Hours() / 24)
+}
+
+// civilDate возвращает полночь UTC календарной даты t в её часовом поясе
+func civilDate(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,222 @@
+package amortization
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/money"
+)
+
+var testStart = time.Date(2025, time.January, 15, 0, 0, 0, 0, time.UTC)
+
+func generate(t *testing.T, scheduleType string, amount string, rate float64, count int) (money.Amount, Terms, []*models.PaymentSchedule) {
+	t.Helper()
+	generator, err := GeneratorFor(scheduleType)
+	if err != nil {
+		t.Fatalf("GeneratorFor(%q): %v", scheduleType, err)
+	}
+	balance := money.MustParse(amount)
+	terms := NewTerms(rate, models.DayCount30360)
+	return balance, terms, generator.Generate(balance, terms, testStart, MonthlyDates(testStart, count))
+}
+
+func principals(schedules []*models.PaymentSchedule) []money.Amount {
+	parts := make([]money.Amount, len(schedules))
+	for i, schedule := range schedules {
+		parts[i] = schedule.Principal
+	}
+	return parts
+}
+
+func TestGeneratorsRepayExactPrincipal(t *testing.T) {
+	tests := []struct {
+		scheduleType string
+		amount       string
+		rate         float64
+		count        int
+	}{
+		{models.ScheduleTypeAnnuity, "100000.00", 12, 12},
+		{models.ScheduleTypeAnnuity, "0.12", 12, 12},
+		{models.ScheduleTypeAnnuity, "1000.00", 0, 7},
+		{models.ScheduleTypeDifferentiated, "100000.00", 12, 12},
+		{models.ScheduleTypeDifferentiated, "0.10", 12, 6},
+		{models.ScheduleTypeInterestOnly, "100000.00", 12, 12},
+		{models.ScheduleTypeZeroInterest, "1000.00", 0, 3},
+		{models.ScheduleTypeZeroInterest, "0.10", 0, 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scheduleType+" "+tt.amount, func(t *testing.T) {
+			balance, _, schedules := generate(t, tt.scheduleType, tt.amount, tt.rate, tt.count)
+
+			if len(schedules) != tt.count {
+				t.Fatalf("schedule has %d payments, want %d", len(schedules), tt.count)
+			}
+			var total money.Amount
+			remaining := balance
+			for i, schedule := range schedules {
+				total = total.Add(schedule.Principal)
+				remaining = remaining.Sub(schedule.Principal)
+				if schedule.RemainingPrincipal != remaining {
+					t.Errorf("payment %d: remaining principal = %s, want %s", i+1, schedule.RemainingPrincipal, remaining)
+				}
+				if schedule.Amount != schedule.Principal.Add(schedule.Interest) {
+					t.Errorf("payment %d: amount %s is not principal %s plus interest %s", i+1, schedule.Amount, schedule.Principal, schedule.Interest)
+				}
+				if schedule.Principal.IsNegative() || (tt.scheduleType != models.ScheduleTypeInterestOnly && !schedule.Principal.IsPositive()) {
+					t.Errorf("payment %d: principal = %s", i+1, schedule.Principal)
+				}
+			}
+			if total != balance {
+				t.Errorf("total principal = %s, want %s", total, balance)
+			}
+		})
+	}
+}
+
+func TestEqualPartGeneratorsLeaveRemainderToLastPayment(t *testing.T) {
+	tests := []struct {
+		scheduleType string
+		amount       string
+		rate         float64
+		want         []string
+	}{
+		{models.ScheduleTypeZeroInterest, "1000.00", 0, []string{"333.33", "333.33", "333.34"}},
+		{models.ScheduleTypeZeroInterest, "100.00", 0, []string{"14.28", "14.28", "14.28", "14.28", "14.28", "14.28", "14.32"}},
+		{models.ScheduleTypeDifferentiated, "1000.00", 12, []string{"333.33", "333.33", "333.34"}},
+		// При округлении половины вверх части по 0.02 гасили бы долг за пять платежей
+		{models.ScheduleTypeDifferentiated, "0.10", 12, []string{"0.01", "0.01", "0.01", "0.01", "0.01", "0.05"}},
+		{models.ScheduleTypeAnnuity, "1000.00", 0, []string{"333.33", "333.33", "333.34"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scheduleType+" "+tt.amount, func(t *testing.T) {
+			_, _, schedules := generate(t, tt.scheduleType, tt.amount, tt.rate, len(tt.want))
+
+			got := principals(schedules)
+			if len(got) != len(tt.want) {
+				t.Fatalf("principal parts = %v, want %v", got, tt.want)
+			}
+			for i, want := range tt.want {
+				if got[i] != money.MustParse(want) {
+					t.Errorf("principal parts = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestAnnuityReferenceSchedule(t *testing.T) {
+	balance, terms, schedules := generate(t, models.ScheduleTypeAnnuity, "100000.00", 12, 12)
+
+	payment := annuity{}.Installment(balance, terms, 12)
+	if want := money.MustParse("8884.88"); payment != want {
+		t.Fatalf("installment = %s, want %s", payment, want)
+	}
+	// По 30/360 каждый период — ровно месяц: проценты первого периода — 1% долга
+	if want := money.MustParse("1000.00"); schedules[0].Interest != want {
+		t.Errorf("first interest = %s, want %s", schedules[0].Interest, want)
+	}
+	for i, schedule := range schedules[:len(schedules)-1] {
+		if schedule.Amount != payment {
+			t.Errorf("payment %d = %s, want %s", i+1, schedule.Amount, payment)
+		}
+	}
+	last := schedules[len(schedules)-1]
+	if want := money.MustParse("8884.85"); last.Amount != want {
+		t.Errorf("last payment = %s, want %s", last.Amount, want)
+	}
+	if !last.RemainingPrincipal.IsZero() {
+		t.Errorf("remaining principal after the last payment = %s", last.RemainingPrincipal)
+	}
+}
+
+func TestInterestOnlyRepaysPrincipalWithLastPayment(t *testing.T) {
+	balance, _, schedules := generate(t, models.ScheduleTypeInterestOnly, "100000.00", 12, 12)
+
+	interest := money.MustParse("1000.00")
+	for i, schedule := range schedules[:len(schedules)-1] {
+		if !schedule.Principal.IsZero() || schedule.Interest != interest {
+			t.Errorf("payment %d: principal %s, interest %s; want 0 and %s", i+1, schedule.Principal, schedule.Interest, interest)
+		}
+	}
+	last := schedules[len(schedules)-1]
+	if last.Principal != balance || last.Amount != balance.Add(interest) {
+		t.Errorf("last payment: principal %s, amount %s; want %s and %s", last.Principal, last.Amount, balance, balance.Add(interest))
+	}
+}
+
+func TestMonthlyDatesClampToMonthEnd(t *testing.T) {
+	tests := []struct {
+		name  string
+		start time.Time
+		want  []time.Time
+	}{
+		{
+			name:  "january 31",
+			start: date(2025, time.January, 31),
+			want: []time.Time{
+				date(2025, time.February, 28), date(2025, time.March, 31), date(2025, time.April, 30),
+				date(2025, time.May, 31), date(2025, time.June, 30), date(2025, time.July, 31),
+			},
+		},
+		{
+			name:  "leap day",
+			start: date(2024, time.February, 29),
+			want: []time.Time{
+				date(2024, time.March, 29), date(2024, time.April, 29), date(2024, time.May, 29),
+				date(2024, time.June, 29), date(2024, time.July, 29), date(2024, time.August, 29),
+				date(2024, time.September, 29), date(2024, time.October, 29), date(2024, time.November, 29),
+				date(2024, time.December, 29), date(2025, time.January, 29), date(2025, time.February, 28),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MonthlyDates(tt.start, len(tt.want))
+			for i := range tt.want {
+				if !got[i].Equal(tt.want[i]) {
+					t.Errorf("payment %d date = %s, want %s", i+1, got[i].Format(time.DateOnly), tt.want[i].Format(time.DateOnly))
+				}
+			}
+		})
+	}
+}
+
+func TestGeneratorFromMonthEndPaysOncePerMonth(t *testing.T) {
+	tests := []struct {
+		name          string
+		start         time.Time
+		firstInterest string
+	}{
+		// По 30/360 с 31 января по 28 февраля — 28 дней
+		{"january 31", date(2025, time.January, 31), "933.33"},
+		// С 29 февраля по 29 марта — ровно месяц
+		{"leap day", date(2024, time.February, 29), "1000.00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generator, err := GeneratorFor(models.ScheduleTypeAnnuity)
+			if err != nil {
+				t.Fatal(err)
+			}
+			balance := money.MustParse("100000.00")
+			schedules := generator.Generate(balance, NewTerms(12, models.DayCount30360), tt.start, MonthlyDates(tt.start, 12))
+
+			if got := schedules[0].Interest; got != money.MustParse(tt.firstInterest) {
+				t.Errorf("first interest = %s, want %s", got, tt.firstInterest)
+			}
+			periodStart := tt.start
+			for i, schedule := range schedules {
+				if !schedule.PeriodStart.Equal(periodStart) {
+					t.Errorf("payment %d starts %s, want %s", i+1, schedule.PeriodStart.Format(time.DateOnly), periodStart.Format(time.DateOnly))
+				}
+				wantYear, wantMonth, _ := tt.start.AddDate(0, 0, 1-tt.start.Day()).AddDate(0, i+1, 0).Date()
+				if year, month, _ := schedule.PaymentDate.Date(); year != wantYear || month != wantMonth {
+					t.Errorf("payment %d falls on %s, want %d-%02d", i+1, schedule.PaymentDate.Format(time.DateOnly), wantYear, wantMonth)
+				}
+				periodStart = schedule.PaymentDate
+			}
+		})
+	}
+}
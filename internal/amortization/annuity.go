@@ -0,0 +1,41 @@
+package amortization
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/money"
+)
+
+// annuity — равные платежи: проценты начисляются на остаток долга, остальная часть платежа
+// гасит основной долг. При начислении по фактическим дням проценты периодов различаются,
+// поэтому различается и разбивка платежа.
+type annuity struct{}
+
+func (a annuity) Generate(balance money.Amount, terms Terms, start time.Time, dates []time.Time) []*models.PaymentSchedule {
+	payment := a.Installment(balance, terms, len(dates))
+	return build(balance, terms, start, dates, func(_, interest money.Amount) money.Amount {
+		return payment.Sub(interest)
+	})
+}
+
+// Installment вычисляет аннуитетный платёж: P = S * r * (1+r)^n / ((1+r)^n - 1), где r — месячная ставка.
+// Все вычисления выполняются над точными дробями, округление — только в конце.
+func (annuity) Installment(balance money.Amount, terms Terms, count int) money.Amount {
+	rate := terms.monthlyRate()
+	if rate.Sign() == 0 {
+		return equalPart(balance, count)
+	}
+
+	growth := new(big.Rat).Add(big.NewRat(1, 1), rate)
+	compound := big.NewRat(1, 1)
+	for i := 0; i < count; i++ {
+		compound.Mul(compound, growth)
+	}
+
+	numerator := new(big.Rat).Mul(rate, compound)
+	denominator := new(big.Rat).Sub(compound, big.NewRat(1, 1))
+	annuityFactor := new(big.Rat).Quo(numerator, denominator)
+	return balance.MulRat(annuityFactor, money.HalfUp)
+}
@@ -0,0 +1,48 @@
+package amortization
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/bank-service/internal/models"
+)
+
+// YearFraction возвращает долю года между from и to по конвенции dayCount:
+// actual/365 — фактические дни на 365; actual/actual — фактические дни каждого календарного
+// года на длину этого года (365 или 366); 30/360 — месяцы по 30 дней в году из 360 дней
+// (при 31-м числе в начале периода 31-е число в конце считается 30-м). Неизвестная конвенция
+// считается как 30/360.
+func YearFraction(dayCount string, from, to time.Time) *big.Rat {
+	if !from.Before(to) {
+		return new(big.Rat)
+	}
+	switch dayCount {
+	case models.DayCountActual365:
+		return big.NewRat(int64(days(from, to)), 365)
+	case models.DayCountActualActual:
+		fraction := new(big.Rat)
+		for from.Before(to) {
+			end := time.Date(from.Year()+1, time.January, 1, 0, 0, 0, 0, from.Location())
+			if to.Before(end) {
+				end = to
+			}
+			fraction.Add(fraction, big.NewRat(int64(days(from, end)), int64(daysInYear(from.Year()))))
+			from = end
+		}
+		return fraction
+	}
+
+	y1, m1, d1 := from.Date()
+	y2, m2, d2 := to.Date()
+	if d1 == 31 {
+		d1 = 30
+	}
+	if d2 == 31 && d1 == 30 {
+		d2 = 30
+	}
+	return big.NewRat(int64(360*(y2-y1)+30*(int(m2)-int(m1))+d2-d1), 360)
+}
+
+func daysInYear(year int) int {
+	return time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC).YearDay()
+}
@@ -0,0 +1,49 @@
+package amortization
+
+import (
+	"math/big"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/bank-service/internal/models"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestYearFraction(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		dayCount string
+		from, to time.Time
+		want     *big.Rat
+	}{
+		{"actual/365 leap february", models.DayCountActual365, date(2024, time.January, 31), date(2024, time.February, 29), big.NewRat(29, 365)},
+		{"actual/365 leap year", models.DayCountActual365, date(2024, time.January, 1), date(2025, time.January, 1), big.NewRat(366, 365)},
+		// 9 марта 2025 года в Нью-Йорке переводят часы: в сутках 23 часа
+		{"actual/365 across DST", models.DayCountActual365, time.Date(2025, time.March, 8, 0, 0, 0, 0, newYork), time.Date(2025, time.March, 10, 0, 0, 0, 0, newYork), big.NewRat(2, 365)},
+		{"actual/365 empty period", models.DayCountActual365, date(2025, time.March, 10), date(2025, time.March, 10), new(big.Rat)},
+		{"actual/actual leap day", models.DayCountActualActual, date(2024, time.February, 28), date(2024, time.March, 1), big.NewRat(2, 366)},
+		{"actual/actual across year end", models.DayCountActualActual, date(2023, time.July, 1), date(2024, time.July, 1), new(big.Rat).Add(big.NewRat(184, 365), big.NewRat(182, 366))},
+		{"actual/actual across DST", models.DayCountActualActual, time.Date(2025, time.March, 8, 0, 0, 0, 0, newYork), time.Date(2025, time.March, 10, 0, 0, 0, 0, newYork), big.NewRat(2, 365)},
+		{"30/360 whole months", models.DayCount30360, date(2024, time.January, 15), date(2024, time.July, 15), big.NewRat(180, 360)},
+		{"30/360 from month end to leap february end", models.DayCount30360, date(2024, time.January, 31), date(2024, time.February, 29), big.NewRat(29, 360)},
+		{"30/360 from 30th to 31st", models.DayCount30360, date(2024, time.January, 30), date(2024, time.March, 31), big.NewRat(60, 360)},
+		{"30/360 from 31st to 31st", models.DayCount30360, date(2024, time.January, 31), date(2024, time.March, 31), big.NewRat(60, 360)},
+		{"30/360 from leap day to 31st", models.DayCount30360, date(2024, time.February, 29), date(2024, time.March, 31), big.NewRat(32, 360)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := YearFraction(tt.dayCount, tt.from, tt.to); got.Cmp(tt.want) != 0 {
+				t.Errorf("YearFraction(%s, %s, %s) = %s, want %s", tt.dayCount, tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
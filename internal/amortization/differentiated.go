@@ -0,0 +1,23 @@
+package amortization
+
+import (
+	"time"
+
+	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/money"
+)
+
+// differentiated — основной долг гасится равными частями, проценты на остаток уменьшаются
+// от платежа к платежу
+type differentiated struct{}
+
+func (d differentiated) Generate(balance money.Amount, terms Terms, start time.Time, dates []time.Time) []*models.PaymentSchedule {
+	part := d.Installment(balance, terms, len(dates))
+	return build(balance, terms, start, dates, func(_, _ money.Amount) money.Amount {
+		return part
+	})
+}
+
+func (differentiated) Installment(balance money.Amount, _ Terms, count int) money.Amount {
+	return equalPart(balance, count)
+}
@@ -0,0 +1,70 @@
+package amortization
+
+import (
+	"math"
+	"time"
+
+	"github.com/bank-service/internal/money"
+)
+
+// CashFlow — денежный поток по кредиту: выдача со знаком минус, платежи заёмщика со знаком плюс
+type CashFlow struct {
+	Date   time.Time
+	Amount money.Amount
+}
+
+// FullCostRate рассчитывает полную стоимость кредита в процентах годовых по формуле 353-ФЗ
+// с базовым периодом в один месяц: ПСК = i * 12 * 100, где i — решение уравнения
+// Σ ДПk / ((1 + ek*i) * (1 + i)^qk) = 0. qk — число полных месяцев от первого потока (выдачи)
+// до k-го, ek — доля неполного месяца. Границы месяцев считаются так же, как даты платежей
+// в MonthlyDates: при выдаче 31-го числа месяц заканчивается последним днём короче месяца. Результат округляется до тысячных.
+func FullCostRate(flows []CashFlow) float64 {
+	if len(flows) == 0 {
+		return 0
+	}
+	start := flows[0].Date
+
+	type period struct {
+		amount, e float64
+		q         int
+	}
+	periods := make([]period, len(flows))
+	for k, flow := range flows {
+		q := 0
+		for !addMonthsClamped(start, q+1).After(flow.Date) {
+			q++
+		}
+		from, to := addMonthsClamped(start, q), addMonthsClamped(start, q+1)
+		amount, _ := flow.Amount.Rat().Float64()
+		periods[k] = period{
+			amount: amount,
+			e:      float64(days(from, flow.Date)) / float64(days(from, to)),
+			q:      q,
+		}
+	}
+	presentValue := func(i float64) float64 {
+		var sum float64
+		for _, p := range periods {
+			sum += p.amount / ((1 + p.e*i) * math.Pow(1+i, float64(p.q)))
+		}
+		return sum
+	}
+
+	// Приведённая сумма потоков убывает с ростом ставки; без переплаты стоимость нулевая
+	if presentValue(0) <= 0 {
+		return 0
+	}
+	low, high := 0.0, 1.0
+	for presentValue(high) > 0 && high < 1e6 {
+		low, high = high, high*2
+	}
+	for n := 0; n < 200; n++ {
+		mid := (low + high) / 2
+		if presentValue(mid) > 0 {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return math.Round(low*12*100*1000) / 1000
+}
@@ -0,0 +1,116 @@
+package amortization
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/money"
+)
+
+func TestFullCostRate(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		flows []CashFlow
+		want  float64
+	}{
+		{
+			name: "one month at 1%",
+			flows: []CashFlow{
+				{Date: date(2025, time.January, 15), Amount: money.MustParse("-100000.00")},
+				{Date: date(2025, time.February, 15), Amount: money.MustParse("101000.00")},
+			},
+			want: 12,
+		},
+		{
+			// 15 дней из 30 дней апреля — половина базового периода
+			name: "part of a month",
+			flows: []CashFlow{
+				{Date: date(2025, time.April, 1), Amount: money.MustParse("-100000.00")},
+				{Date: date(2025, time.April, 16), Amount: money.MustParse("100500.00")},
+			},
+			want: 12,
+		},
+		{
+			// Без перехода к календарным датам 15 дней марта из 31 превращались в 14 из 30
+			name: "part of a month across DST",
+			flows: []CashFlow{
+				{Date: time.Date(2025, time.March, 1, 0, 0, 0, 0, newYork), Amount: money.MustParse("-100000.00")},
+				{Date: time.Date(2025, time.March, 16, 0, 0, 0, 0, newYork), Amount: money.MustParse("100483.87")},
+			},
+			want: 12,
+		},
+		{
+			// Месяц от 31 января заканчивается 28 февраля
+			name: "one month from month end",
+			flows: []CashFlow{
+				{Date: date(2025, time.January, 31), Amount: money.MustParse("-100000.00")},
+				{Date: date(2025, time.February, 28), Amount: money.MustParse("101000.00")},
+			},
+			want: 12,
+		},
+		{
+			// 14 дней из 28 дней базового периода с 31 января по 28 февраля
+			name: "part of a month from month end",
+			flows: []CashFlow{
+				{Date: date(2025, time.January, 31), Amount: money.MustParse("-100000.00")},
+				{Date: date(2025, time.February, 14), Amount: money.MustParse("100500.00")},
+			},
+			want: 12,
+		},
+		{
+			name: "two months from month end",
+			flows: []CashFlow{
+				{Date: date(2025, time.January, 31), Amount: money.MustParse("-100000.00")},
+				{Date: date(2025, time.February, 28), Amount: money.MustParse("1000.00")},
+				{Date: date(2025, time.March, 31), Amount: money.MustParse("101000.00")},
+			},
+			want: 12,
+		},
+		{
+			name: "no overpayment",
+			flows: []CashFlow{
+				{Date: date(2025, time.January, 15), Amount: money.MustParse("-1000.00")},
+				{Date: date(2025, time.February, 15), Amount: money.MustParse("500.00")},
+				{Date: date(2025, time.March, 15), Amount: money.MustParse("500.00")},
+			},
+			want: 0,
+		},
+		{name: "no flows", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FullCostRate(tt.flows); got != tt.want {
+				t.Errorf("FullCostRate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Эталонный график: аннуитет 100 000.00 на 12 месяцев под 12% по 30/360. Округление
+// платежей до копейки сдвигает ПСК от номинальной ставки меньше чем на тысячную.
+func TestFullCostRateOfReferenceSchedule(t *testing.T) {
+	balance, _, schedules := generate(t, models.ScheduleTypeAnnuity, "100000.00", 12, 12)
+
+	flows := []CashFlow{{Date: testStart, Amount: balance.Neg()}}
+	for _, schedule := range schedules {
+		flows = append(flows, CashFlow{Date: schedule.PaymentDate, Amount: schedule.Amount})
+	}
+	if got, want := FullCostRate(flows), 12.0; got != want {
+		t.Errorf("FullCostRate() = %v, want %v", got, want)
+	}
+
+	differentiated := []CashFlow{{Date: testStart, Amount: balance.Neg()}}
+	_, _, schedules = generate(t, models.ScheduleTypeDifferentiated, "100000.00", 12, 12)
+	for _, schedule := range schedules {
+		differentiated = append(differentiated, CashFlow{Date: schedule.PaymentDate, Amount: schedule.Amount})
+	}
+	if got, want := FullCostRate(differentiated), 12.0; got != want {
+		t.Errorf("differentiated FullCostRate() = %v, want %v", got, want)
+	}
+}
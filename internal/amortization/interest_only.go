@@ -0,0 +1,23 @@
+package amortization
+
+import (
+	"time"
+
+	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/money"
+)
+
+// interestOnly — в течение срока платятся только проценты, весь основной долг гасится
+// последним платежом
+type interestOnly struct{}
+
+func (interestOnly) Generate(balance money.Amount, terms Terms, start time.Time, dates []time.Time) []*models.PaymentSchedule {
+	return build(balance, terms, start, dates, func(_, _ money.Amount) money.Amount {
+		return 0
+	})
+}
+
+// Installment возвращает проценты за месяц по номинальной ставке: от срока регулярный платёж не зависит
+func (interestOnly) Installment(balance money.Amount, terms Terms, _ int) money.Amount {
+	return balance.MulRat(terms.monthlyRate(), money.HalfUp)
+}
@@ -0,0 +1,23 @@
+package amortization
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/money"
+)
+
+// zeroInterest — беспроцентная рассрочка: долг гасится равными частями
+type zeroInterest struct{}
+
+func (z zeroInterest) Generate(balance money.Amount, terms Terms, start time.Time, dates []time.Time) []*models.PaymentSchedule {
+	part := z.Installment(balance, terms, len(dates))
+	return build(balance, Terms{Rate: new(big.Rat), DayCount: terms.DayCount}, start, dates, func(_, _ money.Amount) money.Amount {
+		return part
+	})
+}
+
+func (zeroInterest) Installment(balance money.Amount, _ Terms, count int) money.Amount {
+	return equalPart(balance, count)
+}
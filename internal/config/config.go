@@ -0,0 +1,624 @@
+// Package config загружает настройки сервиса: значения по умолчанию профиля,
+// необязательный YAML-файл и переменные окружения BANK_*, которые имеют наивысший приоритет.
+// Для любой переменной можно вместо значения указать путь к файлу в переменной с суффиксом _FILE.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/money"
+	"github.com/bank-service/internal/pan"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// Профили окружений
+const (
+	ProfileDev     = "dev"
+	ProfileStaging = "staging"
+	ProfileProd    = "prod"
+)
+
+// EnvPrefix — префикс переменных окружения сервиса
+const EnvPrefix = "BANK_"
+
+// minProdSecretLength — минимальная длина секретов подписи вне dev-профиля
+const minProdSecretLength = 32
+
+type Config struct {
+	Profile     string            `yaml:"profile"`
+	Server      ServerConfig      `yaml:"server"`
+	Database    DatabaseConfig    `yaml:"database"`
+	Security    SecurityConfig    `yaml:"security"`
+	Exchange    ExchangeConfig    `yaml:"exchange"`
+	Holds       HoldsConfig       `yaml:"holds"`
+	Cards       CardsConfig       `yaml:"cards"`
+	Credits     CreditsConfig     `yaml:"credits"`
+	ISO8583     ISO8583Config     `yaml:"iso8583"`
+	Limits      LimitsConfig      `yaml:"limits"`
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+	Log         LogConfig         `yaml:"log"`
+}
+
+type ServerConfig struct {
+	Addr              string        `yaml:"addr"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	// ShutdownTimeout — сколько ждать завершения обрабатываемых запросов и воркеров при остановке
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+}
+
+type DatabaseConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	User     string `yaml:"user"`
+	Password Secret `yaml:"password"`
+	Name     string `yaml:"name"`
+	SSLMode  string `yaml:"sslmode"`
+}
+
+type SecurityConfig struct {
+	JWTSecret  Secret `yaml:"jwt_secret"`
+	HMACSecret Secret `yaml:"hmac_secret"`
+	// AccessTokenTTL — срок действия access-токена; отзыв проверяется при каждом запросе
+	AccessTokenTTL time.Duration `yaml:"access_token_ttl"`
+	// RefreshTokenTTL — срок действия refresh-токена, продлевается при каждом обмене
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl"`
+	// CardKeyFile — файл ключей шифрования реквизитов карт (KEK), см. пакет envelope
+	CardKeyFile string `yaml:"card_key_file"`
+}
+
+type ExchangeConfig struct {
+	// CBRURL — адрес ежедневной выгрузки курсов ЦБ
+	CBRURL string `yaml:"cbr_url"`
+	// RatesFile — файл с таблицей курсов; если задан, используется вместо выгрузки ЦБ
+	RatesFile string `yaml:"rates_file"`
+}
+
+type HoldsConfig struct {
+	// ExpiryInterval — период запуска фонового снятия просроченных холдов
+	ExpiryInterval time.Duration `yaml:"expiry_interval"`
+}
+
+type CardsConfig struct {
+	// DefaultProduct — продукт, который выпускается, если клиент не указал другой
+	DefaultProduct string `yaml:"default_product"`
+	// Products — карточные продукты по кодам
+	Products map[string]CardProductConfig `yaml:"products"`
+	// ExpiryInterval — период запуска фонового перевода карт с истёкшим сроком в статус expired
+	ExpiryInterval time.Duration `yaml:"expiry_interval"`
+}
+
+type CardProductConfig struct {
+	// BINRanges — BIN ("220070") или диапазоны BIN ("22007000-22007099") продукта
+	BINRanges []string `yaml:"bin_ranges"`
+	// ValidityMonths — срок действия карты с месяца выпуска
+	ValidityMonths int `yaml:"validity_months"`
+}
+
+type CreditsConfig struct {
+	// RepaymentInterval — период запуска автосписания наступивших платежей по кредитам,
+	// начисления неустойки и пересчёта статусов просроченных кредитов
+	RepaymentInterval time.Duration `yaml:"repayment_interval"`
+	// DefaultAfterDays — через сколько дней просрочки платежа кредит считается дефолтным
+	DefaultAfterDays int           `yaml:"default_after_days"`
+	Penalty          PenaltyConfig `yaml:"penalty"`
+}
+
+// PenaltyConfig — неустойка за просрочку платежа. Ставка пени ограничивается пределами
+// закона о потребительском кредите: 20% годовых по процентным кредитам и 0,1% в день
+// по беспроцентным.
+type PenaltyConfig struct {
+	// FixedFee — штраф, начисляемый один раз на просроченный платёж ("500.00"); пустая строка — без штрафа
+	FixedFee string `yaml:"fixed_fee"`
+	// DailyRate — пени в процентах от просроченной суммы за день ("0.1"); пустая строка — без пени
+	DailyRate string `yaml:"daily_rate"`
+}
+
+// Fee возвращает сумму штрафа
+func (p PenaltyConfig) Fee() (money.Amount, error) {
+	if p.FixedFee == "" {
+		return 0, nil
+	}
+	fee, err := money.Parse(p.FixedFee)
+	if err != nil {
+		return 0, err
+	}
+	if fee.IsNegative() {
+		return 0, errors.New("must not be negative")
+	}
+	return fee, nil
+}
+
+// Rate возвращает ставку пени как долю просроченной суммы за день
+func (p PenaltyConfig) Rate() (*big.Rat, error) {
+	if p.DailyRate == "" {
+		return new(big.Rat), nil
+	}
+	rate, ok := new(big.Rat).SetString(p.DailyRate)
+	if !ok {
+		return nil, fmt.Errorf("invalid rate %q", p.DailyRate)
+	}
+	if rate.Sign() < 0 {
+		return nil, errors.New("must not be negative")
+	}
+	return rate.Quo(rate, big.NewRat(100, 1)), nil
+}
+
+type ISO8583Config struct {
+	// Addr — адрес TCP-симулятора процессинга ISO 8583; пустой адрес отключает симулятор
+	Addr string `yaml:"addr"`
+}
+
+type LimitsConfig struct {
+	// Defaults — лимиты банка по умолчанию; для отдельных карт, счетов и пользователей
+	// их переопределяют сотрудники банка через /admin
+	Defaults []LimitConfig `yaml:"defaults"`
+}
+
+// LimitConfig — лимит по умолчанию для всех карт, счетов или пользователей в валюте Currency
+type LimitConfig struct {
+	Scope     string `yaml:"scope"`
+	Operation string `yaml:"operation"`
+	Period    string `yaml:"period"`
+	Currency  string `yaml:"currency"`
+	// MaxAmount — сумма в виде строки ("100000.00"); пустая строка не ограничивает сумму
+	MaxAmount string `yaml:"max_amount"`
+	MaxCount  *int   `yaml:"max_count"`
+}
+
+// Limit переводит лимит из конфигурации в модель
+func (l LimitConfig) Limit() (*models.SpendingLimit, error) {
+	limit := &models.SpendingLimit{
+		LimitKey: models.LimitKey{
+			Scope:     l.Scope,
+			Operation: l.Operation,
+			Period:    l.Period,
+			Currency:  money.Currency(strings.ToUpper(l.Currency)),
+		},
+		MaxCount: l.MaxCount,
+		Source:   models.LimitSourceBank,
+	}
+	if l.MaxAmount != "" {
+		amount, err := money.Parse(l.MaxAmount)
+		if err != nil {
+			return nil, fmt.Errorf("max_amount: %w", err)
+		}
+		limit.MaxAmount = &amount
+	}
+	if err := limit.Validate(); err != nil {
+		return nil, err
+	}
+	return limit, nil
+}
+
+type IdempotencyConfig struct {
+	// KeyTTL — сколько хранится ответ на запрос с Idempotency-Key; после этого ключ можно использовать заново
+	KeyTTL time.Duration `yaml:"key_ttl"`
+	// ProcessingTimeout — через сколько ключ незавершённого запроса считается брошенным
+	// (например, после падения процесса) и освобождается; не меньше server.write_timeout
+	ProcessingTimeout time.Duration `yaml:"processing_timeout"`
+	// CleanupInterval — период удаления истёкших ключей
+	CleanupInterval time.Duration `yaml:"cleanup_interval"`
+}
+
+type LogConfig struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+}
+
+// Defaults возвращает настройки по умолчанию для профиля. В dev-профиле заданы
+// учётные данные локальной базы и тестовые секреты; в staging и prod секреты
+// и пароль базы должны быть переданы явно.
+func Defaults(profile string) *Config {
+	cfg := &Config{
+		Profile: profile,
+		Server: ServerConfig{
+			Addr:              ":8080",
+			ReadTimeout:       15 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+			ShutdownTimeout:   30 * time.Second,
+		},
+		Database: DatabaseConfig{
+			Host:    "localhost",
+			Port:    5432,
+			Name:    "bank_service",
+			SSLMode: "require",
+		},
+		Security: SecurityConfig{
+			AccessTokenTTL:  15 * time.Minute,
+			RefreshTokenTTL: 30 * 24 * time.Hour,
+		},
+		Exchange: ExchangeConfig{CBRURL: "https://www.cbr.ru/scripts/XML_daily.asp"},
+		Holds:    HoldsConfig{ExpiryInterval: time.Minute},
+		Cards:    CardsConfig{DefaultProduct: "classic", ExpiryInterval: time.Hour},
+		Credits:  CreditsConfig{RepaymentInterval: time.Hour, DefaultAfterDays: 90},
+		Idempotency: IdempotencyConfig{
+			KeyTTL:            24 * time.Hour,
+			ProcessingTimeout: 5 * time.Minute,
+			CleanupInterval:   time.Hour,
+		},
+		Log: LogConfig{Level: "info", Format: "json"},
+	}
+	if profile == ProfileDev {
+		cfg.Database.Port = 5436
+		cfg.Database.User = "test"
+		cfg.Database.Password = "test"
+		cfg.Database.SSLMode = "disable"
+		cfg.Security.JWTSecret = "your_jwt_secret"
+		cfg.Security.HMACSecret = "your_hmac_secret"
+		// В dev-профиле файл ключей создаётся при первом запуске
+		cfg.Security.CardKeyFile = "card-keys.json"
+		// Тестовый BIN; в staging и prod диапазоны задаются в файле конфигурации
+		cfg.Cards.Products = map[string]CardProductConfig{
+			"classic": {BINRanges: []string{"22007099"}, ValidityMonths: 48},
+		}
+		// Симулятор процессинга ISO 8583 включён только в dev-профиле
+		cfg.ISO8583.Addr = ":8583"
+		// Тестовая неустойка; пени по процентным кредитам будут ограничены 20% годовых
+		cfg.Credits.Penalty = PenaltyConfig{FixedFee: "500.00", DailyRate: "0.1"}
+		// Тестовые лимиты; в staging и prod лимиты задаются в файле конфигурации
+		dailyPurchases := 50
+		cfg.Limits.Defaults = []LimitConfig{
+			{Scope: "card", Operation: "card_purchase", Period: "daily", Currency: "RUB", MaxAmount: "100000.00", MaxCount: &dailyPurchases},
+			{Scope: "account", Operation: "withdrawal", Period: "daily", Currency: "RUB", MaxAmount: "300000.00"},
+			{Scope: "user", Operation: "transfer", Period: "monthly", Currency: "RUB", MaxAmount: "5000000.00"},
+		}
+		// Курсы ЦБ берутся из локальной заглушки (cmd/cbr-stub)
+		cfg.Exchange.CBRURL = "http://localhost:8090/scripts/XML_daily.asp"
+		cfg.Log.Level = "debug"
+	}
+	return cfg
+}
+
+// Load собирает конфигурацию. Профиль берётся из BANK_PROFILE (по умолчанию prod,
+// чтобы забытая переменная не отключала проверку секретов), путь к YAML-файлу — из аргумента path, а при его отсутствии из BANK_CONFIG_FILE.
+// Профиль, указанный в файле, должен совпадать с профилем окружения.
+func Load(path string) (*Config, error) {
+	profile := os.Getenv(EnvPrefix + "PROFILE")
+	if profile == "" {
+		profile = ProfileProd
+	}
+	if !isProfile(profile) {
+		return nil, fmt.Errorf("unknown profile %q", profile)
+	}
+	cfg := Defaults(profile)
+
+	if path == "" {
+		path = os.Getenv(EnvPrefix + "CONFIG_FILE")
+	}
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, fmt.Errorf("failed to load config file %s: %w", path, err)
+		}
+		if cfg.Profile != profile {
+			return nil, fmt.Errorf("config file %s is for profile %q, but %q is active", path, cfg.Profile, profile)
+		}
+	}
+
+	if err := cfg.loadEnv(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	// Опечатка в имени параметра не должна молча оставлять значение по умолчанию
+	decoder.KnownFields(true)
+	return decoder.Decode(c)
+}
+
+// loadEnv применяет переменные окружения поверх значений из профиля и файла
+func (c *Config) loadEnv() error {
+	bindings := []struct {
+		name string
+		set  func(string) error
+	}{
+		{"HTTP_ADDR", setString(&c.Server.Addr)},
+		{"HTTP_READ_TIMEOUT", setDuration(&c.Server.ReadTimeout)},
+		{"HTTP_READ_HEADER_TIMEOUT", setDuration(&c.Server.ReadHeaderTimeout)},
+		{"HTTP_WRITE_TIMEOUT", setDuration(&c.Server.WriteTimeout)},
+		{"HTTP_IDLE_TIMEOUT", setDuration(&c.Server.IdleTimeout)},
+		{"SHUTDOWN_TIMEOUT", setDuration(&c.Server.ShutdownTimeout)},
+		{"DB_HOST", setString(&c.Database.Host)},
+		{"DB_PORT", setInt(&c.Database.Port)},
+		{"DB_USER", setString(&c.Database.User)},
+		{"DB_PASSWORD", setSecret(&c.Database.Password)},
+		{"DB_NAME", setString(&c.Database.Name)},
+		{"DB_SSLMODE", setString(&c.Database.SSLMode)},
+		{"JWT_SECRET", setSecret(&c.Security.JWTSecret)},
+		{"HMAC_SECRET", setSecret(&c.Security.HMACSecret)},
+		{"CARD_KEY_FILE", setString(&c.Security.CardKeyFile)},
+		{"ACCESS_TOKEN_TTL", setDuration(&c.Security.AccessTokenTTL)},
+		{"REFRESH_TOKEN_TTL", setDuration(&c.Security.RefreshTokenTTL)},
+		{"CBR_URL", setString(&c.Exchange.CBRURL)},
+		{"EXCHANGE_RATES_FILE", setString(&c.Exchange.RatesFile)},
+		{"HOLD_EXPIRY_INTERVAL", setDuration(&c.Holds.ExpiryInterval)},
+		{"CARD_DEFAULT_PRODUCT", setString(&c.Cards.DefaultProduct)},
+		{"CARD_EXPIRY_INTERVAL", setDuration(&c.Cards.ExpiryInterval)},
+		{"CREDIT_REPAYMENT_INTERVAL", setDuration(&c.Credits.RepaymentInterval)},
+		{"CREDIT_DEFAULT_AFTER_DAYS", setInt(&c.Credits.DefaultAfterDays)},
+		{"CREDIT_PENALTY_FIXED_FEE", setString(&c.Credits.Penalty.FixedFee)},
+		{"CREDIT_PENALTY_DAILY_RATE", setString(&c.Credits.Penalty.DailyRate)},
+		{"ISO8583_ADDR", setString(&c.ISO8583.Addr)},
+		{"IDEMPOTENCY_KEY_TTL", setDuration(&c.Idempotency.KeyTTL)},
+		{"IDEMPOTENCY_PROCESSING_TIMEOUT", setDuration(&c.Idempotency.ProcessingTimeout)},
+		{"IDEMPOTENCY_CLEANUP_INTERVAL", setDuration(&c.Idempotency.CleanupInterval)},
+		{"LOG_LEVEL", setString(&c.Log.Level)},
+		{"LOG_FORMAT", setString(&c.Log.Format)},
+	}
+
+	for _, binding := range bindings {
+		name := EnvPrefix + binding.name
+		value, hasValue := os.LookupEnv(name)
+		file, hasFile := os.LookupEnv(name + "_FILE")
+		if hasValue && hasFile {
+			return fmt.Errorf("both %s and %s_FILE are set", name, name)
+		}
+		if hasFile {
+			content, err := readSecretFile(file)
+			if err != nil {
+				return fmt.Errorf("failed to read %s_FILE: %w", name, err)
+			}
+			value, hasValue = content, true
+		}
+		if !hasValue {
+			continue
+		}
+		if err := binding.set(value); err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Validate проверяет согласованность настроек. Вне dev-профиля дополнительно
+// требуются шифрование соединения с базой и достаточно длинные секреты.
+func (c *Config) Validate() error {
+	var problems []string
+	if !isProfile(c.Profile) {
+		problems = append(problems, fmt.Sprintf("unknown profile %q", c.Profile))
+	}
+	if c.Server.Addr == "" {
+		problems = append(problems, "server.addr is required")
+	}
+	timeouts := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"server.read_timeout", c.Server.ReadTimeout},
+		{"server.read_header_timeout", c.Server.ReadHeaderTimeout},
+		{"server.write_timeout", c.Server.WriteTimeout},
+		{"server.idle_timeout", c.Server.IdleTimeout},
+		{"server.shutdown_timeout", c.Server.ShutdownTimeout},
+		{"security.access_token_ttl", c.Security.AccessTokenTTL},
+		{"security.refresh_token_ttl", c.Security.RefreshTokenTTL},
+		{"idempotency.key_ttl", c.Idempotency.KeyTTL},
+		{"idempotency.processing_timeout", c.Idempotency.ProcessingTimeout},
+		{"idempotency.cleanup_interval", c.Idempotency.CleanupInterval},
+	}
+	for _, timeout := range timeouts {
+		if timeout.value <= 0 {
+			problems = append(problems, timeout.name+" must be positive")
+		}
+	}
+	if c.Database.Host == "" {
+		problems = append(problems, "database.host is required")
+	}
+	if c.Database.Port <= 0 || c.Database.Port > 65535 {
+		problems = append(problems, "database.port must be between 1 and 65535")
+	}
+	if c.Database.User == "" {
+		problems = append(problems, "database.user is required")
+	}
+	if c.Database.Name == "" {
+		problems = append(problems, "database.name is required")
+	}
+	switch c.Database.SSLMode {
+	case "disable", "require", "verify-ca", "verify-full":
+	default:
+		problems = append(problems, fmt.Sprintf("database.sslmode %q is not supported", c.Database.SSLMode))
+	}
+	if c.Security.JWTSecret == "" {
+		problems = append(problems, "security.jwt_secret is required")
+	}
+	if c.Security.HMACSecret == "" {
+		problems = append(problems, "security.hmac_secret is required")
+	}
+	if c.Security.CardKeyFile == "" {
+		problems = append(problems, "security.card_key_file is required")
+	}
+	if c.Security.RefreshTokenTTL <= c.Security.AccessTokenTTL {
+		problems = append(problems, "security.refresh_token_ttl must be longer than security.access_token_ttl")
+	}
+	if c.Idempotency.ProcessingTimeout < c.Server.WriteTimeout {
+		problems = append(problems, "idempotency.processing_timeout must not be shorter than server.write_timeout")
+	}
+	if c.Exchange.RatesFile == "" {
+		if _, err := url.ParseRequestURI(c.Exchange.CBRURL); err != nil {
+			problems = append(problems, "exchange.cbr_url must be a valid URL")
+		}
+	}
+	if c.Holds.ExpiryInterval <= 0 {
+		problems = append(problems, "holds.expiry_interval must be positive")
+	}
+	if c.Cards.ExpiryInterval <= 0 {
+		problems = append(problems, "cards.expiry_interval must be positive")
+	}
+	if c.Credits.RepaymentInterval <= 0 {
+		problems = append(problems, "credits.repayment_interval must be positive")
+	}
+	if c.Credits.DefaultAfterDays <= 0 {
+		problems = append(problems, "credits.default_after_days must be positive")
+	}
+	if _, err := c.Credits.Penalty.Fee(); err != nil {
+		problems = append(problems, fmt.Sprintf("credits.penalty.fixed_fee: %v", err))
+	}
+	if _, err := c.Credits.Penalty.Rate(); err != nil {
+		problems = append(problems, fmt.Sprintf("credits.penalty.daily_rate: %v", err))
+	}
+	if _, ok := c.Cards.Products[c.Cards.DefaultProduct]; !ok {
+		problems = append(problems, fmt.Sprintf("cards.default_product %q is not defined in cards.products", c.Cards.DefaultProduct))
+	}
+	for name, product := range c.Cards.Products {
+		if len(product.BINRanges) == 0 {
+			problems = append(problems, fmt.Sprintf("cards.products.%s.bin_ranges is required", name))
+		}
+		for _, binRange := range product.BINRanges {
+			if _, err := pan.ParseRange(binRange); err != nil {
+				problems = append(problems, fmt.Sprintf("cards.products.%s.bin_ranges: %v", name, err))
+			}
+		}
+		if product.ValidityMonths <= 0 {
+			problems = append(problems, fmt.Sprintf("cards.products.%s.validity_months must be positive", name))
+		}
+	}
+	seen := make(map[models.LimitKey]bool)
+	for i, limitCfg := range c.Limits.Defaults {
+		limit, err := limitCfg.Limit()
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("limits.defaults[%d]: %v", i, err))
+			continue
+		}
+		if seen[limit.LimitKey] {
+			problems = append(problems, fmt.Sprintf("limits.defaults[%d] duplicates an earlier limit", i))
+		}
+		seen[limit.LimitKey] = true
+	}
+	if _, err := logrus.ParseLevel(c.Log.Level); err != nil {
+		problems = append(problems, fmt.Sprintf("log.level %q is not supported", c.Log.Level))
+	}
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		problems = append(problems, "log.format must be json or text")
+	}
+
+	if c.Profile != ProfileDev {
+		if c.Database.SSLMode == "disable" {
+			problems = append(problems, "database.sslmode must not be disable outside the dev profile")
+		}
+		if c.Database.Password == "" {
+			problems = append(problems, "database.password is required outside the dev profile")
+		}
+		if len(c.Security.JWTSecret) < minProdSecretLength {
+			problems = append(problems, fmt.Sprintf("security.jwt_secret must be at least %d characters outside the dev profile", minProdSecretLength))
+		}
+		if len(c.Security.HMACSecret) < minProdSecretLength {
+			problems = append(problems, fmt.Sprintf("security.hmac_secret must be at least %d characters outside the dev profile", minProdSecretLength))
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New("invalid configuration: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// DSN возвращает строку подключения к PostgreSQL с паролем; её нельзя логировать
+func (c *Config) DSN() string {
+	return c.Database.dsn(c.Database.Password.Value())
+}
+
+// RedactedDSN возвращает строку подключения со скрытым паролем
+func (c *Config) RedactedDSN() string {
+	return c.Database.dsn(c.Database.Password.String())
+}
+
+func (d DatabaseConfig) dsn(password string) string {
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s search_path=bank",
+		quoteDSN(d.Host), d.Port, quoteDSN(d.User), quoteDSN(password), quoteDSN(d.Name), d.SSLMode)
+}
+
+// quoteDSN экранирует значение для строки подключения в формате key=value
+func quoteDSN(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// NewLogger создаёт логгер с уровнем и форматом из конфигурации
+func (c *Config) NewLogger() *logrus.Logger {
+	logger := logrus.New()
+	if c.Log.Format == "text" {
+		logger.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	} else {
+		logger.SetFormatter(&logrus.JSONFormatter{})
+	}
+	level, err := logrus.ParseLevel(c.Log.Level)
+	if err != nil {
+		level = logrus.InfoLevel
+	}
+	logger.SetLevel(level)
+	return logger
+}
+
+// String возвращает конфигурацию в YAML со скрытыми секретами для записи в лог при старте
+func (c *Config) String() string {
+	out, err := yaml.Marshal(c)
+	if err != nil {
+		return err.Error()
+	}
+	return string(out)
+}
+
+func isProfile(profile string) bool {
+	return profile == ProfileDev || profile == ProfileStaging || profile == ProfileProd
+}
+
+func setString(target *string) func(string) error {
+	return func(value string) error {
+		*target = value
+		return nil
+	}
+}
+
+func setSecret(target *Secret) func(string) error {
+	return func(value string) error {
+		*target = Secret(value)
+		return nil
+	}
+}
+
+func setInt(target *int) func(string) error {
+	return func(value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		*target = n
+		return nil
+	}
+}
+
+func setDuration(target *time.Duration) func(string) error {
+	return func(value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		*target = d
+		return nil
+	}
+}
@@ -0,0 +1,226 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// clearEnv убирает переменные BANK_* на время теста
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if strings.HasPrefix(name, EnvPrefix) {
+			t.Setenv(name, "")
+			os.Unsetenv(name)
+		}
+	}
+}
+
+func TestLoadDefaultsToProdProfile(t *testing.T) {
+	clearEnv(t)
+
+	_, err := Load("")
+	if err == nil {
+		t.Fatal("Load without BANK_PROFILE accepted a config without secrets")
+	}
+	for _, problem := range []string{"database.password", "security.jwt_secret", "security.hmac_secret"} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("error %q does not mention %s", err, problem)
+		}
+	}
+}
+
+func TestLoadUsesExplicitDevProfile(t *testing.T) {
+	clearEnv(t)
+	t.Setenv(EnvPrefix+"PROFILE", ProfileDev)
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Profile != ProfileDev {
+		t.Errorf("profile = %q, want %q", cfg.Profile, ProfileDev)
+	}
+}
+
+// writeFile создаёт во временном каталоге теста файл с содержимым content
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestLoadEnvOverridesFile(t *testing.T) {
+	clearEnv(t)
+	path := writeFile(t, "config.yaml", `
+profile: dev
+server:
+  addr: ":9000"
+database:
+  name: from_file
+log:
+  level: warn
+`)
+	t.Setenv(EnvPrefix+"PROFILE", ProfileDev)
+	t.Setenv(EnvPrefix+"CONFIG_FILE", path)
+	t.Setenv(EnvPrefix+"HTTP_ADDR", ":9100")
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Server.Addr != ":9100" {
+		t.Errorf("server.addr = %q, want the environment value :9100", cfg.Server.Addr)
+	}
+	if cfg.Database.Name != "from_file" || cfg.Log.Level != "warn" {
+		t.Errorf("database.name = %q, log.level = %q; want the file values", cfg.Database.Name, cfg.Log.Level)
+	}
+	if defaults := Defaults(ProfileDev); cfg.Database.Host != defaults.Database.Host {
+		t.Errorf("database.host = %q, want the profile default %q", cfg.Database.Host, defaults.Database.Host)
+	}
+}
+
+func TestLoadRejectsInvalidFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"other profile", "profile: prod\n", `is for profile "prod"`},
+		{"unknown field", "profile: dev\nserver:\n  adr: \":9000\"\n", "adr"},
+		{"empty secret reference", "profile: dev\nsecurity:\n  jwt_secret: {file: \"\"}\n", "secret reference must contain a file path"},
+		{"missing secret file", "profile: dev\nsecurity:\n  jwt_secret: {file: /nonexistent/jwt}\n", "/nonexistent/jwt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			t.Setenv(EnvPrefix+"PROFILE", ProfileDev)
+
+			_, err := Load(writeFile(t, "config.yaml", tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load() error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+const (
+	testDBPassword = "db-password-from-file"
+	testJWTSecret  = "jwt-secret-from-file-0123456789abcdef"
+	testHMACSecret = "hmac-secret-from-yaml-0123456789abcdef"
+)
+
+// loadProdWithSecretFiles загружает prod-конфигурацию, секреты которой читаются из файлов:
+// пароль базы и секрет JWT — через BANK_*_FILE, секрет HMAC — через ссылку в YAML
+func loadProdWithSecretFiles(t *testing.T) *Config {
+	t.Helper()
+	clearEnv(t)
+	hmacFile := writeFile(t, "hmac_secret", testHMACSecret+"\n")
+	path := writeFile(t, "config.yaml", `
+profile: prod
+database:
+  user: bank
+security:
+  hmac_secret: {file: `+hmacFile+`}
+  card_key_file: /run/secrets/card_keys
+cards:
+  products:
+    classic:
+      bin_ranges: ["22007000-22007049"]
+      validity_months: 48
+`)
+	t.Setenv(EnvPrefix+"PROFILE", ProfileProd)
+	t.Setenv(EnvPrefix+"DB_SSLMODE", "require")
+	t.Setenv(EnvPrefix+"DB_PASSWORD_FILE", writeFile(t, "db_password", testDBPassword+"\r\n"))
+	t.Setenv(EnvPrefix+"JWT_SECRET_FILE", writeFile(t, "jwt_secret", testJWTSecret))
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	return cfg
+}
+
+func TestLoadReadsSecretFiles(t *testing.T) {
+	cfg := loadProdWithSecretFiles(t)
+
+	if got := cfg.Database.Password.Value(); got != testDBPassword {
+		t.Errorf("database.password = %q, want %q without the trailing newline", got, testDBPassword)
+	}
+	if got := cfg.Security.JWTSecret.Value(); got != testJWTSecret {
+		t.Errorf("security.jwt_secret = %q, want %q", got, testJWTSecret)
+	}
+	if got := cfg.Security.HMACSecret.Value(); got != testHMACSecret {
+		t.Errorf("security.hmac_secret = %q, want %q", got, testHMACSecret)
+	}
+	if !strings.Contains(cfg.DSN(), "password="+testDBPassword) {
+		t.Error("DSN does not contain the database password")
+	}
+}
+
+func TestLoadRejectsAmbiguousSecretSources(t *testing.T) {
+	clearEnv(t)
+	t.Setenv(EnvPrefix+"PROFILE", ProfileDev)
+	t.Setenv(EnvPrefix+"JWT_SECRET", testJWTSecret)
+	t.Setenv(EnvPrefix+"JWT_SECRET_FILE", writeFile(t, "jwt_secret", testJWTSecret))
+
+	if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "both BANK_JWT_SECRET and BANK_JWT_SECRET_FILE are set") {
+		t.Errorf("Load() error = %v, want a conflict between the value and the file", err)
+	}
+
+	t.Setenv(EnvPrefix+"JWT_SECRET", "")
+	os.Unsetenv(EnvPrefix + "JWT_SECRET")
+	t.Setenv(EnvPrefix+"JWT_SECRET_FILE", "/nonexistent/jwt_secret")
+	if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "BANK_JWT_SECRET_FILE") {
+		t.Errorf("Load() error = %v, want a failure to read BANK_JWT_SECRET_FILE", err)
+	}
+}
+
+func TestSecretsAreRedacted(t *testing.T) {
+	cfg := loadProdWithSecretFiles(t)
+
+	var logged bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&logged)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.WithField("config", cfg).WithField("password", cfg.Database.Password).Info("loaded")
+
+	encoded, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("marshal config: %v", err)
+	}
+	outputs := map[string]string{
+		"String":      cfg.String(),
+		"RedactedDSN": cfg.RedactedDSN(),
+		"%v":          fmt.Sprintf("%v", cfg),
+		"%+v":         fmt.Sprintf("%+v", *cfg),
+		"%#v":         fmt.Sprintf("%#v", *cfg),
+		"%s secret":   fmt.Sprintf("%s", cfg.Security.JWTSecret),
+		"json":        string(encoded),
+		"log":         logged.String(),
+	}
+	for name, output := range outputs {
+		for _, secret := range []string{testDBPassword, testJWTSecret, testHMACSecret} {
+			if strings.Contains(output, secret) {
+				t.Errorf("%s output leaks a secret: %s", name, output)
+			}
+		}
+	}
+	if !strings.Contains(cfg.String(), "jwt_secret: '[REDACTED]'") {
+		t.Errorf("String() does not show the redacted placeholder:\n%s", cfg.String())
+	}
+	if !strings.Contains(cfg.RedactedDSN(), "password=[REDACTED]") {
+		t.Errorf("RedactedDSN() = %q, want the redacted placeholder", cfg.RedactedDSN())
+	}
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "[REDACTED]"
+
+// Secret — строковое значение, которое не должно попадать в логи.
+// При форматировании и сериализации выводится заглушка, само значение доступно через Value.
+type Secret string
+
+func (s Secret) Value() string {
+	return string(s)
+}
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+func (s Secret) GoString() string {
+	return s.String()
+}
+
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s Secret) MarshalYAML() (interface{}, error) {
+	return s.String(), nil
+}
+
+// UnmarshalYAML принимает секрет строкой или ссылкой на файл:
+//
+//	password: s3cret
+//	password: {file: /run/secrets/db_password}
+func (s *Secret) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*s = Secret(node.Value)
+		return nil
+	}
+	var ref struct {
+		File string `yaml:"file"`
+	}
+	if err := node.Decode(&ref); err != nil {
+		return err
+	}
+	if ref.File == "" {
+		return errors.New("secret reference must contain a file path")
+	}
+	value, err := readSecretFile(ref.File)
+	if err != nil {
+		return err
+	}
+	*s = Secret(value)
+	return nil
+}
+
+// readSecretFile читает секрет из смонтированного файла, отбрасывая завершающий перевод строки
+func readSecretFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
@@ -0,0 +1,186 @@
+// Package envelope реализует конвертное шифрование: каждая запись шифруется собственным
+// случайным ключом данных (DEK) по AES-256-GCM, а сам ключ данных хранится рядом с записью
+// в зашифрованном ключом шифрования ключей (KEK) виде. KEK хранятся только в файле ключей
+// и никогда не попадают в базу.
+//
+// Файл ключей — JSON вида {"active": "2024-01", "keys": {"2024-01": "<base64 32 байта>"}}.
+// При ротации новый ключ добавляется в keys и становится active; старые ключи остаются
+// в файле, пока ими зашифрован хотя бы один ключ данных.
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// KeySize — длина KEK и ключей данных в байтах (AES-256)
+const KeySize = 32
+
+var (
+	ErrUnknownKey = errors.New("unknown key encryption key")
+	ErrDecrypt    = errors.New("failed to decrypt data")
+)
+
+// KeyRing — набор KEK, один из которых используется для новых ключей данных
+type KeyRing struct {
+	active string
+	keys   map[string][]byte
+}
+
+// NewKeyRing создаёт набор ключей; active должен присутствовать в keys
+func NewKeyRing(active string, keys map[string][]byte) (*KeyRing, error) {
+	if len(keys[active]) != KeySize {
+		return nil, fmt.Errorf("active key %q must be %d bytes long", active, KeySize)
+	}
+	ring := &KeyRing{active: active, keys: make(map[string][]byte, len(keys))}
+	for id, key := range keys {
+		if len(key) != KeySize {
+			return nil, fmt.Errorf("key %q must be %d bytes long", id, KeySize)
+		}
+		ring.keys[id] = append([]byte(nil), key...)
+	}
+	return ring, nil
+}
+
+// LoadKeyRing читает набор KEK из файла ключей
+func LoadKeyRing(path string) (*KeyRing, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file struct {
+		Active string            `json:"active"`
+		Keys   map[string]string `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse key file: %w", err)
+	}
+	keys := make(map[string][]byte, len(file.Keys))
+	for id, encoded := range file.Keys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("key %q is not valid base64: %w", id, err)
+		}
+		keys[id] = key
+	}
+	return NewKeyRing(file.Active, keys)
+}
+
+// GenerateKeyFile создаёт файл ключей с одним случайным KEK и правами 0600.
+// Существующий файл не перезаписывается.
+func GenerateKeyFile(path, keyID string) error {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"active": keyID,
+		"keys":   map[string]string{keyID: base64.StdEncoding.EncodeToString(key)},
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ActiveKeyID возвращает идентификатор KEK для новых ключей данных
+func (r *KeyRing) ActiveKeyID() string {
+	return r.active
+}
+
+// DataKey — расшифрованный ключ данных одной записи вместе с его зашифрованной копией
+type DataKey struct {
+	KeyID   string
+	Wrapped []byte
+	key     []byte
+}
+
+// NewDataKey создаёт случайный ключ данных и шифрует его активным KEK
+func (r *KeyRing) NewDataKey() (*DataKey, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	wrapped, err := seal(r.keys[r.active], key, []byte(r.active))
+	if err != nil {
+		return nil, err
+	}
+	return &DataKey{KeyID: r.active, Wrapped: wrapped, key: key}, nil
+}
+
+// OpenDataKey расшифровывает ключ данных, сохранённый вместе с записью
+func (r *KeyRing) OpenDataKey(keyID string, wrapped []byte) (*DataKey, error) {
+	kek, ok := r.keys[keyID]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	key, err := open(kek, wrapped, []byte(keyID))
+	if err != nil {
+		return nil, err
+	}
+	return &DataKey{KeyID: keyID, Wrapped: wrapped, key: key}, nil
+}
+
+// Encrypt шифрует значение ключом данных. aad (например, имя поля) не шифруется,
+// но должен совпасть при расшифровке, поэтому шифротекст нельзя переставить в другое поле.
+func (k *DataKey) Encrypt(plaintext, aad []byte) ([]byte, error) {
+	return seal(k.key, plaintext, aad)
+}
+
+func (k *DataKey) Decrypt(ciphertext, aad []byte) ([]byte, error) {
+	return open(k.key, ciphertext, aad)
+}
+
+// seal шифрует данные AES-256-GCM; случайный nonce записывается перед шифротекстом
+func seal(key, plaintext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func open(key, ciphertext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize()+gcm.Overhead() {
+		return nil, ErrDecrypt
+	}
+	nonce, sealed := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, sealed, aad)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,134 @@
+package exchange
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bank-service/internal/money"
+)
+
+// DefaultCBRURL — адрес ежедневной выгрузки официальных курсов Банка России
+const DefaultCBRURL = "https://www.cbr.ru/scripts/XML_daily.asp"
+
+// CBRProvider получает официальные курсы Банка России из ежедневной XML-выгрузки.
+// Курсы кешируются и перезапрашиваются не чаще одного раза за cacheTTL.
+type CBRProvider struct {
+	url      string
+	client   *http.Client
+	cacheTTL time.Duration
+
+	mutex     sync.Mutex
+	rates     map[money.Currency]*big.Rat
+	date      time.Time
+	fetchedAt time.Time
+}
+
+func NewCBRProvider(url string, client *http.Client) *CBRProvider {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &CBRProvider{
+		url:      url,
+		client:   client,
+		cacheTTL: time.Hour,
+	}
+}
+
+func (p *CBRProvider) Rate(ctx context.Context, from, to money.Currency) (*Rate, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.rates == nil || time.Since(p.fetchedAt) > p.cacheTTL {
+		rates, date, err := p.fetch(ctx)
+		if err != nil {
+			// Пока есть ранее загруженные курсы, продолжаем работать по ним
+			if p.rates == nil {
+				return nil, err
+			}
+		} else {
+			p.rates, p.date, p.fetchedAt = rates, date, time.Now()
+		}
+	}
+
+	return crossRate(money.RUB, p.rates, from, to, p.date)
+}
+
+// cbrValCurs соответствует корневому элементу выгрузки XML_daily.asp
+type cbrValCurs struct {
+	XMLName xml.Name `xml:"ValCurs"`
+	Date    string   `xml:"Date,attr"`
+	Valutes []struct {
+		CharCode string `xml:"CharCode"`
+		Nominal  string `xml:"Nominal"`
+		Value    string `xml:"Value"`
+	} `xml:"Valute"`
+}
+
+func (p *CBRProvider) fetch(ctx context.Context) (map[money.Currency]*big.Rat, time.Time, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to fetch CBR rates: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, time.Time{}, fmt.Errorf("failed to fetch CBR rates: unexpected status %d", resp.StatusCode)
+	}
+
+	return parseCBRDaily(resp.Body)
+}
+
+// parseCBRDaily разбирает выгрузку ЦБ. Курс указан за Nominal единиц валюты,
+// а десятичный разделитель — запятая.
+func parseCBRDaily(r io.Reader) (map[money.Currency]*big.Rat, time.Time, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = charsetReader
+
+	var doc cbrValCurs
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to parse CBR rates: %w", err)
+	}
+
+	date, err := time.Parse("02.01.2006", doc.Date)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("invalid CBR rates date %q: %w", doc.Date, err)
+	}
+
+	rates := make(map[money.Currency]*big.Rat)
+	for _, v := range doc.Valutes {
+		currency := money.Currency(strings.TrimSpace(v.CharCode))
+		if !currency.IsValid() {
+			continue
+		}
+		value, ok := new(big.Rat).SetString(strings.Replace(strings.TrimSpace(v.Value), ",", ".", 1))
+		if !ok {
+			return nil, time.Time{}, fmt.Errorf("invalid CBR rate for %s: %q", currency, v.Value)
+		}
+		nominal, ok := new(big.Rat).SetString(strings.TrimSpace(v.Nominal))
+		if !ok || nominal.Sign() <= 0 {
+			return nil, time.Time{}, fmt.Errorf("invalid CBR nominal for %s: %q", currency, v.Nominal)
+		}
+		rates[currency] = value.Quo(value, nominal)
+	}
+	return rates, date, nil
+}
+
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "windows-1251", "cp1251":
+		return newWindows1251Reader(input), nil
+	case "utf-8", "":
+		return input, nil
+	}
+	return nil, fmt.Errorf("unsupported charset %q", charset)
+}
@@ -0,0 +1,78 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/bank-service/internal/money"
+)
+
+// RateScale — количество знаков после запятой, до которого округляются курсы.
+// Перевод выполняется по уже округлённому курсу, поэтому записанный в транзакции курс
+// позволяет точно воспроизвести сумму зачисления.
+const RateScale = 8
+
+var ErrRateNotFound = errors.New("exchange rate not found")
+
+// Rate — курс конвертации: 1 единица From стоит Value единиц To
+type Rate struct {
+	From  money.Currency
+	To    money.Currency
+	Value *big.Rat
+	Date  time.Time
+}
+
+// Convert переводит сумму в валюте From в валюту To
+func (r *Rate) Convert(amount money.Amount) money.Amount {
+	return amount.MulRat(r.Value, money.HalfUp)
+}
+
+// String возвращает курс в десятичной записи с RateScale знаками
+func (r *Rate) String() string {
+	return r.Value.FloatString(RateScale)
+}
+
+// Provider возвращает курс конвертации между двумя валютами
+type Provider interface {
+	Rate(ctx context.Context, from, to money.Currency) (*Rate, error)
+}
+
+// crossRate вычисляет курс from→to по курсам обеих валют к базовой валюте
+// (сколько единиц базовой валюты стоит одна единица валюты)
+func crossRate(base money.Currency, perUnit map[money.Currency]*big.Rat, from, to money.Currency, date time.Time) (*Rate, error) {
+	if from == to {
+		return &Rate{From: from, To: to, Value: big.NewRat(1, 1), Date: date}, nil
+	}
+
+	lookup := func(c money.Currency) (*big.Rat, error) {
+		if c == base {
+			return big.NewRat(1, 1), nil
+		}
+		value, ok := perUnit[c]
+		if !ok || value.Sign() <= 0 {
+			return nil, fmt.Errorf("%w: %s", ErrRateNotFound, c)
+		}
+		return value, nil
+	}
+
+	fromValue, err := lookup(from)
+	if err != nil {
+		return nil, err
+	}
+	toValue, err := lookup(to)
+	if err != nil {
+		return nil, err
+	}
+
+	value := new(big.Rat).Quo(fromValue, toValue)
+	return &Rate{From: from, To: to, Value: roundRate(value), Date: date}, nil
+}
+
+// roundRate округляет курс до RateScale знаков после запятой
+func roundRate(value *big.Rat) *big.Rat {
+	rounded, _ := new(big.Rat).SetString(value.FloatString(RateScale))
+	return rounded
+}
@@ -0,0 +1,79 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/bank-service/internal/money"
+)
+
+// StaticProvider отдаёт курсы из фиксированной таблицы, заданной относительно базовой валюты
+type StaticProvider struct {
+	base  money.Currency
+	rates map[money.Currency]*big.Rat
+	date  time.Time
+}
+
+func NewStaticProvider(base money.Currency, rates map[money.Currency]*big.Rat) *StaticProvider {
+	return &StaticProvider{
+		base:  base,
+		rates: rates,
+		date:  time.Now(),
+	}
+}
+
+// LoadStaticProvider читает таблицу курсов из JSON-файла вида
+//
+//	{"base": "RUB", "date": "2026-10-17", "rates": {"USD": "81.3750", "EUR": "94.5021"}}
+//
+// Курсы задаются строками, чтобы избежать потери точности.
+func LoadStaticProvider(path string) (*StaticProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file struct {
+		Base  string            `json:"base"`
+		Date  string            `json:"date"`
+		Rates map[string]string `json:"rates"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse exchange rates file: %w", err)
+	}
+
+	base, err := money.ParseCurrency(file.Base)
+	if err != nil {
+		return nil, err
+	}
+	rates := make(map[money.Currency]*big.Rat, len(file.Rates))
+	for code, value := range file.Rates {
+		currency, err := money.ParseCurrency(code)
+		if err != nil {
+			return nil, err
+		}
+		rate, ok := new(big.Rat).SetString(value)
+		if !ok || rate.Sign() <= 0 {
+			return nil, fmt.Errorf("invalid exchange rate for %s: %q", code, value)
+		}
+		rates[currency] = rate
+	}
+
+	provider := NewStaticProvider(base, rates)
+	if file.Date != "" {
+		date, err := time.Parse("2006-01-02", file.Date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exchange rates date: %w", err)
+		}
+		provider.date = date
+	}
+	return provider, nil
+}
+
+func (p *StaticProvider) Rate(ctx context.Context, from, to money.Currency) (*Rate, error) {
+	return crossRate(p.base, p.rates, from, to, p.date)
+}
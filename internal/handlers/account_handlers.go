@@ -1,12 +1,20 @@
 package handlers
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/money"
 	"github.com/bank-service/internal/services"
+	"github.com/bank-service/internal/statements"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 )
@@ -23,6 +31,33 @@ func NewAccountHandler(accountService services.AccountService, logger *logrus.Lo
 	}
 }
 
+// transactionResponse — представление операции по счёту в ответах API
+type transactionResponse struct {
+	ID           int64        `json:"id"`
+	AccountID    int64        `json:"account_id"`
+	Amount       money.Amount `json:"amount"`
+	Type         string       `json:"type"`
+	Description  string       `json:"description"`
+	ExchangeRate string       `json:"exchange_rate,omitempty"`
+	CreatedAt    string       `json:"created_at"`
+}
+
+func newTransactionResponses(transactions []*models.Transaction) []transactionResponse {
+	resp := make([]transactionResponse, len(transactions))
+	for i, transaction := range transactions {
+		resp[i] = transactionResponse{
+			ID:           transaction.ID,
+			AccountID:    transaction.AccountID,
+			Amount:       transaction.Amount,
+			Type:         transaction.Type,
+			Description:  transaction.Description,
+			ExchangeRate: transaction.ExchangeRate,
+			CreatedAt:    transaction.CreatedAt.Format(time.RFC3339),
+		}
+	}
+	return resp
+}
+
 func (h *AccountHandler) CreateAccount(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value("user_id").(int64)
 	if !ok {
@@ -31,25 +66,49 @@ func (h *AccountHandler) CreateAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	account, err := h.accountService.CreateAccount(r.Context(), userID)
+	// Тело запроса необязательно: без него открывается рублёвый счёт
+	var req struct {
+		Currency string `json:"currency"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		h.logger.Error("Failed to decode request: ", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	currency := money.RUB
+	if req.Currency != "" {
+		parsed, err := money.ParseCurrency(req.Currency)
+		if err != nil {
+			h.logger.Error("Invalid currency: ", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		currency = parsed
+	}
+
+	account, err := h.accountService.CreateAccount(r.Context(), userID, currency)
 	if err != nil {
 		h.logger.Error("Failed to create account: ", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, err)
 		return
 	}
 
 	resp := struct {
-		ID        int64   `json:"id"`
-		UserID    int64   `json:"user_id"`
-		Balance   float64 `json:"balance"`
-		Currency  string  `json:"currency"`
-		CreatedAt string  `json:"created_at"`
+		ID               int64          `json:"id"`
+		UserID           int64          `json:"user_id"`
+		Balance          money.Amount   `json:"balance"`
+		AvailableBalance money.Amount   `json:"available_balance"`
+		Currency         money.Currency `json:"currency"`
+		Status           string         `json:"status"`
+		CreatedAt        string         `json:"created_at"`
 	}{
-		ID:        account.ID,
-		UserID:    account.UserID,
-		Balance:   account.Balance,
-		Currency:  account.Currency,
-		CreatedAt: account.CreatedAt.Format(time.RFC3339),
+		ID:               account.ID,
+		UserID:           account.UserID,
+		Balance:          account.Balance,
+		AvailableBalance: account.AvailableBalance,
+		Currency:         account.Currency,
+		Status:           account.Status,
+		CreatedAt:        account.CreatedAt.Format(time.RFC3339),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -70,30 +129,39 @@ func (h *AccountHandler) GetAccounts(w http.ResponseWriter, r *http.Request) {
 	accounts, err := h.accountService.GetAccounts(r.Context(), userID)
 	if err != nil {
 		h.logger.Error("Failed to get accounts: ", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, err)
 		return
 	}
 
 	resp := make([]struct {
-		ID        int64   `json:"id"`
-		UserID    int64   `json:"user_id"`
-		Balance   float64 `json:"balance"`
-		Currency  string  `json:"currency"`
-		CreatedAt string  `json:"created_at"`
+		ID               int64          `json:"id"`
+		UserID           int64          `json:"user_id"`
+		Balance          money.Amount   `json:"balance"`
+		AvailableBalance money.Amount   `json:"available_balance"`
+		Currency         money.Currency `json:"currency"`
+		Status           string         `json:"status"`
+		Holds            []holdResponse `json:"holds"`
+		CreatedAt        string         `json:"created_at"`
 	}, len(accounts))
 	for i, account := range accounts {
 		resp[i] = struct {
-			ID        int64   `json:"id"`
-			UserID    int64   `json:"user_id"`
-			Balance   float64 `json:"balance"`
-			Currency  string  `json:"currency"`
-			CreatedAt string  `json:"created_at"`
+			ID               int64          `json:"id"`
+			UserID           int64          `json:"user_id"`
+			Balance          money.Amount   `json:"balance"`
+			AvailableBalance money.Amount   `json:"available_balance"`
+			Currency         money.Currency `json:"currency"`
+			Status           string         `json:"status"`
+			Holds            []holdResponse `json:"holds"`
+			CreatedAt        string         `json:"created_at"`
 		}{
-			ID:        account.ID,
-			UserID:    account.UserID,
-			Balance:   account.Balance,
-			Currency:  account.Currency,
-			CreatedAt: account.CreatedAt.Format(time.RFC3339),
+			ID:               account.ID,
+			UserID:           account.UserID,
+			Balance:          account.Balance,
+			AvailableBalance: account.AvailableBalance,
+			Currency:         account.Currency,
+			Status:           account.Status,
+			Holds:            newHoldResponses(account.Holds),
+			CreatedAt:        account.CreatedAt.Format(time.RFC3339),
 		}
 	}
 
@@ -121,7 +189,7 @@ func (h *AccountHandler) Deposit(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		Amount float64 `json:"amount"`
+		Amount money.Amount `json:"amount"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.logger.Error("Failed to decode request: ", err)
@@ -129,28 +197,9 @@ func (h *AccountHandler) Deposit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	accounts, err := h.accountService.GetAccounts(r.Context(), userID)
-	if err != nil {
-		h.logger.Error("Failed to get accounts: ", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-	var accountExists bool
-	for _, account := range accounts {
-		if account.ID == accountID {
-			accountExists = true
-			break
-		}
-	}
-	if !accountExists {
-		h.logger.Error("Account not found or unauthorized")
-		http.Error(w, "Account not found or unauthorized", http.StatusForbidden)
-		return
-	}
-
-	if err := h.accountService.Deposit(r.Context(), accountID, req.Amount); err != nil {
+	if err := h.accountService.Deposit(r.Context(), userID, accountID, req.Amount); err != nil {
 		h.logger.Error("Failed to deposit: ", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, err)
 		return
 	}
 
@@ -174,7 +223,7 @@ func (h *AccountHandler) Withdraw(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		Amount float64 `json:"amount"`
+		Amount money.Amount `json:"amount"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.logger.Error("Failed to decode request: ", err)
@@ -182,28 +231,9 @@ func (h *AccountHandler) Withdraw(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	accounts, err := h.accountService.GetAccounts(r.Context(), userID)
-	if err != nil {
-		h.logger.Error("Failed to get accounts: ", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-	var accountExists bool
-	for _, account := range accounts {
-		if account.ID == accountID {
-			accountExists = true
-			break
-		}
-	}
-	if !accountExists {
-		h.logger.Error("Account not found or unauthorized")
-		http.Error(w, "Account not found or unauthorized", http.StatusForbidden)
-		return
-	}
-
-	if err := h.accountService.Withdraw(r.Context(), accountID, req.Amount); err != nil {
+	if err := h.accountService.Withdraw(r.Context(), userID, accountID, req.Amount); err != nil {
 		h.logger.Error("Failed to withdraw: ", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, err)
 		return
 	}
 
@@ -219,9 +249,9 @@ func (h *AccountHandler) Transfer(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		FromAccountID int64   `json:"from_account_id"`
-		ToAccountID   int64   `json:"to_account_id"`
-		Amount        float64 `json:"amount"`
+		FromAccountID int64        `json:"from_account_id"`
+		ToAccountID   int64        `json:"to_account_id"`
+		Amount        money.Amount `json:"amount"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.logger.Error("Failed to decode request: ", err)
@@ -229,35 +259,62 @@ func (h *AccountHandler) Transfer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	accounts, err := h.accountService.GetAccounts(r.Context(), userID)
-	if err != nil {
-		h.logger.Error("Failed to get accounts: ", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := h.accountService.Transfer(r.Context(), userID, req.FromAccountID, req.ToAccountID, req.Amount); err != nil {
+		h.logger.Error("Failed to transfer: ", err)
+		writeError(w, err)
 		return
 	}
-	var fromAccountExists bool
-	for _, account := range accounts {
-		if account.ID == req.FromAccountID {
-			fromAccountExists = true
-			break
-		}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *AccountHandler) GetTransactions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error("user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
 	}
-	if !fromAccountExists {
-		h.logger.Error("Source account not found or unauthorized")
-		http.Error(w, "Source account not found or unauthorized", http.StatusForbidden)
+
+	vars := mux.Vars(r)
+	accountID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid account ID: ", err)
+		http.Error(w, "Invalid account ID", http.StatusBadRequest)
 		return
 	}
 
-	if err := h.accountService.Transfer(r.Context(), req.FromAccountID, req.ToAccountID, req.Amount); err != nil {
-		h.logger.Error("Failed to transfer: ", err)
+	filter, err := parseTransactionFilter(r)
+	if err != nil {
+		h.logger.Error("Invalid transaction filter: ", err)
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
+	page, err := h.accountService.GetTransactions(r.Context(), accountID, userID, filter)
+	if err != nil {
+		h.logger.Error("Failed to get transactions: ", err)
+		writeError(w, err)
+		return
+	}
+
+	resp := struct {
+		Transactions []transactionResponse `json:"transactions"`
+		NextCursor   string                `json:"next_cursor,omitempty"`
+	}{
+		Transactions: newTransactionResponses(page.Transactions),
+		NextCursor:   page.NextCursor,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Error("Failed to encode response: ", err)
+	}
 }
 
-func (h *AccountHandler) GetTransactions(w http.ResponseWriter, r *http.Request) {
+// GetStatement формирует выписку по счёту за период [from, to) в формате json, csv, ofx или pdf
+func (h *AccountHandler) GetStatement(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value("user_id").(int64)
 	if !ok {
 		h.logger.Error("user_id not found in context")
@@ -273,36 +330,91 @@ func (h *AccountHandler) GetTransactions(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	transactions, err := h.accountService.GetTransactions(r.Context(), accountID, userID)
+	from, to, err := parseStatementPeriod(r)
 	if err != nil {
-		h.logger.Error("Failed to get transactions: ", err)
+		h.logger.Error("Invalid statement period: ", err)
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	resp := make([]struct {
-		ID          int64   `json:"id"`
-		AccountID   int64   `json:"account_id"`
-		Amount      float64 `json:"amount"`
-		Type        string  `json:"type"`
-		Description string  `json:"description"`
-		CreatedAt   string  `json:"created_at"`
-	}, len(transactions))
-	for i, transaction := range transactions {
-		resp[i] = struct {
-			ID          int64   `json:"id"`
-			AccountID   int64   `json:"account_id"`
-			Amount      float64 `json:"amount"`
-			Type        string  `json:"type"`
-			Description string  `json:"description"`
-			CreatedAt   string  `json:"created_at"`
-		}{
-			ID:          transaction.ID,
-			AccountID:   transaction.AccountID,
-			Amount:      transaction.Amount,
-			Type:        transaction.Type,
-			Description: transaction.Description,
-			CreatedAt:   transaction.CreatedAt.Format(time.RFC3339),
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	var renderer statements.Renderer
+	if format != "json" {
+		renderer, err = statements.RendererFor(format)
+		if err != nil {
+			h.logger.Error("Invalid statement format: ", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	statement, err := h.accountService.GetStatement(r.Context(), accountID, userID, from, to)
+	if err != nil {
+		h.logger.Error("Failed to get statement: ", err)
+		writeError(w, err)
+		return
+	}
+
+	if renderer != nil {
+		// Документ формируется в памяти, чтобы при ошибке не отдать клиенту обрезанный файл
+		var buf bytes.Buffer
+		if err := renderer.Render(&buf, statement); err != nil {
+			h.logger.Error("Failed to render statement: ", err)
+			http.Error(w, "Failed to render statement", http.StatusInternalServerError)
+			return
+		}
+		filename := fmt.Sprintf("statement-%d-%s-%s.%s", accountID, from.Format("20060102"), to.Format("20060102"), renderer.FileExtension())
+		w.Header().Set("Content-Type", renderer.ContentType())
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+		w.WriteHeader(http.StatusOK)
+		if _, err := buf.WriteTo(w); err != nil {
+			h.logger.Error("Failed to write statement: ", err)
+		}
+		return
+	}
+
+	type transactionResponse struct {
+		ID           int64        `json:"id"`
+		Amount       money.Amount `json:"amount"`
+		Type         string       `json:"type"`
+		Description  string       `json:"description"`
+		ExchangeRate string       `json:"exchange_rate,omitempty"`
+		CreatedAt    string       `json:"created_at"`
+	}
+	resp := struct {
+		AccountID      int64                 `json:"account_id"`
+		Currency       money.Currency        `json:"currency"`
+		From           string                `json:"from"`
+		To             string                `json:"to"`
+		OpeningBalance money.Amount          `json:"opening_balance"`
+		ClosingBalance money.Amount          `json:"closing_balance"`
+		TotalCredits   money.Amount          `json:"total_credits"`
+		TotalDebits    money.Amount          `json:"total_debits"`
+		Transactions   []transactionResponse `json:"transactions"`
+		GeneratedAt    string                `json:"generated_at"`
+	}{
+		AccountID:      statement.Account.ID,
+		Currency:       statement.Account.Currency,
+		From:           statement.From.Format(time.RFC3339),
+		To:             statement.To.Format(time.RFC3339),
+		OpeningBalance: statement.OpeningBalance,
+		ClosingBalance: statement.ClosingBalance,
+		TotalCredits:   statement.TotalCredits,
+		TotalDebits:    statement.TotalDebits,
+		Transactions:   make([]transactionResponse, len(statement.Transactions)),
+		GeneratedAt:    statement.GeneratedAt.Format(time.RFC3339),
+	}
+	for i, transaction := range statement.Transactions {
+		resp.Transactions[i] = transactionResponse{
+			ID:           transaction.ID,
+			Amount:       transaction.Amount,
+			Type:         transaction.Type,
+			Description:  transaction.Description,
+			ExchangeRate: transaction.ExchangeRate,
+			CreatedAt:    transaction.CreatedAt.Format(time.RFC3339),
 		}
 	}
 
@@ -312,3 +424,92 @@ func (h *AccountHandler) GetTransactions(w http.ResponseWriter, r *http.Request)
 		h.logger.Error("Failed to encode response: ", err)
 	}
 }
+
+// parseTransactionFilter разбирает параметры запроса истории операций:
+// from, to (RFC 3339 или YYYY-MM-DD; to не включается), type (можно через запятую или несколько раз),
+// min_amount, max_amount, q (поиск по описанию), cursor и limit
+func parseTransactionFilter(r *http.Request) (models.TransactionFilter, error) {
+	query := r.URL.Query()
+	var filter models.TransactionFilter
+
+	if v := query.Get("from"); v != "" {
+		from, err := parseTimeParam(v)
+		if err != nil {
+			return filter, errors.New("invalid from: " + err.Error())
+		}
+		filter.From = &from
+	}
+	if v := query.Get("to"); v != "" {
+		to, err := parseTimeParam(v)
+		if err != nil {
+			return filter, errors.New("invalid to: " + err.Error())
+		}
+		filter.To = &to
+	}
+	for _, v := range query["type"] {
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				filter.Types = append(filter.Types, t)
+			}
+		}
+	}
+	if v := query.Get("min_amount"); v != "" {
+		amount, err := money.Parse(v)
+		if err != nil {
+			return filter, errors.New("invalid min_amount: " + err.Error())
+		}
+		filter.MinAmount = &amount
+	}
+	if v := query.Get("max_amount"); v != "" {
+		amount, err := money.Parse(v)
+		if err != nil {
+			return filter, errors.New("invalid max_amount: " + err.Error())
+		}
+		filter.MaxAmount = &amount
+	}
+	filter.Query = strings.TrimSpace(query.Get("q"))
+	if v := query.Get("cursor"); v != "" {
+		cursor, err := models.ParseTransactionCursor(v)
+		if err != nil {
+			return filter, err
+		}
+		filter.After = cursor
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return filter, errors.New("invalid limit")
+		}
+		filter.Limit = limit
+	}
+	return filter, nil
+}
+
+// parseStatementPeriod читает период выписки из параметров from (обязателен) и to
+// (по умолчанию текущий момент). Дата без времени в параметре to включает весь указанный день.
+func parseStatementPeriod(r *http.Request) (time.Time, time.Time, error) {
+	query := r.URL.Query()
+	from, err := parseTimeParam(query.Get("from"))
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("invalid from parameter")
+	}
+	to := time.Now()
+	if v := query.Get("to"); v != "" {
+		to, err = parseTimeParam(v)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid to parameter")
+		}
+		if len(v) == len("2006-01-02") {
+			to = to.AddDate(0, 0, 1)
+		}
+	}
+	return from, to, nil
+}
+
+// parseTimeParam принимает время в RFC 3339 или дату YYYY-MM-DD (начало суток UTC)
+func parseTimeParam(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", v)
+}
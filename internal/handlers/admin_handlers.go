@@ -0,0 +1,483 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/money"
+	"github.com/bank-service/internal/services"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// AdminHandler обслуживает маршруты /admin для сотрудников банка.
+// Проверка роли выполняется middleware.RequireRole при регистрации маршрутов.
+type AdminHandler struct {
+	adminService services.AdminService
+	logger       *logrus.Logger
+}
+
+func NewAdminHandler(adminService services.AdminService, logger *logrus.Logger) *AdminHandler {
+	return &AdminHandler{
+		adminService: adminService,
+		logger:       logger,
+	}
+}
+
+// adminUserResponse — представление пользователя для сотрудников банка
+type adminUserResponse struct {
+	ID        int64  `json:"id"`
+	Username  string `json:"username"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	CreatedAt string `json:"created_at"`
+}
+
+func newAdminUserResponse(user *models.User) adminUserResponse {
+	return adminUserResponse{
+		ID:        user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		Role:      user.Role,
+		CreatedAt: user.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// adminAccountResponse — представление счёта для сотрудников банка
+type adminAccountResponse struct {
+	ID               int64          `json:"id"`
+	UserID           int64          `json:"user_id"`
+	Balance          money.Amount   `json:"balance"`
+	AvailableBalance money.Amount   `json:"available_balance"`
+	Currency         money.Currency `json:"currency"`
+	Status           string         `json:"status"`
+	Holds            []holdResponse `json:"holds"`
+	CreatedAt        string         `json:"created_at"`
+	UpdatedAt        string         `json:"updated_at"`
+}
+
+func newAdminAccountResponse(account *models.Account) adminAccountResponse {
+	return adminAccountResponse{
+		ID:               account.ID,
+		UserID:           account.UserID,
+		Balance:          account.Balance,
+		AvailableBalance: account.AvailableBalance,
+		Currency:         account.Currency,
+		Status:           account.Status,
+		Holds:            newHoldResponses(account.Holds),
+		CreatedAt:        account.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:        account.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// SearchUsers ищет пользователей по подстроке имени или email: GET /admin/users?q=...&limit=...
+func (h *AdminHandler) SearchUsers(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed <= 0 {
+			h.logger.Error("Invalid limit: ", v)
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	users, err := h.adminService.SearchUsers(r.Context(), r.URL.Query().Get("q"), limit)
+	if err != nil {
+		h.logger.Error("Failed to search users: ", err)
+		writeError(w, err)
+		return
+	}
+
+	resp := make([]adminUserResponse, len(users))
+	for i, user := range users {
+		resp[i] = newAdminUserResponse(user)
+	}
+	h.writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *AdminHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseInt(mux.Vars(r)["user_id"], 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid user ID: ", err)
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	user, accounts, err := h.adminService.GetUser(r.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to get user: ", err)
+		writeError(w, err)
+		return
+	}
+
+	resp := struct {
+		adminUserResponse
+		Accounts []adminAccountResponse `json:"accounts"`
+	}{
+		adminUserResponse: newAdminUserResponse(user),
+		Accounts:          make([]adminAccountResponse, len(accounts)),
+	}
+	for i, account := range accounts {
+		resp.Accounts[i] = newAdminAccountResponse(account)
+	}
+	h.writeJSON(w, http.StatusOK, resp)
+}
+
+// SetUserRole назначает пользователю роль: PUT /admin/users/{user_id}/role
+func (h *AdminHandler) SetUserRole(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error("user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := strconv.ParseInt(mux.Vars(r)["user_id"], 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid user ID: ", err)
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Role string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode request: ", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.adminService.SetUserRole(r.Context(), actorID, userID, req.Role)
+	if err != nil {
+		h.logger.Error("Failed to set user role: ", err)
+		writeError(w, err)
+		return
+	}
+	h.logger.Info("User ", actorID, " set role ", user.Role, " for user ", user.ID)
+
+	h.writeJSON(w, http.StatusOK, newAdminUserResponse(user))
+}
+
+func (h *AdminHandler) GetAccount(w http.ResponseWriter, r *http.Request) {
+	accountID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid account ID: ", err)
+		http.Error(w, "Invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	account, err := h.adminService.GetAccount(r.Context(), accountID)
+	if err != nil {
+		h.logger.Error("Failed to get account: ", err)
+		writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newAdminAccountResponse(account))
+}
+
+// GetAccountTransactions возвращает историю операций любого счёта с теми же фильтрами,
+// что и GET /accounts/{id}/transactions
+func (h *AdminHandler) GetAccountTransactions(w http.ResponseWriter, r *http.Request) {
+	accountID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid account ID: ", err)
+		http.Error(w, "Invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	filter, err := parseTransactionFilter(r)
+	if err != nil {
+		h.logger.Error("Invalid transaction filter: ", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.adminService.GetAccountTransactions(r.Context(), accountID, filter)
+	if err != nil {
+		h.logger.Error("Failed to get transactions: ", err)
+		writeError(w, err)
+		return
+	}
+
+	resp := struct {
+		Transactions []transactionResponse `json:"transactions"`
+		NextCursor   string                `json:"next_cursor,omitempty"`
+	}{
+		Transactions: newTransactionResponses(page.Transactions),
+		NextCursor:   page.NextCursor,
+	}
+	h.writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *AdminHandler) FreezeAccount(w http.ResponseWriter, r *http.Request) {
+	h.changeAccountStatus(w, r, h.adminService.FreezeAccount)
+}
+
+func (h *AdminHandler) UnfreezeAccount(w http.ResponseWriter, r *http.Request) {
+	h.changeAccountStatus(w, r, h.adminService.UnfreezeAccount)
+}
+
+// changeAccountStatus разбирает запрос {"reason": "..."} к /admin/accounts/{id}/freeze|unfreeze
+func (h *AdminHandler) changeAccountStatus(w http.ResponseWriter, r *http.Request, change func(ctx context.Context, actorID, accountID int64, reason string) (*models.Account, error)) {
+	actorID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error("user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	accountID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid account ID: ", err)
+		http.Error(w, "Invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode request: ", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	account, err := change(r.Context(), actorID, accountID, req.Reason)
+	if err != nil {
+		h.logger.Error("Failed to change account status: ", err)
+		writeError(w, err)
+		return
+	}
+	h.logger.Info("User ", actorID, " set status ", account.Status, " for account ", account.ID)
+
+	h.writeJSON(w, http.StatusOK, newAdminAccountResponse(account))
+}
+
+// ReverseTransaction сторнирует операцию: POST /admin/transactions/{transaction_id}/reverse
+func (h *AdminHandler) ReverseTransaction(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error("user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	transactionID, err := strconv.ParseInt(mux.Vars(r)["transaction_id"], 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid transaction ID: ", err)
+		http.Error(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode request: ", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	reversals, err := h.adminService.ReverseTransaction(r.Context(), actorID, transactionID, req.Reason)
+	if err != nil {
+		h.logger.Error("Failed to reverse transaction: ", err)
+		writeError(w, err)
+		return
+	}
+	h.logger.Info("User ", actorID, " reversed transaction ", transactionID)
+
+	resp := struct {
+		Transactions []transactionResponse `json:"transactions"`
+	}{
+		Transactions: newTransactionResponses(reversals),
+	}
+	h.writeJSON(w, http.StatusCreated, resp)
+}
+
+// RevealCard раскрывает полный номер и срок действия карты: POST /admin/cards/{card_id}/reveal.
+// Причина обязательна и попадает в журнал действий сотрудников.
+func (h *AdminHandler) RevealCard(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error("user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	cardID, err := strconv.ParseInt(mux.Vars(r)["card_id"], 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid card ID: ", err)
+		http.Error(w, "Invalid card ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode request: ", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	card, err := h.adminService.RevealCard(r.Context(), actorID, cardID, req.Reason)
+	if err != nil {
+		h.logger.Error("Failed to reveal card: ", err)
+		writeError(w, err)
+		return
+	}
+	h.logger.Warn("User ", actorID, " revealed card ", card.ID)
+
+	resp := struct {
+		ID         int64  `json:"id"`
+		AccountID  int64  `json:"account_id"`
+		CardNumber string `json:"card_number"`
+		ExpiryDate string `json:"expiry_date"`
+	}{
+		ID:         card.ID,
+		AccountID:  card.AccountID,
+		CardNumber: card.CardNumber,
+		ExpiryDate: card.ExpiryDate,
+	}
+	// Раскрытые реквизиты не должны оседать в кешах
+	w.Header().Set("Cache-Control", "no-store")
+	h.writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *AdminHandler) writeJSON(w http.ResponseWriter, status int, resp interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Error("Failed to encode response: ", err)
+	}
+}
+
+// parseLimitScope разбирает {scope}/{scope_id} маршрутов /admin/limits
+func parseLimitScope(r *http.Request) (string, int64, error) {
+	vars := mux.Vars(r)
+	scopeID, err := strconv.ParseInt(vars["scope_id"], 10, 64)
+	if err != nil {
+		return "", 0, err
+	}
+	return vars["scope"], scopeID, nil
+}
+
+// GetLimits возвращает действующие лимиты: GET /admin/limits/{scope}/{scope_id}
+func (h *AdminHandler) GetLimits(w http.ResponseWriter, r *http.Request) {
+	scope, scopeID, err := parseLimitScope(r)
+	if err != nil {
+		h.logger.Error("Invalid limit scope ID: ", err)
+		http.Error(w, "Invalid scope ID", http.StatusBadRequest)
+		return
+	}
+
+	limits, err := h.adminService.GetLimits(r.Context(), scope, scopeID)
+	if err != nil {
+		h.logger.Error("Failed to get limits: ", err)
+		writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newLimitResponses(limits))
+}
+
+// SetLimit переопределяет лимит банка для карты, счёта или пользователя:
+// PUT /admin/limits/{scope}/{scope_id}
+func (h *AdminHandler) SetLimit(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error("user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	scope, scopeID, err := parseLimitScope(r)
+	if err != nil {
+		h.logger.Error("Invalid limit scope ID: ", err)
+		http.Error(w, "Invalid scope ID", http.StatusBadRequest)
+		return
+	}
+
+	var req limitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode request: ", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	limit := &models.SpendingLimit{
+		LimitKey: models.LimitKey{
+			Scope:     scope,
+			ScopeID:   scopeID,
+			Operation: req.Operation,
+			Period:    req.Period,
+			Currency:  req.Currency,
+		},
+		MaxAmount: req.MaxAmount,
+		MaxCount:  req.MaxCount,
+	}
+	if _, err := h.adminService.SetLimit(r.Context(), actorID, limit, req.Reason); err != nil {
+		h.logger.Error("Failed to set limit: ", err)
+		writeError(w, err)
+		return
+	}
+	h.logger.Info("User ", actorID, " set ", limit.Period, " ", limit.Operation, " limit for ", scope, " ", scopeID)
+
+	limits, err := h.adminService.GetLimits(r.Context(), scope, scopeID)
+	if err != nil {
+		h.logger.Error("Failed to get limits: ", err)
+		writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newLimitResponses(limits))
+}
+
+// RemoveLimit удаляет переопределение лимита банка, после чего действует лимит по умолчанию:
+// DELETE /admin/limits/{scope}/{scope_id}/{operation}/{period}
+func (h *AdminHandler) RemoveLimit(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error("user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	scope, scopeID, err := parseLimitScope(r)
+	if err != nil {
+		h.logger.Error("Invalid limit scope ID: ", err)
+		http.Error(w, "Invalid scope ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Currency money.Currency `json:"currency"`
+		Reason   string         `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode request: ", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	key := models.LimitKey{
+		Scope:     scope,
+		ScopeID:   scopeID,
+		Operation: vars["operation"],
+		Period:    vars["period"],
+		Currency:  req.Currency,
+	}
+	if err := h.adminService.RemoveLimit(r.Context(), actorID, key, req.Reason); err != nil {
+		h.logger.Error("Failed to remove limit: ", err)
+		writeError(w, err)
+		return
+	}
+	h.logger.Info("User ", actorID, " removed ", key.Period, " ", key.Operation, " limit for ", scope, " ", scopeID)
+	w.WriteHeader(http.StatusNoContent)
+}
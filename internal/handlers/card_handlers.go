@@ -1,11 +1,14 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/money"
 	"github.com/bank-service/internal/services"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
@@ -23,8 +26,31 @@ func NewCardHandler(cardService services.CardService, logger *logrus.Logger) *Ca
 	}
 }
 
+// cardResponse — карта в ответах API; полный номер карты в них не попадает
+type cardResponse struct {
+	ID             int64  `json:"id"`
+	AccountID      int64  `json:"account_id"`
+	Product        string `json:"product"`
+	Status         string `json:"status"`
+	CardNumber     string `json:"card_number"`
+	ReissuedFromID int64  `json:"reissued_from_id,omitempty"`
+	CreatedAt      string `json:"created_at"`
+}
+
+func newCardResponse(card *models.Card) cardResponse {
+	return cardResponse{
+		ID:             card.ID,
+		AccountID:      card.AccountID,
+		Product:        card.Product,
+		Status:         card.Status,
+		CardNumber:     card.MaskedNumber,
+		ReissuedFromID: card.ReissuedFromID,
+		CreatedAt:      card.CreatedAt.Format(time.RFC3339),
+	}
+}
+
 func (h *CardHandler) CreateCard(w http.ResponseWriter, r *http.Request) {
-	// Извлекаем user_id из контекста (для логирования или других проверок)
+	// Извлекаем user_id из контекста: карты доступны только владельцу счёта
 	userID, ok := r.Context().Value("user_id").(int64)
 	if !ok {
 		h.logger.Error("user_id not found in context")
@@ -34,10 +60,8 @@ func (h *CardHandler) CreateCard(w http.ResponseWriter, r *http.Request) {
 
 	// Декодируем тело запроса
 	var req struct {
-		AccountID  int64  `json:"account_id"`
-		CardNumber string `json:"card_number"`
-		ExpiryDate string `json:"expiry_date"`
-		CVV        string `json:"cvv"`
+		AccountID int64  `json:"account_id"`
+		Product   string `json:"product"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.logger.Error("Failed to decode request: ", err)
@@ -45,30 +69,36 @@ func (h *CardHandler) CreateCard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Создаём карту
-	card, err := h.cardService.CreateCard(r.Context(), req.AccountID, req.CardNumber, req.ExpiryDate, req.CVV)
+	// Выпускаем карту; реквизиты генерирует банк
+	card, cvv, err := h.cardService.CreateCard(r.Context(), userID, req.AccountID, req.Product)
 	if err != nil {
 		h.logger.WithField("user_id", userID).Error("Failed to create card: ", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, err)
 		return
 	}
 
-	// Формируем ответ
+	h.writeIssuedCard(w, card, cvv)
+}
+
+// writeIssuedCard отвечает на выпуск карты. Полные реквизиты и CVV показываются только здесь
+// (card_number перекрывает маскированный номер из cardResponse); CVV в открытом виде больше нигде не хранится.
+func (h *CardHandler) writeIssuedCard(w http.ResponseWriter, card *models.Card, cvv string) {
 	resp := struct {
-		ID         int64  `json:"id"`
-		AccountID  int64  `json:"account_id"`
-		CardNumber string `json:"card_number"`
-		ExpiryDate string `json:"expiry_date"`
-		CreatedAt  string `json:"created_at"`
+		cardResponse
+		CardNumber   string `json:"card_number"`
+		MaskedNumber string `json:"masked_number"`
+		ExpiryDate   string `json:"expiry_date"`
+		CVV          string `json:"cvv"`
 	}{
-		ID:         card.ID,
-		AccountID:  card.AccountID,
-		CardNumber: card.CardNumber,
-		ExpiryDate: card.ExpiryDate,
-		CreatedAt:  card.CreatedAt.Format(time.RFC3339),
+		cardResponse: newCardResponse(card),
+		CardNumber:   card.CardNumber,
+		MaskedNumber: card.MaskedNumber,
+		ExpiryDate:   card.ExpiryDate,
+		CVV:          cvv,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
 	w.WriteHeader(http.StatusCreated)
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
 		h.logger.Error("Failed to encode response: ", err)
@@ -76,7 +106,7 @@ func (h *CardHandler) CreateCard(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *CardHandler) GetCards(w http.ResponseWriter, r *http.Request) {
-	// Извлекаем user_id из контекста (для логирования или других проверок)
+	// Извлекаем user_id из контекста: карты доступны только владельцу счёта
 	userID, ok := r.Context().Value("user_id").(int64)
 	if !ok {
 		h.logger.Error("user_id not found in context")
@@ -94,37 +124,208 @@ func (h *CardHandler) GetCards(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Получаем карты
-	cards, err := h.cardService.GetCards(r.Context(), accountID)
+	cards, err := h.cardService.GetCards(r.Context(), userID, accountID)
 	if err != nil {
 		h.logger.WithField("user_id", userID).Error("Failed to get cards: ", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, err)
 		return
 	}
 
 	// Формируем ответ
-	resp := make([]struct {
-		ID         int64  `json:"id"`
-		AccountID  int64  `json:"account_id"`
-		CardNumber string `json:"card_number"`
-		ExpiryDate string `json:"expiry_date"`
-		CreatedAt  string `json:"created_at"`
-	}, len(cards))
+	resp := make([]cardResponse, len(cards))
 	for i, card := range cards {
-		resp[i] = struct {
-			ID         int64  `json:"id"`
-			AccountID  int64  `json:"account_id"`
-			CardNumber string `json:"card_number"`
-			ExpiryDate string `json:"expiry_date"`
-			CreatedAt  string `json:"created_at"`
-		}{
-			ID:         card.ID,
-			AccountID:  card.AccountID,
-			CardNumber: card.CardNumber,
-			ExpiryDate: card.ExpiryDate,
-			CreatedAt:  card.CreatedAt.Format(time.RFC3339),
+		resp[i] = newCardResponse(card)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Error("Failed to encode response: ", err)
+	}
+}
+
+func (h *CardHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error("user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Извлекаем card_id из URL
+	vars := mux.Vars(r)
+	cardID, err := strconv.ParseInt(vars["card_id"], 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid card ID: ", err)
+		http.Error(w, "Invalid card ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Amount   money.Amount `json:"amount"`
+		Merchant string       `json:"merchant"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode request: ", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Блокируем средства; списание произойдёт при подтверждении холда
+	hold, err := h.cardService.Authorize(r.Context(), cardID, userID, req.Amount, req.Merchant)
+	if err != nil {
+		h.logger.WithField("user_id", userID).Error("Failed to authorize card payment: ", err)
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(newHoldResponse(hold)); err != nil {
+		h.logger.Error("Failed to encode response: ", err)
+	}
+}
+
+// cardStatusRequest — необязательное тело запросов смены статуса карты
+type cardStatusRequest struct {
+	Reason string `json:"reason"`
+	// Stolen отличает украденную карту от утерянной
+	Stolen bool `json:"stolen"`
+}
+
+// BlockCard временно блокирует карту: POST /cards/{card_id}/block
+func (h *CardHandler) BlockCard(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, func(ctx context.Context, userID, cardID int64, req cardStatusRequest) (*models.Card, error) {
+		return h.cardService.BlockCard(ctx, userID, cardID, req.Reason)
+	})
+}
+
+// UnblockCard снимает временную блокировку: POST /cards/{card_id}/unblock
+func (h *CardHandler) UnblockCard(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, func(ctx context.Context, userID, cardID int64, req cardStatusRequest) (*models.Card, error) {
+		return h.cardService.UnblockCard(ctx, userID, cardID, req.Reason)
+	})
+}
+
+// ReportLost отмечает карту утерянной или украденной: POST /cards/{card_id}/lost
+func (h *CardHandler) ReportLost(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, func(ctx context.Context, userID, cardID int64, req cardStatusRequest) (*models.Card, error) {
+		return h.cardService.ReportLost(ctx, userID, cardID, req.Stolen, req.Reason)
+	})
+}
+
+// CloseCard закрывает карту: POST /cards/{card_id}/close
+func (h *CardHandler) CloseCard(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, func(ctx context.Context, userID, cardID int64, req cardStatusRequest) (*models.Card, error) {
+		return h.cardService.CloseCard(ctx, userID, cardID, req.Reason)
+	})
+}
+
+// changeStatus разбирает запрос к /cards/{card_id}/block|unblock|lost|close
+func (h *CardHandler) changeStatus(w http.ResponseWriter, r *http.Request, change func(ctx context.Context, userID, cardID int64, req cardStatusRequest) (*models.Card, error)) {
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error("user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	cardID, err := strconv.ParseInt(mux.Vars(r)["card_id"], 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid card ID: ", err)
+		http.Error(w, "Invalid card ID", http.StatusBadRequest)
+		return
+	}
+
+	var req cardStatusRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.logger.Error("Failed to decode request: ", err)
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
 		}
 	}
 
+	card, err := change(r.Context(), userID, cardID, req)
+	if err != nil {
+		h.logger.WithField("user_id", userID).Error("Failed to change card status: ", err)
+		writeError(w, err)
+		return
+	}
+	h.logger.WithField("user_id", userID).Info("Card ", card.ID, " is now ", card.Status)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(newCardResponse(card)); err != nil {
+		h.logger.Error("Failed to encode response: ", err)
+	}
+}
+
+// ReissueCard выпускает карту с новым номером взамен прежней: POST /cards/{card_id}/reissue
+func (h *CardHandler) ReissueCard(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error("user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	cardID, err := strconv.ParseInt(mux.Vars(r)["card_id"], 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid card ID: ", err)
+		http.Error(w, "Invalid card ID", http.StatusBadRequest)
+		return
+	}
+
+	card, cvv, err := h.cardService.ReissueCard(r.Context(), userID, cardID)
+	if err != nil {
+		h.logger.WithField("user_id", userID).Error("Failed to reissue card: ", err)
+		writeError(w, err)
+		return
+	}
+	h.logger.WithField("user_id", userID).Info("Card ", cardID, " reissued as ", card.ID)
+
+	h.writeIssuedCard(w, card, cvv)
+}
+
+// GetStatusHistory возвращает журнал смены статусов карты: GET /cards/{card_id}/history
+func (h *CardHandler) GetStatusHistory(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error("user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	cardID, err := strconv.ParseInt(mux.Vars(r)["card_id"], 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid card ID: ", err)
+		http.Error(w, "Invalid card ID", http.StatusBadRequest)
+		return
+	}
+
+	history, err := h.cardService.GetStatusHistory(r.Context(), userID, cardID)
+	if err != nil {
+		h.logger.WithField("user_id", userID).Error("Failed to get card history: ", err)
+		writeError(w, err)
+		return
+	}
+
+	resp := make([]struct {
+		FromStatus string `json:"from_status,omitempty"`
+		ToStatus   string `json:"to_status"`
+		ActorID    int64  `json:"actor_id,omitempty"`
+		Reason     string `json:"reason,omitempty"`
+		CreatedAt  string `json:"created_at"`
+	}, len(history))
+	for i, change := range history {
+		resp[i].FromStatus = change.FromStatus
+		resp[i].ToStatus = change.ToStatus
+		resp[i].ActorID = change.ActorID
+		resp[i].Reason = change.Reason
+		resp[i].CreatedAt = change.CreatedAt.Format(time.RFC3339)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
@@ -6,6 +6,8 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/money"
 	"github.com/bank-service/internal/services"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
@@ -23,6 +25,112 @@ func NewCreditHandler(creditService services.CreditService, logger *logrus.Logge
 	}
 }
 
+type creditResponse struct {
+	ID           int64          `json:"id"`
+	UserID       int64          `json:"user_id"`
+	AccountID    int64          `json:"account_id,omitempty"`
+	Amount       money.Amount   `json:"amount"`
+	Currency     money.Currency `json:"currency"`
+	InterestRate float64        `json:"interest_rate"`
+	TermMonths   int            `json:"term_months"`
+	ScheduleType string         `json:"schedule_type"`
+	DayCount     string         `json:"day_count"`
+	Status       string         `json:"status"`
+	CreatedAt    time.Time      `json:"created_at"`
+}
+
+func newCreditResponse(credit *models.Credit) creditResponse {
+	return creditResponse{
+		ID:           credit.ID,
+		UserID:       credit.UserID,
+		AccountID:    credit.AccountID,
+		Amount:       credit.Amount,
+		Currency:     credit.Currency,
+		InterestRate: credit.InterestRate,
+		TermMonths:   credit.TermMonths,
+		ScheduleType: credit.ScheduleType,
+		DayCount:     credit.DayCount,
+		Status:       credit.Status,
+		CreatedAt:    credit.CreatedAt,
+	}
+}
+
+type paymentScheduleResponse struct {
+	ID                 int64        `json:"id"`
+	CreditID           int64        `json:"credit_id"`
+	PeriodStart        time.Time    `json:"period_start"`
+	PaymentDate        time.Time    `json:"payment_date"`
+	Amount             money.Amount `json:"amount"`
+	Principal          money.Amount `json:"principal"`
+	Interest           money.Amount `json:"interest"`
+	RemainingPrincipal money.Amount `json:"remaining_principal"`
+	Paid               bool         `json:"paid"`
+	PaidAt             *time.Time   `json:"paid_at,omitempty"`
+	Penalty            money.Amount `json:"penalty"`
+	SupersededAt       *time.Time   `json:"superseded_at,omitempty"`
+}
+
+func newPaymentScheduleResponses(schedules []*models.PaymentSchedule) []paymentScheduleResponse {
+	resp := make([]paymentScheduleResponse, len(schedules))
+	for i, schedule := range schedules {
+		resp[i] = paymentScheduleResponse{
+			ID:                 schedule.ID,
+			CreditID:           schedule.CreditID,
+			PeriodStart:        schedule.PeriodStart,
+			PaymentDate:        schedule.PaymentDate,
+			Amount:             schedule.Amount,
+			Principal:          schedule.Principal,
+			Interest:           schedule.Interest,
+			RemainingPrincipal: schedule.RemainingPrincipal,
+			Paid:               schedule.Paid,
+			PaidAt:             schedule.PaidAt,
+			Penalty:            schedule.Penalty,
+			SupersededAt:       schedule.SupersededAt,
+		}
+	}
+	return resp
+}
+
+type creditPaymentResponse struct {
+	ID         int64        `json:"id"`
+	CreditID   int64        `json:"credit_id"`
+	ScheduleID int64        `json:"schedule_id,omitempty"`
+	Kind       string       `json:"kind"`
+	AccountID  int64        `json:"account_id"`
+	Amount     money.Amount `json:"amount"`
+	Principal  money.Amount `json:"principal"`
+	Interest   money.Amount `json:"interest"`
+	Penalty    money.Amount `json:"penalty"`
+	Source     string       `json:"source"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+func newCreditPaymentResponse(payment *models.CreditPayment) creditPaymentResponse {
+	return creditPaymentResponse{
+		ID:         payment.ID,
+		CreditID:   payment.CreditID,
+		ScheduleID: payment.ScheduleID,
+		Kind:       payment.Kind,
+		AccountID:  payment.AccountID,
+		Amount:     payment.Amount,
+		Principal:  payment.Principal,
+		Interest:   payment.Interest,
+		Penalty:    payment.Penalty,
+		Source:     payment.Source,
+		CreatedAt:  payment.CreatedAt,
+	}
+}
+
+type creditPenaltyResponse struct {
+	ID          int64        `json:"id"`
+	ScheduleID  int64        `json:"schedule_id"`
+	Kind        string       `json:"kind"`
+	AccrualDate string       `json:"accrual_date"`
+	Base        money.Amount `json:"base"`
+	Rate        float64      `json:"rate,omitempty"`
+	Amount      money.Amount `json:"amount"`
+}
+
 func (h *CreditHandler) CreateCredit(w http.ResponseWriter, r *http.Request) {
 	// Извлекаем user_id из контекста
 	userID, ok := r.Context().Value("user_id").(int64)
@@ -34,9 +142,14 @@ func (h *CreditHandler) CreateCredit(w http.ResponseWriter, r *http.Request) {
 
 	// Декодируем тело запроса
 	var req struct {
-		Amount       float64 `json:"amount"`
-		InterestRate float64 `json:"interest_rate"`
-		TermMonths   int     `json:"term_months"`
+		AccountID    int64        `json:"account_id"`
+		Amount       money.Amount `json:"amount"`
+		InterestRate float64      `json:"interest_rate"`
+		TermMonths   int          `json:"term_months"`
+		// ScheduleType — annuity (по умолчанию), differentiated, interest_only или zero_interest
+		ScheduleType string `json:"schedule_type"`
+		// DayCount — 30/360 (по умолчанию), actual/365 или actual/actual
+		DayCount string `json:"day_count"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.logger.Error("Failed to decode request: ", err)
@@ -44,26 +157,17 @@ func (h *CreditHandler) CreateCredit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Создаём кредит
-	credit, err := h.creditService.CreateCredit(r.Context(), userID, req.Amount, req.InterestRate, req.TermMonths)
+	// Создаём кредит и зачисляем его на счёт
+	credit, err := h.creditService.CreateCredit(r.Context(), userID, req.AccountID, req.Amount, req.InterestRate, req.TermMonths, req.ScheduleType, req.DayCount)
 	if err != nil {
 		h.logger.Error("Failed to create credit: ", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, err)
 		return
 	}
 
-	// Формируем ответ
-	resp := struct {
-		ID           int64     `json:"id"`
-		UserID       int64     `json:"user_id"`
-		Amount       float64   `json:"amount"`
-		InterestRate float64   `json:"interest_rate"`
-		TermMonths   int       `json:"term_months"`
-		CreatedAt    time.Time `json:"created_at"`
-	}{credit.ID, credit.UserID, credit.Amount, credit.InterestRate, credit.TermMonths, credit.CreatedAt}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	if err := json.NewEncoder(w).Encode(resp); err != nil {
+	if err := json.NewEncoder(w).Encode(newCreditResponse(credit)); err != nil {
 		h.logger.Error("Failed to encode response: ", err)
 	}
 }
@@ -81,28 +185,14 @@ func (h *CreditHandler) GetCredits(w http.ResponseWriter, r *http.Request) {
 	credits, err := h.creditService.GetCredits(r.Context(), userID)
 	if err != nil {
 		h.logger.Error("Failed to get credits: ", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, err)
 		return
 	}
 
 	// Формируем ответ
-	resp := make([]struct {
-		ID           int64     `json:"id"`
-		UserID       int64     `json:"user_id"`
-		Amount       float64   `json:"amount"`
-		InterestRate float64   `json:"interest_rate"`
-		TermMonths   int       `json:"term_months"`
-		CreatedAt    time.Time `json:"created_at"`
-	}, len(credits))
+	resp := make([]creditResponse, len(credits))
 	for i, credit := range credits {
-		resp[i] = struct {
-			ID           int64     `json:"id"`
-			UserID       int64     `json:"user_id"`
-			Amount       float64   `json:"amount"`
-			InterestRate float64   `json:"interest_rate"`
-			TermMonths   int       `json:"term_months"`
-			CreatedAt    time.Time `json:"created_at"`
-		}{credit.ID, credit.UserID, credit.Amount, credit.InterestRate, credit.TermMonths, credit.CreatedAt}
+		resp[i] = newCreditResponse(credit)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -130,32 +220,375 @@ func (h *CreditHandler) GetPaymentSchedules(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	// Получаем график платежей
-	schedules, err := h.creditService.GetPaymentSchedules(r.Context(), creditID, userID)
+	// Получаем график платежей; include=superseded добавляет графики, заменённые после досрочных погашений
+	includeSuperseded := r.URL.Query().Get("include") == "superseded"
+	schedules, err := h.creditService.GetPaymentSchedules(r.Context(), creditID, userID, includeSuperseded)
 	if err != nil {
 		h.logger.Error("Failed to get payment schedules: ", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(newPaymentScheduleResponses(schedules)); err != nil {
+		h.logger.Error("Failed to encode response: ", err)
+	}
+}
+
+// Repay погашает ближайший платёж по графику со счёта кредита: POST /credits/{credit_id}/repayments
+func (h *CreditHandler) Repay(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error("user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	creditID, err := strconv.ParseInt(mux.Vars(r)["credit_id"], 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid credit ID: ", err)
+		http.Error(w, "Invalid credit ID", http.StatusBadRequest)
+		return
+	}
+
+	payment, err := h.creditService.Repay(r.Context(), userID, creditID)
+	if err != nil {
+		h.logger.WithField("user_id", userID).Error("Failed to repay credit: ", err)
+		writeError(w, err)
+		return
+	}
+	h.logger.WithField("user_id", userID).Info("Repaid ", payment.Amount, " of credit ", creditID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(newCreditPaymentResponse(payment)); err != nil {
+		h.logger.Error("Failed to encode response: ", err)
+	}
+}
+
+// payoffQuoteResponse — сумма полного досрочного погашения на дату
+type payoffQuoteResponse struct {
+	CreditID  int64        `json:"credit_id"`
+	Date      string       `json:"date"`
+	Principal money.Amount `json:"principal"`
+	Interest  money.Amount `json:"interest"`
+	Penalty   money.Amount `json:"penalty"`
+	Total     money.Amount `json:"total"`
+}
+
+// GetPayoffQuote рассчитывает сумму полного досрочного погашения:
+// GET /credits/{credit_id}/payoff?date=YYYY-MM-DD (по умолчанию — на сегодня)
+func (h *CreditHandler) GetPayoffQuote(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error("user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	creditID, err := strconv.ParseInt(mux.Vars(r)["credit_id"], 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid credit ID: ", err)
+		http.Error(w, "Invalid credit ID", http.StatusBadRequest)
+		return
+	}
+
+	var date time.Time
+	if v := r.URL.Query().Get("date"); v != "" {
+		if date, err = time.Parse("2006-01-02", v); err != nil {
+			http.Error(w, "Invalid date parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	quote, err := h.creditService.GetPayoffQuote(r.Context(), userID, creditID, date)
+	if err != nil {
+		h.logger.WithField("user_id", userID).Error("Failed to quote credit payoff: ", err)
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(payoffQuoteResponse{
+		CreditID:  quote.CreditID,
+		Date:      quote.Date.Format("2006-01-02"),
+		Principal: quote.Principal,
+		Interest:  quote.Interest,
+		Penalty:   quote.Penalty,
+		Total:     quote.Total,
+	}); err != nil {
+		h.logger.Error("Failed to encode response: ", err)
+	}
+}
+
+// PayOff полностью досрочно погашает кредит со счёта кредита: POST /credits/{credit_id}/payoff
+func (h *CreditHandler) PayOff(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error("user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	creditID, err := strconv.ParseInt(mux.Vars(r)["credit_id"], 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid credit ID: ", err)
+		http.Error(w, "Invalid credit ID", http.StatusBadRequest)
+		return
+	}
+
+	payment, err := h.creditService.PayOff(r.Context(), userID, creditID)
+	if err != nil {
+		h.logger.WithField("user_id", userID).Error("Failed to pay off credit: ", err)
+		writeError(w, err)
+		return
+	}
+	h.logger.WithField("user_id", userID).Info("Paid off credit ", creditID, " with ", payment.Amount)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(newCreditPaymentResponse(payment)); err != nil {
+		h.logger.Error("Failed to encode response: ", err)
+	}
+}
+
+// Prepay частично досрочно погашает кредит и пересчитывает график:
+// POST /credits/{credit_id}/prepayments
+func (h *CreditHandler) Prepay(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error("user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	creditID, err := strconv.ParseInt(mux.Vars(r)["credit_id"], 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid credit ID: ", err)
+		http.Error(w, "Invalid credit ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Amount money.Amount `json:"amount"`
+		Mode   string       `json:"mode"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode request: ", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	payment, schedules, err := h.creditService.Prepay(r.Context(), userID, creditID, req.Amount, req.Mode)
+	if err != nil {
+		h.logger.WithField("user_id", userID).Error("Failed to prepay credit: ", err)
+		writeError(w, err)
+		return
+	}
+	h.logger.WithField("user_id", userID).Info("Prepaid ", payment.Amount, " of credit ", creditID, " (", req.Mode, ")")
+
+	resp := struct {
+		Payment  creditPaymentResponse     `json:"payment"`
+		Schedule []paymentScheduleResponse `json:"schedule"`
+	}{
+		Payment:  newCreditPaymentResponse(payment),
+		Schedule: newPaymentScheduleResponses(schedules),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Error("Failed to encode response: ", err)
+	}
+}
+
+// creditSummaryResponse — сводка по кредиту для раскрытия информации заёмщику
+type creditSummaryResponse struct {
+	CreditID             int64          `json:"credit_id"`
+	Currency             money.Currency `json:"currency"`
+	ScheduleType         string         `json:"schedule_type"`
+	DayCount             string         `json:"day_count"`
+	InterestRate         float64        `json:"interest_rate"`
+	Principal            money.Amount   `json:"principal"`
+	PaidPrincipal        money.Amount   `json:"paid_principal"`
+	OutstandingPrincipal money.Amount   `json:"outstanding_principal"`
+	TotalInterest        money.Amount   `json:"total_interest"`
+	PaidInterest         money.Amount   `json:"paid_interest"`
+	PaidPenalty          money.Amount   `json:"paid_penalty"`
+	TotalPayments        money.Amount   `json:"total_payments"`
+	FullCost             money.Amount   `json:"full_cost"`
+	FullCostRate         float64        `json:"full_cost_rate"`
+	Payments             int            `json:"payments"`
+	RemainingPayments    int            `json:"remaining_payments"`
+	MaturityDate         string         `json:"maturity_date"`
+}
+
+func newCreditSummaryResponse(summary *models.CreditSummary) creditSummaryResponse {
+	return creditSummaryResponse{
+		CreditID:             summary.CreditID,
+		Currency:             summary.Currency,
+		ScheduleType:         summary.ScheduleType,
+		DayCount:             summary.DayCount,
+		InterestRate:         summary.InterestRate,
+		Principal:            summary.Principal,
+		PaidPrincipal:        summary.PaidPrincipal,
+		OutstandingPrincipal: summary.OutstandingPrincipal,
+		TotalInterest:        summary.TotalInterest,
+		PaidInterest:         summary.PaidInterest,
+		PaidPenalty:          summary.PaidPenalty,
+		TotalPayments:        summary.TotalPayments,
+		FullCost:             summary.FullCost,
+		FullCostRate:         summary.FullCostRate,
+		Payments:             summary.Payments,
+		RemainingPayments:    summary.RemainingPayments,
+		MaturityDate:         summary.MaturityDate.Format("2006-01-02"),
+	}
+}
+
+// GetSummary возвращает сводку по кредиту: проценты за весь срок и полную стоимость кредита:
+// GET /credits/{credit_id}/summary
+func (h *CreditHandler) GetSummary(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error("user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	creditID, err := strconv.ParseInt(mux.Vars(r)["credit_id"], 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid credit ID: ", err)
+		http.Error(w, "Invalid credit ID", http.StatusBadRequest)
+		return
+	}
+
+	summary, err := h.creditService.GetSummary(r.Context(), userID, creditID)
+	if err != nil {
+		h.logger.WithField("user_id", userID).Error("Failed to get credit summary: ", err)
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(newCreditSummaryResponse(summary)); err != nil {
+		h.logger.Error("Failed to encode response: ", err)
+	}
+}
+
+// GetPayments возвращает платежи по кредиту: GET /credits/{credit_id}/repayments
+func (h *CreditHandler) GetPayments(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error("user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	creditID, err := strconv.ParseInt(mux.Vars(r)["credit_id"], 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid credit ID: ", err)
+		http.Error(w, "Invalid credit ID", http.StatusBadRequest)
+		return
+	}
+
+	payments, err := h.creditService.GetPayments(r.Context(), userID, creditID)
+	if err != nil {
+		h.logger.WithField("user_id", userID).Error("Failed to get credit payments: ", err)
+		writeError(w, err)
+		return
+	}
+
+	resp := make([]creditPaymentResponse, len(payments))
+	for i, payment := range payments {
+		resp[i] = newCreditPaymentResponse(payment)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Error("Failed to encode response: ", err)
+	}
+}
+
+// GetPenalties возвращает начисления неустойки по кредиту: GET /credits/{credit_id}/penalties
+func (h *CreditHandler) GetPenalties(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error("user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	creditID, err := strconv.ParseInt(mux.Vars(r)["credit_id"], 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid credit ID: ", err)
+		http.Error(w, "Invalid credit ID", http.StatusBadRequest)
+		return
+	}
+
+	penalties, err := h.creditService.GetPenalties(r.Context(), userID, creditID)
+	if err != nil {
+		h.logger.WithField("user_id", userID).Error("Failed to get credit penalties: ", err)
+		writeError(w, err)
+		return
+	}
+
+	resp := make([]creditPenaltyResponse, len(penalties))
+	for i, penalty := range penalties {
+		resp[i] = creditPenaltyResponse{
+			ID:          penalty.ID,
+			ScheduleID:  penalty.ScheduleID,
+			Kind:        penalty.Kind,
+			AccrualDate: penalty.AccrualDate.Format("2006-01-02"),
+			Base:        penalty.Base,
+			Rate:        penalty.Rate,
+			Amount:      penalty.Amount,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Error("Failed to encode response: ", err)
+	}
+}
+
+// GetStatusHistory возвращает журнал смены статусов кредита: GET /credits/{credit_id}/history
+func (h *CreditHandler) GetStatusHistory(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error("user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	creditID, err := strconv.ParseInt(mux.Vars(r)["credit_id"], 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid credit ID: ", err)
+		http.Error(w, "Invalid credit ID", http.StatusBadRequest)
+		return
+	}
+
+	history, err := h.creditService.GetStatusHistory(r.Context(), userID, creditID)
+	if err != nil {
+		h.logger.WithField("user_id", userID).Error("Failed to get credit status history: ", err)
+		writeError(w, err)
 		return
 	}
 
-	// Формируем ответ
 	resp := make([]struct {
-		ID          int64     `json:"id"`
-		CreditID    int64     `json:"credit_id"`
-		PaymentDate time.Time `json:"payment_date"`
-		Amount      float64   `json:"amount"`
-		Paid        bool      `json:"paid"`
-		Penalty     float64   `json:"penalty"`
-	}, len(schedules))
-	for i, schedule := range schedules {
-		resp[i] = struct {
-			ID          int64     `json:"id"`
-			CreditID    int64     `json:"credit_id"`
-			PaymentDate time.Time `json:"payment_date"`
-			Amount      float64   `json:"amount"`
-			Paid        bool      `json:"paid"`
-			Penalty     float64   `json:"penalty"`
-		}{schedule.ID, schedule.CreditID, schedule.PaymentDate, schedule.Amount, schedule.Paid, schedule.Penalty}
+		FromStatus string `json:"from_status,omitempty"`
+		ToStatus   string `json:"to_status"`
+		Reason     string `json:"reason,omitempty"`
+		CreatedAt  string `json:"created_at"`
+	}, len(history))
+	for i, change := range history {
+		resp[i].FromStatus = change.FromStatus
+		resp[i].ToStatus = change.ToStatus
+		resp[i].Reason = change.Reason
+		resp[i].CreatedAt = change.CreatedAt.Format(time.RFC3339)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
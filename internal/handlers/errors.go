@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/bank-service/internal/policy"
+	"github.com/lib/pq"
+)
+
+// integrityViolationClass — класс кодов SQLSTATE для нарушений ограничений
+// целостности (уникальность, внешние ключи, CHECK)
+const integrityViolationClass = "23"
+
+// errorStatus выбирает HTTP-статус для ошибки сервиса: отказ политики доступа
+// даёт 404 и для несуществующего, и для чужого ресурса, конфликт с
+// ограничениями базы — 409, сбой инфраструктуры — 500, остальные ошибки
+// считаются ошибками запроса и дают 400
+func errorStatus(err error) int {
+	if errors.Is(err, policy.ErrNotFound) {
+		return http.StatusNotFound
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		if pqErr.Code.Class() == integrityViolationClass {
+			return http.StatusConflict
+		}
+		return http.StatusInternalServerError
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) ||
+		errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, sql.ErrTxDone) ||
+		errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, context.Canceled) {
+		return http.StatusInternalServerError
+	}
+	return http.StatusBadRequest
+}
+
+// writeError отвечает клиенту статусом из errorStatus. Текст внутренних ошибок
+// не раскрывается: в теле ответа остаётся только общее сообщение
+func writeError(w http.ResponseWriter, err error) {
+	status := errorStatus(err)
+	if status >= http.StatusInternalServerError {
+		http.Error(w, "Internal server error", status)
+		return
+	}
+	http.Error(w, err.Error(), status)
+}
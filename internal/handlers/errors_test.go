@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bank-service/internal/policy"
+	"github.com/lib/pq"
+)
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"not found", policy.ErrNotFound, http.StatusNotFound},
+		{"foreign resource", fmt.Errorf("account 1: %w", &policy.Error{Resource: policy.ResourceAccount}), http.StatusNotFound},
+		{"validation", errors.New("amount must be positive"), http.StatusBadRequest},
+		{"unique violation", &pq.Error{Code: "23505"}, http.StatusConflict},
+		{"database failure", fmt.Errorf("lock accounts: %w", &pq.Error{Code: "40P01"}), http.StatusInternalServerError},
+		{"connection closed", sql.ErrConnDone, http.StatusInternalServerError},
+		{"timeout", fmt.Errorf("query: %w", context.DeadlineExceeded), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errorStatus(tt.err); got != tt.want {
+				t.Errorf("errorStatus(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteErrorHidesInternalErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	writeError(w, &pq.Error{Code: "XX000", Message: "relation bank.accounts is corrupted"})
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+	if got := w.Body.String(); got != "Internal server error\n" {
+		t.Errorf("body = %q, want the generic message", got)
+	}
+}
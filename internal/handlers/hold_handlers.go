@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/money"
+	"github.com/bank-service/internal/policy"
+	"github.com/bank-service/internal/services"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+type HoldHandler struct {
+	holdService services.HoldService
+	policy      policy.Policy
+	logger      *logrus.Logger
+}
+
+func NewHoldHandler(holdService services.HoldService, policy policy.Policy, logger *logrus.Logger) *HoldHandler {
+	return &HoldHandler{
+		holdService: holdService,
+		policy:      policy,
+		logger:      logger,
+	}
+}
+
+// holdResponse — представление холда в ответах API
+type holdResponse struct {
+	ID             int64        `json:"id"`
+	AccountID      int64        `json:"account_id"`
+	CardID         int64        `json:"card_id,omitempty"`
+	Amount         money.Amount `json:"amount"`
+	CapturedAmount money.Amount `json:"captured_amount"`
+	Status         string       `json:"status"`
+	Description    string       `json:"description"`
+	ExpiresAt      string       `json:"expires_at"`
+	CreatedAt      string       `json:"created_at"`
+}
+
+func newHoldResponse(hold *models.Hold) holdResponse {
+	return holdResponse{
+		ID:             hold.ID,
+		AccountID:      hold.AccountID,
+		CardID:         hold.CardID,
+		Amount:         hold.Amount,
+		CapturedAmount: hold.CapturedAmount,
+		Status:         hold.Status,
+		Description:    hold.Description,
+		ExpiresAt:      hold.ExpiresAt.Format(time.RFC3339),
+		CreatedAt:      hold.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func newHoldResponses(holds []*models.Hold) []holdResponse {
+	resp := make([]holdResponse, len(holds))
+	for i, hold := range holds {
+		resp[i] = newHoldResponse(hold)
+	}
+	return resp
+}
+
+func (h *HoldHandler) PlaceHold(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error("user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	accountID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid account ID: ", err)
+		http.Error(w, "Invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Amount           money.Amount `json:"amount"`
+		Description      string       `json:"description"`
+		ExpiresInMinutes int          `json:"expires_in_minutes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode request: ", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.policy.Account(r.Context(), userID, accountID); err != nil {
+		h.logger.Error("Account access denied: ", err)
+		writeError(w, err)
+		return
+	}
+
+	ttl := time.Duration(req.ExpiresInMinutes) * time.Minute
+	hold, err := h.holdService.PlaceHold(r.Context(), accountID, 0, req.Amount, req.Description, ttl)
+	if err != nil {
+		h.logger.Error("Failed to place hold: ", err)
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(newHoldResponse(hold)); err != nil {
+		h.logger.Error("Failed to encode response: ", err)
+	}
+}
+
+func (h *HoldHandler) CaptureHold(w http.ResponseWriter, r *http.Request) {
+	holdID, ok := h.authorizeHold(w, r)
+	if !ok {
+		return
+	}
+
+	// Тело необязательно: без суммы подтверждается весь холд
+	var req struct {
+		Amount money.Amount `json:"amount"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		h.logger.Error("Failed to decode request: ", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	hold, err := h.holdService.CaptureHold(r.Context(), holdID, req.Amount)
+	if err != nil {
+		h.logger.Error("Failed to capture hold: ", err)
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(newHoldResponse(hold)); err != nil {
+		h.logger.Error("Failed to encode response: ", err)
+	}
+}
+
+func (h *HoldHandler) ReleaseHold(w http.ResponseWriter, r *http.Request) {
+	holdID, ok := h.authorizeHold(w, r)
+	if !ok {
+		return
+	}
+
+	hold, err := h.holdService.ReleaseHold(r.Context(), holdID)
+	if err != nil {
+		h.logger.Error("Failed to release hold: ", err)
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(newHoldResponse(hold)); err != nil {
+		h.logger.Error("Failed to encode response: ", err)
+	}
+}
+
+// authorizeHold извлекает hold_id из URL и проверяет, что холд стоит на счёте пользователя.
+// При ошибке ответ уже записан и возвращается false.
+func (h *HoldHandler) authorizeHold(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error("user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return 0, false
+	}
+
+	vars := mux.Vars(r)
+	holdID, err := strconv.ParseInt(vars["hold_id"], 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid hold ID: ", err)
+		http.Error(w, "Invalid hold ID", http.StatusBadRequest)
+		return 0, false
+	}
+
+	if _, err := h.policy.Hold(r.Context(), userID, holdID); err != nil {
+		h.logger.Error("Hold access denied: ", err)
+		writeError(w, err)
+		return 0, false
+	}
+	return holdID, true
+}
@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bank-service/internal/iso8583"
+	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/money"
+	"github.com/bank-service/internal/services"
+	"github.com/sirupsen/logrus"
+)
+
+// iso8583IdleTimeout — через сколько закрывается соединение, по которому не приходят сообщения.
+// Терминалы и процессинг поддерживают соединение эхо-тестами (0800).
+const iso8583IdleTimeout = 5 * time.Minute
+
+// iso8583RequiredFields — поля, без которых запрос авторизации или финансовой операции не обрабатывается
+var iso8583RequiredFields = []int{
+	iso8583.FieldPAN, iso8583.FieldAmount, iso8583.FieldTransmissionDateTime,
+	iso8583.FieldSTAN, iso8583.FieldTerminalID, iso8583.FieldCurrency,
+}
+
+// ISO8583Handler — симулятор карточного процессинга: принимает сообщения ISO 8583 по TCP
+// и отвечает на каждое сообщение в порядке поступления
+type ISO8583Handler struct {
+	processingService services.CardProcessingService
+	logger            *logrus.Logger
+}
+
+func NewISO8583Handler(processingService services.CardProcessingService, logger *logrus.Logger) *ISO8583Handler {
+	return &ISO8583Handler{
+		processingService: processingService,
+		logger:            logger,
+	}
+}
+
+// Serve принимает соединения до отмены ctx, затем закрывает слушатель и открытые
+// соединения и ждёт завершения их обработчиков
+func (h *ISO8583Handler) Serve(ctx context.Context, ln net.Listener) error {
+	var (
+		mutex sync.Mutex
+		conns = make(map[net.Conn]struct{})
+		wg    sync.WaitGroup
+	)
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+		mutex.Lock()
+		for conn := range conns {
+			conn.Close()
+		}
+		mutex.Unlock()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			wg.Wait()
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		mutex.Lock()
+		if ctx.Err() != nil {
+			mutex.Unlock()
+			conn.Close()
+			continue
+		}
+		conns[conn] = struct{}{}
+		mutex.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				mutex.Lock()
+				delete(conns, conn)
+				mutex.Unlock()
+				conn.Close()
+			}()
+			h.serveConn(ctx, conn)
+		}()
+	}
+}
+
+func (h *ISO8583Handler) serveConn(ctx context.Context, conn net.Conn) {
+	logger := h.logger.WithField("remote_addr", conn.RemoteAddr().String())
+	logger.Debug("ISO 8583 connection opened")
+	for {
+		conn.SetReadDeadline(time.Now().Add(iso8583IdleTimeout))
+		data, err := iso8583.ReadFrame(conn)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && ctx.Err() == nil {
+				logger.Debug("ISO 8583 connection closed: ", err)
+			}
+			return
+		}
+
+		// Сообщение, принятое до остановки, обрабатывается до конца
+		resp := h.handle(context.WithoutCancel(ctx), logger, data)
+		if resp == nil {
+			continue
+		}
+		out, err := resp.Pack()
+		if err != nil {
+			logger.Error("Failed to pack ISO 8583 response: ", err)
+			return
+		}
+		if err := iso8583.WriteFrame(conn, out); err != nil {
+			logger.Debug("Failed to write ISO 8583 response: ", err)
+			return
+		}
+	}
+}
+
+// handle обрабатывает одно сообщение и возвращает ответ; nil означает, что ответить нельзя
+func (h *ISO8583Handler) handle(ctx context.Context, logger *logrus.Entry, data []byte) *iso8583.Message {
+	msg, err := iso8583.Unpack(data)
+	if err != nil {
+		logger.Warn("Malformed ISO 8583 message: ", err)
+		// Ответить можно, только если разобран тип сообщения
+		if len(data) < 4 || !isRequestMTI(string(data[:4])) {
+			return nil
+		}
+		resp := iso8583.NewMessage(iso8583.ResponseMTI(string(data[:4])))
+		resp.Set(iso8583.FieldResponseCode, iso8583.ResponseFormatError)
+		return resp
+	}
+
+	resp := msg.Response()
+	var auth *models.CardAuthorization
+	code := iso8583.ResponseFormatError
+	switch msg.MTI {
+	case iso8583.MTINetworkRequest:
+		code = iso8583.ResponseApproved
+	case iso8583.MTIAuthorizationRequest, iso8583.MTIFinancialRequest:
+		req, ok := parseCardRequest(msg)
+		if !ok {
+			break
+		}
+		auth, err = h.processingService.Authorize(ctx, req)
+	case iso8583.MTIReversalRequest:
+		req, ok := parseCardRequest(msg)
+		if !ok {
+			break
+		}
+		auth, err = h.processingService.Reverse(ctx, req)
+	default:
+		code = iso8583.ResponseUnsupportedFunction
+	}
+	if err != nil {
+		logger.Error("Failed to process ISO 8583 message ", msg.MTI, ": ", err)
+		code = iso8583.ResponseSystemMalfunction
+	} else if auth != nil {
+		code = auth.ResponseCode
+		if auth.ApprovalCode != "" {
+			resp.Set(iso8583.FieldApprovalCode, auth.ApprovalCode)
+		}
+	}
+	resp.Set(iso8583.FieldResponseCode, code)
+
+	logger.WithFields(logrus.Fields{
+		"mti":           msg.MTI,
+		"card_number":   models.MaskCardNumber(msg.Get(iso8583.FieldPAN)),
+		"stan":          msg.Get(iso8583.FieldSTAN),
+		"terminal_id":   strings.TrimSpace(msg.Get(iso8583.FieldTerminalID)),
+		"response_code": code,
+	}).Info("ISO 8583 message processed")
+	return resp
+}
+
+// parseCardRequest переводит запрос 0100, 0200 или 0400 в запрос сервиса процессинга.
+// Возвращает false, если не хватает обязательных полей или они имеют неверный формат.
+func parseCardRequest(msg *iso8583.Message) (*models.CardAuthorizationRequest, bool) {
+	for _, field := range iso8583RequiredFields {
+		if !msg.Has(field) {
+			return nil, false
+		}
+	}
+	// Сумма в поле 4 передаётся в минимальных единицах валюты
+	minor, err := strconv.ParseInt(msg.Get(iso8583.FieldAmount), 10, 64)
+	if err != nil {
+		return nil, false
+	}
+
+	req := &models.CardAuthorizationRequest{
+		CardMessageKey: models.CardMessageKey{
+			TerminalID:    strings.TrimSpace(msg.Get(iso8583.FieldTerminalID)),
+			STAN:          msg.Get(iso8583.FieldSTAN),
+			TransmittedAt: msg.Get(iso8583.FieldTransmissionDateTime),
+		},
+		CardNumber:   msg.Get(iso8583.FieldPAN),
+		ExpiryDate:   msg.Get(iso8583.FieldExpiryDate),
+		CVV:          msg.Get(iso8583.FieldAdditionalData),
+		Amount:       money.FromMinor(minor),
+		CurrencyCode: msg.Get(iso8583.FieldCurrency),
+		RRN:          strings.TrimSpace(msg.Get(iso8583.FieldRRN)),
+		Merchant:     strings.TrimSpace(msg.Get(iso8583.FieldCardAcceptorName)),
+	}
+	switch msg.MTI {
+	case iso8583.MTIAuthorizationRequest:
+		req.Operation = models.CardOperationAuthorization
+	case iso8583.MTIFinancialRequest:
+		req.Operation = models.CardOperationFinancial
+	case iso8583.MTIReversalRequest:
+		req.Operation = models.CardOperationReversal
+		original, ok := parseOriginalData(msg.Get(iso8583.FieldOriginalData), req.TerminalID)
+		if !ok {
+			return nil, false
+		}
+		req.Original = original
+	}
+	return req, true
+}
+
+// parseOriginalData разбирает поле 90 отмены. Исходное сообщение ищется на том же терминале.
+func parseOriginalData(value, terminalID string) (*models.CardMessageKey, bool) {
+	if len(value) < 20 {
+		return nil, false
+	}
+	original := &models.CardMessageKey{
+		TerminalID:    terminalID,
+		STAN:          value[4:10],
+		TransmittedAt: value[10:20],
+	}
+	switch value[:4] {
+	case iso8583.MTIAuthorizationRequest:
+		original.Operation = models.CardOperationAuthorization
+	case iso8583.MTIFinancialRequest:
+		original.Operation = models.CardOperationFinancial
+	default:
+		return nil, false
+	}
+	return original, true
+}
+
+// isRequestMTI сообщает, является ли строка типом запроса, на который положен ответ
+func isRequestMTI(mti string) bool {
+	switch mti {
+	case iso8583.MTIAuthorizationRequest, iso8583.MTIFinancialRequest, iso8583.MTIReversalRequest, iso8583.MTINetworkRequest:
+		return true
+	}
+	return false
+}
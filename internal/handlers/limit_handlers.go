@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/money"
+	"github.com/bank-service/internal/services"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// LimitHandler обслуживает лимиты карт и счетов клиента
+type LimitHandler struct {
+	limitService services.LimitService
+	logger       *logrus.Logger
+}
+
+func NewLimitHandler(limitService services.LimitService, logger *logrus.Logger) *LimitHandler {
+	return &LimitHandler{
+		limitService: limitService,
+		logger:       logger,
+	}
+}
+
+// limitRequest — тело запроса на установку лимита. Пустое ограничение не действует.
+type limitRequest struct {
+	Operation string         `json:"operation"`
+	Period    string         `json:"period"`
+	Currency  money.Currency `json:"currency"`
+	MaxAmount *money.Amount  `json:"max_amount"`
+	MaxCount  *int           `json:"max_count"`
+	Reason    string         `json:"reason"`
+}
+
+// limitResponse — действующий лимит и его остаток в текущем периоде
+type limitResponse struct {
+	Scope           string         `json:"scope"`
+	ScopeID         int64          `json:"scope_id"`
+	Operation       string         `json:"operation"`
+	Period          string         `json:"period"`
+	Currency        money.Currency `json:"currency"`
+	MaxAmount       *money.Amount  `json:"max_amount,omitempty"`
+	MaxCount        *int           `json:"max_count,omitempty"`
+	CustomerSet     bool           `json:"customer_set"`
+	UsedAmount      money.Amount   `json:"used_amount"`
+	UsedCount       int            `json:"used_count"`
+	RemainingAmount *money.Amount  `json:"remaining_amount,omitempty"`
+	RemainingCount  *int           `json:"remaining_count,omitempty"`
+	PeriodStart     string         `json:"period_start"`
+}
+
+func newLimitResponses(limits []*models.EffectiveLimit) []limitResponse {
+	resp := make([]limitResponse, len(limits))
+	for i, limit := range limits {
+		resp[i] = limitResponse{
+			Scope:       limit.Scope,
+			ScopeID:     limit.ScopeID,
+			Operation:   limit.Operation,
+			Period:      limit.Period,
+			Currency:    limit.Currency,
+			MaxAmount:   limit.MaxAmount,
+			MaxCount:    limit.MaxCount,
+			CustomerSet: limit.CustomerSet,
+			UsedAmount:  limit.UsedAmount,
+			UsedCount:   limit.UsedCount,
+			PeriodStart: limit.PeriodStart.Format(time.RFC3339),
+		}
+		// Расход может превышать лимит, если лимит понизили посреди периода
+		if limit.MaxAmount != nil {
+			remaining := limit.MaxAmount.Sub(limit.UsedAmount)
+			if remaining.IsNegative() {
+				remaining = 0
+			}
+			resp[i].RemainingAmount = &remaining
+		}
+		if limit.MaxCount != nil {
+			remaining := *limit.MaxCount - limit.UsedCount
+			if remaining < 0 {
+				remaining = 0
+			}
+			resp[i].RemainingCount = &remaining
+		}
+	}
+	return resp
+}
+
+// GetCardLimits возвращает действующие лимиты карты: GET /cards/{card_id}/limits
+func (h *LimitHandler) GetCardLimits(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error("user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	cardID, err := strconv.ParseInt(mux.Vars(r)["card_id"], 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid card ID: ", err)
+		http.Error(w, "Invalid card ID", http.StatusBadRequest)
+		return
+	}
+
+	limits, err := h.limitService.GetCardLimits(r.Context(), userID, cardID)
+	if err != nil {
+		h.logger.WithField("user_id", userID).Error("Failed to get card limits: ", err)
+		writeError(w, err)
+		return
+	}
+	h.writeLimits(w, limits)
+}
+
+// SetCardLimit устанавливает собственный лимит клиента по карте: PUT /cards/{card_id}/limits.
+// Лимит клиента может только понизить лимит банка.
+func (h *LimitHandler) SetCardLimit(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error("user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	cardID, err := strconv.ParseInt(mux.Vars(r)["card_id"], 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid card ID: ", err)
+		http.Error(w, "Invalid card ID", http.StatusBadRequest)
+		return
+	}
+
+	var req limitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode request: ", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	limit := &models.SpendingLimit{
+		LimitKey:  models.LimitKey{Operation: req.Operation, Period: req.Period},
+		MaxAmount: req.MaxAmount,
+		MaxCount:  req.MaxCount,
+	}
+	if _, err := h.limitService.SetCardLimit(r.Context(), userID, cardID, limit); err != nil {
+		h.logger.WithField("user_id", userID).Error("Failed to set card limit: ", err)
+		writeError(w, err)
+		return
+	}
+	h.logger.WithField("user_id", userID).Info("Set ", limit.Period, " ", limit.Operation, " limit for card ", cardID)
+
+	limits, err := h.limitService.GetCardLimits(r.Context(), userID, cardID)
+	if err != nil {
+		h.logger.WithField("user_id", userID).Error("Failed to get card limits: ", err)
+		writeError(w, err)
+		return
+	}
+	h.writeLimits(w, limits)
+}
+
+// RemoveCardLimit удаляет собственный лимит клиента: DELETE /cards/{card_id}/limits/{operation}/{period}
+func (h *LimitHandler) RemoveCardLimit(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error("user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	cardID, err := strconv.ParseInt(vars["card_id"], 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid card ID: ", err)
+		http.Error(w, "Invalid card ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.limitService.RemoveCardLimit(r.Context(), userID, cardID, vars["operation"], vars["period"]); err != nil {
+		h.logger.WithField("user_id", userID).Error("Failed to remove card limit: ", err)
+		writeError(w, err)
+		return
+	}
+	h.logger.WithField("user_id", userID).Info("Removed ", vars["period"], " ", vars["operation"], " limit for card ", cardID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetAccountLimits возвращает действующие лимиты счёта: GET /accounts/{id}/limits
+func (h *LimitHandler) GetAccountLimits(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error("user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	accountID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid account ID: ", err)
+		http.Error(w, "Invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	limits, err := h.limitService.GetAccountLimits(r.Context(), userID, accountID)
+	if err != nil {
+		h.logger.WithField("user_id", userID).Error("Failed to get account limits: ", err)
+		writeError(w, err)
+		return
+	}
+	h.writeLimits(w, limits)
+}
+
+func (h *LimitHandler) writeLimits(w http.ResponseWriter, limits []*models.EffectiveLimit) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(newLimitResponses(limits)); err != nil {
+		h.logger.Error("Failed to encode response: ", err)
+	}
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/bank-service/internal/iso20022"
+	"github.com/bank-service/internal/services"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// maxPaymentFileSize ограничивает размер загружаемого файла pain.001
+const maxPaymentFileSize = 10 << 20
+
+type PaymentHandler struct {
+	paymentService services.PaymentService
+	logger         *logrus.Logger
+}
+
+func NewPaymentHandler(paymentService services.PaymentService, logger *logrus.Logger) *PaymentHandler {
+	return &PaymentHandler{
+		paymentService: paymentService,
+		logger:         logger,
+	}
+}
+
+// ExportCamt053 выгружает операции счёта за период как выписку camt.053
+func (h *PaymentHandler) ExportCamt053(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error("user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	accountID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid account ID: ", err)
+		http.Error(w, "Invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	from, to, err := parseStatementPeriod(r)
+	if err != nil {
+		h.logger.Error("Invalid statement period: ", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	document, err := h.paymentService.ExportStatement(r.Context(), accountID, userID, from, to)
+	if err != nil {
+		h.logger.Error("Failed to export statement: ", err)
+		writeError(w, err)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := document.Write(&buf); err != nil {
+		h.logger.Error("Failed to encode camt.053: ", err)
+		http.Error(w, "Failed to render statement", http.StatusInternalServerError)
+		return
+	}
+	filename := fmt.Sprintf("camt053-%d-%s-%s.xml", accountID, from.Format("20060102"), to.Format("20060102"))
+	w.Header().Set("Content-Type", "application/xml")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.WriteHeader(http.StatusOK)
+	if _, err := buf.WriteTo(w); err != nil {
+		h.logger.Error("Failed to write statement: ", err)
+	}
+}
+
+// ImportPain001 принимает файл pain.001 и отвечает отчётом pain.002 со статусом каждого поручения.
+// Документ, не прошедший проверку по схеме, отклоняется целиком с кодом 422.
+func (h *PaymentHandler) ImportPain001(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error("user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	document, err := iso20022.ParsePain001(http.MaxBytesReader(w, r.Body, maxPaymentFileSize))
+	if err != nil {
+		h.logger.Error("Invalid pain.001 document: ", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.paymentService.ImportCreditTransfers(r.Context(), userID, document)
+	statusCode := http.StatusOK
+	if errors.Is(err, services.ErrInvalidPaymentFile) {
+		h.logger.WithField("msg_id", document.Initiation.GroupHeader.MessageID).Warn("Rejected pain.001 document: ", err)
+		statusCode = http.StatusUnprocessableEntity
+	} else if err != nil {
+		h.logger.Error("Failed to import payments: ", err)
+		http.Error(w, "Failed to import payments", http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := report.Write(&buf); err != nil {
+		h.logger.Error("Failed to encode pain.002: ", err)
+		http.Error(w, "Failed to render status report", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(statusCode)
+	if _, err := buf.WriteTo(w); err != nil {
+		h.logger.Error("Failed to write status report: ", err)
+	}
+}
@@ -3,8 +3,11 @@ package handlers
 import (
 	//"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"time"
 
+	"github.com/bank-service/internal/models"
 	"github.com/bank-service/internal/services"
 	"github.com/sirupsen/logrus"
 )
@@ -36,7 +39,7 @@ func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 	user, err := h.userService.Register(r.Context(), req.Username, req.Email, req.Password)
 	if err != nil {
 		h.logger.Error("Failed to register user: ", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, err)
 		return
 	}
 
@@ -63,17 +66,89 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := h.userService.Login(r.Context(), req.Email, req.Password)
+	tokens, err := h.userService.Login(r.Context(), req.Email, req.Password)
 	if err != nil {
 		h.logger.Error("Failed to login user: ", err)
 		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return
 	}
 
+	h.writeTokens(w, tokens)
+}
+
+// RefreshToken обменивает refresh-токен на новую пару токенов
+func (h *UserHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode request: ", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.RefreshToken == "" {
+		http.Error(w, "refresh_token is required", http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := h.userService.RefreshTokens(r.Context(), req.RefreshToken)
+	if errors.Is(err, services.ErrRefreshTokenReused) {
+		h.logger.Warn("Refresh token reuse detected, session revoked")
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if errors.Is(err, services.ErrInvalidRefreshToken) {
+		h.logger.Warn("Invalid refresh token")
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to refresh tokens: ", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeTokens(w, tokens)
+}
+
+// Logout завершает текущую сессию пользователя
+func (h *UserHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error("user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	jti, _ := r.Context().Value("token_id").(string)
+	sessionID, _ := r.Context().Value("session_id").(string)
+	expiresAt, _ := r.Context().Value("token_expires_at").(time.Time)
+
+	if err := h.userService.Logout(r.Context(), userID, jti, sessionID, expiresAt); err != nil {
+		h.logger.Error("Failed to logout user: ", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeTokens отвечает парой токенов. Поле token дублирует access_token
+// для клиентов, написанных до появления refresh-токенов.
+func (h *UserHandler) writeTokens(w http.ResponseWriter, tokens *models.TokenPair) {
 	resp := struct {
-		Token string `json:"token"`
-	}{token}
+		Token        string `json:"token"`
+		AccessToken  string `json:"access_token"`
+		TokenType    string `json:"token_type"`
+		ExpiresIn    int64  `json:"expires_in"`
+		RefreshToken string `json:"refresh_token"`
+	}{
+		Token:        tokens.AccessToken,
+		AccessToken:  tokens.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(time.Until(tokens.AccessExpiresAt).Seconds()),
+		RefreshToken: tokens.RefreshToken,
+	}
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
 		h.logger.Error("Failed to encode response: ", err)
@@ -0,0 +1,179 @@
+package iso20022
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/money"
+)
+
+// Camt053Document — выписка банка клиенту (BankToCustomerStatement)
+type Camt053Document struct {
+	XMLName   xml.Name `xml:"Document"`
+	Namespace string   `xml:"xmlns,attr"`
+	Statement struct {
+		GroupHeader struct {
+			MessageID    string `xml:"MsgId"`
+			CreationTime string `xml:"CreDtTm"`
+		} `xml:"GrpHdr"`
+		Statements []camtStatement `xml:"Stmt"`
+	} `xml:"BkToCstmrStmt"`
+}
+
+type camtStatement struct {
+	ID           string `xml:"Id"`
+	CreationTime string `xml:"CreDtTm"`
+	Period       struct {
+		From string `xml:"FrDtTm"`
+		To   string `xml:"ToDtTm"`
+	} `xml:"FrToDt"`
+	Account  CashAccount   `xml:"Acct"`
+	Balances []camtBalance `xml:"Bal"`
+	Summary  struct {
+		Total   camtEntriesSummary `xml:"TtlNtries"`
+		Credits camtEntriesSummary `xml:"TtlCdtNtries"`
+		Debits  camtEntriesSummary `xml:"TtlDbtNtries"`
+	} `xml:"TxsSummry"`
+	Entries []camtEntry `xml:"Ntry"`
+}
+
+type camtBalance struct {
+	Code                 string            `xml:"Tp>CdOrPrtry>Cd"`
+	Amount               CurrencyAndAmount `xml:"Amt"`
+	CreditDebitIndicator string            `xml:"CdtDbtInd"`
+	DateTime             string            `xml:"Dt>DtTm"`
+}
+
+type camtEntriesSummary struct {
+	Count int    `xml:"NbOfNtries"`
+	Sum   string `xml:"Sum"`
+}
+
+type camtEntry struct {
+	Reference            string            `xml:"NtryRef"`
+	Amount               CurrencyAndAmount `xml:"Amt"`
+	CreditDebitIndicator string            `xml:"CdtDbtInd"`
+	Status               string            `xml:"Sts>Cd"`
+	BookingDate          string            `xml:"BookgDt>DtTm"`
+	ValueDate            string            `xml:"ValDt>DtTm"`
+	ServicerReference    string            `xml:"AcctSvcrRef"`
+	TransactionCode      struct {
+		Domain      *camtTransactionDomain `xml:"Domn,omitempty"`
+		Proprietary struct {
+			Code string `xml:"Cd"`
+		} `xml:"Prtry"`
+	} `xml:"BkTxCd"`
+	Details struct {
+		Transaction struct {
+			ServicerReference string `xml:"Refs>AcctSvcrRef"`
+			Remittance        string `xml:"RmtInf>Ustrd,omitempty"`
+		} `xml:"TxDtls"`
+	} `xml:"NtryDtls"`
+	ExchangeRate string `xml:"AddtlNtryInf,omitempty"`
+}
+
+type camtTransactionDomain struct {
+	Code   string `xml:"Cd"`
+	Family struct {
+		Code    string `xml:"Cd"`
+		SubCode string `xml:"SubFmlyCd"`
+	} `xml:"Fmly"`
+}
+
+// bankTransactionCodes сопоставляет типы операций с кодами домена, семейства и подсемейства
+// из внешнего справочника Bank Transaction Code
+var bankTransactionCodes = map[string][3]string{
+	models.TransactionTypeDeposit:      {"PMNT", "CNTR", "CDPT"},
+	models.TransactionTypeWithdrawal:   {"PMNT", "CNTR", "CWDL"},
+	models.TransactionTypeTransferIn:   {"PMNT", "RCDT", "BOOK"},
+	models.TransactionTypeTransferOut:  {"PMNT", "ICDT", "BOOK"},
+	models.TransactionTypeHoldCapture:  {"PMNT", "CCRD", "POSD"},
+	models.TransactionTypeCardPurchase: {"PMNT", "CCRD", "POSD"},
+	// Выдача и погашение потребительского кредита
+	models.TransactionTypeCreditDisbursement: {"LDAS", "CSLN", "DDWN"},
+	models.TransactionTypeCreditRepayment:    {"LDAS", "CSLN", "RPMT"},
+}
+
+// NewCamt053 формирует выписку camt.053 по данным выписки счёта.
+// Суммы в ISO 20022 всегда положительны, направление задаёт CdtDbtInd.
+func NewCamt053(statement *models.Statement, messageID string) *Camt053Document {
+	currency := statement.Account.Currency
+	document := &Camt053Document{Namespace: Camt053Namespace}
+	document.Statement.GroupHeader.MessageID = messageID
+	document.Statement.GroupHeader.CreationTime = formatDateTime(statement.GeneratedAt)
+
+	stmt := camtStatement{
+		ID:           messageID,
+		CreationTime: formatDateTime(statement.GeneratedAt),
+		Account:      newCashAccount(statement.Account.ID, currency),
+	}
+	stmt.Period.From = formatDateTime(statement.From)
+	stmt.Period.To = formatDateTime(statement.To)
+	stmt.Balances = []camtBalance{
+		newCamtBalance("OPBD", statement.OpeningBalance, currency, statement.From),
+		newCamtBalance("CLBD", statement.ClosingBalance, currency, statement.To),
+	}
+
+	var credits, debits int
+	for _, transaction := range statement.Transactions {
+		reference := strconv.FormatInt(transaction.ID, 10)
+		entry := camtEntry{
+			Reference:            reference,
+			Amount:               newCurrencyAndAmount(transaction.Amount, currency),
+			CreditDebitIndicator: creditDebitIndicator(transaction.Amount),
+			Status:               "BOOK",
+			BookingDate:          formatDateTime(transaction.CreatedAt),
+			ValueDate:            formatDateTime(transaction.CreatedAt),
+			ServicerReference:    reference,
+		}
+		if code, ok := bankTransactionCodes[transaction.Type]; ok {
+			entry.TransactionCode.Domain = &camtTransactionDomain{Code: code[0]}
+			entry.TransactionCode.Domain.Family.Code = code[1]
+			entry.TransactionCode.Domain.Family.SubCode = code[2]
+		}
+		entry.TransactionCode.Proprietary.Code = transaction.Type
+		entry.Details.Transaction.ServicerReference = reference
+		entry.Details.Transaction.Remittance = transaction.Description
+		if transaction.ExchangeRate != "" {
+			entry.ExchangeRate = "Exchange rate " + transaction.ExchangeRate
+		}
+		stmt.Entries = append(stmt.Entries, entry)
+
+		if transaction.Amount.IsNegative() {
+			debits++
+		} else {
+			credits++
+		}
+	}
+
+	stmt.Summary.Total = camtEntriesSummary{Count: credits + debits, Sum: statement.TotalCredits.Add(statement.TotalDebits).String()}
+	stmt.Summary.Credits = camtEntriesSummary{Count: credits, Sum: statement.TotalCredits.String()}
+	stmt.Summary.Debits = camtEntriesSummary{Count: debits, Sum: statement.TotalDebits.String()}
+
+	document.Statement.Statements = []camtStatement{stmt}
+	return document
+}
+
+// Write записывает документ camt.053 в w
+func (d *Camt053Document) Write(w io.Writer) error {
+	return writeDocument(w, d)
+}
+
+func newCamtBalance(code string, amount money.Amount, currency money.Currency, at time.Time) camtBalance {
+	return camtBalance{
+		Code:                 code,
+		Amount:               newCurrencyAndAmount(amount, currency),
+		CreditDebitIndicator: creditDebitIndicator(amount),
+		DateTime:             formatDateTime(at),
+	}
+}
+
+func creditDebitIndicator(amount money.Amount) string {
+	if amount.IsNegative() {
+		return Debit
+	}
+	return Credit
+}
@@ -0,0 +1,124 @@
+package iso20022
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/bank-service/internal/money"
+)
+
+// Версии сообщений ISO 20022, с которыми работает сервис
+const (
+	Camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.08"
+	Pain001Namespace = "urn:iso:std:iso:20022:tech:xsd:pain.001.001.09"
+	Pain002Namespace = "urn:iso:std:iso:20022:tech:xsd:pain.002.001.10"
+
+	// pain.001.001.03 до сих пор отправляет большинство ERP-систем, структура
+	// используемых элементов в нём совпадает с 001.09
+	Pain001LegacyNamespace = "urn:iso:std:iso:20022:tech:xsd:pain.001.001.03"
+)
+
+// Коды дебета и кредита (CdtDbtInd)
+const (
+	Credit = "CRDT"
+	Debit  = "DBIT"
+)
+
+// maxText35 — ограничение Max35Text из схемы для идентификаторов сообщений
+const maxText35 = 35
+
+// dateTimeLayout — формат ISODateTime без дробной части секунд
+const dateTimeLayout = "2006-01-02T15:04:05Z07:00"
+
+// CurrencyAndAmount соответствует ActiveOrHistoricCurrencyAndAmount: сумма с атрибутом Ccy
+type CurrencyAndAmount struct {
+	Value    string `xml:",chardata"`
+	Currency string `xml:"Ccy,attr"`
+}
+
+func newCurrencyAndAmount(amount money.Amount, currency money.Currency) CurrencyAndAmount {
+	return CurrencyAndAmount{Value: amount.Abs().String(), Currency: currency.String()}
+}
+
+// AccountIdentification идентифицирует счёт. У счетов банка нет IBAN,
+// поэтому используется произвольный идентификатор Othr/Id с номером счёта.
+type AccountIdentification struct {
+	IBAN  string `xml:"IBAN,omitempty"`
+	Other *struct {
+		ID string `xml:"Id"`
+	} `xml:"Othr,omitempty"`
+}
+
+// CashAccount — счёт с идентификатором и необязательной валютой
+type CashAccount struct {
+	ID       AccountIdentification `xml:"Id"`
+	Currency string                `xml:"Ccy,omitempty"`
+}
+
+func newCashAccount(accountID int64, currency money.Currency) CashAccount {
+	account := CashAccount{Currency: currency.String()}
+	account.ID.Other = &struct {
+		ID string `xml:"Id"`
+	}{ID: strconv.FormatInt(accountID, 10)}
+	return account
+}
+
+// AccountID возвращает номер счёта банка из Othr/Id
+func (a CashAccount) AccountID() (int64, bool) {
+	if a.ID.Other == nil {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(a.ID.Other.ID, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
+
+// PartyIdentification — сторона платежа; используется только наименование
+type PartyIdentification struct {
+	Name string `xml:"Nm,omitempty"`
+}
+
+// StatusReason — причина статуса с кодом ExternalStatusReason1Code
+type StatusReason struct {
+	Reason struct {
+		Code string `xml:"Cd"`
+	} `xml:"Rsn"`
+	AdditionalInfo []string `xml:"AddtlInf,omitempty"`
+}
+
+// maxAdditionalInfo — ограничение Max105Text для AddtlInf
+const maxAdditionalInfo = 105
+
+func newStatusReason(code string, info ...string) *StatusReason {
+	reason := &StatusReason{}
+	reason.Reason.Code = code
+	for _, line := range info {
+		if runes := []rune(line); len(runes) > maxAdditionalInfo {
+			line = string(runes[:maxAdditionalInfo])
+		}
+		reason.AdditionalInfo = append(reason.AdditionalInfo, line)
+	}
+	return reason
+}
+
+func formatDateTime(t time.Time) string {
+	return t.UTC().Format(dateTimeLayout)
+}
+
+// writeDocument записывает документ с XML-декларацией
+func writeDocument(w io.Writer, document interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,218 @@
+package iso20022
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bank-service/internal/money"
+)
+
+var ErrUnsupportedMessage = errors.New("unsupported ISO 20022 message")
+
+// Pain001Document — поручение клиента на кредитовый перевод (CustomerCreditTransferInitiation).
+// Описаны только элементы, которые использует банк; остальные при разборе игнорируются.
+type Pain001Document struct {
+	XMLName    xml.Name `xml:"Document"`
+	Initiation struct {
+		GroupHeader struct {
+			MessageID       string               `xml:"MsgId"`
+			CreationTime    string               `xml:"CreDtTm"`
+			NumberOfTxs     string               `xml:"NbOfTxs"`
+			ControlSum      string               `xml:"CtrlSum"`
+			InitiatingParty *PartyIdentification `xml:"InitgPty"`
+		} `xml:"GrpHdr"`
+		PaymentInfos []PaymentInformation `xml:"PmtInf"`
+	} `xml:"CstmrCdtTrfInitn"`
+}
+
+// PaymentInformation — блок платежей с общим счётом плательщика
+type PaymentInformation struct {
+	ID                     string `xml:"PmtInfId"`
+	PaymentMethod          string `xml:"PmtMtd"`
+	NumberOfTxs            string `xml:"NbOfTxs"`
+	ControlSum             string `xml:"CtrlSum"`
+	RequestedExecutionDate struct {
+		// В pain.001.001.09 дата вложена в Dt или DtTm, в 001.03 указана напрямую
+		Date     string `xml:"Dt"`
+		DateTime string `xml:"DtTm"`
+		Value    string `xml:",chardata"`
+	} `xml:"ReqdExctnDt"`
+	Debtor        *PartyIdentification        `xml:"Dbtr"`
+	DebtorAccount *CashAccount                `xml:"DbtrAcct"`
+	Transactions  []CreditTransferTransaction `xml:"CdtTrfTxInf"`
+}
+
+// CreditTransferTransaction — отдельное поручение на перевод
+type CreditTransferTransaction struct {
+	PaymentID struct {
+		InstructionID string `xml:"InstrId"`
+		EndToEndID    string `xml:"EndToEndId"`
+	} `xml:"PmtId"`
+	Amount struct {
+		Instructed *CurrencyAndAmount `xml:"InstdAmt"`
+	} `xml:"Amt"`
+	Creditor        *PartyIdentification `xml:"Cdtr"`
+	CreditorAccount *CashAccount         `xml:"CdtrAcct"`
+	Remittance      []string             `xml:"RmtInf>Ustrd"`
+}
+
+// ParsePain001 разбирает документ pain.001 поддерживаемой версии
+func ParsePain001(r io.Reader) (*Pain001Document, error) {
+	document := &Pain001Document{}
+	if err := xml.NewDecoder(r).Decode(document); err != nil {
+		return nil, fmt.Errorf("malformed XML: %w", err)
+	}
+	switch document.XMLName.Space {
+	case Pain001Namespace, Pain001LegacyNamespace:
+	default:
+		return nil, ErrUnsupportedMessage
+	}
+	return document, nil
+}
+
+// MessageName возвращает идентификатор версии сообщения, например pain.001.001.09
+func (d *Pain001Document) MessageName() string {
+	return d.XMLName.Space[strings.LastIndex(d.XMLName.Space, ":")+1:]
+}
+
+// Validate проверяет документ на соответствие ограничениям схемы, от которых зависит
+// исполнение: обязательные элементы, длины идентификаторов, форматы дат и сумм,
+// а также контрольные количество и сумму поручений. Возвращает все найденные нарушения.
+func (d *Pain001Document) Validate() []string {
+	var violations []string
+	addf := func(format string, args ...interface{}) {
+		violations = append(violations, fmt.Sprintf(format, args...))
+	}
+	checkID := func(path, value string, required bool) {
+		if value == "" {
+			if required {
+				addf("%s is required", path)
+			}
+			return
+		}
+		if len([]rune(value)) > maxText35 {
+			addf("%s must be at most %d characters", path, maxText35)
+		}
+	}
+
+	header := d.Initiation.GroupHeader
+	checkID("GrpHdr/MsgId", header.MessageID, true)
+	if _, err := parseDateTime(header.CreationTime); err != nil {
+		addf("GrpHdr/CreDtTm must be an ISO date-time")
+	}
+	if header.InitiatingParty == nil {
+		addf("GrpHdr/InitgPty is required")
+	}
+	if len(d.Initiation.PaymentInfos) == 0 {
+		addf("at least one PmtInf is required")
+	}
+
+	var total int
+	var sum money.Amount
+	sumValid := true
+	seenPaymentInfos := make(map[string]bool)
+	for i, info := range d.Initiation.PaymentInfos {
+		path := fmt.Sprintf("PmtInf[%d]", i+1)
+		checkID(path+"/PmtInfId", info.ID, true)
+		if seenPaymentInfos[info.ID] {
+			addf("%s/PmtInfId %q is not unique", path, info.ID)
+		}
+		seenPaymentInfos[info.ID] = true
+		if info.PaymentMethod != "TRF" {
+			addf("%s/PmtMtd must be TRF", path)
+		}
+		if _, err := info.ExecutionDate(); err != nil {
+			addf("%s/ReqdExctnDt must be an ISO date", path)
+		}
+		if info.Debtor == nil {
+			addf("%s/Dbtr is required", path)
+		}
+		if info.DebtorAccount == nil {
+			addf("%s/DbtrAcct is required", path)
+		}
+		if len(info.Transactions) == 0 {
+			addf("%s must contain at least one CdtTrfTxInf", path)
+		}
+
+		var infoSum money.Amount
+		infoSumValid := true
+		for j, transaction := range info.Transactions {
+			txPath := fmt.Sprintf("%s/CdtTrfTxInf[%d]", path, j+1)
+			checkID(txPath+"/PmtId/InstrId", transaction.PaymentID.InstructionID, false)
+			checkID(txPath+"/PmtId/EndToEndId", transaction.PaymentID.EndToEndID, true)
+			if transaction.Creditor == nil {
+				addf("%s/Cdtr is required", txPath)
+			}
+			if transaction.CreditorAccount == nil {
+				addf("%s/CdtrAcct is required", txPath)
+			}
+			instructed := transaction.Amount.Instructed
+			if instructed == nil {
+				addf("%s/Amt/InstdAmt is required", txPath)
+				infoSumValid = false
+				continue
+			}
+			if !money.Currency(instructed.Currency).IsValid() {
+				addf("%s/Amt/InstdAmt/@Ccy %q is not a supported currency", txPath, instructed.Currency)
+			}
+			amount, err := money.Parse(strings.TrimSpace(instructed.Value))
+			if err != nil || !amount.IsPositive() {
+				addf("%s/Amt/InstdAmt must be a positive amount with at most two decimals", txPath)
+				infoSumValid = false
+				continue
+			}
+			infoSum = infoSum.Add(amount)
+		}
+
+		total += len(info.Transactions)
+		if info.NumberOfTxs != "" && info.NumberOfTxs != strconv.Itoa(len(info.Transactions)) {
+			addf("%s/NbOfTxs does not match the number of transactions", path)
+		}
+		if info.ControlSum != "" && infoSumValid && !controlSumMatches(info.ControlSum, infoSum) {
+			addf("%s/CtrlSum does not match the sum of instructed amounts", path)
+		}
+		sum = sum.Add(infoSum)
+		sumValid = sumValid && infoSumValid
+	}
+
+	if header.NumberOfTxs != strconv.Itoa(total) {
+		addf("GrpHdr/NbOfTxs does not match the number of transactions")
+	}
+	if header.ControlSum != "" && sumValid && !controlSumMatches(header.ControlSum, sum) {
+		addf("GrpHdr/CtrlSum does not match the sum of instructed amounts")
+	}
+	return violations
+}
+
+// ExecutionDate возвращает запрошенную дату исполнения блока платежей
+func (p *PaymentInformation) ExecutionDate() (time.Time, error) {
+	date := p.RequestedExecutionDate
+	switch {
+	case date.Date != "":
+		return time.Parse("2006-01-02", date.Date)
+	case date.DateTime != "":
+		return parseDateTime(date.DateTime)
+	}
+	return time.Parse("2006-01-02", strings.TrimSpace(date.Value))
+}
+
+// parseDateTime разбирает ISODateTime; зона времени в схеме необязательна
+func parseDateTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02T15:04:05.999999999", value)
+}
+
+// controlSumMatches сравнивает контрольную сумму с фактической; CtrlSum может
+// содержать незначащие нули после двух знаков, поэтому сравниваются числа
+func controlSumMatches(controlSum string, sum money.Amount) bool {
+	expected, ok := new(big.Rat).SetString(strings.TrimSpace(controlSum))
+	return ok && expected.Cmp(sum.Rat()) == 0
+}
@@ -0,0 +1,151 @@
+package iso20022
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Статусы платежей (ExternalPaymentTransactionStatus1Code / ExternalPaymentGroupStatus1Code)
+const (
+	StatusAcceptedSettlementCompleted = "ACSC"
+	StatusPartiallyAccepted           = "PART"
+	StatusRejected                    = "RJCT"
+)
+
+// Коды причин отказа (ExternalStatusReason1Code)
+const (
+	ReasonInvalidFileFormat      = "FF01"
+	ReasonInvalidDebtorAccount   = "AC02"
+	ReasonInvalidCreditorAccount = "AC03"
+	ReasonBlockedAccount         = "AC06"
+	ReasonTransactionForbidden   = "AG01"
+	ReasonNotAllowedCurrency     = "AM03"
+	ReasonInsufficientFunds      = "AM04"
+	ReasonInvalidAmount          = "AM12"
+	ReasonInvalidDate            = "DT01"
+	ReasonIncorrectCurrency      = "CURR"
+	ReasonNarrative              = "NARR"
+)
+
+// Pain002Document — отчёт о статусе платежей клиента (CustomerPaymentStatusReport)
+type Pain002Document struct {
+	XMLName   xml.Name `xml:"Document"`
+	Namespace string   `xml:"xmlns,attr"`
+	Report    struct {
+		GroupHeader struct {
+			MessageID    string `xml:"MsgId"`
+			CreationTime string `xml:"CreDtTm"`
+		} `xml:"GrpHdr"`
+		OriginalGroup struct {
+			MessageID     string          `xml:"OrgnlMsgId"`
+			MessageName   string          `xml:"OrgnlMsgNmId"`
+			NumberOfTxs   string          `xml:"OrgnlNbOfTxs,omitempty"`
+			ControlSum    string          `xml:"OrgnlCtrlSum,omitempty"`
+			Status        string          `xml:"GrpSts"`
+			StatusReasons []*StatusReason `xml:"StsRsnInf,omitempty"`
+		} `xml:"OrgnlGrpInfAndSts"`
+		PaymentInfos []*PaymentInfoStatus `xml:"OrgnlPmtInfAndSts,omitempty"`
+	} `xml:"CstmrPmtStsRpt"`
+}
+
+// PaymentInfoStatus — статус блока платежей и входящих в него поручений
+type PaymentInfoStatus struct {
+	OriginalID   string               `xml:"OrgnlPmtInfId"`
+	Status       string               `xml:"PmtInfSts"`
+	Transactions []*TransactionStatus `xml:"TxInfAndSts"`
+}
+
+// TransactionStatus — статус отдельного поручения
+type TransactionStatus struct {
+	StatusID              string        `xml:"StsId"`
+	OriginalInstructionID string        `xml:"OrgnlInstrId,omitempty"`
+	OriginalEndToEndID    string        `xml:"OrgnlEndToEndId"`
+	Status                string        `xml:"TxSts"`
+	StatusReason          *StatusReason `xml:"StsRsnInf,omitempty"`
+}
+
+// NewPain002 создаёт отчёт о статусе для исходного документа pain.001.
+// Статус группы выставляется методом Finalize после заполнения статусов поручений.
+func NewPain002(original *Pain001Document, messageID string, now time.Time) *Pain002Document {
+	report := &Pain002Document{Namespace: Pain002Namespace}
+	report.Report.GroupHeader.MessageID = messageID
+	report.Report.GroupHeader.CreationTime = formatDateTime(now)
+	report.Report.OriginalGroup.MessageID = original.Initiation.GroupHeader.MessageID
+	report.Report.OriginalGroup.MessageName = original.MessageName()
+	report.Report.OriginalGroup.NumberOfTxs = original.Initiation.GroupHeader.NumberOfTxs
+	report.Report.OriginalGroup.ControlSum = original.Initiation.GroupHeader.ControlSum
+	return report
+}
+
+// Reject отклоняет документ целиком, например при нарушении схемы
+func (d *Pain002Document) Reject(code string, info ...string) {
+	d.Report.OriginalGroup.Status = StatusRejected
+	d.Report.OriginalGroup.StatusReasons = append(d.Report.OriginalGroup.StatusReasons, newStatusReason(code, info...))
+}
+
+// AddPaymentInfo добавляет блок статусов для исходного PmtInf
+func (d *Pain002Document) AddPaymentInfo(originalID string) *PaymentInfoStatus {
+	status := &PaymentInfoStatus{OriginalID: originalID}
+	d.Report.PaymentInfos = append(d.Report.PaymentInfos, status)
+	return status
+}
+
+// Accept отмечает поручение исполненным
+func (p *PaymentInfoStatus) Accept(transaction *CreditTransferTransaction) {
+	p.add(transaction, StatusAcceptedSettlementCompleted, nil)
+}
+
+// RejectTransaction отмечает поручение отклонённым с указанным кодом причины
+func (p *PaymentInfoStatus) RejectTransaction(transaction *CreditTransferTransaction, code string, info ...string) {
+	p.add(transaction, StatusRejected, newStatusReason(code, info...))
+}
+
+func (p *PaymentInfoStatus) add(transaction *CreditTransferTransaction, status string, reason *StatusReason) {
+	p.Transactions = append(p.Transactions, &TransactionStatus{
+		StatusID:              p.OriginalID + "-" + strconv.Itoa(len(p.Transactions)+1),
+		OriginalInstructionID: transaction.PaymentID.InstructionID,
+		OriginalEndToEndID:    transaction.PaymentID.EndToEndID,
+		Status:                status,
+		StatusReason:          reason,
+	})
+}
+
+// Finalize вычисляет статусы блоков и группы по статусам поручений:
+// ACSC — исполнены все, RJCT — не исполнено ни одно, PART — исполнена часть
+func (d *Pain002Document) Finalize() {
+	if d.Report.OriginalGroup.Status == StatusRejected {
+		return
+	}
+	var accepted, rejected int
+	for _, info := range d.Report.PaymentInfos {
+		var infoAccepted, infoRejected int
+		for _, transaction := range info.Transactions {
+			if transaction.Status == StatusRejected {
+				infoRejected++
+			} else {
+				infoAccepted++
+			}
+		}
+		info.Status = aggregateStatus(infoAccepted, infoRejected)
+		accepted += infoAccepted
+		rejected += infoRejected
+	}
+	d.Report.OriginalGroup.Status = aggregateStatus(accepted, rejected)
+}
+
+func aggregateStatus(accepted, rejected int) string {
+	switch {
+	case rejected == 0:
+		return StatusAcceptedSettlementCompleted
+	case accepted == 0:
+		return StatusRejected
+	}
+	return StatusPartiallyAccepted
+}
+
+// Write записывает отчёт pain.002 в w
+func (d *Pain002Document) Write(w io.Writer) error {
+	return writeDocument(w, d)
+}
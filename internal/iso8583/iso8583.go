@@ -0,0 +1,348 @@
+// Package iso8583 кодирует и разбирает сообщения ISO 8583 (версия 1987), которыми
+// обменивается симулятор карточного процессинга. Поддерживается подмножество полей,
+// нужное для авторизации, финансовых операций и их отмены.
+//
+// Формат: MTI из четырёх ASCII-цифр, двоичный первичный битмап (8 байт), при наличии
+// полей 65–128 — двоичный вторичный битмап, затем поля в ASCII. Длина полей переменной
+// длины передаётся ASCII-префиксом из двух (LLVAR) или трёх (LLLVAR) цифр.
+// На транспортном уровне каждое сообщение предваряется двухбайтовой длиной (big-endian).
+package iso8583
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Типы сообщений (MTI)
+const (
+	MTIAuthorizationRequest  = "0100"
+	MTIAuthorizationResponse = "0110"
+	MTIFinancialRequest      = "0200"
+	MTIFinancialResponse     = "0210"
+	MTIReversalRequest       = "0400"
+	MTIReversalResponse      = "0410"
+	MTINetworkRequest        = "0800"
+	MTINetworkResponse       = "0810"
+)
+
+// Номера используемых полей
+const (
+	FieldPAN                  = 2
+	FieldProcessingCode       = 3
+	FieldAmount               = 4
+	FieldTransmissionDateTime = 7
+	FieldSTAN                 = 11
+	FieldLocalTime            = 12
+	FieldLocalDate            = 13
+	FieldExpiryDate           = 14
+	FieldMerchantType         = 18
+	FieldPOSEntryMode         = 22
+	FieldRRN                  = 37
+	FieldApprovalCode         = 38
+	FieldResponseCode         = 39
+	FieldTerminalID           = 41
+	FieldMerchantID           = 42
+	FieldCardAcceptorName     = 43
+	// FieldAdditionalData в симуляторе содержит CVV2
+	FieldAdditionalData = 48
+	FieldCurrency       = 49
+	// FieldNetworkCode — код сетевого сообщения 0800 (301 — эхо-тест)
+	FieldNetworkCode = 70
+	// FieldOriginalData — данные исходного сообщения в запросе отмены:
+	// MTI (4), STAN (6), дата и время передачи (10), идентификаторы эквайрера и отправителя (по 11)
+	FieldOriginalData = 90
+)
+
+// Коды ответа (поле 39)
+const (
+	ResponseApproved            = "00"
+	ResponseDoNotHonor          = "05"
+	ResponseInvalidTransaction  = "12"
+	ResponseInvalidAmount       = "13"
+	ResponseInvalidCardNumber   = "14"
+	ResponseOriginalNotFound    = "25"
+	ResponseFormatError         = "30"
+	ResponseUnsupportedFunction = "40"
+	ResponseLostCard            = "41"
+	ResponseStolenCard          = "43"
+	ResponseInsufficientFunds   = "51"
+	ResponseExpiredCard         = "54"
+	ResponseExceedsAmountLimit  = "61"
+	ResponseRestrictedCard      = "62"
+	ResponseExceedsCountLimit   = "65"
+	ResponseSystemMalfunction   = "96"
+	ResponseCVVMismatch         = "N7"
+)
+
+// maxFrameLength — максимальная длина сообщения, которую можно передать двухбайтовым префиксом
+const maxFrameLength = 1<<16 - 1
+
+var (
+	ErrUnsupportedField = errors.New("unsupported field")
+	ErrMalformed        = errors.New("malformed message")
+)
+
+type lengthType int
+
+const (
+	fixed lengthType = iota
+	llvar
+	lllvar
+)
+
+// fieldSpec описывает формат поля: n — только цифры, остальные — печатные ASCII-символы
+type fieldSpec struct {
+	length  lengthType
+	max     int
+	numeric bool
+}
+
+var specs = map[int]fieldSpec{
+	FieldPAN:                  {llvar, 19, true},
+	FieldProcessingCode:       {fixed, 6, true},
+	FieldAmount:               {fixed, 12, true},
+	FieldTransmissionDateTime: {fixed, 10, true},
+	FieldSTAN:                 {fixed, 6, true},
+	FieldLocalTime:            {fixed, 6, true},
+	FieldLocalDate:            {fixed, 4, true},
+	FieldExpiryDate:           {fixed, 4, true},
+	FieldMerchantType:         {fixed, 4, true},
+	FieldPOSEntryMode:         {fixed, 3, true},
+	FieldRRN:                  {fixed, 12, false},
+	FieldApprovalCode:         {fixed, 6, false},
+	FieldResponseCode:         {fixed, 2, false},
+	FieldTerminalID:           {fixed, 8, false},
+	FieldMerchantID:           {fixed, 15, false},
+	FieldCardAcceptorName:     {fixed, 40, false},
+	FieldAdditionalData:       {lllvar, 999, false},
+	FieldCurrency:             {fixed, 3, true},
+	FieldNetworkCode:          {fixed, 3, true},
+	FieldOriginalData:         {fixed, 42, true},
+}
+
+// Message — сообщение ISO 8583: тип и значения полей в текстовом виде
+type Message struct {
+	MTI    string
+	fields map[int]string
+}
+
+func NewMessage(mti string) *Message {
+	return &Message{MTI: mti, fields: make(map[int]string)}
+}
+
+// Get возвращает значение поля; у полей фиксированной длины сохраняется дополнение
+func (m *Message) Get(field int) string {
+	return m.fields[field]
+}
+
+func (m *Message) Has(field int) bool {
+	_, ok := m.fields[field]
+	return ok
+}
+
+// Set задаёт значение поля. Значение поля фиксированной длины дополняется до нужной
+// длины нулями слева (цифровые поля) или пробелами справа (остальные).
+func (m *Message) Set(field int, value string) error {
+	spec, ok := specs[field]
+	if !ok {
+		return fmt.Errorf("%w %d", ErrUnsupportedField, field)
+	}
+	if spec.length == fixed && len(value) < spec.max {
+		if spec.numeric {
+			value = strings.Repeat("0", spec.max-len(value)) + value
+		} else {
+			value += strings.Repeat(" ", spec.max-len(value))
+		}
+	}
+	if err := spec.check(value); err != nil {
+		return fmt.Errorf("field %d: %w", field, err)
+	}
+	m.fields[field] = value
+	return nil
+}
+
+// Fields возвращает номера заполненных полей по возрастанию
+func (m *Message) Fields() []int {
+	fields := make([]int, 0, len(m.fields))
+	for field := range m.fields {
+		fields = append(fields, field)
+	}
+	sort.Ints(fields)
+	return fields
+}
+
+// Response создаёт ответ на запрос: MTI ответа и эхо полей, которые эквайрер
+// использует для сопоставления ответа с запросом
+func (m *Message) Response() *Message {
+	resp := NewMessage(ResponseMTI(m.MTI))
+	for _, field := range []int{FieldPAN, FieldProcessingCode, FieldAmount, FieldTransmissionDateTime, FieldSTAN,
+		FieldLocalTime, FieldLocalDate, FieldRRN, FieldTerminalID, FieldMerchantID, FieldCurrency, FieldNetworkCode, FieldOriginalData} {
+		if value, ok := m.fields[field]; ok {
+			resp.fields[field] = value
+		}
+	}
+	return resp
+}
+
+// ResponseMTI возвращает тип ответа на запрос: 0100 → 0110, 0200 → 0210 и т. д.
+func ResponseMTI(mti string) string {
+	if len(mti) != 4 {
+		return mti
+	}
+	function := mti[2] - '0'
+	if function%2 == 0 {
+		function++
+	}
+	return mti[:2] + string('0'+function) + mti[3:]
+}
+
+// Pack кодирует сообщение
+func (m *Message) Pack() ([]byte, error) {
+	if len(m.MTI) != 4 || !isDigits(m.MTI) {
+		return nil, fmt.Errorf("%w: invalid MTI %q", ErrMalformed, m.MTI)
+	}
+	fields := m.Fields()
+
+	bitmap := make([]byte, 8)
+	if len(fields) > 0 && fields[len(fields)-1] > 64 {
+		bitmap = make([]byte, 16)
+		bitmap[0] |= 0x80
+	}
+	for _, field := range fields {
+		if field < 2 || field > 128 {
+			return nil, fmt.Errorf("%w %d", ErrUnsupportedField, field)
+		}
+		bitmap[(field-1)/8] |= 0x80 >> ((field - 1) % 8)
+	}
+
+	out := append([]byte(m.MTI), bitmap...)
+	for _, field := range fields {
+		spec := specs[field]
+		value := m.fields[field]
+		switch spec.length {
+		case llvar:
+			out = append(out, fmt.Sprintf("%02d", len(value))...)
+		case lllvar:
+			out = append(out, fmt.Sprintf("%03d", len(value))...)
+		}
+		out = append(out, value...)
+	}
+	return out, nil
+}
+
+// Unpack разбирает сообщение
+func Unpack(data []byte) (*Message, error) {
+	if len(data) < 12 {
+		return nil, fmt.Errorf("%w: message is too short", ErrMalformed)
+	}
+	m := NewMessage(string(data[:4]))
+	if !isDigits(m.MTI) {
+		return nil, fmt.Errorf("%w: invalid MTI %q", ErrMalformed, m.MTI)
+	}
+
+	bitmap := data[4:12]
+	pos := 12
+	if bitmap[0]&0x80 != 0 {
+		if len(data) < 20 {
+			return nil, fmt.Errorf("%w: secondary bitmap is missing", ErrMalformed)
+		}
+		bitmap = data[4:20]
+		pos = 20
+	}
+
+	for field := 2; field <= len(bitmap)*8; field++ {
+		if bitmap[(field-1)/8]&(0x80>>((field-1)%8)) == 0 {
+			continue
+		}
+		spec, ok := specs[field]
+		if !ok {
+			return nil, fmt.Errorf("%w %d", ErrUnsupportedField, field)
+		}
+
+		length := spec.max
+		if spec.length != fixed {
+			prefix := 2
+			if spec.length == lllvar {
+				prefix = 3
+			}
+			if pos+prefix > len(data) {
+				return nil, fmt.Errorf("%w: field %d length is truncated", ErrMalformed, field)
+			}
+			n, err := strconv.Atoi(string(data[pos : pos+prefix]))
+			if err != nil || n > spec.max {
+				return nil, fmt.Errorf("%w: field %d has invalid length", ErrMalformed, field)
+			}
+			length = n
+			pos += prefix
+		}
+		if pos+length > len(data) {
+			return nil, fmt.Errorf("%w: field %d is truncated", ErrMalformed, field)
+		}
+		value := string(data[pos : pos+length])
+		if err := spec.check(value); err != nil {
+			return nil, fmt.Errorf("%w: field %d: %v", ErrMalformed, field, err)
+		}
+		m.fields[field] = value
+		pos += length
+	}
+	if pos != len(data) {
+		return nil, fmt.Errorf("%w: %d unexpected trailing bytes", ErrMalformed, len(data)-pos)
+	}
+	return m, nil
+}
+
+func (s fieldSpec) check(value string) error {
+	if s.length == fixed && len(value) != s.max {
+		return fmt.Errorf("must be %d characters long", s.max)
+	}
+	if len(value) > s.max {
+		return fmt.Errorf("must be at most %d characters long", s.max)
+	}
+	if s.numeric && value != "" && !isDigits(value) {
+		return errors.New("must contain only digits")
+	}
+	for i := 0; i < len(value); i++ {
+		if value[i] < 0x20 || value[i] > 0x7e {
+			return errors.New("must contain only printable ASCII characters")
+		}
+	}
+	return nil
+}
+
+// ReadFrame читает одно сообщение с двухбайтовым префиксом длины
+func ReadFrame(r io.Reader) ([]byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	data := make([]byte, binary.BigEndian.Uint16(header[:]))
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// WriteFrame записывает сообщение с двухбайтовым префиксом длины
+func WriteFrame(w io.Writer, data []byte) error {
+	if len(data) > maxFrameLength {
+		return fmt.Errorf("message is too long: %d bytes", len(data))
+	}
+	frame := make([]byte, 2, 2+len(data))
+	binary.BigEndian.PutUint16(frame, uint16(len(data)))
+	_, err := w.Write(append(frame, data...))
+	return err
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,134 @@
+// Package lifecycle управляет запуском и остановкой компонентов сервиса:
+// фоновых воркеров, HTTP-сервера и соединения с базой.
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type stopHook struct {
+	name string
+	stop func(ctx context.Context) error
+}
+
+// Manager запускает компоненты и останавливает их в порядке, обратном регистрации:
+// компонент, зарегистрированный последним (обычно HTTP-сервер), останавливается первым,
+// а ресурсы, от которых зависят остальные (соединение с базой), закрываются последними.
+type Manager struct {
+	logger *logrus.Logger
+	mutex  sync.Mutex
+	hooks  []stopHook
+	failed chan error
+}
+
+func NewManager(logger *logrus.Logger) *Manager {
+	return &Manager{
+		logger: logger,
+		failed: make(chan error, 1),
+	}
+}
+
+// OnStop регистрирует действие, выполняемое при остановке
+func (m *Manager) OnStop(name string, stop func(ctx context.Context) error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.hooks = append(m.hooks, stopHook{name: name, stop: stop})
+}
+
+// Go запускает компонент в отдельной горутине. Контекст компонента отменяется при остановке,
+// после чего менеджер ждёт его завершения. Ошибка, которую компонент вернул до остановки,
+// считается фатальной и инициирует остановку всего сервиса.
+func (m *Manager) Go(name string, run func(ctx context.Context) error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := run(ctx); err != nil && ctx.Err() == nil {
+			m.fail(fmt.Errorf("%s: %w", name, err))
+		}
+	}()
+
+	m.OnStop(name, func(stopCtx context.Context) error {
+		cancel()
+		select {
+		case <-done:
+			return nil
+		case <-stopCtx.Done():
+			return stopCtx.Err()
+		}
+	})
+}
+
+// Every запускает задачу с заданным периодом до остановки сервиса.
+// Ошибки задачи логируются и не прерывают следующие запуски.
+func (m *Manager) Every(name string, interval time.Duration, task func(ctx context.Context) error) {
+	m.Go(name, func(ctx context.Context) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				if err := task(ctx); err != nil && ctx.Err() == nil {
+					m.logger.Error("Background task ", name, " failed: ", err)
+				}
+			}
+		}
+	})
+}
+
+func (m *Manager) fail(err error) {
+	select {
+	case m.failed <- err:
+	default:
+	}
+}
+
+// Wait блокируется до сигнала SIGINT/SIGTERM или фатальной ошибки компонента, затем
+// останавливает компоненты, отводя на всю остановку не более timeout. Повторный сигнал
+// во время остановки завершает процесс немедленно. Возвращает ошибку, вызвавшую остановку.
+func (m *Manager) Wait(timeout time.Duration) error {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	var cause error
+	select {
+	case sig := <-signals:
+		m.logger.Info("Received ", sig, ", shutting down")
+	case cause = <-m.failed:
+		m.logger.Error("Shutting down after failure: ", cause)
+	}
+
+	go func() {
+		sig := <-signals
+		m.logger.Warn("Received ", sig, " during shutdown, exiting immediately")
+		os.Exit(1)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	m.mutex.Lock()
+	hooks := m.hooks
+	m.mutex.Unlock()
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hook := hooks[i]
+		m.logger.Debug("Stopping ", hook.name)
+		if err := hook.stop(ctx); err != nil {
+			m.logger.Error("Failed to stop ", hook.name, ": ", err)
+			continue
+		}
+		m.logger.Info("Stopped ", hook.name)
+	}
+	return cause
+}
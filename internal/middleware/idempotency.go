@@ -0,0 +1,200 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/repositories"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	IdempotencyKeyHeader    = "Idempotency-Key"
+	maxIdempotencyKeyLength = 255
+	// maxIdempotentBodySize — наибольшее тело запроса с ключом; не меньше допустимого размера файла pain.001
+	maxIdempotentBodySize = 10 << 20
+	// idempotencyStoreTimeout — сколько ждать сохранения ответа или освобождения ключа
+	// после того, как обработчик завершился
+	idempotencyStoreTimeout = 5 * time.Second
+)
+
+// IdempotencyMiddleware обрабатывает заголовок Idempotency-Key: первый запрос с ключом
+// выполняется и его ответ сохраняется на keyTTL, повторы с тем же телом получают сохранённый ответ,
+// а повторное использование ключа с другим запросом отклоняется с 422.
+// Если обработка не завершилась за processingTimeout (например, упал процесс), ключ можно занять заново.
+// Должен подключаться после AuthMiddleware, так как ключи привязаны к пользователю.
+func IdempotencyMiddleware(repo repositories.IdempotencyRepository, keyTTL, processingTimeout time.Duration, logger *logrus.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				http.Error(w, "Idempotency-Key is too long", http.StatusBadRequest)
+				return
+			}
+
+			userID, ok := r.Context().Value("user_id").(int64)
+			if !ok {
+				logger.Error("user_id not found in context")
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			// Читаем тело целиком, чтобы вычислить отпечаток запроса и передать тело дальше
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodySize))
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				http.Error(w, "Request body is too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			if err != nil {
+				logger.Error("Failed to read request body: ", err)
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			now := time.Now()
+			record := &models.IdempotencyKey{
+				UserID:      userID,
+				Key:         key,
+				Method:      r.Method,
+				Path:        r.URL.Path,
+				RequestHash: requestFingerprint(r.Method, r.URL.Path, body),
+				CreatedAt:   now,
+				ExpiresAt:   now.Add(processingTimeout),
+			}
+
+			reserved, err := repo.Reserve(r.Context(), record)
+			if err != nil {
+				logger.Error("Failed to reserve idempotency key: ", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			if !reserved {
+				replayIdempotentResponse(w, r, repo, record, logger)
+				return
+			}
+
+			// Ошибки сервера и паника обработчика не сохраняются: ключ освобождается,
+			// и клиент может безопасно повторить запрос с тем же ключом. Отложенный вызов
+			// выполняется и при панике, которая затем продолжает раскручивать стек.
+			completed := false
+			defer func() {
+				if completed {
+					return
+				}
+				ctx, cancel := storeContext(r)
+				defer cancel()
+				if err := repo.Delete(ctx, userID, key); err != nil {
+					logger.Error("Failed to release idempotency key: ", err)
+				}
+			}()
+
+			recorder := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+			if recorder.statusCode >= http.StatusInternalServerError {
+				return
+			}
+			completed = true
+
+			completedAt := time.Now()
+			record.StatusCode = recorder.statusCode
+			record.ResponseBody = recorder.body.Bytes()
+			record.ContentType = recorder.Header().Get("Content-Type")
+			record.CompletedAt = &completedAt
+			record.ExpiresAt = completedAt.Add(keyTTL)
+			ctx, cancel := storeContext(r)
+			defer cancel()
+			if err := repo.Complete(ctx, record); err != nil {
+				// Ключ остаётся незавершённым до истечения processingTimeout: операция уже
+				// выполнена, и немедленный повтор не должен выполнить её ещё раз
+				logger.Error("Failed to store idempotent response: ", err)
+			}
+		})
+	}
+}
+
+func replayIdempotentResponse(w http.ResponseWriter, r *http.Request, repo repositories.IdempotencyRepository, record *models.IdempotencyKey, logger *logrus.Logger) {
+	stored, err := repo.Find(r.Context(), record.UserID, record.Key)
+	if err != nil {
+		logger.Error("Failed to load idempotency key: ", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if stored == nil {
+		// Ключ был освобождён после ошибки сервера между вставкой и чтением
+		http.Error(w, "Request with this Idempotency-Key is being processed, retry later", http.StatusConflict)
+		return
+	}
+
+	if stored.RequestHash != record.RequestHash {
+		logger.WithField("user_id", record.UserID).Warn("Idempotency-Key reused with a different request")
+		http.Error(w, "Idempotency-Key was already used with a different request", http.StatusUnprocessableEntity)
+		return
+	}
+	if !stored.IsCompleted() {
+		http.Error(w, "Request with this Idempotency-Key is being processed, retry later", http.StatusConflict)
+		return
+	}
+
+	logger.WithField("user_id", record.UserID).Debug("Replaying idempotent response for key ", record.Key)
+	if stored.ContentType != "" {
+		w.Header().Set("Content-Type", stored.ContentType)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(stored.StatusCode)
+	_, _ = w.Write(stored.ResponseBody)
+}
+
+// storeContext возвращает контекст для записи результата запроса: клиент мог уже
+// отключиться, но сохранение ответа или освобождение ключа должно дойти до базы
+func storeContext(r *http.Request) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(r.Context()), idempotencyStoreTimeout)
+}
+
+// requestFingerprint вычисляет отпечаток запроса, по которому распознаются повторы
+func requestFingerprint(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder передаёт ответ клиенту и одновременно запоминает его для сохранения
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(statusCode int) {
+	if rr.wroteHeader {
+		return
+	}
+	rr.statusCode = statusCode
+	rr.wroteHeader = true
+	rr.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	if !rr.wroteHeader {
+		rr.WriteHeader(http.StatusOK)
+	}
+	rr.body.Write(b)
+	return rr.ResponseWriter.Write(b)
+}
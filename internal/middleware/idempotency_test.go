@@ -0,0 +1,228 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/repositories"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	testKeyTTL            = 24 * time.Hour
+	testProcessingTimeout = 5 * time.Minute
+)
+
+// fakeIdempotencyRepository хранит ключи в памяти и запоминает, был ли отменён
+// контекст, с которым ключ сохраняли или освобождали
+type fakeIdempotencyRepository struct {
+	repositories.IdempotencyRepository
+	keys             map[string]*models.IdempotencyKey
+	reserveCalls     int
+	completeCtxErr   error
+	deleteCalls      int
+	deleteCtxErr     error
+	completedRecords []models.IdempotencyKey
+}
+
+func newFakeIdempotencyRepository() *fakeIdempotencyRepository {
+	return &fakeIdempotencyRepository{keys: make(map[string]*models.IdempotencyKey)}
+}
+
+func (r *fakeIdempotencyRepository) Reserve(ctx context.Context, key *models.IdempotencyKey) (bool, error) {
+	r.reserveCalls++
+	if _, ok := r.keys[key.Key]; ok {
+		return false, nil
+	}
+	stored := *key
+	r.keys[key.Key] = &stored
+	return true, nil
+}
+
+func (r *fakeIdempotencyRepository) Find(ctx context.Context, userID int64, key string) (*models.IdempotencyKey, error) {
+	stored, ok := r.keys[key]
+	if !ok {
+		return nil, nil
+	}
+	found := *stored
+	return &found, nil
+}
+
+func (r *fakeIdempotencyRepository) Complete(ctx context.Context, key *models.IdempotencyKey) error {
+	r.completeCtxErr = ctx.Err()
+	stored := *key
+	r.keys[key.Key] = &stored
+	r.completedRecords = append(r.completedRecords, stored)
+	return nil
+}
+
+func (r *fakeIdempotencyRepository) Delete(ctx context.Context, userID int64, key string) error {
+	r.deleteCalls++
+	r.deleteCtxErr = ctx.Err()
+	delete(r.keys, key)
+	return nil
+}
+
+func newIdempotentHandler(repo repositories.IdempotencyRepository, next http.HandlerFunc) http.Handler {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return IdempotencyMiddleware(repo, testKeyTTL, testProcessingTimeout, logger)(next)
+}
+
+func newIdempotentRequest(ctx context.Context, key, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/transfer", strings.NewReader(body))
+	r.Header.Set(IdempotencyKeyHeader, key)
+	return r.WithContext(context.WithValue(ctx, "user_id", int64(1)))
+}
+
+func TestIdempotencyMiddlewareStoresAndReplaysResponse(t *testing.T) {
+	repo := newFakeIdempotencyRepository()
+	calls := 0
+	handler := newIdempotentHandler(repo, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":1}`))
+	})
+
+	before := time.Now()
+	first := httptest.NewRecorder()
+	handler.ServeHTTP(first, newIdempotentRequest(context.Background(), "k1", `{"amount":"10.00"}`))
+	second := httptest.NewRecorder()
+	handler.ServeHTTP(second, newIdempotentRequest(context.Background(), "k1", `{"amount":"10.00"}`))
+
+	if calls != 1 {
+		t.Fatalf("handler called %d times, want 1", calls)
+	}
+	if second.Code != http.StatusCreated || second.Body.String() != `{"id":1}` {
+		t.Errorf("replayed response = %d %q, want 201 {\"id\":1}", second.Code, second.Body.String())
+	}
+	if second.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("replayed response is not marked with Idempotent-Replayed")
+	}
+	stored := repo.keys["k1"]
+	if stored.ExpiresAt.Before(before.Add(testKeyTTL)) {
+		t.Errorf("completed key expires at %v, want at least %v", stored.ExpiresAt, before.Add(testKeyTTL))
+	}
+}
+
+func TestIdempotencyMiddlewareReservesKeyForProcessingTimeout(t *testing.T) {
+	repo := newFakeIdempotencyRepository()
+	var reserved models.IdempotencyKey
+	handler := newIdempotentHandler(repo, func(w http.ResponseWriter, r *http.Request) {
+		reserved = *repo.keys["k1"]
+		w.WriteHeader(http.StatusOK)
+	})
+
+	handler.ServeHTTP(httptest.NewRecorder(), newIdempotentRequest(context.Background(), "k1", `{}`))
+
+	if got := reserved.ExpiresAt.Sub(reserved.CreatedAt); got != testProcessingTimeout {
+		t.Errorf("pending key lives %v, want %v", got, testProcessingTimeout)
+	}
+}
+
+// cancelKey передаёт обработчику функцию отмены контекста запроса
+type cancelKey struct{}
+
+func TestIdempotencyMiddlewareReleasesKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{
+			name: "server error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+			},
+		},
+		{
+			name: "panic",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				panic("handler failed")
+			},
+		},
+		{
+			name: "panic after cancelled request",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				r.Context().Value(cancelKey{}).(context.CancelFunc)()
+				panic(http.ErrAbortHandler)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeIdempotencyRepository()
+			handler := newIdempotentHandler(repo, tt.handler)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			r := newIdempotentRequest(context.WithValue(ctx, cancelKey{}, cancel), "k1", `{}`)
+			func() {
+				defer func() { _ = recover() }()
+				handler.ServeHTTP(httptest.NewRecorder(), r)
+			}()
+
+			if repo.deleteCalls != 1 {
+				t.Fatalf("key released %d times, want 1", repo.deleteCalls)
+			}
+			if repo.deleteCtxErr != nil {
+				t.Errorf("key released with a finished context: %v", repo.deleteCtxErr)
+			}
+			if _, ok := repo.keys["k1"]; ok {
+				t.Error("key is still reserved")
+			}
+			if len(repo.completedRecords) != 0 {
+				t.Error("failed response was stored")
+			}
+		})
+	}
+}
+
+func TestIdempotencyMiddlewareStoresResponseAfterClientDisconnect(t *testing.T) {
+	repo := newFakeIdempotencyRepository()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handler := newIdempotentHandler(repo, func(w http.ResponseWriter, r *http.Request) {
+		// Клиент отключился, когда операция уже выполнена
+		cancel()
+		w.WriteHeader(http.StatusOK)
+	})
+
+	handler.ServeHTTP(httptest.NewRecorder(), newIdempotentRequest(ctx, "k1", `{}`))
+
+	if len(repo.completedRecords) != 1 {
+		t.Fatalf("response stored %d times, want 1", len(repo.completedRecords))
+	}
+	if repo.completeCtxErr != nil {
+		t.Errorf("response stored with a finished context: %v", repo.completeCtxErr)
+	}
+	if repo.deleteCalls != 0 {
+		t.Error("key of a completed request was released")
+	}
+}
+
+func TestIdempotencyMiddlewareRejectsTooLargeBody(t *testing.T) {
+	repo := newFakeIdempotencyRepository()
+	called := false
+	handler := newIdempotentHandler(repo, func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	body := bytes.Repeat([]byte("a"), maxIdempotentBodySize+1)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newIdempotentRequest(context.Background(), "k1", string(body)))
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+	if called || repo.reserveCalls != 0 {
+		t.Error("oversized request reached the handler or reserved the key")
+	}
+}
@@ -5,12 +5,15 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/repositories"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/sirupsen/logrus"
 )
 
-// AuthMiddleware проверяет JWT-токен и добавляет user_id в контекст
-func AuthMiddleware(jwtSecret string, logger *logrus.Logger) func(http.Handler) http.Handler {
+// AuthMiddleware проверяет JWT-токен, отклоняет отозванные токены (по jti и сессии sid)
+// и добавляет в контекст user_id, role, token_id, session_id и token_expires_at
+func AuthMiddleware(jwtSecret string, tokenRepo repositories.TokenRepository, logger *logrus.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Извлекаем токен из заголовка Authorization
@@ -65,12 +68,77 @@ func AuthMiddleware(jwtSecret string, logger *logrus.Logger) func(http.Handler)
 				return
 			}
 
-			// Добавляем user_id в контекст
+			// Токены без jti и sid нельзя отозвать, поэтому они не принимаются
+			jti, _ := claims["jti"].(string)
+			sessionID, _ := claims["sid"].(string)
+			if jti == "" || sessionID == "" {
+				logger.Warn("Token without jti or sid")
+				http.Error(w, "Invalid token claims", http.StatusUnauthorized)
+				return
+			}
+			expiresAt, err := claims.GetExpirationTime()
+			if err != nil || expiresAt == nil {
+				logger.Warn("Token without expiration time")
+				http.Error(w, "Invalid token claims", http.StatusUnauthorized)
+				return
+			}
+
+			// Токен без роли получает наименьшие права
+			role, _ := claims["role"].(string)
+			if role == "" {
+				role = models.RoleCustomer
+			}
+			if !models.IsRole(role) {
+				logger.Warn("Unknown role in token: ", role)
+				http.Error(w, "Invalid token claims", http.StatusUnauthorized)
+				return
+			}
+
+			revoked, err := tokenRepo.IsAccessTokenRevoked(r.Context(), jti, sessionID)
+			if err != nil {
+				logger.Error("Failed to check token revocation: ", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			if revoked {
+				logger.Warn("Revoked token used, jti: ", jti)
+				http.Error(w, "Token has been revoked", http.StatusUnauthorized)
+				return
+			}
+
+			// Добавляем user_id и данные токена в контекст
 			ctx := context.WithValue(r.Context(), "user_id", int64(userID))
+			ctx = context.WithValue(ctx, "role", role)
+			ctx = context.WithValue(ctx, "token_id", jti)
+			ctx = context.WithValue(ctx, "session_id", sessionID)
+			ctx = context.WithValue(ctx, "token_expires_at", expiresAt.Time)
 			logger.Debug("Authenticated user_id: ", int64(userID))
 
 			// Передаем управление следующему обработчику
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
-}
\ No newline at end of file
+}
+
+// RequireRole пропускает запрос, только если роль из контекста входит в список разрешённых.
+// Должен подключаться после AuthMiddleware.
+func RequireRole(logger *logrus.Logger, roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role, ok := r.Context().Value("role").(string)
+			if !ok {
+				logger.Error("role not found in context")
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			for _, allowed := range roles {
+				if role == allowed {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			logger.Warn("Access denied for role ", role, ": ", r.Method, " ", r.URL.Path)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+		})
+	}
+}
@@ -1,9 +1,15 @@
 package models
 
 import (
+	"encoding/base64"
 	"errors"
 	"regexp"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/bank-service/internal/money"
+	"github.com/bank-service/internal/pan"
 )
 
 type User struct {
@@ -11,10 +17,29 @@ type User struct {
 	Username  string    `json:"username"`
 	Email     string    `json:"email"`
 	Password  string    `json:"password"`
+	Role      string    `json:"role"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// Роли пользователей. Клиенты работают только со своими счетами, сотрудники банка —
+// через /admin: операционист и администратор могут изменять данные, аудитор — только читать.
+const (
+	RoleCustomer = "customer"
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+	RoleAuditor  = "auditor"
+)
+
+// IsRole сообщает, является ли строка известной ролью
+func IsRole(role string) bool {
+	switch role {
+	case RoleCustomer, RoleOperator, RoleAdmin, RoleAuditor:
+		return true
+	}
+	return false
+}
+
 func (u *User) Validate() error {
 	if u.Username == "" || len(u.Username) < 3 {
 		return errors.New("username must be at least 3 characters long")
@@ -28,33 +53,245 @@ func (u *User) Validate() error {
 	return nil
 }
 
+// Account — счёт клиента. AvailableBalance — остаток за вычетом действующих холдов, доступный для списания.
 type Account struct {
-	ID        int64     `json:"id"`
-	UserID    int64     `json:"user_id"`
-	Balance   float64   `json:"balance"`
-	Currency  string    `json:"currency"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID               int64          `json:"id"`
+	UserID           int64          `json:"user_id"`
+	Balance          money.Amount   `json:"balance"`
+	AvailableBalance money.Amount   `json:"available_balance"`
+	Currency         money.Currency `json:"currency"`
+	Status           string         `json:"status"`
+	Holds            []*Hold        `json:"holds,omitempty"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+}
+
+// Статусы счёта. С замороженного счёта нельзя списывать средства, зачисления на него разрешены.
+const (
+	AccountStatusActive = "active"
+	AccountStatusFrozen = "frozen"
+)
+
+// IsFrozen сообщает, заморожен ли счёт
+func (a *Account) IsFrozen() bool {
+	return a.Status == AccountStatusFrozen
+}
+
+// BalanceMoney возвращает баланс счёта вместе с его валютой
+func (a *Account) BalanceMoney() money.Money {
+	return money.New(a.Balance, a.Currency)
+}
+
+// Статусы холда (блокировки средств)
+const (
+	HoldStatusActive   = "active"
+	HoldStatusCaptured = "captured"
+	HoldStatusReleased = "released"
+	HoldStatusExpired  = "expired"
+)
+
+// Hold — блокировка средств на счёте до списания (например, авторизация покупки по карте).
+// Заблокированная сумма уменьшает доступный остаток, но не остаток по главной книге.
+type Hold struct {
+	ID             int64        `json:"id"`
+	AccountID      int64        `json:"account_id"`
+	CardID         int64        `json:"card_id,omitempty"`
+	Amount         money.Amount `json:"amount"`
+	CapturedAmount money.Amount `json:"captured_amount"`
+	Status         string       `json:"status"`
+	Description    string       `json:"description"`
+	ExpiresAt      time.Time    `json:"expires_at"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+}
+
+// IsActive сообщает, блокирует ли холд средства в момент now
+func (h *Hold) IsActive(now time.Time) bool {
+	return h.Status == HoldStatusActive && now.Before(h.ExpiresAt)
 }
 
+func (h *Hold) Validate() error {
+	if h.AccountID <= 0 {
+		return errors.New("invalid account ID")
+	}
+	if !h.Amount.IsPositive() {
+		return errors.New("amount must be positive")
+	}
+	if !h.ExpiresAt.After(h.CreatedAt) {
+		return errors.New("hold expiry must be in the future")
+	}
+	return nil
+}
+
+// Типы операций по счёту
+const (
+	TransactionTypeDeposit      = "deposit"
+	TransactionTypeWithdrawal   = "withdrawal"
+	TransactionTypeTransferIn   = "transfer_in"
+	TransactionTypeTransferOut  = "transfer_out"
+	TransactionTypeHoldCapture  = "hold_capture"
+	TransactionTypeCardPurchase = "card_purchase"
+	TransactionTypeReversal     = "reversal"
+	// Тип проводки перевода между счетами: в истории счетов он разносится
+	// на пару операций transfer_out и transfer_in
+	TransactionTypeTransfer = "transfer"
+	// Выдача кредита на счёт клиента и списание платежа по кредиту
+	TransactionTypeCreditDisbursement = "credit_disbursement"
+	TransactionTypeCreditRepayment    = "credit_repayment"
+)
+
+// IsTransactionType сообщает, является ли строка известным типом операции
+func IsTransactionType(t string) bool {
+	switch t {
+	case TransactionTypeDeposit, TransactionTypeWithdrawal, TransactionTypeTransferIn,
+		TransactionTypeTransferOut, TransactionTypeHoldCapture, TransactionTypeCardPurchase,
+		TransactionTypeReversal, TransactionTypeCreditDisbursement, TransactionTypeCreditRepayment:
+		return true
+	}
+	return false
+}
+
+// AccountOperation — зачисление на клиентский счёт (Amount > 0) или списание с него (Amount < 0)
+// с корреспонденцией на системные счета. Сумма разносок Counterparts равна -Amount,
+// валюта разносок — валюта счёта.
+type AccountOperation struct {
+	AccountID    int64
+	Type         string
+	Description  string
+	Amount       money.Amount
+	Counterparts []*Posting
+	CreatedAt    time.Time
+}
+
+// Transaction — операция по счёту для выписки клиента. EntryID ссылается на проводку журнала,
+// ExchangeRate заполняется для переводов с конвертацией.
 type Transaction struct {
-	ID          int64     `json:"id"`
-	AccountID   int64     `json:"account_id"`
-	Amount      float64   `json:"amount"`
-	Type        string    `json:"type"`
-	Description string    `json:"description"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID           int64        `json:"id"`
+	AccountID    int64        `json:"account_id"`
+	Amount       money.Amount `json:"amount"`
+	Type         string       `json:"type"`
+	Description  string       `json:"description"`
+	EntryID      int64        `json:"entry_id"`
+	ExchangeRate string       `json:"exchange_rate,omitempty"`
+	CreatedAt    time.Time    `json:"created_at"`
 }
 
+// Card — банковская карта. Номер и срок действия хранятся в базе только в зашифрованном
+// виде (Encrypted); CardNumber и ExpiryDate заполняются лишь при выпуске карты
+// и при явном раскрытии реквизитов, в остальных случаях доступен только MaskedNumber.
 type Card struct {
+	ID           int64             `json:"id"`
+	AccountID    int64             `json:"account_id"`
+	Product      string            `json:"product"`
+	Status       string            `json:"status"`
+	CardNumber   string            `json:"-"`
+	ExpiryDate   string            `json:"-"`
+	MaskedNumber string            `json:"masked_number"`
+	CVV          string            `json:"-"`
+	HMAC         string            `json:"-"`
+	Encrypted    EncryptedCardData `json:"-"`
+	// ExpiresAt — момент окончания действия: начало месяца, следующего за ExpiryDate
+	ExpiresAt time.Time `json:"expires_at"`
+	// ReissuedFromID — карта, взамен которой выпущена эта (0, если карта выпущена впервые)
+	ReissuedFromID int64     `json:"reissued_from_id,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Статусы карты. Операции по карте возможны только в статусе active; из lost, stolen
+// и expired карту можно только закрыть, closed — конечный статус.
+const (
+	CardStatusActive  = "active"
+	CardStatusBlocked = "blocked"
+	CardStatusLost    = "lost"
+	CardStatusStolen  = "stolen"
+	CardStatusExpired = "expired"
+	CardStatusClosed  = "closed"
+)
+
+// Причины смены статуса карты, которые проставляет сам сервис
+const (
+	CardReasonIssued   = "issued"
+	CardReasonReissued = "reissued"
+	CardReasonExpired  = "expiry date passed"
+)
+
+// cardTransitions — допустимые переходы между статусами карты
+var cardTransitions = map[string][]string{
+	CardStatusActive:  {CardStatusBlocked, CardStatusLost, CardStatusStolen, CardStatusExpired, CardStatusClosed},
+	CardStatusBlocked: {CardStatusActive, CardStatusLost, CardStatusStolen, CardStatusExpired, CardStatusClosed},
+	CardStatusLost:    {CardStatusClosed},
+	CardStatusStolen:  {CardStatusClosed},
+	CardStatusExpired: {CardStatusClosed},
+}
+
+// CanTransitionTo сообщает, можно ли перевести карту в статус status
+func (c *Card) CanTransitionTo(status string) bool {
+	for _, next := range cardTransitions[c.Status] {
+		if next == status {
+			return true
+		}
+	}
+	return false
+}
+
+// IsUsable сообщает, можно ли проводить операции по карте в момент now. Пустой ExpiresAt
+// бывает у карт, выпущенных до учёта срока действия, пока его не заполнила задача истечения карт.
+func (c *Card) IsUsable(now time.Time) bool {
+	return c.Status == CardStatusActive && (c.ExpiresAt.IsZero() || now.Before(c.ExpiresAt))
+}
+
+// CardExpiresAt вычисляет момент окончания действия карты по сроку MM/YY:
+// карта действует до конца указанного месяца включительно
+func CardExpiresAt(expiryDate string) (time.Time, error) {
+	expiry, err := time.Parse("01/06", expiryDate)
+	if err != nil {
+		return time.Time{}, errors.New("invalid expiry date format (MM/YY)")
+	}
+	return expiry.AddDate(0, 1, 0), nil
+}
+
+// CardStatusChange — запись журнала смены статуса карты; ActorID равен 0 для действий системы
+type CardStatusChange struct {
 	ID         int64     `json:"id"`
-	AccountID  int64     `json:"account_id"`
-	CardNumber string    `json:"card_number"`
-	ExpiryDate string    `json:"expiry_date"`
-	CVV        string    `json:"cvv"`
-	HMAC       string    `json:"hmac"`
+	CardID     int64     `json:"card_id"`
+	FromStatus string    `json:"from_status,omitempty"`
+	ToStatus   string    `json:"to_status"`
+	ActorID    int64     `json:"actor_id,omitempty"`
+	Reason     string    `json:"reason,omitempty"`
 	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// EncryptedCardData — реквизиты карты, зашифрованные ключом данных DataKey,
+// который в свою очередь зашифрован ключом KeyID из файла ключей
+type EncryptedCardData struct {
+	KeyID      string
+	DataKey    []byte
+	CardNumber []byte
+	ExpiryDate []byte
+}
+
+// MaskCardNumber скрывает середину номера карты: 4276 **** **** 1234
+func MaskCardNumber(cardNumber string) string {
+	if len(cardNumber) < 8 {
+		return strings.Repeat("*", len(cardNumber))
+	}
+	middle := strings.Repeat("*", len(cardNumber)-8)
+	masked := cardNumber[:4] + middle + cardNumber[len(cardNumber)-4:]
+
+	// Группы по четыре цифры, как на лицевой стороне карты
+	var b strings.Builder
+	for i := 0; i < len(masked); i += 4 {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		end := i + 4
+		if end > len(masked) {
+			end = len(masked)
+		}
+		b.WriteString(masked[i:end])
+	}
+	return b.String()
 }
 
 func (c *Card) Validate() error {
@@ -64,6 +301,9 @@ func (c *Card) Validate() error {
 	if !regexp.MustCompile(`^\d{16}$`).MatchString(c.CardNumber) {
 		return errors.New("card number must be 16 digits")
 	}
+	if !pan.Valid(c.CardNumber) {
+		return errors.New("card number fails the Luhn check")
+	}
 	if !regexp.MustCompile(`^(0[1-9]|1[0-2])\/\d{2}$`).MatchString(c.ExpiryDate) {
 		return errors.New("invalid expiry date format (MM/YY)")
 	}
@@ -73,21 +313,78 @@ func (c *Card) Validate() error {
 	return nil
 }
 
+// Статусы кредита: current — платежи вносятся в срок, overdue — есть просроченный платёж,
+// defaulted — просрочка превысила допустимый срок; дефолт снимается только полным погашением
+const (
+	CreditStatusCurrent   = "current"
+	CreditStatusOverdue   = "overdue"
+	CreditStatusDefaulted = "defaulted"
+	CreditStatusClosed    = "closed"
+)
+
+// Типы графика платежей по кредиту
+const (
+	// ScheduleTypeAnnuity — равные платежи
+	ScheduleTypeAnnuity = "annuity"
+	// ScheduleTypeDifferentiated — равные части основного долга и убывающие проценты
+	ScheduleTypeDifferentiated = "differentiated"
+	// ScheduleTypeInterestOnly — только проценты, основной долг гасится последним платежом
+	ScheduleTypeInterestOnly = "interest_only"
+	// ScheduleTypeZeroInterest — беспроцентная рассрочка равными платежами
+	ScheduleTypeZeroInterest = "zero_interest"
+)
+
+// Конвенции подсчёта дней для начисления процентов: доля года — число дней периода,
+// делённое на 365, на длину календарного года или по правилу 30/360
+const (
+	DayCountActual365    = "actual/365"
+	DayCountActualActual = "actual/actual"
+	DayCount30360        = "30/360"
+)
+
+func IsDayCount(c string) bool {
+	switch c {
+	case DayCountActual365, DayCountActualActual, DayCount30360:
+		return true
+	}
+	return false
+}
+
+func IsScheduleType(t string) bool {
+	switch t {
+	case ScheduleTypeAnnuity, ScheduleTypeDifferentiated, ScheduleTypeInterestOnly, ScheduleTypeZeroInterest:
+		return true
+	}
+	return false
+}
+
+// Credit — кредит, выданный на счёт AccountID и погашаемый с него же.
+// У кредитов, оформленных до привязки к счёту, AccountID равен нулю.
 type Credit struct {
-	ID           int64     `json:"id"`
-	UserID       int64     `json:"user_id"`
-	Amount       float64   `json:"amount"`
-	InterestRate float64   `json:"interest_rate"`
-	TermMonths   int       `json:"term_months"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           int64          `json:"id"`
+	UserID       int64          `json:"user_id"`
+	AccountID    int64          `json:"account_id,omitempty"`
+	Amount       money.Amount   `json:"amount"`
+	Currency     money.Currency `json:"currency"`
+	InterestRate float64        `json:"interest_rate"`
+	TermMonths   int            `json:"term_months"`
+	ScheduleType string         `json:"schedule_type"`
+	DayCount     string         `json:"day_count"`
+	Status       string         `json:"status"`
+	// DisbursementEntryID — проводка выдачи кредита на счёт
+	DisbursementEntryID int64     `json:"disbursement_entry_id,omitempty"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
 }
 
 func (c *Credit) Validate() error {
 	if c.UserID <= 0 {
 		return errors.New("invalid user ID")
 	}
-	if c.Amount <= 0 {
+	if c.AccountID <= 0 {
+		return errors.New("invalid account ID")
+	}
+	if !c.Amount.IsPositive() {
 		return errors.New("amount must be positive")
 	}
 	if c.InterestRate < 0 || c.InterestRate > 100 {
@@ -96,29 +393,538 @@ func (c *Credit) Validate() error {
 	if c.TermMonths <= 0 {
 		return errors.New("term months must be positive")
 	}
+	// На каждый платёж графика приходится хотя бы одна копейка основного долга
+	if c.Amount.Cmp(money.FromMinor(int64(c.TermMonths))) < 0 {
+		return errors.New("amount is too small for the term")
+	}
+	if !IsScheduleType(c.ScheduleType) {
+		return errors.New("schedule type must be annuity, differentiated, interest_only or zero_interest")
+	}
+	if !IsDayCount(c.DayCount) {
+		return errors.New("day count must be actual/365, actual/actual or 30/360")
+	}
+	if c.ScheduleType == ScheduleTypeZeroInterest && c.InterestRate != 0 {
+		return errors.New("zero_interest schedule requires a zero interest rate")
+	}
+	if c.ScheduleType == ScheduleTypeInterestOnly && c.InterestRate == 0 {
+		return errors.New("interest_only schedule requires a positive interest rate")
+	}
 	return nil
 }
 
+// PaymentSchedule — платёж по графику. Amount складывается из основного долга Principal
+// и процентов Interest за период с PeriodStart по PaymentDate; RemainingPrincipal — остаток
+// основного долга после платежа; Penalty — неустойка, начисленная за просрочку платежа.
+// Платёж с SupersededAt заменён новым графиком и хранится как история.
 type PaymentSchedule struct {
-	ID          int64     `json:"id"`
-	CreditID    int64     `json:"credit_id"`
-	PaymentDate time.Time `json:"payment_date"`
-	Amount      float64   `json:"amount"`
-	Paid        bool      `json:"paid"`
-	Penalty     float64   `json:"penalty"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID                 int64        `json:"id"`
+	CreditID           int64        `json:"credit_id"`
+	PeriodStart        time.Time    `json:"period_start"`
+	PaymentDate        time.Time    `json:"payment_date"`
+	Amount             money.Amount `json:"amount"`
+	Principal          money.Amount `json:"principal"`
+	Interest           money.Amount `json:"interest"`
+	RemainingPrincipal money.Amount `json:"remaining_principal"`
+	Paid               bool         `json:"paid"`
+	PaidAt             *time.Time   `json:"paid_at,omitempty"`
+	Penalty            money.Amount `json:"penalty"`
+	SupersededAt       *time.Time   `json:"superseded_at,omitempty"`
+	CreatedAt          time.Time    `json:"created_at"`
+	UpdatedAt          time.Time    `json:"updated_at"`
 }
 
 func (ps *PaymentSchedule) Validate() error {
 	if ps.CreditID <= 0 {
 		return errors.New("invalid credit ID")
 	}
-	if ps.Amount <= 0 {
+	if !ps.Amount.IsPositive() {
 		return errors.New("amount must be positive")
 	}
+	if ps.Principal.IsNegative() || ps.Interest.IsNegative() || ps.Principal.Add(ps.Interest) != ps.Amount {
+		return errors.New("payment must split into non-negative principal and interest")
+	}
+	if ps.RemainingPrincipal.IsNegative() {
+		return errors.New("remaining principal must not be negative")
+	}
 	if ps.PaymentDate.IsZero() {
 		return errors.New("payment date is required")
 	}
+	if !ps.PeriodStart.Before(ps.PaymentDate) {
+		return errors.New("payment period must end after it starts")
+	}
+	return nil
+}
+
+// Источники платежа по кредиту: клиент через API или фоновое автосписание
+const (
+	CreditPaymentSourceManual = "manual"
+	CreditPaymentSourceAuto   = "auto"
+)
+
+// Виды платежа по кредиту
+const (
+	CreditPaymentKindScheduled  = "scheduled"
+	CreditPaymentKindPrepayment = "prepayment"
+	CreditPaymentKindPayoff     = "payoff"
+)
+
+// Способы пересчёта графика после частичного досрочного погашения
+const (
+	PrepaymentReduceTerm    = "reduce_term"
+	PrepaymentReducePayment = "reduce_payment"
+)
+
+// CreditPayment — списание со счёта в погашение кредита. Платёж по графику ссылается на
+// ScheduleID; досрочные погашения графика не имеют. Amount складывается из основного долга,
+// процентов и неустойки.
+type CreditPayment struct {
+	ID         int64        `json:"id"`
+	CreditID   int64        `json:"credit_id"`
+	ScheduleID int64        `json:"schedule_id,omitempty"`
+	Kind       string       `json:"kind"`
+	AccountID  int64        `json:"account_id"`
+	Amount     money.Amount `json:"amount"`
+	Principal  money.Amount `json:"principal"`
+	Interest   money.Amount `json:"interest"`
+	Penalty    money.Amount `json:"penalty"`
+	EntryID    int64        `json:"entry_id"`
+	Source     string       `json:"source"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+// PayoffQuote — сумма полного досрочного погашения кредита на дату Date: остаток основного
+// долга, проценты по наступившим платежам и за истёкшую часть текущего периода и неустойка
+type PayoffQuote struct {
+	CreditID  int64        `json:"credit_id"`
+	Date      time.Time    `json:"date"`
+	Principal money.Amount `json:"principal"`
+	Interest  money.Amount `json:"interest"`
+	Penalty   money.Amount `json:"penalty"`
+	Total     money.Amount `json:"total"`
+}
+
+// CreditSummary — сводка по кредиту для раскрытия информации заёмщику. Суммы складываются из
+// внесённых платежей и оставшихся платежей действующего графика. FullCost — полная стоимость
+// кредита в денежном выражении (все платежи сверх основного долга, без неустойки),
+// FullCostRate — она же в процентах годовых по формуле ПСК из 353-ФЗ.
+type CreditSummary struct {
+	CreditID             int64          `json:"credit_id"`
+	Currency             money.Currency `json:"currency"`
+	ScheduleType         string         `json:"schedule_type"`
+	DayCount             string         `json:"day_count"`
+	InterestRate         float64        `json:"interest_rate"`
+	Principal            money.Amount   `json:"principal"`
+	PaidPrincipal        money.Amount   `json:"paid_principal"`
+	OutstandingPrincipal money.Amount   `json:"outstanding_principal"`
+	TotalInterest        money.Amount   `json:"total_interest"`
+	PaidInterest         money.Amount   `json:"paid_interest"`
+	PaidPenalty          money.Amount   `json:"paid_penalty"`
+	TotalPayments        money.Amount   `json:"total_payments"`
+	FullCost             money.Amount   `json:"full_cost"`
+	FullCostRate         float64        `json:"full_cost_rate"`
+	Payments             int            `json:"payments"`
+	RemainingPayments    int            `json:"remaining_payments"`
+	MaturityDate         time.Time      `json:"maturity_date"`
+}
+
+// Виды неустойки: разовый штраф за просрочку платежа и пени за каждый день просрочки
+const (
+	PenaltyKindFee   = "fee"
+	PenaltyKindDaily = "daily"
+)
+
+// CreditPenalty — начисление неустойки по просроченному платежу ScheduleID за день AccrualDate.
+// Base — просроченная сумма, Rate — ставка пени в процентах за день (для штрафа 0).
+type CreditPenalty struct {
+	ID          int64        `json:"id"`
+	CreditID    int64        `json:"credit_id"`
+	ScheduleID  int64        `json:"schedule_id"`
+	Kind        string       `json:"kind"`
+	AccrualDate time.Time    `json:"accrual_date"`
+	Base        money.Amount `json:"base"`
+	Rate        float64      `json:"rate,omitempty"`
+	Amount      money.Amount `json:"amount"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+// CreditStatusChange — запись журнала смены статуса кредита
+type CreditStatusChange struct {
+	ID         int64     `json:"id"`
+	CreditID   int64     `json:"credit_id"`
+	FromStatus string    `json:"from_status,omitempty"`
+	ToStatus   string    `json:"to_status"`
+	Reason     string    `json:"reason,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Системные счета главной книги, с которыми корреспондируют клиентские счета
+const (
+	SystemAccountCashIn         = "cash-in"
+	SystemAccountCashOut        = "cash-out"
+	SystemAccountCreditIssuance = "credit-issuance"
+	SystemAccountOpeningBalance = "opening-balance"
+	// SystemAccountFXPosition — валютная позиция банка, через которую проходят конверсионные переводы
+	SystemAccountFXPosition = "fx-position"
+	// SystemAccountCardSettlement — расчёты с платёжной системой по операциям с картами
+	SystemAccountCardSettlement = "card-settlement"
+	// SystemAccountCreditInterest — процентный доход банка по кредитам; основной долг
+	// возвращается на SystemAccountCreditIssuance
+	SystemAccountCreditInterest = "credit-interest"
+	// SystemAccountCreditPenalty — доход банка от неустойки по просроченным платежам
+	SystemAccountCreditPenalty = "credit-penalty"
+)
+
+// JournalEntry — проводка в журнале двойной записи. Сумма её разносок
+// в каждой валюте всегда равна нулю. Сторнирующая проводка ссылается
+// на исходную через ReversesEntryID; каждую проводку можно сторнировать один раз.
+type JournalEntry struct {
+	ID              int64      `json:"id"`
+	Type            string     `json:"type"`
+	Description     string     `json:"description"`
+	ReversesEntryID int64      `json:"reverses_entry_id,omitempty"`
+	Postings        []*Posting `json:"postings"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// Posting — разноска по одному счёту: клиентскому (AccountID) либо системному (SystemAccount).
+// Положительная сумма увеличивает остаток счёта, отрицательная — уменьшает.
+type Posting struct {
+	ID            int64          `json:"id"`
+	EntryID       int64          `json:"entry_id"`
+	AccountID     int64          `json:"account_id,omitempty"`
+	SystemAccount string         `json:"system_account,omitempty"`
+	Amount        money.Amount   `json:"amount"`
+	Currency      money.Currency `json:"currency"`
+	CreatedAt     time.Time      `json:"created_at"`
+}
+
+func (e *JournalEntry) Validate() error {
+	if e.Type == "" {
+		return errors.New("journal entry type is required")
+	}
+	if len(e.Postings) < 2 {
+		return errors.New("journal entry must have at least two postings")
+	}
+	totals := make(map[money.Currency]money.Amount)
+	for _, p := range e.Postings {
+		if (p.AccountID == 0) == (p.SystemAccount == "") {
+			return errors.New("posting must reference exactly one account")
+		}
+		if p.Amount.IsZero() {
+			return errors.New("posting amount must not be zero")
+		}
+		if !p.Currency.IsValid() {
+			return money.ErrUnknownCurrency
+		}
+		totals[p.Currency] = totals[p.Currency].Add(p.Amount)
+	}
+	for _, total := range totals {
+		if !total.IsZero() {
+			return errors.New("journal entry is not balanced")
+		}
+	}
 	return nil
 }
+
+// IdempotencyKey хранит результат запроса, выполненного с заголовком Idempotency-Key,
+// чтобы повторная отправка того же запроса вернула исходный ответ, а не выполнила операцию снова
+type IdempotencyKey struct {
+	UserID       int64      `json:"user_id"`
+	Key          string     `json:"key"`
+	Method       string     `json:"method"`
+	Path         string     `json:"path"`
+	RequestHash  string     `json:"request_hash"`
+	StatusCode   int        `json:"status_code"`
+	ResponseBody []byte     `json:"response_body"`
+	ContentType  string     `json:"content_type"`
+	CreatedAt    time.Time  `json:"created_at"`
+	CompletedAt  *time.Time `json:"completed_at"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+}
+
+// IsCompleted сообщает, сохранён ли уже ответ на запрос
+func (k *IdempotencyKey) IsCompleted() bool {
+	return k.CompletedAt != nil
+}
+
+// TransactionFilter задаёт условия выборки истории операций по счёту.
+// Пустые поля не ограничивают выборку; суммы сравниваются по модулю.
+type TransactionFilter struct {
+	From      *time.Time
+	To        *time.Time
+	Types     []string
+	MinAmount *money.Amount
+	MaxAmount *money.Amount
+	Query     string
+	After     *TransactionCursor
+	Limit     int
+}
+
+// TransactionCursor указывает на последнюю операцию предыдущей страницы.
+// Операции упорядочены по (created_at, id) по убыванию.
+type TransactionCursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+// Encode кодирует курсор в непрозрачную строку для передачи клиенту
+func (c *TransactionCursor) Encode() string {
+	raw := strconv.FormatInt(c.CreatedAt.UnixNano(), 10) + ":" + strconv.FormatInt(c.ID, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseTransactionCursor разбирает строку, полученную из TransactionCursor.Encode
+func ParseTransactionCursor(s string) (*TransactionCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, errors.New("invalid cursor")
+	}
+	unixNano, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	cursorID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	return &TransactionCursor{CreatedAt: time.Unix(0, unixNano), ID: cursorID}, nil
+}
+
+// TransactionPage — страница истории операций. NextCursor пуст на последней странице.
+type TransactionPage struct {
+	Transactions []*Transaction `json:"transactions"`
+	NextCursor   string         `json:"next_cursor,omitempty"`
+}
+
+// Statement — выписка по счёту за период [From, To): входящий остаток, операции
+// в хронологическом порядке и исходящий остаток
+type Statement struct {
+	Account        *Account       `json:"account"`
+	From           time.Time      `json:"from"`
+	To             time.Time      `json:"to"`
+	OpeningBalance money.Amount   `json:"opening_balance"`
+	ClosingBalance money.Amount   `json:"closing_balance"`
+	TotalCredits   money.Amount   `json:"total_credits"`
+	TotalDebits    money.Amount   `json:"total_debits"`
+	Transactions   []*Transaction `json:"transactions"`
+	GeneratedAt    time.Time      `json:"generated_at"`
+}
+
+// TokenFamily — сессия пользователя: цепочка refresh-токенов, выданных при одном входе.
+// Отзыв семейства делает недействительными все её refresh- и access-токены.
+type TokenFamily struct {
+	ID           string     `json:"id"`
+	UserID       int64      `json:"user_id"`
+	CreatedAt    time.Time  `json:"created_at"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	RevokeReason string     `json:"revoke_reason"`
+}
+
+// RefreshToken хранится только в виде SHA-256 хеша. Токен одноразовый: при обмене
+// помечается использованным, и повторное предъявление считается признаком утечки.
+// FamilyRevokedAt заполняется при чтении токена вместе с его семейством.
+type RefreshToken struct {
+	ID              int64      `json:"id"`
+	FamilyID        string     `json:"family_id"`
+	UserID          int64      `json:"user_id"`
+	TokenHash       string     `json:"-"`
+	ExpiresAt       time.Time  `json:"expires_at"`
+	UsedAt          *time.Time `json:"used_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	FamilyRevokedAt *time.Time `json:"-"`
+}
+
+// TokenPair — результат входа или обновления токенов
+type TokenPair struct {
+	AccessToken     string    `json:"access_token"`
+	AccessExpiresAt time.Time `json:"access_expires_at"`
+	RefreshToken    string    `json:"refresh_token"`
+}
+
+// AdminAction — запись журнала действий сотрудников банка.
+// Нулевой ActorID означает действие, выполненное из командной строки.
+type AdminAction struct {
+	ID         int64     `json:"id"`
+	ActorID    int64     `json:"actor_id"`
+	Action     string    `json:"action"`
+	TargetType string    `json:"target_type"`
+	TargetID   int64     `json:"target_id"`
+	Details    string    `json:"details"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Операции карточного процессинга
+const (
+	// CardOperationAuthorization — авторизация (0100): средства блокируются холдом
+	CardOperationAuthorization = "authorization"
+	// CardOperationFinancial — финансовая операция (0200): средства списываются сразу
+	CardOperationFinancial = "financial"
+	// CardOperationReversal — отмена авторизации или финансовой операции (0400)
+	CardOperationReversal = "reversal"
+)
+
+// CardMessageKey идентифицирует сообщение процессинга: терминал, номер сообщения (STAN)
+// и время передачи в формате MMDDhhmmss. Повтор сообщения с тем же ключом получает прежний ответ.
+type CardMessageKey struct {
+	Operation     string `json:"operation"`
+	TerminalID    string `json:"terminal_id"`
+	STAN          string `json:"stan"`
+	TransmittedAt string `json:"transmitted_at"`
+}
+
+// CardAuthorizationRequest — разобранный запрос процессинга. Original заполняется у отмены.
+type CardAuthorizationRequest struct {
+	CardMessageKey
+	CardNumber string
+	// ExpiryDate — срок действия в формате YYMM, как в поле 14; может быть пустым
+	ExpiryDate string
+	// CVV — CVV2; проверяется, если передан
+	CVV          string
+	Amount       money.Amount
+	CurrencyCode string
+	RRN          string
+	Merchant     string
+	Original     *CardMessageKey
+}
+
+// CardAuthorization — обработанное сообщение процессинга и ответ на него. Отклонённые
+// сообщения тоже сохраняются: по ним отвечают на повторы и разбирают спорные операции.
+type CardAuthorization struct {
+	ID int64 `json:"id"`
+	CardMessageKey
+	CardID       int64        `json:"card_id,omitempty"`
+	RRN          string       `json:"rrn,omitempty"`
+	Amount       money.Amount `json:"amount"`
+	CurrencyCode string       `json:"currency_code"`
+	Merchant     string       `json:"merchant,omitempty"`
+	ResponseCode string       `json:"response_code"`
+	ApprovalCode string       `json:"approval_code,omitempty"`
+	// HoldID — холд, поставленный авторизацией; EntryID — проводка финансовой операции или отмены
+	HoldID     int64     `json:"hold_id,omitempty"`
+	EntryID    int64     `json:"entry_id,omitempty"`
+	OriginalID int64     `json:"original_id,omitempty"`
+	Reversed   bool      `json:"reversed"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Области действия лимитов расходных операций
+const (
+	LimitScopeCard    = "card"
+	LimitScopeAccount = "account"
+	LimitScopeUser    = "user"
+)
+
+// Периоды лимитов: календарные сутки и календарный месяц по UTC
+const (
+	LimitPeriodDaily   = "daily"
+	LimitPeriodMonthly = "monthly"
+)
+
+// Операции, на которые устанавливаются лимиты
+const (
+	LimitOperationWithdrawal   = TransactionTypeWithdrawal
+	LimitOperationTransfer     = TransactionTypeTransfer
+	LimitOperationCardPurchase = TransactionTypeCardPurchase
+)
+
+// Источники лимитов. Лимит банка задаётся в конфигурации или сотрудником банка,
+// клиент может установить собственный лимит по карте только ниже лимита банка.
+const (
+	LimitSourceBank     = "bank"
+	LimitSourceCustomer = "customer"
+)
+
+// LimitKey определяет, к чему относится лимит и его счётчик. Лимиты на уровне пользователя
+// считаются отдельно для каждой валюты; у карты и счёта валюта совпадает с валютой счёта.
+type LimitKey struct {
+	Scope     string         `json:"scope"`
+	ScopeID   int64          `json:"scope_id"`
+	Operation string         `json:"operation"`
+	Period    string         `json:"period"`
+	Currency  money.Currency `json:"currency"`
+}
+
+// SpendingLimit — лимит суммы и (или) количества операций за период.
+// Пустое ограничение не действует; нулевое запрещает операции.
+type SpendingLimit struct {
+	ID int64 `json:"id"`
+	LimitKey
+	MaxAmount *money.Amount `json:"max_amount,omitempty"`
+	MaxCount  *int          `json:"max_count,omitempty"`
+	Source    string        `json:"source"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+func (l *SpendingLimit) Validate() error {
+	switch l.Scope {
+	case LimitScopeCard, LimitScopeAccount, LimitScopeUser:
+	default:
+		return errors.New("invalid limit scope")
+	}
+	switch l.Operation {
+	case LimitOperationWithdrawal, LimitOperationTransfer, LimitOperationCardPurchase:
+	default:
+		return errors.New("invalid limit operation")
+	}
+	if l.Period != LimitPeriodDaily && l.Period != LimitPeriodMonthly {
+		return errors.New("limit period must be daily or monthly")
+	}
+	if !l.Currency.IsValid() {
+		return money.ErrUnknownCurrency
+	}
+	if l.MaxAmount == nil && l.MaxCount == nil {
+		return errors.New("max_amount or max_count is required")
+	}
+	if l.MaxAmount != nil && l.MaxAmount.IsNegative() {
+		return errors.New("max_amount must not be negative")
+	}
+	if l.MaxCount != nil && *l.MaxCount < 0 {
+		return errors.New("max_count must not be negative")
+	}
+	if l.Source != LimitSourceBank && l.Source != LimitSourceCustomer {
+		return errors.New("invalid limit source")
+	}
+	if l.Source == LimitSourceCustomer && l.Scope != LimitScopeCard {
+		return errors.New("customers can set only card limits")
+	}
+	return nil
+}
+
+// LimitPeriodStart возвращает начало периода лимита, в который попадает момент at
+func LimitPeriodStart(period string, at time.Time) time.Time {
+	at = at.UTC()
+	if period == LimitPeriodMonthly {
+		return time.Date(at.Year(), at.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// LimitUsage — расходная операция, которая учитывается в лимитах карты (если CardID задан),
+// счёта и его владельца
+type LimitUsage struct {
+	Operation string
+	CardID    int64
+	AccountID int64
+	UserID    int64
+	Currency  money.Currency
+	Amount    money.Amount
+	At        time.Time
+}
+
+// EffectiveLimit — действующий лимит с учётом лимитов банка и клиента и израсходованная
+// в текущем периоде часть
+type EffectiveLimit struct {
+	LimitKey
+	MaxAmount *money.Amount `json:"max_amount,omitempty"`
+	MaxCount  *int          `json:"max_count,omitempty"`
+	// CustomerSet сообщает, что действует лимит, установленный клиентом
+	CustomerSet bool         `json:"customer_set"`
+	UsedAmount  money.Amount `json:"used_amount"`
+	UsedCount   int          `json:"used_count"`
+	PeriodStart time.Time    `json:"period_start"`
+}
@@ -0,0 +1,108 @@
+package money
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Currency — трёхбуквенный код валюты ISO 4217
+type Currency string
+
+const (
+	RUB Currency = "RUB"
+	USD Currency = "USD"
+	EUR Currency = "EUR"
+	CNY Currency = "CNY"
+	GBP Currency = "GBP"
+	CHF Currency = "CHF"
+	KZT Currency = "KZT"
+	BYN Currency = "BYN"
+)
+
+// currencies содержит поддерживаемые валюты и их цифровые коды ISO 4217
+var currencies = map[Currency]string{
+	RUB: "643",
+	USD: "840",
+	EUR: "978",
+	CNY: "156",
+	GBP: "826",
+	CHF: "756",
+	KZT: "398",
+	BYN: "933",
+}
+
+// ParseCurrency проверяет код валюты и приводит его к верхнему регистру
+func ParseCurrency(code string) (Currency, error) {
+	c := Currency(strings.ToUpper(strings.TrimSpace(code)))
+	if _, ok := currencies[c]; !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownCurrency, code)
+	}
+	return c, nil
+}
+
+// CurrencyByNumericCode находит валюту по цифровому коду ISO 4217
+func CurrencyByNumericCode(code string) (Currency, error) {
+	for c, numeric := range currencies {
+		if numeric == code {
+			return c, nil
+		}
+	}
+	return "", fmt.Errorf("%w: numeric code %q", ErrUnknownCurrency, code)
+}
+
+// IsValid сообщает, поддерживается ли валюта
+func (c Currency) IsValid() bool {
+	_, ok := currencies[c]
+	return ok
+}
+
+// NumericCode возвращает цифровой код валюты ISO 4217
+func (c Currency) NumericCode() string {
+	return currencies[c]
+}
+
+func (c Currency) String() string {
+	return string(c)
+}
+
+// Money — сумма в конкретной валюте. Арифметика над суммами в разных валютах возвращает ошибку.
+type Money struct {
+	Amount   Amount   `json:"amount"`
+	Currency Currency `json:"currency"`
+}
+
+func New(amount Amount, currency Currency) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+func (m Money) checkCurrency(other Money) error {
+	if m.Currency != other.Currency {
+		return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	return nil
+}
+
+func (m Money) Add(other Money) (Money, error) {
+	if err := m.checkCurrency(other); err != nil {
+		return Money{}, err
+	}
+	return New(m.Amount.Add(other.Amount), m.Currency), nil
+}
+
+func (m Money) Sub(other Money) (Money, error) {
+	if err := m.checkCurrency(other); err != nil {
+		return Money{}, err
+	}
+	return New(m.Amount.Sub(other.Amount), m.Currency), nil
+}
+
+func (m Money) Cmp(other Money) (int, error) {
+	if err := m.checkCurrency(other); err != nil {
+		return 0, err
+	}
+	return m.Amount.Cmp(other.Amount), nil
+}
+
+func (m Money) String() string {
+	return m.Amount.String() + " " + string(m.Currency)
+}
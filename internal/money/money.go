@@ -0,0 +1,291 @@
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Scale — количество знаков после запятой, с которым хранятся суммы (NUMERIC(15, 2))
+const Scale = 2
+
+const scaleFactor = 100
+
+var (
+	ErrInvalidAmount    = errors.New("invalid amount")
+	ErrTooManyDecimals  = errors.New("amount must have at most 2 decimal places")
+	ErrUnknownCurrency  = errors.New("unknown currency")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	ErrOverflow         = errors.New("amount overflows int64 minor units")
+)
+
+// Amount — денежная сумма в минимальных единицах (копейках, центах).
+// Хранится как целое число, поэтому сложение и вычитание выполняются точно.
+// Результат, не помещающийся в int64, не заворачивается через знак: арифметика
+// паникует с ErrOverflow.
+type Amount int64
+
+// FromMinor создаёт сумму из количества минимальных единиц
+func FromMinor(minor int64) Amount {
+	return Amount(minor)
+}
+
+// FromMajor создаёт сумму из целого количества основных единиц (рублей)
+func FromMajor(major int64) Amount {
+	if major > math.MaxInt64/scaleFactor || major < math.MinInt64/scaleFactor {
+		panic(ErrOverflow)
+	}
+	return Amount(major * scaleFactor)
+}
+
+// Parse разбирает десятичную строку вида "-123.45" без потери точности
+func Parse(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, ErrInvalidAmount
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	intPart, fracPart, hasDot := strings.Cut(s, ".")
+	if intPart == "" || (hasDot && fracPart == "") {
+		return 0, ErrInvalidAmount
+	}
+	if len(fracPart) > Scale {
+		// Незначащие нули допустимы: "10.500" == "10.50"
+		if strings.Trim(fracPart[Scale:], "0") != "" {
+			return 0, ErrTooManyDecimals
+		}
+		fracPart = fracPart[:Scale]
+	}
+	if !isDigits(intPart) || !isDigits(fracPart) {
+		return 0, ErrInvalidAmount
+	}
+	for len(fracPart) < Scale {
+		fracPart += "0"
+	}
+
+	minor, err := strconv.ParseInt(intPart+fracPart, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidAmount
+	}
+	if negative {
+		minor = -minor
+	}
+	return Amount(minor), nil
+}
+
+// MustParse аналогичен Parse, но паникует при ошибке. Используется для констант.
+func MustParse(s string) Amount {
+	a, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Minor возвращает сумму в минимальных единицах
+func (a Amount) Minor() int64 {
+	return int64(a)
+}
+
+func (a Amount) Add(b Amount) Amount {
+	sum := a + b
+	// Слагаемые одного знака не могут дать сумму другого знака
+	if (a > 0 && b > 0 && sum < 0) || (a < 0 && b < 0 && sum >= 0) {
+		panic(ErrOverflow)
+	}
+	return sum
+}
+
+func (a Amount) Sub(b Amount) Amount {
+	diff := a - b
+	if (b > 0 && diff > a) || (b < 0 && diff < a) {
+		panic(ErrOverflow)
+	}
+	return diff
+}
+
+func (a Amount) Neg() Amount {
+	if a == math.MinInt64 {
+		panic(ErrOverflow)
+	}
+	return -a
+}
+
+func (a Amount) Abs() Amount {
+	if a < 0 {
+		return a.Neg()
+	}
+	return a
+}
+
+// Cmp возвращает -1, 0 или 1 в зависимости от того, меньше, равна или больше сумма a суммы b
+func (a Amount) Cmp(b Amount) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func (a Amount) IsZero() bool {
+	return a == 0
+}
+
+func (a Amount) IsPositive() bool {
+	return a > 0
+}
+
+func (a Amount) IsNegative() bool {
+	return a < 0
+}
+
+// Rat возвращает сумму в основных единицах как точную дробь
+func (a Amount) Rat() *big.Rat {
+	return big.NewRat(int64(a), scaleFactor)
+}
+
+// MulRat умножает сумму на точную дробь и округляет результат до копеек
+func (a Amount) MulRat(r *big.Rat, mode RoundingMode) Amount {
+	return FromRat(new(big.Rat).Mul(a.Rat(), r), mode)
+}
+
+// Split делит сумму на n частей с точностью до копейки. Остаток от деления
+// распределяется по одной копейке на первые части, так что сумма частей всегда равна исходной.
+func (a Amount) Split(n int) []Amount {
+	if n <= 0 {
+		return nil
+	}
+	parts := make([]Amount, n)
+	quotient := int64(a) / int64(n)
+	remainder := int64(a) % int64(n)
+	for i := range parts {
+		parts[i] = Amount(quotient)
+		if remainder > 0 {
+			parts[i]++
+			remainder--
+		} else if remainder < 0 {
+			parts[i]--
+			remainder++
+		}
+	}
+	return parts
+}
+
+// String форматирует сумму в виде десятичной строки с двумя знаками после запятой
+func (a Amount) String() string {
+	minor := int64(a)
+	sign := ""
+	if minor < 0 {
+		sign = "-"
+	}
+	abs := uint64(minor)
+	if minor < 0 {
+		abs = uint64(-minor)
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, abs/scaleFactor, abs%scaleFactor)
+}
+
+// MarshalJSON сериализует сумму как JSON-число с двумя знаками после запятой
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON принимает как JSON-число, так и строку. Значение разбирается
+// из текстового представления, без промежуточного преобразования во float64.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	// Экспоненциальная запись (1e3) допустима для JSON-чисел
+	if strings.ContainsAny(s, "eE") {
+		r, ok := new(big.Rat).SetString(s)
+		if !ok {
+			return ErrInvalidAmount
+		}
+		scaled := new(big.Rat).Mul(r, big.NewRat(scaleFactor, 1))
+		if !scaled.IsInt() {
+			return ErrTooManyDecimals
+		}
+		if !scaled.Num().IsInt64() {
+			return ErrInvalidAmount
+		}
+		*a = Amount(scaled.Num().Int64())
+		return nil
+	}
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// Scan реализует sql.Scanner для колонок NUMERIC
+func (a *Amount) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*a = 0
+		return nil
+	case []byte:
+		return a.scanString(string(v))
+	case string:
+		return a.scanString(v)
+	case int64:
+		*a = FromMajor(v)
+		return nil
+	case float64:
+		return a.scanString(strconv.FormatFloat(v, 'f', Scale, 64))
+	}
+	return fmt.Errorf("money: cannot scan %T into Amount", src)
+}
+
+func (a *Amount) scanString(s string) error {
+	parsed, err := Parse(s)
+	if err == ErrTooManyDecimals {
+		// Значения из базы с большей точностью (например, результат агрегатов)
+		// округляются банковским способом
+		r, ok := new(big.Rat).SetString(s)
+		if !ok {
+			return ErrInvalidAmount
+		}
+		parsed, err = FromRat(r, HalfEven), nil
+	}
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// Value реализует driver.Valuer: сумма передаётся в базу строкой, чтобы NUMERIC получил точное значение
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
@@ -0,0 +1,219 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestParseAndString(t *testing.T) {
+	tests := []struct {
+		input string
+		minor int64
+		want  string
+	}{
+		{"0", 0, "0.00"},
+		{"10", 1000, "10.00"},
+		{"10.5", 1050, "10.50"},
+		{"10.05", 1005, "10.05"},
+		{"10.500", 1050, "10.50"},
+		{"+1.23", 123, "1.23"},
+		{" 7.00 ", 700, "7.00"},
+		{"-0.01", -1, "-0.01"},
+		{"-0.99", -99, "-0.99"},
+		{"-123.45", -12345, "-123.45"},
+		{"-0.00", 0, "0.00"},
+		{"92233720368547758.07", math.MaxInt64, "92233720368547758.07"},
+		{"-92233720368547758.07", -math.MaxInt64, "-92233720368547758.07"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.input, err)
+			}
+			if got.Minor() != tt.minor {
+				t.Errorf("Parse(%q) = %d minor units, want %d", tt.input, got.Minor(), tt.minor)
+			}
+			if got.String() != tt.want {
+				t.Errorf("Parse(%q).String() = %q, want %q", tt.input, got.String(), tt.want)
+			}
+			again, err := Parse(got.String())
+			if err != nil || again != got {
+				t.Errorf("Parse(%q) = %v, %v; want %v", got.String(), again, err, got)
+			}
+		})
+	}
+}
+
+func TestParseRejectsInvalidAmounts(t *testing.T) {
+	tests := []struct {
+		input string
+		want  error
+	}{
+		{"", ErrInvalidAmount},
+		{"-", ErrInvalidAmount},
+		{".50", ErrInvalidAmount},
+		{"10.", ErrInvalidAmount},
+		{"1,50", ErrInvalidAmount},
+		{"--1", ErrInvalidAmount},
+		{"1e3", ErrInvalidAmount},
+		{"10.001", ErrTooManyDecimals},
+		{"92233720368547758.08", ErrInvalidAmount},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if _, err := Parse(tt.input); !errors.Is(err, tt.want) {
+				t.Errorf("Parse(%q) error = %v, want %v", tt.input, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestStringOfMinInt64(t *testing.T) {
+	if got, want := Amount(math.MinInt64).String(), "-92233720368547758.08"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestFromRatRounding(t *testing.T) {
+	tests := []struct {
+		value string
+		mode  RoundingMode
+		want  string
+	}{
+		// Ровно половина копейки
+		{"0.125", HalfUp, "0.13"},
+		{"0.125", HalfEven, "0.12"},
+		{"0.135", HalfEven, "0.14"},
+		{"0.125", Down, "0.12"},
+		{"0.125", Up, "0.13"},
+		{"-0.125", HalfUp, "-0.13"},
+		{"-0.125", HalfEven, "-0.12"},
+		{"-0.135", HalfEven, "-0.14"},
+		{"-0.125", Down, "-0.12"},
+		{"-0.125", Up, "-0.13"},
+		// Чуть меньше и чуть больше половины
+		{"0.12499", HalfUp, "0.12"},
+		{"0.12499", HalfEven, "0.12"},
+		{"0.12501", HalfEven, "0.13"},
+		{"0.12001", Down, "0.12"},
+		{"0.12001", Up, "0.13"},
+		// Без дробной копейки округление ничего не меняет
+		{"0.12", Up, "0.12"},
+		{"-0.12", Down, "-0.12"},
+	}
+	for _, tt := range tests {
+		r, ok := new(big.Rat).SetString(tt.value)
+		if !ok {
+			t.Fatalf("bad rational %q", tt.value)
+		}
+		if got := FromRat(r, tt.mode); got != MustParse(tt.want) {
+			t.Errorf("FromRat(%s, %d) = %s, want %s", tt.value, tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestMulRat(t *testing.T) {
+	tests := []struct {
+		amount string
+		rat    *big.Rat
+		mode   RoundingMode
+		want   string
+	}{
+		{"100000.00", big.NewRat(1, 100), HalfUp, "1000.00"},
+		{"100.00", big.NewRat(1, 3), HalfUp, "33.33"},
+		{"100.00", big.NewRat(2, 3), HalfUp, "66.67"},
+		{"100.00", big.NewRat(2, 3), Down, "66.66"},
+		{"0.05", big.NewRat(1, 2), HalfEven, "0.02"},
+		{"0.07", big.NewRat(1, 2), HalfEven, "0.04"},
+		{"-100.00", big.NewRat(1, 3), Up, "-33.34"},
+	}
+	for _, tt := range tests {
+		if got := MustParse(tt.amount).MulRat(tt.rat, tt.mode); got != MustParse(tt.want) {
+			t.Errorf("%s * %s = %s, want %s", tt.amount, tt.rat, got, tt.want)
+		}
+	}
+}
+
+func TestSplitKeepsTotal(t *testing.T) {
+	tests := []struct {
+		amount string
+		n      int
+		want   []string
+	}{
+		{"100.00", 3, []string{"33.34", "33.33", "33.33"}},
+		{"-100.00", 3, []string{"-33.34", "-33.33", "-33.33"}},
+		{"0.01", 2, []string{"0.01", "0.00"}},
+	}
+	for _, tt := range tests {
+		parts := MustParse(tt.amount).Split(tt.n)
+		var total Amount
+		for i, part := range parts {
+			total = total.Add(part)
+			if part != MustParse(tt.want[i]) {
+				t.Errorf("Split(%s, %d) = %v, want %v", tt.amount, tt.n, parts, tt.want)
+				break
+			}
+		}
+		if total != MustParse(tt.amount) {
+			t.Errorf("parts of %s add up to %s", tt.amount, total)
+		}
+	}
+}
+
+// expectOverflow проверяет, что операция паникует с ErrOverflow
+func expectOverflow(t *testing.T, name string, op func()) {
+	t.Helper()
+	defer func() {
+		t.Helper()
+		recovered := recover()
+		err, _ := recovered.(error)
+		if !errors.Is(err, ErrOverflow) {
+			t.Errorf("%s: recovered %v, want %v", name, recovered, ErrOverflow)
+		}
+	}()
+	op()
+}
+
+func TestArithmeticOverflow(t *testing.T) {
+	const (
+		maxAmount = Amount(math.MaxInt64)
+		minAmount = Amount(math.MinInt64)
+	)
+	expectOverflow(t, "maxAmount + 1", func() { maxAmount.Add(1) })
+	expectOverflow(t, "minAmount + -1", func() { minAmount.Add(-1) })
+	expectOverflow(t, "minAmount + minAmount", func() { minAmount.Add(minAmount) })
+	expectOverflow(t, "minAmount - 1", func() { minAmount.Sub(1) })
+	expectOverflow(t, "maxAmount - -1", func() { maxAmount.Sub(-1) })
+	expectOverflow(t, "0 - minAmount", func() { Amount(0).Sub(minAmount) })
+	expectOverflow(t, "-minAmount", func() { minAmount.Neg() })
+	expectOverflow(t, "|minAmount|", func() { minAmount.Abs() })
+	expectOverflow(t, "FromMajor", func() { FromMajor(math.MaxInt64/scaleFactor + 1) })
+	expectOverflow(t, "maxAmount * 2", func() { maxAmount.MulRat(big.NewRat(2, 1), HalfUp) })
+	expectOverflow(t, "maxAmount * 1.000...1 rounded up", func() { maxAmount.MulRat(big.NewRat(math.MaxInt64, math.MaxInt64-1), Up) })
+
+	// У самой границы результат точный
+	if got := (maxAmount - 1).Add(1); got != maxAmount {
+		t.Errorf("(maxAmount-1) + 1 = %d, want %d", got, maxAmount)
+	}
+	if got := (minAmount + 1).Sub(1); got != minAmount {
+		t.Errorf("(minAmount+1) - 1 = %d, want %d", got, minAmount)
+	}
+	if got := maxAmount.Add(minAmount); got != -1 {
+		t.Errorf("maxAmount + minAmount = %d, want -1", got)
+	}
+	if got := minAmount.Sub(-1); got != minAmount+1 {
+		t.Errorf("minAmount - -1 = %d, want %d", got, minAmount+1)
+	}
+	if got := maxAmount.Neg(); got != -maxAmount {
+		t.Errorf("-maxAmount = %d, want %d", got, -maxAmount)
+	}
+	if got := maxAmount.MulRat(big.NewRat(1, 1), HalfUp); got != maxAmount {
+		t.Errorf("maxAmount * 1 = %d, want %d", got, maxAmount)
+	}
+	if got := FromMajor(math.MaxInt64 / scaleFactor); got.Minor() != math.MaxInt64/scaleFactor*scaleFactor {
+		t.Errorf("FromMajor at the limit = %d", got)
+	}
+}
@@ -0,0 +1,57 @@
+package money
+
+import "math/big"
+
+// RoundingMode определяет способ округления дробных копеек
+type RoundingMode int
+
+const (
+	// HalfUp — округление половины от нуля (коммерческое округление)
+	HalfUp RoundingMode = iota
+	// HalfEven — банковское округление половины к чётному
+	HalfEven
+	// Down — отбрасывание дробной части (к нулю)
+	Down
+	// Up — округление от нуля
+	Up
+)
+
+// FromRat округляет сумму в основных единицах, заданную точной дробью, до копеек.
+// Паникует с ErrOverflow, если результат не помещается в Amount.
+func FromRat(r *big.Rat, mode RoundingMode) Amount {
+	scaled := new(big.Rat).Mul(r, big.NewRat(scaleFactor, 1))
+	num := new(big.Int).Set(scaled.Num())
+	den := scaled.Denom()
+
+	negative := num.Sign() < 0
+	num.Abs(num)
+
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Sign() != 0 {
+		// Сравниваем удвоенный остаток со знаменателем, чтобы определить положение относительно половины
+		cmpHalf := new(big.Int).Lsh(rem, 1).Cmp(den)
+		roundUp := false
+		switch mode {
+		case HalfUp:
+			roundUp = cmpHalf >= 0
+		case HalfEven:
+			roundUp = cmpHalf > 0 || (cmpHalf == 0 && quo.Bit(0) == 1)
+		case Down:
+			roundUp = false
+		case Up:
+			roundUp = true
+		}
+		if roundUp {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+
+	if !quo.IsInt64() {
+		panic(ErrOverflow)
+	}
+	minor := quo.Int64()
+	if negative {
+		minor = -minor
+	}
+	return Amount(minor)
+}
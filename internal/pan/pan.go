@@ -0,0 +1,137 @@
+// Package pan генерирует и проверяет номера банковских карт (PAN): контрольная цифра
+// по алгоритму Луна и диапазоны BIN — первых цифр номера, закреплённых за продуктом эмитента.
+package pan
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Length — длина номера карты, выпускаемой банком
+const Length = 16
+
+// maxBINLength — BIN не может занимать весь номер: нужны хотя бы одна цифра
+// номера счёта и контрольная цифра
+const maxBINLength = Length - 2
+
+var ErrInvalidRange = errors.New("invalid BIN range")
+
+// Range — диапазон BIN одинаковой длины, границы включаются
+type Range struct {
+	Low    uint64
+	High   uint64
+	Digits int
+}
+
+// ParseRange разбирает BIN ("220070") или диапазон BIN ("22007000-22007099")
+func ParseRange(s string) (Range, error) {
+	low, high, found := strings.Cut(strings.TrimSpace(s), "-")
+	if !found {
+		high = low
+	}
+	low, high = strings.TrimSpace(low), strings.TrimSpace(high)
+	if len(low) != len(high) {
+		return Range{}, fmt.Errorf("%w %q: bounds must have the same length", ErrInvalidRange, s)
+	}
+	if len(low) < 6 || len(low) > maxBINLength || !isDigits(low) || !isDigits(high) {
+		return Range{}, fmt.Errorf("%w %q: BIN must be 6 to %d digits", ErrInvalidRange, s, maxBINLength)
+	}
+	r := Range{Digits: len(low)}
+	r.Low, _ = strconv.ParseUint(low, 10, 64)
+	r.High, _ = strconv.ParseUint(high, 10, 64)
+	if r.Low > r.High {
+		return Range{}, fmt.Errorf("%w %q: lower bound is greater than upper bound", ErrInvalidRange, s)
+	}
+	return r, nil
+}
+
+// Generate выпускает случайный номер с контрольной цифрой Луна. BIN выбирается
+// равновероятно среди всех BIN диапазонов, остальные цифры случайны.
+func Generate(ranges []Range) (string, error) {
+	if len(ranges) == 0 {
+		return "", errors.New("no BIN ranges configured")
+	}
+	total := new(big.Int)
+	for _, r := range ranges {
+		total.Add(total, new(big.Int).SetUint64(r.High-r.Low+1))
+	}
+	n, err := rand.Int(rand.Reader, total)
+	if err != nil {
+		return "", err
+	}
+
+	// Находим диапазон, в который попал случайный номер BIN
+	var bin string
+	for _, r := range ranges {
+		size := new(big.Int).SetUint64(r.High - r.Low + 1)
+		if n.Cmp(size) < 0 {
+			bin = fmt.Sprintf("%0*d", r.Digits, r.Low+n.Uint64())
+			break
+		}
+		n.Sub(n, size)
+	}
+
+	var b strings.Builder
+	b.WriteString(bin)
+	digits := make([]byte, Length-1-len(bin))
+	if _, err := rand.Read(digits); err != nil {
+		return "", err
+	}
+	for _, d := range digits {
+		// Смещение от деления по модулю на 256 % 10 пренебрежимо для номера карты
+		b.WriteByte('0' + d%10)
+	}
+	payload := b.String()
+	return payload + string(CheckDigit(payload)), nil
+}
+
+// CheckDigit вычисляет контрольную цифру Луна для номера без неё
+func CheckDigit(payload string) byte {
+	// Контрольная цифра дописывается справа, поэтому удваиваются цифры,
+	// стоящие на чётных позициях от конца полного номера
+	sum := luhnSum(payload, true)
+	return byte('0' + (10-sum%10)%10)
+}
+
+// Valid проверяет, что номер состоит из цифр и его контрольная цифра верна
+func Valid(number string) bool {
+	if len(number) < 2 || !isDigits(number) {
+		return false
+	}
+	return luhnSum(number, false)%10 == 0
+}
+
+// luhnSum складывает цифры справа налево, удваивая каждую вторую;
+// doubleFirst указывает, удваивается ли самая правая цифра
+func luhnSum(number string, doubleFirst bool) int {
+	sum := 0
+	double := doubleFirst
+	for i := len(number) - 1; i >= 0; i-- {
+		d := int(number[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
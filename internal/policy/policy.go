@@ -0,0 +1,130 @@
+// Package policy проверяет, что пользователь владеет ресурсом, к которому обращается.
+// Сервисы не сравнивают UserID сами, а запрашивают ресурс через Policy. Отсутствующий
+// и чужой ресурс одинаково дают ErrNotFound (404), чтобы по ответу нельзя было узнать,
+// существует ли чужой счёт, карта или кредит. Ответ 403 означает только нехватку роли
+// (см. middleware.RequireRole).
+package policy
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/repositories"
+)
+
+var ErrNotFound = errors.New("resource not found")
+
+// Ресурсы, на которые распространяется политика
+const (
+	ResourceAccount = "account"
+	ResourceCard    = "card"
+	ResourceCredit  = "credit"
+	ResourceHold    = "hold"
+)
+
+// Error — отказ политики по конкретному ресурсу. Сравнивается с ErrNotFound через errors.Is.
+type Error struct {
+	Resource string
+}
+
+func (e *Error) Error() string {
+	return e.Resource + " not found"
+}
+
+func (e *Error) Unwrap() error {
+	return ErrNotFound
+}
+
+func notFound(resource string) error {
+	return &Error{Resource: resource}
+}
+
+// Policy возвращает ресурс, только если он принадлежит пользователю
+type Policy interface {
+	Account(ctx context.Context, userID, accountID int64) (*models.Account, error)
+	Card(ctx context.Context, userID, cardID int64) (*models.Card, error)
+	Credit(ctx context.Context, userID, creditID int64) (*models.Credit, error)
+	Hold(ctx context.Context, userID, holdID int64) (*models.Hold, error)
+}
+
+type ownershipPolicy struct {
+	accountRepo repositories.AccountRepository
+	cardRepo    repositories.CardRepository
+	creditRepo  repositories.CreditRepository
+	holdRepo    repositories.HoldRepository
+}
+
+func New(accountRepo repositories.AccountRepository, cardRepo repositories.CardRepository, creditRepo repositories.CreditRepository, holdRepo repositories.HoldRepository) Policy {
+	return &ownershipPolicy{
+		accountRepo: accountRepo,
+		cardRepo:    cardRepo,
+		creditRepo:  creditRepo,
+		holdRepo:    holdRepo,
+	}
+}
+
+func (p *ownershipPolicy) Account(ctx context.Context, userID, accountID int64) (*models.Account, error) {
+	account, err := p.accountRepo.FindByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil || account.UserID != userID {
+		return nil, notFound(ResourceAccount)
+	}
+	return account, nil
+}
+
+// Card проверяет владельца счёта, к которому выпущена карта
+func (p *ownershipPolicy) Card(ctx context.Context, userID, cardID int64) (*models.Card, error) {
+	card, err := p.cardRepo.FindByID(ctx, cardID)
+	if err != nil {
+		return nil, err
+	}
+	if card == nil {
+		return nil, notFound(ResourceCard)
+	}
+	if _, err := p.owner(ctx, userID, card.AccountID, ResourceCard); err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+func (p *ownershipPolicy) Credit(ctx context.Context, userID, creditID int64) (*models.Credit, error) {
+	credit, err := p.creditRepo.FindByID(ctx, creditID)
+	if err != nil {
+		return nil, err
+	}
+	if credit == nil || credit.UserID != userID {
+		return nil, notFound(ResourceCredit)
+	}
+	return credit, nil
+}
+
+// Hold проверяет владельца счёта, на котором стоит холд
+func (p *ownershipPolicy) Hold(ctx context.Context, userID, holdID int64) (*models.Hold, error) {
+	hold, err := p.holdRepo.FindByID(ctx, holdID)
+	if err != nil {
+		return nil, err
+	}
+	if hold == nil {
+		return nil, notFound(ResourceHold)
+	}
+	if _, err := p.owner(ctx, userID, hold.AccountID, ResourceHold); err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+// owner проверяет счёт, через который пользователь владеет дочерним ресурсом.
+// Отказ описывается в терминах дочернего ресурса, чтобы не раскрывать номер чужого счёта.
+func (p *ownershipPolicy) owner(ctx context.Context, userID, accountID int64, resource string) (*models.Account, error) {
+	account, err := p.accountRepo.FindByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil || account.UserID != userID {
+		return nil, notFound(resource)
+	}
+	return account, nil
+}
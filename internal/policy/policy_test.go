@@ -0,0 +1,66 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/repositories"
+)
+
+type fakeAccountRepository struct {
+	repositories.AccountRepository
+}
+
+func (r *fakeAccountRepository) FindByID(_ context.Context, id int64) (*models.Account, error) {
+	if id != 10 {
+		return nil, nil
+	}
+	return &models.Account{ID: 10, UserID: 1}, nil
+}
+
+type fakeCardRepository struct {
+	repositories.CardRepository
+}
+
+func (r *fakeCardRepository) FindByID(_ context.Context, id int64) (*models.Card, error) {
+	if id != 11 {
+		return nil, nil
+	}
+	return &models.Card{ID: 11, AccountID: 10}, nil
+}
+
+func TestPolicyHidesOtherUsersResources(t *testing.T) {
+	p := New(&fakeAccountRepository{}, &fakeCardRepository{}, nil, nil)
+	ctx := context.Background()
+
+	if account, err := p.Account(ctx, 1, 10); err != nil || account.ID != 10 {
+		t.Errorf("owner: Account() = %v, %v; want account 10", account, err)
+	}
+	if card, err := p.Card(ctx, 1, 11); err != nil || card.ID != 11 {
+		t.Errorf("owner: Card() = %v, %v; want card 11", card, err)
+	}
+
+	tests := []struct {
+		name string
+		call func() error
+		want string
+	}{
+		{"foreign account", func() error { _, err := p.Account(ctx, 2, 10); return err }, "account not found"},
+		{"missing account", func() error { _, err := p.Account(ctx, 1, 99); return err }, "account not found"},
+		{"card on foreign account", func() error { _, err := p.Card(ctx, 2, 11); return err }, "card not found"},
+		{"missing card", func() error { _, err := p.Card(ctx, 1, 99); return err }, "card not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if !errors.Is(err, ErrNotFound) {
+				t.Fatalf("err = %v, want ErrNotFound", err)
+			}
+			if err.Error() != tt.want {
+				t.Errorf("err = %q, want %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
@@ -3,10 +3,24 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"sort"
 
 	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/money"
 )
 
+// activeHoldsSum — подзапрос суммы действующих холдов по счёту a
+const activeHoldsSum = `COALESCE((
+			SELECT SUM(h.amount) FROM bank.holds h
+			WHERE h.account_id = a.id AND h.status = 'active' AND h.expires_at > CURRENT_TIMESTAMP
+		), 0)`
+
+// activeHoldsSumByID — то же для счёта, заданного параметром $1
+const activeHoldsSumByID = `COALESCE((
+			SELECT SUM(h.amount) FROM bank.holds h
+			WHERE h.account_id = $1 AND h.status = 'active' AND h.expires_at > CURRENT_TIMESTAMP
+		), 0)`
+
 type accountRepository struct {
 	db *sql.DB
 }
@@ -17,13 +31,14 @@ func NewAccountRepository(db *sql.DB) AccountRepository {
 
 func (r *accountRepository) Create(ctx context.Context, account *models.Account) error {
 	query := `
-		INSERT INTO bank.accounts (user_id, balance, currency, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO bank.accounts (user_id, balance, currency, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id`
 	err := r.db.QueryRowContext(ctx, query,
 		account.UserID,
 		account.Balance,
 		account.Currency,
+		account.Status,
 		account.CreatedAt,
 		account.UpdatedAt,
 	).Scan(&account.ID)
@@ -36,14 +51,16 @@ func (r *accountRepository) Create(ctx context.Context, account *models.Account)
 func (r *accountRepository) FindByID(ctx context.Context, id int64) (*models.Account, error) {
 	account := &models.Account{}
 	query := `
-		SELECT id, user_id, balance, currency, created_at, updated_at
-		FROM bank.accounts
-		WHERE id = $1`
+		SELECT a.id, a.user_id, a.balance, a.balance - ` + activeHoldsSum + `, a.currency, a.status, a.created_at, a.updated_at
+		FROM bank.accounts a
+		WHERE a.id = $1`
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&account.ID,
 		&account.UserID,
 		&account.Balance,
+		&account.AvailableBalance,
 		&account.Currency,
+		&account.Status,
 		&account.CreatedAt,
 		&account.UpdatedAt,
 	)
@@ -56,11 +73,70 @@ func (r *accountRepository) FindByID(ctx context.Context, id int64) (*models.Acc
 	return account, nil
 }
 
-func (r *accountRepository) FindByUserID(ctx context.Context, userID int64) ([]*models.Account, error) {
+// FindByIDForUpdate читает счёт внутри транзакции и блокирует строку до её завершения
+func (r *accountRepository) FindByIDForUpdate(ctx context.Context, tx *sql.Tx, id int64) (*models.Account, error) {
+	account := &models.Account{}
 	query := `
-		SELECT id, user_id, balance, currency, created_at, updated_at
+		SELECT id, user_id, balance, currency, status, created_at, updated_at
 		FROM bank.accounts
-		WHERE user_id = $1`
+		WHERE id = $1
+		FOR UPDATE`
+	err := tx.QueryRowContext(ctx, query, id).Scan(
+		&account.ID,
+		&account.UserID,
+		&account.Balance,
+		&account.Currency,
+		&account.Status,
+		&account.CreatedAt,
+		&account.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Сумма холдов читается отдельным запросом уже после получения блокировки,
+	// чтобы учесть холды, зафиксированные конкурирующими транзакциями
+	var held money.Amount
+	err = tx.QueryRowContext(ctx, `SELECT `+activeHoldsSumByID, id).Scan(&held)
+	if err != nil {
+		return nil, err
+	}
+	account.AvailableBalance = account.Balance.Sub(held)
+	return account, nil
+}
+
+// LockByIDs блокирует несколько счетов в порядке возрастания id, чтобы параллельные
+// транзакции, затрагивающие одни и те же счета, не попадали во взаимную блокировку.
+// Отсутствующие счета не попадают в результат.
+func (r *accountRepository) LockByIDs(ctx context.Context, tx *sql.Tx, ids ...int64) (map[int64]*models.Account, error) {
+	sorted := append([]int64(nil), ids...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	accounts := make(map[int64]*models.Account, len(sorted))
+	for _, id := range sorted {
+		if _, ok := accounts[id]; ok {
+			continue
+		}
+		account, err := r.FindByIDForUpdate(ctx, tx, id)
+		if err != nil {
+			return nil, err
+		}
+		if account != nil {
+			accounts[id] = account
+		}
+	}
+	return accounts, nil
+}
+
+func (r *accountRepository) FindByUserID(ctx context.Context, userID int64) ([]*models.Account, error) {
+	query := `
+		SELECT a.id, a.user_id, a.balance, a.balance - ` + activeHoldsSum + `, a.currency, a.status, a.created_at, a.updated_at
+		FROM bank.accounts a
+		WHERE a.user_id = $1
+		ORDER BY a.id`
 	rows, err := r.db.QueryContext(ctx, query, userID)
 	if err != nil {
 		return nil, err
@@ -74,7 +150,9 @@ func (r *accountRepository) FindByUserID(ctx context.Context, userID int64) ([]*
 			&account.ID,
 			&account.UserID,
 			&account.Balance,
+			&account.AvailableBalance,
 			&account.Currency,
+			&account.Status,
 			&account.CreatedAt,
 			&account.UpdatedAt,
 		); err != nil {
@@ -82,24 +160,31 @@ func (r *accountRepository) FindByUserID(ctx context.Context, userID int64) ([]*
 		}
 		accounts = append(accounts, account)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 	return accounts, nil
 }
 
-func (r *accountRepository) UpdateBalance(ctx context.Context, accountID int64, balance float64) error {
+func (r *accountRepository) AddToBalance(ctx context.Context, tx *sql.Tx, accountID int64, delta money.Amount) (money.Amount, error) {
+	var balance money.Amount
 	query := `
 		UPDATE bank.accounts
-		SET balance = $1, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $2`
-	result, err := r.db.ExecContext(ctx, query, balance, accountID)
-	if err != nil {
-		return err
-	}
-	rowsAffected, err := result.RowsAffected()
+		SET balance = balance + $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+		RETURNING balance`
+	err := tx.QueryRowContext(ctx, query, delta, accountID).Scan(&balance)
 	if err != nil {
-		return err
+		return 0, err
 	}
-	if rowsAffected == 0 {
-		return sql.ErrNoRows
-	}
-	return nil
-}
\ No newline at end of file
+	return balance, nil
+}
+
+// SetStatus изменяет статус счёта внутри транзакции, в которой счёт уже заблокирован
+func (r *accountRepository) SetStatus(ctx context.Context, tx *sql.Tx, id int64, status string) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE bank.accounts
+		SET status = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1`, id, status)
+	return err
+}
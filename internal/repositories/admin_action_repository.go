@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/bank-service/internal/models"
+)
+
+type adminActionRepository struct {
+	db *sql.DB
+}
+
+func NewAdminActionRepository(db *sql.DB) AdminActionRepository {
+	return &adminActionRepository{db: db}
+}
+
+// Create записывает действие сотрудника в той же транзакции, что и само изменение,
+// чтобы изменение без записи в журнале было невозможно
+func (r *adminActionRepository) Create(ctx context.Context, tx *sql.Tx, action *models.AdminAction) error {
+	query := `
+		INSERT INTO bank.admin_actions (actor_id, action, target_type, target_id, details, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`
+	return tx.QueryRowContext(ctx, query,
+		sql.NullInt64{Int64: action.ActorID, Valid: action.ActorID != 0},
+		action.Action,
+		action.TargetType,
+		action.TargetID,
+		sql.NullString{String: action.Details, Valid: action.Details != ""},
+		action.CreatedAt,
+	).Scan(&action.ID)
+}
@@ -0,0 +1,119 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/bank-service/internal/models"
+)
+
+type cardAuthorizationRepository struct {
+	db *sql.DB
+}
+
+func NewCardAuthorizationRepository(db *sql.DB) CardAuthorizationRepository {
+	return &cardAuthorizationRepository{db: db}
+}
+
+const cardAuthorizationColumns = `id, operation, terminal_id, stan, transmitted_at, COALESCE(card_id, 0), COALESCE(rrn, ''),
+	amount, currency_code, COALESCE(merchant, ''), response_code, COALESCE(approval_code, ''),
+	COALESCE(hold_id, 0), COALESCE(entry_id, 0), COALESCE(original_id, 0), reversed, created_at`
+
+func scanCardAuthorization(row rowScanner) (*models.CardAuthorization, error) {
+	auth := &models.CardAuthorization{}
+	err := row.Scan(
+		&auth.ID,
+		&auth.Operation,
+		&auth.TerminalID,
+		&auth.STAN,
+		&auth.TransmittedAt,
+		&auth.CardID,
+		&auth.RRN,
+		&auth.Amount,
+		&auth.CurrencyCode,
+		&auth.Merchant,
+		&auth.ResponseCode,
+		&auth.ApprovalCode,
+		&auth.HoldID,
+		&auth.EntryID,
+		&auth.OriginalID,
+		&auth.Reversed,
+		&auth.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return auth, nil
+}
+
+// Create сохраняет сообщение. Если сообщение с тем же ключом уже сохранено
+// (повтор, обработанный параллельно), возвращает false и ничего не записывает.
+func (r *cardAuthorizationRepository) Create(ctx context.Context, tx *sql.Tx, auth *models.CardAuthorization) (bool, error) {
+	query := `
+		INSERT INTO bank.card_authorizations (operation, terminal_id, stan, transmitted_at, card_id, rrn, amount, currency_code,
+			merchant, response_code, approval_code, hold_id, entry_id, original_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		ON CONFLICT (operation, terminal_id, stan, transmitted_at) DO NOTHING
+		RETURNING id`
+	err := tx.QueryRowContext(ctx, query,
+		auth.Operation,
+		auth.TerminalID,
+		auth.STAN,
+		auth.TransmittedAt,
+		sql.NullInt64{Int64: auth.CardID, Valid: auth.CardID != 0},
+		sql.NullString{String: auth.RRN, Valid: auth.RRN != ""},
+		auth.Amount,
+		auth.CurrencyCode,
+		sql.NullString{String: auth.Merchant, Valid: auth.Merchant != ""},
+		auth.ResponseCode,
+		sql.NullString{String: auth.ApprovalCode, Valid: auth.ApprovalCode != ""},
+		sql.NullInt64{Int64: auth.HoldID, Valid: auth.HoldID != 0},
+		sql.NullInt64{Int64: auth.EntryID, Valid: auth.EntryID != 0},
+		sql.NullInt64{Int64: auth.OriginalID, Valid: auth.OriginalID != 0},
+		auth.CreatedAt,
+	).Scan(&auth.ID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *cardAuthorizationRepository) FindByKey(ctx context.Context, key models.CardMessageKey) (*models.CardAuthorization, error) {
+	query := `
+		SELECT ` + cardAuthorizationColumns + `
+		FROM bank.card_authorizations
+		WHERE operation = $1 AND terminal_id = $2 AND stan = $3 AND transmitted_at = $4`
+	auth, err := scanCardAuthorization(r.db.QueryRowContext(ctx, query, key.Operation, key.TerminalID, key.STAN, key.TransmittedAt))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return auth, nil
+}
+
+// FindByKeyForUpdate блокирует исходное сообщение, чтобы его нельзя было отменить дважды
+func (r *cardAuthorizationRepository) FindByKeyForUpdate(ctx context.Context, tx *sql.Tx, key models.CardMessageKey) (*models.CardAuthorization, error) {
+	query := `
+		SELECT ` + cardAuthorizationColumns + `
+		FROM bank.card_authorizations
+		WHERE operation = $1 AND terminal_id = $2 AND stan = $3 AND transmitted_at = $4
+		FOR UPDATE`
+	auth, err := scanCardAuthorization(tx.QueryRowContext(ctx, query, key.Operation, key.TerminalID, key.STAN, key.TransmittedAt))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return auth, nil
+}
+
+func (r *cardAuthorizationRepository) MarkReversed(ctx context.Context, tx *sql.Tx, id int64) error {
+	_, err := tx.ExecContext(ctx, `UPDATE bank.card_authorizations SET reversed = TRUE WHERE id = $1`, id)
+	return err
+}
@@ -3,6 +3,7 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/bank-service/internal/models"
 )
@@ -15,17 +16,58 @@ func NewCardRepository(db *sql.DB) CardRepository {
 	return &cardRepository{db: db}
 }
 
-func (r *cardRepository) Create(ctx context.Context, card *models.Card) error {
+// cardColumns — колонки карты без открытых реквизитов
+const cardColumns = `id, account_id, product, status, masked_number, cvv, COALESCE(hmac, ''),
+	COALESCE(key_id, ''), data_key, card_number_enc, expiry_date_enc, expires_at,
+	COALESCE(reissued_from_id, 0), created_at, updated_at`
+
+func scanCard(row rowScanner) (*models.Card, error) {
+	card := &models.Card{}
+	var expiresAt sql.NullTime
+	err := row.Scan(
+		&card.ID,
+		&card.AccountID,
+		&card.Product,
+		&card.Status,
+		&card.MaskedNumber,
+		&card.CVV,
+		&card.HMAC,
+		&card.Encrypted.KeyID,
+		&card.Encrypted.DataKey,
+		&card.Encrypted.CardNumber,
+		&card.Encrypted.ExpiryDate,
+		&expiresAt,
+		&card.ReissuedFromID,
+		&card.CreatedAt,
+		&card.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	card.ExpiresAt = expiresAt.Time
+	return card, nil
+}
+
+// Create сохраняет карту; реквизиты должны быть уже зашифрованы в card.Encrypted
+func (r *cardRepository) Create(ctx context.Context, tx *sql.Tx, card *models.Card) error {
 	query := `
-		INSERT INTO bank.cards (account_id, card_number, expiry_date, cvv, hmac, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO bank.cards (account_id, product, status, masked_number, card_number_enc, expiry_date_enc, data_key, key_id, cvv, hmac,
+			expires_at, reissued_from_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 		RETURNING id`
-	err := r.db.QueryRowContext(ctx, query,
+	err := tx.QueryRowContext(ctx, query,
 		card.AccountID,
-		card.CardNumber,
-		card.ExpiryDate,
+		card.Product,
+		card.Status,
+		card.MaskedNumber,
+		card.Encrypted.CardNumber,
+		card.Encrypted.ExpiryDate,
+		card.Encrypted.DataKey,
+		card.Encrypted.KeyID,
 		card.CVV,
 		card.HMAC,
+		card.ExpiresAt,
+		sql.NullInt64{Int64: card.ReissuedFromID, Valid: card.ReissuedFromID != 0},
 		card.CreatedAt,
 		card.UpdatedAt,
 	).Scan(&card.ID)
@@ -35,21 +77,81 @@ func (r *cardRepository) Create(ctx context.Context, card *models.Card) error {
 	return nil
 }
 
+func (r *cardRepository) FindByID(ctx context.Context, id int64) (*models.Card, error) {
+	query := `
+		SELECT ` + cardColumns + `
+		FROM bank.cards
+		WHERE id = $1`
+	card, err := scanCard(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+func (r *cardRepository) FindByIDForUpdate(ctx context.Context, tx *sql.Tx, id int64) (*models.Card, error) {
+	query := `
+		SELECT ` + cardColumns + `
+		FROM bank.cards
+		WHERE id = $1
+		FOR UPDATE`
+	card, err := scanCard(tx.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
 func (r *cardRepository) FindByAccountID(ctx context.Context, accountID int64) ([]*models.Card, error) {
 	query := `
-		SELECT id, account_id, card_number, expiry_date, cvv, hmac, created_at, updated_at
+		SELECT ` + cardColumns + `
 		FROM bank.cards
-		WHERE account_id = $1`
+		WHERE account_id = $1
+		ORDER BY id`
 	rows, err := r.db.QueryContext(ctx, query, accountID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	var cards []*models.Card
+	for rows.Next() {
+		card, err := scanCard(rows)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, card)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return cards, nil
+}
+
+// FindUnencrypted возвращает карты, реквизиты которых ещё хранятся открыто
+func (r *cardRepository) FindUnencrypted(ctx context.Context, limit int) ([]*models.Card, error) {
+	query := `
+		SELECT id, card_number, expiry_date
+		FROM bank.cards
+		WHERE card_number_enc IS NULL
+		ORDER BY id
+		LIMIT $1`
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
 	var cards []*models.Card
 	for rows.Next() {
 		card := &models.Card{}
-		if err := rows.Scan(&card.ID, &card.AccountID, &card.CardNumber, &card.ExpiryDate, &card.CVV, &card.HMAC, &card.CreatedAt, &card.UpdatedAt); err != nil {
+		if err := rows.Scan(&card.ID, &card.CardNumber, &card.ExpiryDate); err != nil {
 			return nil, err
 		}
 		cards = append(cards, card)
@@ -59,3 +161,209 @@ func (r *cardRepository) FindByAccountID(ctx context.Context, accountID int64) (
 	}
 	return cards, nil
 }
+
+// SaveEncrypted записывает зашифрованные реквизиты карты и стирает открытые
+func (r *cardRepository) SaveEncrypted(ctx context.Context, card *models.Card) error {
+	query := `
+		UPDATE bank.cards
+		SET card_number_enc = $2, expiry_date_enc = $3, data_key = $4, key_id = $5,
+			card_number = NULL, expiry_date = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND card_number_enc IS NULL`
+	_, err := r.db.ExecContext(ctx, query,
+		card.ID,
+		card.Encrypted.CardNumber,
+		card.Encrypted.ExpiryDate,
+		card.Encrypted.DataKey,
+		card.Encrypted.KeyID,
+	)
+	return err
+}
+
+// ExistsByHMAC проверяет, выпущена ли уже карта с номером, HMAC которого равен hmac
+func (r *cardRepository) ExistsByHMAC(ctx context.Context, hmac string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM bank.cards WHERE hmac = $1)`, hmac).Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+// FindByHMAC находит карту по HMAC её номера
+func (r *cardRepository) FindByHMAC(ctx context.Context, hmac string) (*models.Card, error) {
+	query := `
+		SELECT ` + cardColumns + `
+		FROM bank.cards
+		WHERE hmac = $1`
+	card, err := scanCard(r.db.QueryRowContext(ctx, query, hmac))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+// FindWithoutHMAC возвращает карты без HMAC номера с id больше afterID
+func (r *cardRepository) FindWithoutHMAC(ctx context.Context, afterID int64, limit int) ([]*models.Card, error) {
+	query := `
+		SELECT ` + cardColumns + `
+		FROM bank.cards
+		WHERE hmac IS NULL AND card_number_enc IS NOT NULL AND id > $1
+		ORDER BY id
+		LIMIT $2`
+	rows, err := r.db.QueryContext(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cards []*models.Card
+	for rows.Next() {
+		card, err := scanCard(rows)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, card)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return cards, nil
+}
+
+// SetHMAC записывает HMAC номера карты, если такой HMAC ещё не занят другой картой.
+// Возвращает false, если номер совпал с номером уже учтённой карты.
+func (r *cardRepository) SetHMAC(ctx context.Context, id int64, hmac string) (bool, error) {
+	query := `
+		UPDATE bank.cards
+		SET hmac = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND hmac IS NULL
+			AND NOT EXISTS (SELECT 1 FROM bank.cards WHERE hmac = $2)`
+	result, err := r.db.ExecContext(ctx, query, id, hmac)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func (r *cardRepository) UpdateStatus(ctx context.Context, tx *sql.Tx, id int64, status string, at time.Time) error {
+	result, err := tx.ExecContext(ctx, `UPDATE bank.cards SET status = $2, updated_at = $3 WHERE id = $1`, id, status, at)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// CreateStatusChange записывает смену статуса в той же транзакции, что и само изменение
+func (r *cardRepository) CreateStatusChange(ctx context.Context, tx *sql.Tx, change *models.CardStatusChange) error {
+	query := `
+		INSERT INTO bank.card_status_history (card_id, from_status, to_status, actor_id, reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`
+	return tx.QueryRowContext(ctx, query,
+		change.CardID,
+		sql.NullString{String: change.FromStatus, Valid: change.FromStatus != ""},
+		change.ToStatus,
+		sql.NullInt64{Int64: change.ActorID, Valid: change.ActorID != 0},
+		sql.NullString{String: change.Reason, Valid: change.Reason != ""},
+		change.CreatedAt,
+	).Scan(&change.ID)
+}
+
+func (r *cardRepository) FindStatusHistory(ctx context.Context, cardID int64) ([]*models.CardStatusChange, error) {
+	query := `
+		SELECT id, card_id, COALESCE(from_status, ''), to_status, COALESCE(actor_id, 0), COALESCE(reason, ''), created_at
+		FROM bank.card_status_history
+		WHERE card_id = $1
+		ORDER BY created_at, id`
+	rows, err := r.db.QueryContext(ctx, query, cardID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []*models.CardStatusChange
+	for rows.Next() {
+		change := &models.CardStatusChange{}
+		if err := rows.Scan(&change.ID, &change.CardID, &change.FromStatus, &change.ToStatus, &change.ActorID, &change.Reason, &change.CreatedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// FindWithoutExpiry возвращает действующие карты с id больше afterID, у которых не заполнен expires_at
+func (r *cardRepository) FindWithoutExpiry(ctx context.Context, afterID int64, limit int) ([]*models.Card, error) {
+	query := `
+		SELECT ` + cardColumns + `
+		FROM bank.cards
+		WHERE expires_at IS NULL AND status IN ('active', 'blocked') AND card_number_enc IS NOT NULL AND id > $1
+		ORDER BY id
+		LIMIT $2`
+	rows, err := r.db.QueryContext(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cards []*models.Card
+	for rows.Next() {
+		card, err := scanCard(rows)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, card)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return cards, nil
+}
+
+func (r *cardRepository) SetExpiresAt(ctx context.Context, id int64, expiresAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE bank.cards SET expires_at = $2 WHERE id = $1 AND expires_at IS NULL`, id, expiresAt)
+	return err
+}
+
+// ExpireDue переводит в статус expired карты с истёкшим сроком действия
+// и записывает смену статуса в журнал одним запросом
+func (r *cardRepository) ExpireDue(ctx context.Context, now time.Time) (int64, error) {
+	query := `
+		WITH due AS (
+			SELECT id, status
+			FROM bank.cards
+			WHERE status IN ('active', 'blocked') AND expires_at <= $1
+			FOR UPDATE
+		), expired AS (
+			UPDATE bank.cards c
+			SET status = 'expired', updated_at = $1
+			FROM due
+			WHERE c.id = due.id
+			RETURNING c.id, due.status AS from_status
+		)
+		INSERT INTO bank.card_status_history (card_id, from_status, to_status, reason, created_at)
+		SELECT id, from_status, 'expired', $2::TEXT, $1
+		FROM expired`
+	result, err := r.db.ExecContext(ctx, query, now, models.CardReasonExpired)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
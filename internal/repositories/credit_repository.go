@@ -3,8 +3,10 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/money"
 )
 
 type creditRepository struct {
@@ -15,16 +17,99 @@ func NewCreditRepository(db *sql.DB) CreditRepository {
 	return &creditRepository{db: db}
 }
 
-func (r *creditRepository) CreateCredit(ctx context.Context, credit *models.Credit) error {
+const creditColumns = `id, user_id, COALESCE(account_id, 0), amount, currency, interest_rate, term_months, schedule_type, day_count, status,
+	COALESCE(disbursement_entry_id, 0), created_at, updated_at`
+
+func scanCredit(row rowScanner) (*models.Credit, error) {
+	credit := &models.Credit{}
+	err := row.Scan(
+		&credit.ID,
+		&credit.UserID,
+		&credit.AccountID,
+		&credit.Amount,
+		&credit.Currency,
+		&credit.InterestRate,
+		&credit.TermMonths,
+		&credit.ScheduleType,
+		&credit.DayCount,
+		&credit.Status,
+		&credit.DisbursementEntryID,
+		&credit.CreatedAt,
+		&credit.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return credit, nil
+}
+
+const paymentScheduleColumns = `id, credit_id, period_start, payment_date, amount, principal, interest, remaining_principal,
+	paid, paid_at, penalty, superseded_at, created_at, updated_at`
+
+func scanPaymentSchedule(row rowScanner) (*models.PaymentSchedule, error) {
+	schedule := &models.PaymentSchedule{}
+	var paidAt, supersededAt sql.NullTime
+	err := row.Scan(
+		&schedule.ID,
+		&schedule.CreditID,
+		&schedule.PeriodStart,
+		&schedule.PaymentDate,
+		&schedule.Amount,
+		&schedule.Principal,
+		&schedule.Interest,
+		&schedule.RemainingPrincipal,
+		&schedule.Paid,
+		&paidAt,
+		&schedule.Penalty,
+		&supersededAt,
+		&schedule.CreatedAt,
+		&schedule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if paidAt.Valid {
+		schedule.PaidAt = &paidAt.Time
+	}
+	if supersededAt.Valid {
+		schedule.SupersededAt = &supersededAt.Time
+	}
+	return schedule, nil
+}
+
+func scanPaymentSchedules(rows *sql.Rows) ([]*models.PaymentSchedule, error) {
+	defer rows.Close()
+	var schedules []*models.PaymentSchedule
+	for rows.Next() {
+		schedule, err := scanPaymentSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+func (r *creditRepository) CreateCredit(ctx context.Context, tx *sql.Tx, credit *models.Credit) error {
 	query := `
-		INSERT INTO bank.credits (user_id, amount, interest_rate, term_months, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO bank.credits (user_id, account_id, amount, currency, interest_rate, term_months, schedule_type, day_count, status,
+			disbursement_entry_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		RETURNING id`
-	err := r.db.QueryRowContext(ctx, query,
+	err := tx.QueryRowContext(ctx, query,
 		credit.UserID,
+		sql.NullInt64{Int64: credit.AccountID, Valid: credit.AccountID != 0},
 		credit.Amount,
+		credit.Currency,
 		credit.InterestRate,
 		credit.TermMonths,
+		credit.ScheduleType,
+		credit.DayCount,
+		credit.Status,
+		sql.NullInt64{Int64: credit.DisbursementEntryID, Valid: credit.DisbursementEntryID != 0},
 		credit.CreatedAt,
 		credit.UpdatedAt,
 	).Scan(&credit.ID)
@@ -34,11 +119,44 @@ func (r *creditRepository) CreateCredit(ctx context.Context, credit *models.Cred
 	return nil
 }
 
+func (r *creditRepository) FindByID(ctx context.Context, id int64) (*models.Credit, error) {
+	query := `
+		SELECT ` + creditColumns + `
+		FROM bank.credits
+		WHERE id = $1`
+	credit, err := scanCredit(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return credit, nil
+}
+
+// FindByIDForUpdate блокирует кредит до конца транзакции, чтобы платежи по нему шли по очереди
+func (r *creditRepository) FindByIDForUpdate(ctx context.Context, tx *sql.Tx, id int64) (*models.Credit, error) {
+	query := `
+		SELECT ` + creditColumns + `
+		FROM bank.credits
+		WHERE id = $1
+		FOR UPDATE`
+	credit, err := scanCredit(tx.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return credit, nil
+}
+
 func (r *creditRepository) FindByUserID(ctx context.Context, userID int64) ([]*models.Credit, error) {
 	query := `
-		SELECT id, user_id, amount, interest_rate, term_months, created_at, updated_at
+		SELECT ` + creditColumns + `
 		FROM bank.credits
-		WHERE user_id = $1`
+		WHERE user_id = $1
+		ORDER BY id`
 	rows, err := r.db.QueryContext(ctx, query, userID)
 	if err != nil {
 		return nil, err
@@ -47,8 +165,8 @@ func (r *creditRepository) FindByUserID(ctx context.Context, userID int64) ([]*m
 
 	var credits []*models.Credit
 	for rows.Next() {
-		credit := &models.Credit{}
-		if err := rows.Scan(&credit.ID, &credit.UserID, &credit.Amount, &credit.InterestRate, &credit.TermMonths, &credit.CreatedAt, &credit.UpdatedAt); err != nil {
+		credit, err := scanCredit(rows)
+		if err != nil {
 			return nil, err
 		}
 		credits = append(credits, credit)
@@ -59,15 +177,103 @@ func (r *creditRepository) FindByUserID(ctx context.Context, userID int64) ([]*m
 	return credits, nil
 }
 
-func (r *creditRepository) CreatePaymentSchedule(ctx context.Context, paymentSchedule *models.PaymentSchedule) error {
+// FindWithDuePayments возвращает id непогашенных кредитов с id больше afterID, у которых
+// есть неоплаченные платежи с датой не позже date и счёт для их списания
+func (r *creditRepository) FindWithDuePayments(ctx context.Context, date time.Time, afterID int64, limit int) ([]int64, error) {
 	query := `
-		INSERT INTO bank.payment_schedules (credit_id, payment_date, amount, paid, penalty, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		SELECT c.id
+		FROM bank.credits c
+		WHERE c.status <> 'closed' AND c.account_id IS NOT NULL AND c.id > $2
+			AND EXISTS (
+				SELECT 1 FROM bank.payment_schedules ps
+				WHERE ps.credit_id = c.id AND NOT ps.paid AND ps.superseded_at IS NULL AND ps.payment_date <= $1
+			)
+		ORDER BY c.id
+		LIMIT $3`
+	rows, err := r.db.QueryContext(ctx, query, date, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *creditRepository) UpdateStatus(ctx context.Context, tx *sql.Tx, id int64, status string, updatedAt time.Time) error {
+	query := `
+		UPDATE bank.credits
+		SET status = $2, updated_at = $3
+		WHERE id = $1`
+	_, err := tx.ExecContext(ctx, query, id, status, updatedAt)
+	return err
+}
+
+// CreateStatusChange записывает смену статуса в той же транзакции, что и само изменение
+func (r *creditRepository) CreateStatusChange(ctx context.Context, tx *sql.Tx, change *models.CreditStatusChange) error {
+	query := `
+		INSERT INTO bank.credit_status_history (credit_id, from_status, to_status, reason, created_at)
+		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id`
-	err := r.db.QueryRowContext(ctx, query,
+	return tx.QueryRowContext(ctx, query,
+		change.CreditID,
+		sql.NullString{String: change.FromStatus, Valid: change.FromStatus != ""},
+		change.ToStatus,
+		sql.NullString{String: change.Reason, Valid: change.Reason != ""},
+		change.CreatedAt,
+	).Scan(&change.ID)
+}
+
+func (r *creditRepository) FindStatusHistory(ctx context.Context, creditID int64) ([]*models.CreditStatusChange, error) {
+	query := `
+		SELECT id, credit_id, COALESCE(from_status, ''), to_status, COALESCE(reason, ''), created_at
+		FROM bank.credit_status_history
+		WHERE credit_id = $1
+		ORDER BY created_at, id`
+	rows, err := r.db.QueryContext(ctx, query, creditID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []*models.CreditStatusChange
+	for rows.Next() {
+		change := &models.CreditStatusChange{}
+		if err := rows.Scan(&change.ID, &change.CreditID, &change.FromStatus, &change.ToStatus, &change.Reason, &change.CreatedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+func (r *creditRepository) CreatePaymentSchedule(ctx context.Context, tx *sql.Tx, paymentSchedule *models.PaymentSchedule) error {
+	query := `
+		INSERT INTO bank.payment_schedules (credit_id, period_start, payment_date, amount, principal, interest, remaining_principal,
+			paid, penalty, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id`
+	err := tx.QueryRowContext(ctx, query,
 		paymentSchedule.CreditID,
+		paymentSchedule.PeriodStart,
 		paymentSchedule.PaymentDate,
 		paymentSchedule.Amount,
+		paymentSchedule.Principal,
+		paymentSchedule.Interest,
+		paymentSchedule.RemainingPrincipal,
 		paymentSchedule.Paid,
 		paymentSchedule.Penalty,
 		paymentSchedule.CreatedAt,
@@ -79,27 +285,226 @@ func (r *creditRepository) CreatePaymentSchedule(ctx context.Context, paymentSch
 	return nil
 }
 
-func (r *creditRepository) FindPaymentSchedulesByCreditID(ctx context.Context, creditID int64) ([]*models.PaymentSchedule, error) {
+// FindPaymentSchedulesByCreditID возвращает действующий график кредита, а с includeSuperseded —
+// также платежи прежних графиков
+func (r *creditRepository) FindPaymentSchedulesByCreditID(ctx context.Context, creditID int64, includeSuperseded bool) ([]*models.PaymentSchedule, error) {
 	query := `
-		SELECT id, credit_id, payment_date, amount, paid, penalty, created_at, updated_at
+		SELECT ` + paymentScheduleColumns + `
 		FROM bank.payment_schedules
-		WHERE credit_id = $1`
+		WHERE credit_id = $1 AND ($2 OR superseded_at IS NULL)
+		ORDER BY payment_date, superseded_at NULLS LAST, id`
+	rows, err := r.db.QueryContext(ctx, query, creditID, includeSuperseded)
+	if err != nil {
+		return nil, err
+	}
+	return scanPaymentSchedules(rows)
+}
+
+// FindUnpaidSchedules возвращает неоплаченные платежи действующего графика.
+// Вызывающий должен держать блокировку кредита.
+func (r *creditRepository) FindUnpaidSchedules(ctx context.Context, tx *sql.Tx, creditID int64) ([]*models.PaymentSchedule, error) {
+	query := `
+		SELECT ` + paymentScheduleColumns + `
+		FROM bank.payment_schedules
+		WHERE credit_id = $1 AND NOT paid AND superseded_at IS NULL
+		ORDER BY payment_date, id`
+	rows, err := tx.QueryContext(ctx, query, creditID)
+	if err != nil {
+		return nil, err
+	}
+	return scanPaymentSchedules(rows)
+}
+
+// SupersedeUnpaidSchedules исключает неоплаченные платежи из действующего графика, сохраняя их как историю
+func (r *creditRepository) SupersedeUnpaidSchedules(ctx context.Context, tx *sql.Tx, creditID int64, at time.Time) error {
+	query := `
+		UPDATE bank.payment_schedules
+		SET superseded_at = $2, updated_at = $2
+		WHERE credit_id = $1 AND NOT paid AND superseded_at IS NULL`
+	_, err := tx.ExecContext(ctx, query, creditID, at)
+	return err
+}
+
+// FindNextUnpaidSchedule возвращает самый ранний непогашенный платёж по кредиту.
+// Вызывающий должен держать блокировку кредита.
+func (r *creditRepository) FindNextUnpaidSchedule(ctx context.Context, tx *sql.Tx, creditID int64) (*models.PaymentSchedule, error) {
+	query := `
+		SELECT ` + paymentScheduleColumns + `
+		FROM bank.payment_schedules
+		WHERE credit_id = $1 AND NOT paid AND superseded_at IS NULL
+		ORDER BY payment_date, id
+		LIMIT 1`
+	schedule, err := scanPaymentSchedule(tx.QueryRowContext(ctx, query, creditID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// FindOverdueSchedules возвращает неоплаченные платежи кредита с датой раньше date.
+// Вызывающий должен держать блокировку кредита.
+func (r *creditRepository) FindOverdueSchedules(ctx context.Context, tx *sql.Tx, creditID int64, date time.Time) ([]*models.PaymentSchedule, error) {
+	query := `
+		SELECT ` + paymentScheduleColumns + `
+		FROM bank.payment_schedules
+		WHERE credit_id = $1 AND NOT paid AND superseded_at IS NULL AND payment_date < $2
+		ORDER BY payment_date, id`
+	rows, err := tx.QueryContext(ctx, query, creditID, date)
+	if err != nil {
+		return nil, err
+	}
+	return scanPaymentSchedules(rows)
+}
+
+func (r *creditRepository) MarkSchedulePaid(ctx context.Context, tx *sql.Tx, id int64, paidAt time.Time) error {
+	query := `
+		UPDATE bank.payment_schedules
+		SET paid = TRUE, paid_at = $2, updated_at = $2
+		WHERE id = $1`
+	_, err := tx.ExecContext(ctx, query, id, paidAt)
+	return err
+}
+
+// AddSchedulePenalty увеличивает неустойку по платежу на сумму новых начислений
+func (r *creditRepository) AddSchedulePenalty(ctx context.Context, tx *sql.Tx, id int64, amount money.Amount, updatedAt time.Time) error {
+	query := `
+		UPDATE bank.payment_schedules
+		SET penalty = penalty + $2, updated_at = $3
+		WHERE id = $1`
+	_, err := tx.ExecContext(ctx, query, id, amount, updatedAt)
+	return err
+}
+
+// FindPenaltyState возвращает день последнего начисления пени по платежу (нулевое время,
+// если пени не начислялись) и признак начисленного штрафа
+func (r *creditRepository) FindPenaltyState(ctx context.Context, tx *sql.Tx, scheduleID int64) (time.Time, bool, error) {
+	query := `
+		SELECT MAX(accrual_date) FILTER (WHERE kind = 'daily'), COUNT(*) FILTER (WHERE kind = 'fee') > 0
+		FROM bank.credit_penalties
+		WHERE schedule_id = $1`
+	var (
+		lastDaily sql.NullTime
+		hasFee    bool
+	)
+	if err := tx.QueryRowContext(ctx, query, scheduleID).Scan(&lastDaily, &hasFee); err != nil {
+		return time.Time{}, false, err
+	}
+	return lastDaily.Time, hasFee, nil
+}
+
+func (r *creditRepository) CreatePenalty(ctx context.Context, tx *sql.Tx, penalty *models.CreditPenalty) error {
+	query := `
+		INSERT INTO bank.credit_penalties (credit_id, schedule_id, kind, accrual_date, base, rate, amount, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id`
+	return tx.QueryRowContext(ctx, query,
+		penalty.CreditID,
+		penalty.ScheduleID,
+		penalty.Kind,
+		penalty.AccrualDate,
+		penalty.Base,
+		sql.NullFloat64{Float64: penalty.Rate, Valid: penalty.Kind == models.PenaltyKindDaily},
+		penalty.Amount,
+		penalty.CreatedAt,
+	).Scan(&penalty.ID)
+}
+
+func (r *creditRepository) FindPenaltiesByCreditID(ctx context.Context, creditID int64) ([]*models.CreditPenalty, error) {
+	query := `
+		SELECT id, credit_id, schedule_id, kind, accrual_date, base, COALESCE(rate, 0), amount, created_at
+		FROM bank.credit_penalties
+		WHERE credit_id = $1
+		ORDER BY accrual_date, id`
 	rows, err := r.db.QueryContext(ctx, query, creditID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var schedules []*models.PaymentSchedule
+	var penalties []*models.CreditPenalty
 	for rows.Next() {
-		schedule := &models.PaymentSchedule{}
-		if err := rows.Scan(&schedule.ID, &schedule.CreditID, &schedule.PaymentDate, &schedule.Amount, &schedule.Paid, &schedule.Penalty, &schedule.CreatedAt, &schedule.UpdatedAt); err != nil {
+		penalty := &models.CreditPenalty{}
+		err := rows.Scan(
+			&penalty.ID,
+			&penalty.CreditID,
+			&penalty.ScheduleID,
+			&penalty.Kind,
+			&penalty.AccrualDate,
+			&penalty.Base,
+			&penalty.Rate,
+			&penalty.Amount,
+			&penalty.CreatedAt,
+		)
+		if err != nil {
 			return nil, err
 		}
-		schedules = append(schedules, schedule)
+		penalties = append(penalties, penalty)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
-	return schedules, nil
+	return penalties, nil
+}
+
+func (r *creditRepository) CreatePayment(ctx context.Context, tx *sql.Tx, payment *models.CreditPayment) error {
+	query := `
+		INSERT INTO bank.credit_payments (credit_id, schedule_id, kind, account_id, amount, principal, interest, penalty, entry_id, source, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id`
+	return tx.QueryRowContext(ctx, query,
+		payment.CreditID,
+		sql.NullInt64{Int64: payment.ScheduleID, Valid: payment.ScheduleID != 0},
+		payment.Kind,
+		payment.AccountID,
+		payment.Amount,
+		payment.Principal,
+		payment.Interest,
+		payment.Penalty,
+		payment.EntryID,
+		payment.Source,
+		payment.CreatedAt,
+	).Scan(&payment.ID)
+}
+
+func (r *creditRepository) FindPaymentsByCreditID(ctx context.Context, creditID int64) ([]*models.CreditPayment, error) {
+	query := `
+		SELECT id, credit_id, COALESCE(schedule_id, 0), kind, account_id, amount, principal, interest, penalty, entry_id, source, created_at
+		FROM bank.credit_payments
+		WHERE credit_id = $1
+		ORDER BY created_at, id`
+	rows, err := r.db.QueryContext(ctx, query, creditID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payments []*models.CreditPayment
+	for rows.Next() {
+		payment := &models.CreditPayment{}
+		err := rows.Scan(
+			&payment.ID,
+			&payment.CreditID,
+			&payment.ScheduleID,
+			&payment.Kind,
+			&payment.AccountID,
+			&payment.Amount,
+			&payment.Principal,
+			&payment.Interest,
+			&payment.Penalty,
+			&payment.EntryID,
+			&payment.Source,
+			&payment.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return payments, nil
 }
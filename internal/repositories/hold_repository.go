@@ -0,0 +1,172 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/bank-service/internal/models"
+)
+
+type holdRepository struct {
+	db *sql.DB
+}
+
+func NewHoldRepository(db *sql.DB) HoldRepository {
+	return &holdRepository{db: db}
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanHold(row rowScanner) (*models.Hold, error) {
+	hold := &models.Hold{}
+	var (
+		cardID      sql.NullInt64
+		description sql.NullString
+	)
+	err := row.Scan(
+		&hold.ID,
+		&hold.AccountID,
+		&cardID,
+		&hold.Amount,
+		&hold.CapturedAmount,
+		&hold.Status,
+		&description,
+		&hold.ExpiresAt,
+		&hold.CreatedAt,
+		&hold.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	hold.CardID = cardID.Int64
+	hold.Description = description.String
+	return hold, nil
+}
+
+func (r *holdRepository) Create(ctx context.Context, tx *sql.Tx, hold *models.Hold) error {
+	query := `
+		INSERT INTO bank.holds (account_id, card_id, amount, captured_amount, status, description, expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id`
+	err := tx.QueryRowContext(ctx, query,
+		hold.AccountID,
+		sql.NullInt64{Int64: hold.CardID, Valid: hold.CardID != 0},
+		hold.Amount,
+		hold.CapturedAmount,
+		hold.Status,
+		hold.Description,
+		hold.ExpiresAt,
+		hold.CreatedAt,
+		hold.UpdatedAt,
+	).Scan(&hold.ID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *holdRepository) FindByID(ctx context.Context, id int64) (*models.Hold, error) {
+	query := `
+		SELECT id, account_id, card_id, amount, captured_amount, status, description, expires_at, created_at, updated_at
+		FROM bank.holds
+		WHERE id = $1`
+	hold, err := scanHold(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+func (r *holdRepository) FindByIDForUpdate(ctx context.Context, tx *sql.Tx, id int64) (*models.Hold, error) {
+	query := `
+		SELECT id, account_id, card_id, amount, captured_amount, status, description, expires_at, created_at, updated_at
+		FROM bank.holds
+		WHERE id = $1
+		FOR UPDATE`
+	hold, err := scanHold(tx.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+func (r *holdRepository) FindActiveByAccountID(ctx context.Context, accountID int64) ([]*models.Hold, error) {
+	query := `
+		SELECT id, account_id, card_id, amount, captured_amount, status, description, expires_at, created_at, updated_at
+		FROM bank.holds
+		WHERE account_id = $1 AND status = 'active' AND expires_at > CURRENT_TIMESTAMP
+		ORDER BY created_at DESC`
+	rows, err := r.db.QueryContext(ctx, query, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var holds []*models.Hold
+	for rows.Next() {
+		hold, err := scanHold(rows)
+		if err != nil {
+			return nil, err
+		}
+		holds = append(holds, hold)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return holds, nil
+}
+
+func (r *holdRepository) Update(ctx context.Context, tx *sql.Tx, hold *models.Hold) error {
+	query := `
+		UPDATE bank.holds
+		SET captured_amount = $1, status = $2, updated_at = $3
+		WHERE id = $4`
+	result, err := tx.ExecContext(ctx, query, hold.CapturedAmount, hold.Status, hold.UpdatedAt, hold.ID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ExpireDue переводит в статус expired действующие холды с истёкшим сроком и возвращает их
+func (r *holdRepository) ExpireDue(ctx context.Context, tx *sql.Tx, now time.Time) ([]*models.Hold, error) {
+	query := `
+		UPDATE bank.holds
+		SET status = 'expired', updated_at = $1
+		WHERE status = 'active' AND expires_at <= $1
+		RETURNING id, account_id, card_id, amount, captured_amount, status, description, expires_at, created_at, updated_at`
+	rows, err := tx.QueryContext(ctx, query, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var holds []*models.Hold
+	for rows.Next() {
+		hold, err := scanHold(rows)
+		if err != nil {
+			return nil, err
+		}
+		holds = append(holds, hold)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return holds, nil
+}
@@ -0,0 +1,135 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/bank-service/internal/models"
+)
+
+type idempotencyRepository struct {
+	db *sql.DB
+}
+
+func NewIdempotencyRepository(db *sql.DB) IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+// Reserve сохраняет ключ в состоянии "выполняется". Истёкший ключ, ещё не удалённый
+// фоновой очисткой, занимается заново. Возвращает false, если ключ уже существует и не истёк.
+func (r *idempotencyRepository) Reserve(ctx context.Context, key *models.IdempotencyKey) (bool, error) {
+	query := `
+		INSERT INTO bank.idempotency_keys (user_id, key, request_method, request_path, request_hash, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id, key) DO UPDATE
+		SET request_method = EXCLUDED.request_method,
+			request_path = EXCLUDED.request_path,
+			request_hash = EXCLUDED.request_hash,
+			status_code = NULL,
+			response_body = NULL,
+			response_content_type = NULL,
+			created_at = EXCLUDED.created_at,
+			completed_at = NULL,
+			expires_at = EXCLUDED.expires_at
+		WHERE bank.idempotency_keys.expires_at <= EXCLUDED.created_at`
+	result, err := r.db.ExecContext(ctx, query,
+		key.UserID,
+		key.Key,
+		key.Method,
+		key.Path,
+		key.RequestHash,
+		key.CreatedAt,
+		key.ExpiresAt,
+	)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected == 1, nil
+}
+
+func (r *idempotencyRepository) Find(ctx context.Context, userID int64, key string) (*models.IdempotencyKey, error) {
+	k := &models.IdempotencyKey{}
+	var (
+		statusCode  sql.NullInt64
+		contentType sql.NullString
+		completedAt sql.NullTime
+	)
+	query := `
+		SELECT user_id, key, request_method, request_path, request_hash, status_code, response_body, response_content_type, created_at, completed_at, expires_at
+		FROM bank.idempotency_keys
+		WHERE user_id = $1 AND key = $2`
+	err := r.db.QueryRowContext(ctx, query, userID, key).Scan(
+		&k.UserID,
+		&k.Key,
+		&k.Method,
+		&k.Path,
+		&k.RequestHash,
+		&statusCode,
+		&k.ResponseBody,
+		&contentType,
+		&k.CreatedAt,
+		&completedAt,
+		&k.ExpiresAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	k.StatusCode = int(statusCode.Int64)
+	k.ContentType = contentType.String
+	if completedAt.Valid {
+		k.CompletedAt = &completedAt.Time
+	}
+	return k, nil
+}
+
+func (r *idempotencyRepository) Complete(ctx context.Context, key *models.IdempotencyKey) error {
+	query := `
+		UPDATE bank.idempotency_keys
+		SET status_code = $1, response_body = $2, response_content_type = $3, completed_at = $4, expires_at = $5
+		WHERE user_id = $6 AND key = $7`
+	result, err := r.db.ExecContext(ctx, query,
+		key.StatusCode,
+		key.ResponseBody,
+		key.ContentType,
+		key.CompletedAt,
+		key.ExpiresAt,
+		key.UserID,
+		key.Key,
+	)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *idempotencyRepository) Delete(ctx context.Context, userID int64, key string) error {
+	query := `
+		DELETE FROM bank.idempotency_keys
+		WHERE user_id = $1 AND key = $2`
+	_, err := r.db.ExecContext(ctx, query, userID, key)
+	return err
+}
+
+// DeleteExpired удаляет истёкшие ключи вместе с сохранёнными ответами
+func (r *idempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM bank.idempotency_keys WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -3,8 +3,10 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/money"
 )
 
 // UserRepository определяет методы для работы с пользователями
@@ -12,32 +14,136 @@ type UserRepository interface {
 	Create(ctx context.Context, user *models.User) error
 	FindByEmail(ctx context.Context, email string) (*models.User, error)
 	FindByID(ctx context.Context, id int64) (*models.User, error)
+	Search(ctx context.Context, query string, limit int) ([]*models.User, error)
+	UpdateRole(ctx context.Context, tx *sql.Tx, id int64, role string) error
 }
 
 // AccountRepository определяет методы для работы со счетами
 type AccountRepository interface {
 	Create(ctx context.Context, account *models.Account) error
 	FindByID(ctx context.Context, id int64) (*models.Account, error)
+	FindByIDForUpdate(ctx context.Context, tx *sql.Tx, id int64) (*models.Account, error)
+	LockByIDs(ctx context.Context, tx *sql.Tx, ids ...int64) (map[int64]*models.Account, error)
 	FindByUserID(ctx context.Context, userID int64) ([]*models.Account, error)
-	UpdateBalance(ctx context.Context, accountID int64, balance float64) error
+	AddToBalance(ctx context.Context, tx *sql.Tx, accountID int64, delta money.Amount) (money.Amount, error)
+	SetStatus(ctx context.Context, tx *sql.Tx, id int64, status string) error
 }
 
 // TransactionRepository определяет методы для работы с транзакциями
 type TransactionRepository interface {
 	Create(ctx context.Context, tx *sql.Tx, transaction *models.Transaction) error
-	FindByAccountID(ctx context.Context, accountID int64) ([]*models.Transaction, error)
+	Find(ctx context.Context, accountID int64, filter models.TransactionFilter) ([]*models.Transaction, error)
+	FindStatement(ctx context.Context, accountID int64, from, to time.Time) (*models.Statement, error)
+	FindByID(ctx context.Context, id int64) (*models.Transaction, error)
+	FindByEntryID(ctx context.Context, tx *sql.Tx, entryID int64) ([]*models.Transaction, error)
+}
+
+// LedgerRepository определяет методы для работы с журналом двойной записи
+type LedgerRepository interface {
+	CreateEntry(ctx context.Context, tx *sql.Tx, entry *models.JournalEntry) error
+	FindUnbalancedEntryIDs(ctx context.Context) ([]int64, error)
+	FindMismatchedAccountIDs(ctx context.Context) ([]int64, error)
+	FindEntryForUpdate(ctx context.Context, tx *sql.Tx, id int64) (*models.JournalEntry, error)
+	FindReversalID(ctx context.Context, tx *sql.Tx, entryID int64) (int64, error)
 }
 
 // CardRepository определяет методы для работы с картами
 type CardRepository interface {
-	Create(ctx context.Context, card *models.Card) error
+	Create(ctx context.Context, tx *sql.Tx, card *models.Card) error
+	FindByID(ctx context.Context, id int64) (*models.Card, error)
+	FindByIDForUpdate(ctx context.Context, tx *sql.Tx, id int64) (*models.Card, error)
 	FindByAccountID(ctx context.Context, accountID int64) ([]*models.Card, error)
+	UpdateStatus(ctx context.Context, tx *sql.Tx, id int64, status string, at time.Time) error
+	CreateStatusChange(ctx context.Context, tx *sql.Tx, change *models.CardStatusChange) error
+	FindStatusHistory(ctx context.Context, cardID int64) ([]*models.CardStatusChange, error)
+	FindUnencrypted(ctx context.Context, limit int) ([]*models.Card, error)
+	SaveEncrypted(ctx context.Context, card *models.Card) error
+	ExistsByHMAC(ctx context.Context, hmac string) (bool, error)
+	FindByHMAC(ctx context.Context, hmac string) (*models.Card, error)
+	FindWithoutHMAC(ctx context.Context, afterID int64, limit int) ([]*models.Card, error)
+	SetHMAC(ctx context.Context, id int64, hmac string) (bool, error)
+	FindWithoutExpiry(ctx context.Context, afterID int64, limit int) ([]*models.Card, error)
+	SetExpiresAt(ctx context.Context, id int64, expiresAt time.Time) error
+	ExpireDue(ctx context.Context, now time.Time) (int64, error)
 }
 
 // CreditRepository определяет методы для работы с кредитами и графиком платежей
 type CreditRepository interface {
-	CreateCredit(ctx context.Context, credit *models.Credit) error
+	CreateCredit(ctx context.Context, tx *sql.Tx, credit *models.Credit) error
+	FindByID(ctx context.Context, id int64) (*models.Credit, error)
+	FindByIDForUpdate(ctx context.Context, tx *sql.Tx, id int64) (*models.Credit, error)
 	FindByUserID(ctx context.Context, userID int64) ([]*models.Credit, error)
-	CreatePaymentSchedule(ctx context.Context, paymentSchedule *models.PaymentSchedule) error
-	FindPaymentSchedulesByCreditID(ctx context.Context, creditID int64) ([]*models.PaymentSchedule, error)
+	FindWithDuePayments(ctx context.Context, date time.Time, afterID int64, limit int) ([]int64, error)
+	UpdateStatus(ctx context.Context, tx *sql.Tx, id int64, status string, updatedAt time.Time) error
+	CreateStatusChange(ctx context.Context, tx *sql.Tx, change *models.CreditStatusChange) error
+	FindStatusHistory(ctx context.Context, creditID int64) ([]*models.CreditStatusChange, error)
+	CreatePaymentSchedule(ctx context.Context, tx *sql.Tx, paymentSchedule *models.PaymentSchedule) error
+	FindPaymentSchedulesByCreditID(ctx context.Context, creditID int64, includeSuperseded bool) ([]*models.PaymentSchedule, error)
+	FindUnpaidSchedules(ctx context.Context, tx *sql.Tx, creditID int64) ([]*models.PaymentSchedule, error)
+	SupersedeUnpaidSchedules(ctx context.Context, tx *sql.Tx, creditID int64, at time.Time) error
+	FindNextUnpaidSchedule(ctx context.Context, tx *sql.Tx, creditID int64) (*models.PaymentSchedule, error)
+	FindOverdueSchedules(ctx context.Context, tx *sql.Tx, creditID int64, date time.Time) ([]*models.PaymentSchedule, error)
+	MarkSchedulePaid(ctx context.Context, tx *sql.Tx, id int64, paidAt time.Time) error
+	AddSchedulePenalty(ctx context.Context, tx *sql.Tx, id int64, amount money.Amount, updatedAt time.Time) error
+	FindPenaltyState(ctx context.Context, tx *sql.Tx, scheduleID int64) (time.Time, bool, error)
+	CreatePenalty(ctx context.Context, tx *sql.Tx, penalty *models.CreditPenalty) error
+	FindPenaltiesByCreditID(ctx context.Context, creditID int64) ([]*models.CreditPenalty, error)
+	CreatePayment(ctx context.Context, tx *sql.Tx, payment *models.CreditPayment) error
+	FindPaymentsByCreditID(ctx context.Context, creditID int64) ([]*models.CreditPayment, error)
+}
+
+// IdempotencyRepository определяет методы для хранения ключей идемпотентности
+type IdempotencyRepository interface {
+	Reserve(ctx context.Context, key *models.IdempotencyKey) (bool, error)
+	Find(ctx context.Context, userID int64, key string) (*models.IdempotencyKey, error)
+	Complete(ctx context.Context, key *models.IdempotencyKey) error
+	Delete(ctx context.Context, userID int64, key string) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// HoldRepository определяет методы для работы с холдами (блокировками средств)
+type HoldRepository interface {
+	Create(ctx context.Context, tx *sql.Tx, hold *models.Hold) error
+	FindByID(ctx context.Context, id int64) (*models.Hold, error)
+	FindByIDForUpdate(ctx context.Context, tx *sql.Tx, id int64) (*models.Hold, error)
+	FindActiveByAccountID(ctx context.Context, accountID int64) ([]*models.Hold, error)
+	Update(ctx context.Context, tx *sql.Tx, hold *models.Hold) error
+	ExpireDue(ctx context.Context, tx *sql.Tx, now time.Time) ([]*models.Hold, error)
+}
+
+// TokenRepository хранит сессии, refresh-токены и отозванные access-токены
+type TokenRepository interface {
+	CreateFamily(ctx context.Context, tx *sql.Tx, family *models.TokenFamily) error
+	RevokeFamily(ctx context.Context, tx *sql.Tx, familyID, reason string, revokedAt time.Time) error
+	RevokeUserFamilies(ctx context.Context, tx *sql.Tx, userID int64, reason string, revokedAt time.Time) error
+	CreateRefreshToken(ctx context.Context, tx *sql.Tx, token *models.RefreshToken) error
+	FindRefreshTokenForUpdate(ctx context.Context, tx *sql.Tx, tokenHash string) (*models.RefreshToken, error)
+	MarkRefreshTokenUsed(ctx context.Context, tx *sql.Tx, id int64, usedAt time.Time) error
+	RevokeAccessToken(ctx context.Context, tx *sql.Tx, jti string, userID int64, expiresAt time.Time) error
+	IsAccessTokenRevoked(ctx context.Context, jti, familyID string) (bool, error)
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// AdminActionRepository ведёт журнал действий сотрудников банка
+type AdminActionRepository interface {
+	Create(ctx context.Context, tx *sql.Tx, action *models.AdminAction) error
+}
+
+// CardAuthorizationRepository хранит сообщения карточного процессинга и ответы на них
+type CardAuthorizationRepository interface {
+	Create(ctx context.Context, tx *sql.Tx, auth *models.CardAuthorization) (bool, error)
+	FindByKey(ctx context.Context, key models.CardMessageKey) (*models.CardAuthorization, error)
+	FindByKeyForUpdate(ctx context.Context, tx *sql.Tx, key models.CardMessageKey) (*models.CardAuthorization, error)
+	MarkReversed(ctx context.Context, tx *sql.Tx, id int64) error
+}
+
+// LimitRepository хранит лимиты расходных операций и израсходованную часть лимитов по периодам
+type LimitRepository interface {
+	FindByScope(ctx context.Context, scope string, scopeID int64) ([]*models.SpendingLimit, error)
+	FindForUsage(ctx context.Context, tx *sql.Tx, usage *models.LimitUsage) ([]*models.SpendingLimit, error)
+	Upsert(ctx context.Context, tx *sql.Tx, limit *models.SpendingLimit) error
+	Delete(ctx context.Context, tx *sql.Tx, key models.LimitKey, source string) (bool, error)
+	LockUsage(ctx context.Context, tx *sql.Tx, key models.LimitKey, periodStart time.Time) (money.Amount, int, error)
+	AddUsage(ctx context.Context, tx *sql.Tx, key models.LimitKey, periodStart time.Time, amount money.Amount, count int) error
+	FindUsage(ctx context.Context, key models.LimitKey, periodStart time.Time) (money.Amount, int, error)
 }
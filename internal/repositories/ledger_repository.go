@@ -0,0 +1,166 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/bank-service/internal/models"
+)
+
+type ledgerRepository struct {
+	db *sql.DB
+}
+
+func NewLedgerRepository(db *sql.DB) LedgerRepository {
+	return &ledgerRepository{db: db}
+}
+
+func (r *ledgerRepository) CreateEntry(ctx context.Context, tx *sql.Tx, entry *models.JournalEntry) error {
+	query := `
+		INSERT INTO bank.journal_entries (type, description, reverses_entry_id, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`
+	err := tx.QueryRowContext(ctx, query,
+		entry.Type,
+		entry.Description,
+		sql.NullInt64{Int64: entry.ReversesEntryID, Valid: entry.ReversesEntryID != 0},
+		entry.CreatedAt,
+	).Scan(&entry.ID)
+	if err != nil {
+		return err
+	}
+
+	postingQuery := `
+		INSERT INTO bank.postings (entry_id, account_id, system_account, amount, currency, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`
+	for _, posting := range entry.Postings {
+		posting.EntryID = entry.ID
+		posting.CreatedAt = entry.CreatedAt
+		err := tx.QueryRowContext(ctx, postingQuery,
+			posting.EntryID,
+			sql.NullInt64{Int64: posting.AccountID, Valid: posting.AccountID != 0},
+			sql.NullString{String: posting.SystemAccount, Valid: posting.SystemAccount != ""},
+			posting.Amount,
+			posting.Currency,
+			posting.CreatedAt,
+		).Scan(&posting.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *ledgerRepository) FindUnbalancedEntryIDs(ctx context.Context) ([]int64, error) {
+	query := `
+		SELECT DISTINCT entry_id
+		FROM bank.postings
+		GROUP BY entry_id, currency
+		HAVING SUM(amount) <> 0
+		ORDER BY entry_id`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *ledgerRepository) FindMismatchedAccountIDs(ctx context.Context) ([]int64, error) {
+	query := `
+		SELECT a.id
+		FROM bank.accounts a
+		LEFT JOIN (
+			SELECT account_id, SUM(amount) AS total
+			FROM bank.postings
+			WHERE account_id IS NOT NULL
+			GROUP BY account_id
+		) p ON p.account_id = a.id
+		WHERE a.balance <> COALESCE(p.total, 0)
+		ORDER BY a.id`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// FindEntryForUpdate читает проводку вместе с разносками и блокирует её строку,
+// чтобы одновременные попытки сторнирования выполнялись по очереди
+func (r *ledgerRepository) FindEntryForUpdate(ctx context.Context, tx *sql.Tx, id int64) (*models.JournalEntry, error) {
+	entry := &models.JournalEntry{}
+	var description sql.NullString
+	var reversesEntryID sql.NullInt64
+	query := `
+		SELECT id, type, description, reverses_entry_id, created_at
+		FROM bank.journal_entries
+		WHERE id = $1
+		FOR UPDATE`
+	err := tx.QueryRowContext(ctx, query, id).Scan(&entry.ID, &entry.Type, &description, &reversesEntryID, &entry.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	entry.Description = description.String
+	entry.ReversesEntryID = reversesEntryID.Int64
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, entry_id, COALESCE(account_id, 0), COALESCE(system_account, ''), amount, currency, created_at
+		FROM bank.postings
+		WHERE entry_id = $1
+		ORDER BY id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		posting := &models.Posting{}
+		if err := rows.Scan(&posting.ID, &posting.EntryID, &posting.AccountID, &posting.SystemAccount, &posting.Amount, &posting.Currency, &posting.CreatedAt); err != nil {
+			return nil, err
+		}
+		entry.Postings = append(entry.Postings, posting)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// FindReversalID возвращает id проводки, сторнирующей указанную, или 0
+func (r *ledgerRepository) FindReversalID(ctx context.Context, tx *sql.Tx, entryID int64) (int64, error) {
+	var id int64
+	err := tx.QueryRowContext(ctx, `SELECT id FROM bank.journal_entries WHERE reverses_entry_id = $1`, entryID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return id, err
+}
@@ -0,0 +1,168 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/money"
+)
+
+type limitRepository struct {
+	db *sql.DB
+}
+
+func NewLimitRepository(db *sql.DB) LimitRepository {
+	return &limitRepository{db: db}
+}
+
+const limitColumns = `id, scope, scope_id, operation, period, currency, max_amount, max_count, source, created_at, updated_at`
+
+func scanLimit(row rowScanner) (*models.SpendingLimit, error) {
+	limit := &models.SpendingLimit{}
+	err := row.Scan(
+		&limit.ID,
+		&limit.Scope,
+		&limit.ScopeID,
+		&limit.Operation,
+		&limit.Period,
+		&limit.Currency,
+		&limit.MaxAmount,
+		&limit.MaxCount,
+		&limit.Source,
+		&limit.CreatedAt,
+		&limit.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return limit, nil
+}
+
+func scanLimits(rows *sql.Rows) ([]*models.SpendingLimit, error) {
+	defer rows.Close()
+	var limits []*models.SpendingLimit
+	for rows.Next() {
+		limit, err := scanLimit(rows)
+		if err != nil {
+			return nil, err
+		}
+		limits = append(limits, limit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return limits, nil
+}
+
+func (r *limitRepository) FindByScope(ctx context.Context, scope string, scopeID int64) ([]*models.SpendingLimit, error) {
+	query := `
+		SELECT ` + limitColumns + `
+		FROM bank.spending_limits
+		WHERE scope = $1 AND scope_id = $2
+		ORDER BY operation, period, currency, source`
+	rows, err := r.db.QueryContext(ctx, query, scope, scopeID)
+	if err != nil {
+		return nil, err
+	}
+	return scanLimits(rows)
+}
+
+// FindForUsage возвращает лимиты карты, счёта и пользователя, которые распространяются на операцию
+func (r *limitRepository) FindForUsage(ctx context.Context, tx *sql.Tx, usage *models.LimitUsage) ([]*models.SpendingLimit, error) {
+	query := `
+		SELECT ` + limitColumns + `
+		FROM bank.spending_limits
+		WHERE operation = $1 AND currency = $2
+			AND ((scope = 'card' AND scope_id = $3) OR (scope = 'account' AND scope_id = $4) OR (scope = 'user' AND scope_id = $5))`
+	rows, err := tx.QueryContext(ctx, query, usage.Operation, usage.Currency, usage.CardID, usage.AccountID, usage.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return scanLimits(rows)
+}
+
+// Upsert создаёт лимит или заменяет ограничения существующего лимита с тем же ключом и источником
+func (r *limitRepository) Upsert(ctx context.Context, tx *sql.Tx, limit *models.SpendingLimit) error {
+	query := `
+		INSERT INTO bank.spending_limits (scope, scope_id, operation, period, currency, max_amount, max_count, source, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+		ON CONFLICT (scope, scope_id, operation, period, currency, source)
+		DO UPDATE SET max_amount = EXCLUDED.max_amount, max_count = EXCLUDED.max_count, updated_at = EXCLUDED.updated_at
+		RETURNING id, created_at`
+	return tx.QueryRowContext(ctx, query,
+		limit.Scope,
+		limit.ScopeID,
+		limit.Operation,
+		limit.Period,
+		limit.Currency,
+		limit.MaxAmount,
+		limit.MaxCount,
+		limit.Source,
+		limit.UpdatedAt,
+	).Scan(&limit.ID, &limit.CreatedAt)
+}
+
+func (r *limitRepository) Delete(ctx context.Context, tx *sql.Tx, key models.LimitKey, source string) (bool, error) {
+	query := `
+		DELETE FROM bank.spending_limits
+		WHERE scope = $1 AND scope_id = $2 AND operation = $3 AND period = $4 AND currency = $5 AND source = $6`
+	result, err := tx.ExecContext(ctx, query, key.Scope, key.ScopeID, key.Operation, key.Period, key.Currency, source)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// LockUsage блокирует счётчик периода до конца транзакции и возвращает израсходованную
+// сумму и количество операций. Отсутствующий счётчик создаётся с нулевыми значениями.
+func (r *limitRepository) LockUsage(ctx context.Context, tx *sql.Tx, key models.LimitKey, periodStart time.Time) (money.Amount, int, error) {
+	query := `
+		INSERT INTO bank.limit_usage (scope, scope_id, operation, period, currency, period_start)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (scope, scope_id, operation, period, currency, period_start)
+		DO UPDATE SET amount = bank.limit_usage.amount
+		RETURNING amount, count`
+	var amount money.Amount
+	var count int
+	err := tx.QueryRowContext(ctx, query, key.Scope, key.ScopeID, key.Operation, key.Period, key.Currency, periodStart).Scan(&amount, &count)
+	if err != nil {
+		return 0, 0, err
+	}
+	return amount, count, nil
+}
+
+// AddUsage изменяет счётчик периода; при отмене операции значения уменьшаются, но не ниже нуля
+func (r *limitRepository) AddUsage(ctx context.Context, tx *sql.Tx, key models.LimitKey, periodStart time.Time, amount money.Amount, count int) error {
+	query := `
+		INSERT INTO bank.limit_usage (scope, scope_id, operation, period, currency, period_start, amount, count)
+		VALUES ($1, $2, $3, $4, $5, $6, GREATEST($7::NUMERIC, 0), GREATEST($8::INTEGER, 0))
+		ON CONFLICT (scope, scope_id, operation, period, currency, period_start)
+		DO UPDATE SET amount = GREATEST(bank.limit_usage.amount + $7::NUMERIC, 0),
+			count = GREATEST(bank.limit_usage.count + $8::INTEGER, 0)`
+	_, err := tx.ExecContext(ctx, query, key.Scope, key.ScopeID, key.Operation, key.Period, key.Currency, periodStart, amount, count)
+	return err
+}
+
+// FindUsage возвращает израсходованную часть лимита без блокировки
+func (r *limitRepository) FindUsage(ctx context.Context, key models.LimitKey, periodStart time.Time) (money.Amount, int, error) {
+	query := `
+		SELECT amount, count
+		FROM bank.limit_usage
+		WHERE scope = $1 AND scope_id = $2 AND operation = $3 AND period = $4 AND currency = $5 AND period_start = $6`
+	var amount money.Amount
+	var count int
+	err := r.db.QueryRowContext(ctx, query, key.Scope, key.ScopeID, key.Operation, key.Period, key.Currency, periodStart).Scan(&amount, &count)
+	if err == sql.ErrNoRows {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	return amount, count, nil
+}
@@ -0,0 +1,147 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/bank-service/internal/models"
+)
+
+type tokenRepository struct {
+	db *sql.DB
+}
+
+func NewTokenRepository(db *sql.DB) TokenRepository {
+	return &tokenRepository{db: db}
+}
+
+func (r *tokenRepository) CreateFamily(ctx context.Context, tx *sql.Tx, family *models.TokenFamily) error {
+	query := `
+		INSERT INTO bank.token_families (id, user_id, created_at)
+		VALUES ($1, $2, $3)`
+	_, err := tx.ExecContext(ctx, query, family.ID, family.UserID, family.CreatedAt)
+	return err
+}
+
+// RevokeFamily отзывает сессию; уже отозванная сессия сохраняет исходную причину
+func (r *tokenRepository) RevokeFamily(ctx context.Context, tx *sql.Tx, familyID, reason string, revokedAt time.Time) error {
+	query := `
+		UPDATE bank.token_families
+		SET revoked_at = $2, revoke_reason = $3
+		WHERE id = $1 AND revoked_at IS NULL`
+	_, err := tx.ExecContext(ctx, query, familyID, revokedAt, reason)
+	return err
+}
+
+// RevokeUserFamilies отзывает все активные сессии пользователя
+func (r *tokenRepository) RevokeUserFamilies(ctx context.Context, tx *sql.Tx, userID int64, reason string, revokedAt time.Time) error {
+	query := `
+		UPDATE bank.token_families
+		SET revoked_at = $2, revoke_reason = $3
+		WHERE user_id = $1 AND revoked_at IS NULL`
+	_, err := tx.ExecContext(ctx, query, userID, revokedAt, reason)
+	return err
+}
+
+func (r *tokenRepository) CreateRefreshToken(ctx context.Context, tx *sql.Tx, token *models.RefreshToken) error {
+	query := `
+		INSERT INTO bank.refresh_tokens (family_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`
+	return tx.QueryRowContext(ctx, query,
+		token.FamilyID,
+		token.TokenHash,
+		token.ExpiresAt,
+		token.CreatedAt,
+	).Scan(&token.ID)
+}
+
+// FindRefreshTokenForUpdate находит токен по хешу вместе с состоянием его семейства и блокирует
+// строку токена, чтобы два одновременных обмена одного токена не прошли оба
+func (r *tokenRepository) FindRefreshTokenForUpdate(ctx context.Context, tx *sql.Tx, tokenHash string) (*models.RefreshToken, error) {
+	token := &models.RefreshToken{}
+	var usedAt, familyRevokedAt sql.NullTime
+	query := `
+		SELECT t.id, t.family_id, f.user_id, t.token_hash, t.expires_at, t.used_at, t.created_at, f.revoked_at
+		FROM bank.refresh_tokens t
+		JOIN bank.token_families f ON f.id = t.family_id
+		WHERE t.token_hash = $1
+		FOR UPDATE OF t`
+	err := tx.QueryRowContext(ctx, query, tokenHash).Scan(
+		&token.ID,
+		&token.FamilyID,
+		&token.UserID,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&usedAt,
+		&token.CreatedAt,
+		&familyRevokedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if usedAt.Valid {
+		token.UsedAt = &usedAt.Time
+	}
+	if familyRevokedAt.Valid {
+		token.FamilyRevokedAt = &familyRevokedAt.Time
+	}
+	return token, nil
+}
+
+func (r *tokenRepository) MarkRefreshTokenUsed(ctx context.Context, tx *sql.Tx, id int64, usedAt time.Time) error {
+	_, err := tx.ExecContext(ctx, `UPDATE bank.refresh_tokens SET used_at = $2 WHERE id = $1`, id, usedAt)
+	return err
+}
+
+func (r *tokenRepository) RevokeAccessToken(ctx context.Context, tx *sql.Tx, jti string, userID int64, expiresAt time.Time) error {
+	query := `
+		INSERT INTO bank.revoked_access_tokens (jti, user_id, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (jti) DO NOTHING`
+	_, err := tx.ExecContext(ctx, query, jti, userID, expiresAt)
+	return err
+}
+
+// IsAccessTokenRevoked сообщает, отозван ли access-токен сам по себе или вместе с его сессией
+func (r *tokenRepository) IsAccessTokenRevoked(ctx context.Context, jti, familyID string) (bool, error) {
+	var revoked bool
+	query := `
+		SELECT EXISTS (SELECT 1 FROM bank.revoked_access_tokens WHERE jti = $1)
+			OR EXISTS (SELECT 1 FROM bank.token_families WHERE id = $2 AND revoked_at IS NOT NULL)`
+	err := r.db.QueryRowContext(ctx, query, jti, familyID).Scan(&revoked)
+	return revoked, err
+}
+
+// DeleteExpired удаляет записи, которые уже не могут повлиять на проверку токенов:
+// истёкшие отозванные access-токены и сессии, все refresh-токены которых истекли
+func (r *tokenRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var deleted int64
+	queries := []string{
+		`DELETE FROM bank.revoked_access_tokens WHERE expires_at < $1`,
+		`DELETE FROM bank.token_families f
+		WHERE NOT EXISTS (SELECT 1 FROM bank.refresh_tokens t WHERE t.family_id = f.id AND t.expires_at >= $1)`,
+	}
+	for _, query := range queries {
+		result, err := tx.ExecContext(ctx, query, now)
+		if err != nil {
+			return 0, err
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		deleted += rowsAffected
+	}
+	return deleted, tx.Commit()
+}
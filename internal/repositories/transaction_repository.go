@@ -3,8 +3,12 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/bank-service/internal/models"
+	"github.com/lib/pq"
 )
 
 type transactionRepository struct {
@@ -17,14 +21,16 @@ func NewTransactionRepository(db *sql.DB) TransactionRepository {
 
 func (r *transactionRepository) Create(ctx context.Context, tx *sql.Tx, transaction *models.Transaction) error {
 	query := `
-		INSERT INTO bank.transactions (account_id, amount, type, description, created_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO bank.transactions (account_id, amount, type, description, entry_id, exchange_rate, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING id`
 	err := tx.QueryRowContext(ctx, query,
 		transaction.AccountID,
 		transaction.Amount,
 		transaction.Type,
 		transaction.Description,
+		sql.NullInt64{Int64: transaction.EntryID, Valid: transaction.EntryID != 0},
+		sql.NullString{String: transaction.ExchangeRate, Valid: transaction.ExchangeRate != ""},
 		transaction.CreatedAt,
 	).Scan(&transaction.ID)
 	if err != nil {
@@ -33,13 +39,160 @@ func (r *transactionRepository) Create(ctx context.Context, tx *sql.Tx, transact
 	return nil
 }
 
-func (r *transactionRepository) FindByAccountID(ctx context.Context, accountID int64) ([]*models.Transaction, error) {
+// Find возвращает операции по счёту в порядке убывания (created_at, id).
+// Пагинация по ключу: следующая страница начинается строго после filter.After.
+func (r *transactionRepository) Find(ctx context.Context, accountID int64, filter models.TransactionFilter) ([]*models.Transaction, error) {
 	query := `
-		SELECT id, account_id, amount, type, description, created_at
+		SELECT id, account_id, amount, type, description, COALESCE(entry_id, 0), COALESCE(exchange_rate::TEXT, ''), created_at
 		FROM bank.transactions
-		WHERE account_id = $1
-		ORDER BY created_at DESC`
-	rows, err := r.db.QueryContext(ctx, query, accountID)
+		WHERE account_id = $1`
+	args := []interface{}{accountID}
+	addArg := func(value interface{}) string {
+		args = append(args, value)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if filter.From != nil {
+		query += " AND created_at >= " + addArg(*filter.From)
+	}
+	if filter.To != nil {
+		query += " AND created_at < " + addArg(*filter.To)
+	}
+	if len(filter.Types) > 0 {
+		query += " AND type = ANY(" + addArg(pq.Array(filter.Types)) + ")"
+	}
+	if filter.MinAmount != nil {
+		query += " AND ABS(amount) >= " + addArg(*filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		query += " AND ABS(amount) <= " + addArg(*filter.MaxAmount)
+	}
+	if filter.Query != "" {
+		query += ` AND description ILIKE ` + addArg("%"+escapeLike(filter.Query)+"%") + ` ESCAPE '\'`
+	}
+	if filter.After != nil {
+		query += " AND (created_at, id) < (" + addArg(filter.After.CreatedAt) + ", " + addArg(filter.After.ID) + ")"
+	}
+	query += " ORDER BY created_at DESC, id DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT " + addArg(filter.Limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []*models.Transaction
+	for rows.Next() {
+		transaction := &models.Transaction{}
+		var description sql.NullString
+		if err := rows.Scan(&transaction.ID, &transaction.AccountID, &transaction.Amount, &transaction.Type, &description, &transaction.EntryID, &transaction.ExchangeRate, &transaction.CreatedAt); err != nil {
+			return nil, err
+		}
+		transaction.Description = description.String
+		transactions = append(transactions, transaction)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE в пользовательском вводе
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// FindStatement собирает данные выписки за период [from, to) в одном снимке базы:
+// остатки на границах периода вычисляются от текущего остатка счёта за вычетом более поздних операций
+func (r *transactionRepository) FindStatement(ctx context.Context, accountID int64, from, to time.Time) (*models.Statement, error) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	statement := &models.Statement{
+		Account: &models.Account{},
+		From:    from,
+		To:      to,
+	}
+	query := `
+		SELECT a.id, a.user_id, a.balance, a.currency, a.status, a.created_at, a.updated_at,
+			a.balance - COALESCE((SELECT SUM(t.amount) FROM bank.transactions t WHERE t.account_id = a.id AND t.created_at >= $2), 0),
+			a.balance - COALESCE((SELECT SUM(t.amount) FROM bank.transactions t WHERE t.account_id = a.id AND t.created_at >= $3), 0)
+		FROM bank.accounts a
+		WHERE a.id = $1`
+	err = tx.QueryRowContext(ctx, query, accountID, from, to).Scan(
+		&statement.Account.ID,
+		&statement.Account.UserID,
+		&statement.Account.Balance,
+		&statement.Account.Currency,
+		&statement.Account.Status,
+		&statement.Account.CreatedAt,
+		&statement.Account.UpdatedAt,
+		&statement.OpeningBalance,
+		&statement.ClosingBalance,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, account_id, amount, type, description, COALESCE(entry_id, 0), COALESCE(exchange_rate::TEXT, ''), created_at
+		FROM bank.transactions
+		WHERE account_id = $1 AND created_at >= $2 AND created_at < $3
+		ORDER BY created_at, id`, accountID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		transaction := &models.Transaction{}
+		var description sql.NullString
+		if err := rows.Scan(&transaction.ID, &transaction.AccountID, &transaction.Amount, &transaction.Type, &description, &transaction.EntryID, &transaction.ExchangeRate, &transaction.CreatedAt); err != nil {
+			return nil, err
+		}
+		transaction.Description = description.String
+		statement.Transactions = append(statement.Transactions, transaction)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return statement, nil
+}
+
+func (r *transactionRepository) FindByID(ctx context.Context, id int64) (*models.Transaction, error) {
+	transaction := &models.Transaction{}
+	var description sql.NullString
+	query := `
+		SELECT id, account_id, amount, type, description, COALESCE(entry_id, 0), COALESCE(exchange_rate::TEXT, ''), created_at
+		FROM bank.transactions
+		WHERE id = $1`
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&transaction.ID, &transaction.AccountID, &transaction.Amount, &transaction.Type, &description, &transaction.EntryID, &transaction.ExchangeRate, &transaction.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	transaction.Description = description.String
+	return transaction, nil
+}
+
+// FindByEntryID возвращает операции по счетам, порождённые проводкой журнала
+func (r *transactionRepository) FindByEntryID(ctx context.Context, tx *sql.Tx, entryID int64) ([]*models.Transaction, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, account_id, amount, type, description, COALESCE(entry_id, 0), COALESCE(exchange_rate::TEXT, ''), created_at
+		FROM bank.transactions
+		WHERE entry_id = $1
+		ORDER BY id`, entryID)
 	if err != nil {
 		return nil, err
 	}
@@ -48,9 +201,11 @@ func (r *transactionRepository) FindByAccountID(ctx context.Context, accountID i
 	var transactions []*models.Transaction
 	for rows.Next() {
 		transaction := &models.Transaction{}
-		if err := rows.Scan(&transaction.ID, &transaction.AccountID, &transaction.Amount, &transaction.Type, &transaction.Description, &transaction.CreatedAt); err != nil {
+		var description sql.NullString
+		if err := rows.Scan(&transaction.ID, &transaction.AccountID, &transaction.Amount, &transaction.Type, &description, &transaction.EntryID, &transaction.ExchangeRate, &transaction.CreatedAt); err != nil {
 			return nil, err
 		}
+		transaction.Description = description.String
 		transactions = append(transactions, transaction)
 	}
 	if err := rows.Err(); err != nil {
@@ -17,13 +17,14 @@ func NewUserRepository(db *sql.DB) UserRepository {
 
 func (r *userRepository) Create(ctx context.Context, user *models.User) error {
 	query := `
-		INSERT INTO bank.users (username, email, password, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO bank.users (username, email, password, role, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id`
 	err := r.db.QueryRowContext(ctx, query,
 		user.Username,
 		user.Email,
 		user.Password,
+		user.Role,
 		user.CreatedAt,
 		user.UpdatedAt,
 	).Scan(&user.ID)
@@ -36,7 +37,7 @@ func (r *userRepository) Create(ctx context.Context, user *models.User) error {
 func (r *userRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
 	user := &models.User{}
 	query := `
-		SELECT id, username, email, password, created_at, updated_at
+		SELECT id, username, email, password, role, created_at, updated_at
 		FROM bank.users
 		WHERE email = $1`
 	err := r.db.QueryRowContext(ctx, query, email).Scan(
@@ -44,6 +45,7 @@ func (r *userRepository) FindByEmail(ctx context.Context, email string) (*models
 		&user.Username,
 		&user.Email,
 		&user.Password,
+		&user.Role,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -59,7 +61,7 @@ func (r *userRepository) FindByEmail(ctx context.Context, email string) (*models
 func (r *userRepository) FindByID(ctx context.Context, id int64) (*models.User, error) {
 	user := &models.User{}
 	query := `
-		SELECT id, username, email, password, created_at, updated_at
+		SELECT id, username, email, password, role, created_at, updated_at
 		FROM bank.users
 		WHERE id = $1`
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
@@ -67,6 +69,7 @@ func (r *userRepository) FindByID(ctx context.Context, id int64) (*models.User,
 		&user.Username,
 		&user.Email,
 		&user.Password,
+		&user.Role,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -77,4 +80,50 @@ func (r *userRepository) FindByID(ctx context.Context, id int64) (*models.User,
 		return nil, err
 	}
 	return user, nil
-}
\ No newline at end of file
+}
+
+// Search ищет пользователей по подстроке имени или email без учёта регистра
+func (r *userRepository) Search(ctx context.Context, query string, limit int) ([]*models.User, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, username, email, password, role, created_at, updated_at
+		FROM bank.users
+		WHERE username ILIKE $1 ESCAPE '\' OR email ILIKE $1 ESCAPE '\'
+		ORDER BY id
+		LIMIT $2`, "%"+escapeLike(query)+"%", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*models.User
+	for rows.Next() {
+		user := &models.User{}
+		if err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.Role, &user.CreatedAt, &user.UpdatedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// UpdateRole меняет роль пользователя; если пользователя нет, возвращает sql.ErrNoRows
+func (r *userRepository) UpdateRole(ctx context.Context, tx *sql.Tx, id int64, role string) error {
+	result, err := tx.ExecContext(ctx, `
+		UPDATE bank.users
+		SET role = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1`, id, role)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -4,31 +4,62 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strconv"
 	"sync"
 	"time"
 
+	"github.com/bank-service/internal/exchange"
 	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/money"
+	"github.com/bank-service/internal/policy"
 	"github.com/bank-service/internal/repositories"
 )
 
+const (
+	DefaultTransactionPageSize = 50
+	MaxTransactionPageSize     = 200
+)
+
+var (
+	ErrAccountFrozen     = errors.New("account is frozen")
+	ErrInsufficientFunds = errors.New("insufficient funds")
+)
+
 type accountService struct {
 	accountRepo     repositories.AccountRepository
 	userRepo        repositories.UserRepository
 	transactionRepo repositories.TransactionRepository
+	holdRepo        repositories.HoldRepository
+	ledgerService   LedgerService
+	limitService    LimitService
+	rateProvider    exchange.Provider
+	policy          policy.Policy
 	db              *sql.DB
 	mutex           sync.Mutex
 }
 
-func NewAccountService(accountRepo repositories.AccountRepository, userRepo repositories.UserRepository, transactionRepo repositories.TransactionRepository, db *sql.DB) AccountService {
+func NewAccountService(accountRepo repositories.AccountRepository, userRepo repositories.UserRepository, transactionRepo repositories.TransactionRepository, holdRepo repositories.HoldRepository, ledgerService LedgerService, limitService LimitService, rateProvider exchange.Provider, policy policy.Policy, db *sql.DB) AccountService {
 	return &accountService{
 		accountRepo:     accountRepo,
 		userRepo:        userRepo,
 		transactionRepo: transactionRepo,
+		holdRepo:        holdRepo,
+		ledgerService:   ledgerService,
+		limitService:    limitService,
+		rateProvider:    rateProvider,
+		policy:          policy,
 		db:              db,
 	}
 }
 
-func (s *accountService) CreateAccount(ctx context.Context, userID int64) (*models.Account, error) {
+func (s *accountService) CreateAccount(ctx context.Context, userID int64, currency money.Currency) (*models.Account, error) {
+	if currency == "" {
+		currency = money.RUB
+	}
+	if !currency.IsValid() {
+		return nil, money.ErrUnknownCurrency
+	}
+
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
@@ -41,11 +72,13 @@ func (s *accountService) CreateAccount(ctx context.Context, userID int64) (*mode
 	}
 
 	account := &models.Account{
-		UserID:    userID,
-		Balance:   0.0,
-		Currency:  "RUB",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		UserID:           userID,
+		Balance:          0,
+		AvailableBalance: 0,
+		Currency:         currency,
+		Status:           models.AccountStatusActive,
+		CreatedAt:        time.Now(),
+		UpdatedAt:        time.Now(),
 	}
 
 	err = s.accountRepo.Create(ctx, account)
@@ -69,13 +102,23 @@ func (s *accountService) GetAccounts(ctx context.Context, userID int64) ([]*mode
 	if err != nil {
 		return nil, err
 	}
+	for _, account := range accounts {
+		account.Holds, err = s.holdRepo.FindActiveByAccountID(ctx, account.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return accounts, nil
 }
 
-func (s *accountService) Deposit(ctx context.Context, accountID int64, amount float64) error {
-	if amount <= 0 {
+// Deposit пополняет счёт пользователя; чужой счёт даёт policy.ErrNotFound
+func (s *accountService) Deposit(ctx context.Context, userID, accountID int64, amount money.Amount) error {
+	if !amount.IsPositive() {
 		return errors.New("amount must be positive")
 	}
+	if _, err := s.policy.Account(ctx, userID, accountID); err != nil {
+		return err
+	}
 
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -83,7 +126,9 @@ func (s *accountService) Deposit(ctx context.Context, accountID int64, amount fl
 	}
 	defer tx.Rollback()
 
-	account, err := s.accountRepo.FindByID(ctx, accountID)
+	// Блокируем счёт до конца транзакции: зачисление сериализуется с другими
+	// операциями по счёту, и остаток обновляется без потерянных изменений
+	account, err := s.accountRepo.FindByIDForUpdate(ctx, tx, accountID)
 	if err != nil {
 		return err
 	}
@@ -91,17 +136,26 @@ func (s *accountService) Deposit(ctx context.Context, accountID int64, amount fl
 		return errors.New("account not found")
 	}
 
-	newBalance := account.Balance + amount
-	err = s.accountRepo.UpdateBalance(ctx, accountID, newBalance)
-	if err != nil {
+	// Пополнение: кредит клиентского счёта, дебет кассы
+	entry := &models.JournalEntry{
+		Type:        models.TransactionTypeDeposit,
+		Description: "Deposit",
+		Postings: []*models.Posting{
+			{AccountID: accountID, Amount: amount, Currency: account.Currency},
+			{SystemAccount: models.SystemAccountCashIn, Amount: amount.Neg(), Currency: account.Currency},
+		},
+		CreatedAt: time.Now(),
+	}
+	if err := s.ledgerService.Post(ctx, tx, entry); err != nil {
 		return err
 	}
 
 	transaction := &models.Transaction{
 		AccountID:   accountID,
 		Amount:      amount,
-		Type:        "deposit",
+		Type:        models.TransactionTypeDeposit,
 		Description: "Deposit",
+		EntryID:     entry.ID,
 		CreatedAt:   time.Now(),
 	}
 	err = s.transactionRepo.Create(ctx, tx, transaction)
@@ -112,10 +166,14 @@ func (s *accountService) Deposit(ctx context.Context, accountID int64, amount fl
 	return tx.Commit()
 }
 
-func (s *accountService) Withdraw(ctx context.Context, accountID int64, amount float64) error {
-	if amount <= 0 {
+// Withdraw снимает средства со счёта пользователя; чужой счёт даёт policy.ErrNotFound
+func (s *accountService) Withdraw(ctx context.Context, userID, accountID int64, amount money.Amount) error {
+	if !amount.IsPositive() {
 		return errors.New("amount must be positive")
 	}
+	if _, err := s.policy.Account(ctx, userID, accountID); err != nil {
+		return err
+	}
 
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -123,29 +181,54 @@ func (s *accountService) Withdraw(ctx context.Context, accountID int64, amount f
 	}
 	defer tx.Rollback()
 
-	account, err := s.accountRepo.FindByID(ctx, accountID)
+	// Блокируем счёт до конца транзакции, чтобы проверка остатка и списание были атомарны
+	account, err := s.accountRepo.FindByIDForUpdate(ctx, tx, accountID)
 	if err != nil {
 		return err
 	}
 	if account == nil {
 		return errors.New("account not found")
 	}
-
-	if account.Balance < amount {
-		return errors.New("insufficient funds")
+	if account.IsFrozen() {
+		return ErrAccountFrozen
 	}
 
-	newBalance := account.Balance - amount
-	err = s.accountRepo.UpdateBalance(ctx, accountID, newBalance)
+	// Заблокированные холдами средства снять нельзя
+	if account.AvailableBalance.Cmp(amount) < 0 {
+		return ErrInsufficientFunds
+	}
+	err = s.limitService.Consume(ctx, tx, &models.LimitUsage{
+		Operation: models.LimitOperationWithdrawal,
+		AccountID: account.ID,
+		UserID:    account.UserID,
+		Currency:  account.Currency,
+		Amount:    amount,
+		At:        time.Now(),
+	})
 	if err != nil {
 		return err
 	}
 
+	// Снятие: дебет клиентского счёта, кредит выдачи наличных
+	entry := &models.JournalEntry{
+		Type:        models.TransactionTypeWithdrawal,
+		Description: "Withdrawal",
+		Postings: []*models.Posting{
+			{AccountID: accountID, Amount: amount.Neg(), Currency: account.Currency},
+			{SystemAccount: models.SystemAccountCashOut, Amount: amount, Currency: account.Currency},
+		},
+		CreatedAt: time.Now(),
+	}
+	if err := s.ledgerService.Post(ctx, tx, entry); err != nil {
+		return err
+	}
+
 	transaction := &models.Transaction{
 		AccountID:   accountID,
-		Amount:      -amount,
-		Type:        "withdrawal",
+		Amount:      amount.Neg(),
+		Type:        models.TransactionTypeWithdrawal,
 		Description: "Withdrawal",
+		EntryID:     entry.ID,
 		CreatedAt:   time.Now(),
 	}
 	err = s.transactionRepo.Create(ctx, tx, transaction)
@@ -156,53 +239,102 @@ func (s *accountService) Withdraw(ctx context.Context, accountID int64, amount f
 	return tx.Commit()
 }
 
-func (s *accountService) Transfer(ctx context.Context, fromAccountID, toAccountID int64, amount float64) error {
-	if amount <= 0 {
+// Transfer переводит средства со счёта пользователя на любой счёт банка;
+// чужой счёт списания даёт policy.ErrNotFound
+func (s *accountService) Transfer(ctx context.Context, userID, fromAccountID, toAccountID int64, amount money.Amount) error {
+	if !amount.IsPositive() {
 		return errors.New("amount must be positive")
 	}
-
-	tx, err := s.db.BeginTx(ctx, nil)
-	if err != nil {
+	if _, err := s.policy.Account(ctx, userID, fromAccountID); err != nil {
 		return err
 	}
-	defer tx.Rollback()
 
-	fromAccount, err := s.accountRepo.FindByID(ctx, fromAccountID)
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	if fromAccount == nil {
-		return errors.New("source account not found")
-	}
+	defer tx.Rollback()
 
-	if fromAccount.Balance < amount {
-		return errors.New("insufficient funds")
+	if fromAccountID == toAccountID {
+		return errors.New("cannot transfer to the same account")
 	}
 
-	toAccount, err := s.accountRepo.FindByID(ctx, toAccountID)
+	// Оба счёта блокируются в детерминированном порядке до проверки остатка
+	locked, err := s.accountRepo.LockByIDs(ctx, tx, fromAccountID, toAccountID)
 	if err != nil {
 		return err
 	}
-	if toAccount == nil {
+	fromAccount, ok := locked[fromAccountID]
+	if !ok {
+		return errors.New("source account not found")
+	}
+	toAccount, ok := locked[toAccountID]
+	if !ok {
 		return errors.New("destination account not found")
 	}
+	// Замороженный счёт может получать переводы, но не отправлять их
+	if fromAccount.IsFrozen() {
+		return ErrAccountFrozen
+	}
 
-	err = s.accountRepo.UpdateBalance(ctx, fromAccountID, fromAccount.Balance-amount)
+	if fromAccount.AvailableBalance.Cmp(amount) < 0 {
+		return ErrInsufficientFunds
+	}
+	// Лимиты переводов считаются в валюте счёта списания
+	err = s.limitService.Consume(ctx, tx, &models.LimitUsage{
+		Operation: models.LimitOperationTransfer,
+		AccountID: fromAccount.ID,
+		UserID:    fromAccount.UserID,
+		Currency:  fromAccount.Currency,
+		Amount:    amount,
+		At:        time.Now(),
+	})
 	if err != nil {
 		return err
 	}
 
-	err = s.accountRepo.UpdateBalance(ctx, toAccountID, toAccount.Balance+amount)
-	if err != nil {
+	// Сумма перевода задаётся в валюте счёта списания. Если валюты счетов различаются,
+	// зачисляемая сумма пересчитывается по курсу, а проводка проходит через валютную позицию банка.
+	credited := amount
+	exchangeRate := ""
+	postings := []*models.Posting{
+		{AccountID: fromAccountID, Amount: amount.Neg(), Currency: fromAccount.Currency},
+	}
+	if fromAccount.Currency != toAccount.Currency {
+		rate, err := s.rateProvider.Rate(ctx, fromAccount.Currency, toAccount.Currency)
+		if err != nil {
+			return err
+		}
+		credited = rate.Convert(amount)
+		if !credited.IsPositive() {
+			return errors.New("amount is too small to convert")
+		}
+		exchangeRate = rate.String()
+		postings = append(postings,
+			&models.Posting{SystemAccount: models.SystemAccountFXPosition, Amount: amount, Currency: fromAccount.Currency},
+			&models.Posting{SystemAccount: models.SystemAccountFXPosition, Amount: credited.Neg(), Currency: toAccount.Currency},
+		)
+	}
+	postings = append(postings, &models.Posting{AccountID: toAccountID, Amount: credited, Currency: toAccount.Currency})
+
+	entry := &models.JournalEntry{
+		Type:        models.TransactionTypeTransfer,
+		Description: "Transfer from account " + strconv.FormatInt(fromAccountID, 10) + " to account " + strconv.FormatInt(toAccountID, 10),
+		Postings:    postings,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.ledgerService.Post(ctx, tx, entry); err != nil {
 		return err
 	}
 
 	fromTransaction := &models.Transaction{
-		AccountID:   fromAccountID,
-		Amount:      -amount,
-		Type:        "transfer_out",
-		Description: "Transfer to account " + string(toAccountID),
-		CreatedAt:   time.Now(),
+		AccountID:    fromAccountID,
+		Amount:       amount.Neg(),
+		Type:         models.TransactionTypeTransferOut,
+		Description:  "Transfer to account " + strconv.FormatInt(toAccountID, 10),
+		EntryID:      entry.ID,
+		ExchangeRate: exchangeRate,
+		CreatedAt:    time.Now(),
 	}
 	err = s.transactionRepo.Create(ctx, tx, fromTransaction)
 	if err != nil {
@@ -210,11 +342,13 @@ func (s *accountService) Transfer(ctx context.Context, fromAccountID, toAccountI
 	}
 
 	toTransaction := &models.Transaction{
-		AccountID:   toAccountID,
-		Amount:      amount,
-		Type:        "transfer_in",
-		Description: "Transfer from account " + string(fromAccountID),
-		CreatedAt:   time.Now(),
+		AccountID:    toAccountID,
+		Amount:       credited,
+		Type:         models.TransactionTypeTransferIn,
+		Description:  "Transfer from account " + strconv.FormatInt(fromAccountID, 10),
+		EntryID:      entry.ID,
+		ExchangeRate: exchangeRate,
+		CreatedAt:    time.Now(),
 	}
 	err = s.transactionRepo.Create(ctx, tx, toTransaction)
 	if err != nil {
@@ -224,23 +358,122 @@ func (s *accountService) Transfer(ctx context.Context, fromAccountID, toAccountI
 	return tx.Commit()
 }
 
-func (s *accountService) GetTransactions(ctx context.Context, accountID, userID int64) ([]*models.Transaction, error) {
-	// Проверяем, существует ли счёт и принадлежит ли он пользователю
-	account, err := s.accountRepo.FindByID(ctx, accountID)
+// PostOperation проводит операцию по счёту в транзакции вызывающего: блокирует счёт,
+// перед списанием проверяет его статус и доступный остаток, создаёт проводку и запись
+// в истории операций. Лимиты расходных операций и владелец счёта не проверяются:
+// операции проводят сервисы банка, например выдача и погашение кредитов.
+func (s *accountService) PostOperation(ctx context.Context, tx *sql.Tx, op *models.AccountOperation) (*models.Transaction, error) {
+	if op.Amount.IsZero() {
+		return nil, errors.New("amount must not be zero")
+	}
+
+	account, err := s.accountRepo.FindByIDForUpdate(ctx, tx, op.AccountID)
 	if err != nil {
 		return nil, err
 	}
 	if account == nil {
 		return nil, errors.New("account not found")
 	}
-	if account.UserID != userID {
-		return nil, errors.New("unauthorized access to account")
+	if op.Amount.IsNegative() {
+		if account.IsFrozen() {
+			return nil, ErrAccountFrozen
+		}
+		if account.AvailableBalance.Cmp(op.Amount.Neg()) < 0 {
+			return nil, ErrInsufficientFunds
+		}
+	}
+
+	postings := []*models.Posting{{AccountID: account.ID, Amount: op.Amount, Currency: account.Currency}}
+	for _, counterpart := range op.Counterparts {
+		postings = append(postings, &models.Posting{
+			SystemAccount: counterpart.SystemAccount,
+			Amount:        counterpart.Amount,
+			Currency:      account.Currency,
+		})
+	}
+	entry := &models.JournalEntry{
+		Type:        op.Type,
+		Description: op.Description,
+		Postings:    postings,
+		CreatedAt:   op.CreatedAt,
+	}
+	if err := s.ledgerService.Post(ctx, tx, entry); err != nil {
+		return nil, err
 	}
 
-	// Получаем транзакции
-	transactions, err := s.transactionRepo.FindByAccountID(ctx, accountID)
+	transaction := &models.Transaction{
+		AccountID:   account.ID,
+		Amount:      op.Amount,
+		Type:        op.Type,
+		Description: op.Description,
+		EntryID:     entry.ID,
+		CreatedAt:   op.CreatedAt,
+	}
+	if err := s.transactionRepo.Create(ctx, tx, transaction); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+func (s *accountService) GetTransactions(ctx context.Context, accountID, userID int64, filter models.TransactionFilter) (*models.TransactionPage, error) {
+	// Проверяем, существует ли счёт и принадлежит ли он пользователю
+	if _, err := s.policy.Account(ctx, userID, accountID); err != nil {
+		return nil, err
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultTransactionPageSize
+	}
+	if filter.Limit > MaxTransactionPageSize {
+		filter.Limit = MaxTransactionPageSize
+	}
+	for _, transactionType := range filter.Types {
+		if !models.IsTransactionType(transactionType) {
+			return nil, errors.New("unknown transaction type: " + transactionType)
+		}
+	}
+
+	// Запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница
+	pageSize := filter.Limit
+	filter.Limit++
+	transactions, err := s.transactionRepo.Find(ctx, accountID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.TransactionPage{Transactions: transactions}
+	if len(transactions) > pageSize {
+		page.Transactions = transactions[:pageSize]
+		last := page.Transactions[pageSize-1]
+		cursor := &models.TransactionCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+		page.NextCursor = cursor.Encode()
+	}
+	return page, nil
+}
+
+func (s *accountService) GetStatement(ctx context.Context, accountID, userID int64, from, to time.Time) (*models.Statement, error) {
+	if !from.Before(to) {
+		return nil, errors.New("statement period start must be before its end")
+	}
+	if _, err := s.policy.Account(ctx, userID, accountID); err != nil {
+		return nil, err
+	}
+
+	statement, err := s.transactionRepo.FindStatement(ctx, accountID, from, to)
 	if err != nil {
 		return nil, err
 	}
-	return transactions, nil
+	if statement == nil {
+		return nil, errors.New("account not found")
+	}
+
+	for _, transaction := range statement.Transactions {
+		if transaction.Amount.IsNegative() {
+			statement.TotalDebits = statement.TotalDebits.Add(transaction.Amount.Abs())
+		} else {
+			statement.TotalCredits = statement.TotalCredits.Add(transaction.Amount)
+		}
+	}
+	statement.GeneratedAt = time.Now()
+	return statement, nil
 }
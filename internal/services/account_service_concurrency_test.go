@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/bank-service/internal/exchange"
+	"github.com/bank-service/internal/money"
+	"github.com/bank-service/internal/policy"
+	"github.com/bank-service/internal/repositories"
+)
+
+// concurrencyFixture — сервис счетов на реальной базе: гонки между Transfer и Withdraw
+// проверяются только на блокировках строк PostgreSQL (LockByIDs, FindByIDForUpdate)
+type concurrencyFixture struct {
+	accounts    AccountService
+	ledger      LedgerService
+	accountRepo repositories.AccountRepository
+}
+
+func newConcurrencyFixture(t *testing.T, db *sql.DB) *concurrencyFixture {
+	t.Helper()
+	userRepo := repositories.NewUserRepository(db)
+	accountRepo := repositories.NewAccountRepository(db)
+	holdRepo := repositories.NewHoldRepository(db)
+	cardRepo := repositories.NewCardRepository(db)
+	ownership := policy.New(accountRepo, cardRepo, repositories.NewCreditRepository(db), holdRepo)
+	ledger := NewLedgerService(repositories.NewLedgerRepository(db), accountRepo)
+	// Лимиты не задаются, но счётчики расхода ведутся и тоже проверяются на конкурентный доступ
+	limits := NewLimitService(repositories.NewLimitRepository(db), cardRepo, accountRepo, userRepo, ownership, nil, db)
+	accounts := NewAccountService(accountRepo, userRepo, repositories.NewTransactionRepository(db), holdRepo, ledger, limits, exchange.NewStaticProvider(money.RUB, nil), ownership, db)
+	return &concurrencyFixture{accounts: accounts, ledger: ledger, accountRepo: accountRepo}
+}
+
+// openAccounts открывает count рублёвых счетов нового пользователя с остатком initial
+// и возвращает идентификаторы пользователя и счетов
+func (f *concurrencyFixture) openAccounts(t *testing.T, db *sql.DB, count int, initial money.Amount) (int64, []int64) {
+	t.Helper()
+	ctx := context.Background()
+	user := createTestUser(t, db)
+	ids := make([]int64, count)
+	for i := range ids {
+		account, err := f.accounts.CreateAccount(ctx, user.ID, money.RUB)
+		if err != nil {
+			t.Fatalf("create account: %v", err)
+		}
+		if err := f.accounts.Deposit(ctx, user.ID, account.ID, initial); err != nil {
+			t.Fatalf("fund account: %v", err)
+		}
+		ids[i] = account.ID
+	}
+	return user.ID, ids
+}
+
+// assertBalances проверяет, что остатки неотрицательны, их сумма равна want,
+// а журнал проводок сбалансирован
+func (f *concurrencyFixture) assertBalances(t *testing.T, ids []int64, want money.Amount) {
+	t.Helper()
+	ctx := context.Background()
+	var total money.Amount
+	for _, id := range ids {
+		account, err := f.accountRepo.FindByID(ctx, id)
+		if err != nil {
+			t.Fatalf("read account %d: %v", id, err)
+		}
+		if account.Balance.IsNegative() || account.AvailableBalance.IsNegative() {
+			t.Errorf("account %d went negative: balance %s, available %s", id, account.Balance, account.AvailableBalance)
+		}
+		total = total.Add(account.Balance)
+	}
+	if total != want {
+		t.Errorf("total balance = %s, want %s (lost update)", total, want)
+	}
+	if err := f.ledger.VerifyBooks(ctx); err != nil {
+		t.Errorf("ledger is not balanced: %v", err)
+	}
+}
+
+func TestAccountServiceConcurrentTransfersAndWithdrawals(t *testing.T) {
+	db := openTestDB(t)
+	f := newConcurrencyFixture(t, db)
+
+	const (
+		accountsCount = 5
+		workers       = 16
+		operations    = 50
+	)
+	initial := money.MustParse("1000.00")
+	userID, ids := f.openAccounts(t, db, accountsCount, initial)
+	db.SetMaxOpenConns(workers)
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		withdrawn money.Amount
+		failures  []error
+	)
+	ctx := context.Background()
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			rnd := rand.New(rand.NewSource(seed))
+			for i := 0; i < operations; i++ {
+				from := ids[rnd.Intn(len(ids))]
+				to := ids[rnd.Intn(len(ids))]
+				amount := money.FromMinor(int64(rnd.Intn(50000) + 1))
+
+				var err error
+				if from == to {
+					err = f.accounts.Withdraw(ctx, userID, from, amount)
+				} else {
+					err = f.accounts.Transfer(ctx, userID, from, to, amount)
+				}
+
+				mu.Lock()
+				switch {
+				case err == nil && from == to:
+					withdrawn = withdrawn.Add(amount)
+				case err == nil, errors.Is(err, ErrInsufficientFunds):
+				default:
+					failures = append(failures, err)
+				}
+				mu.Unlock()
+			}
+		}(int64(w))
+	}
+	wg.Wait()
+
+	for _, err := range failures {
+		t.Errorf("operation failed: %v", err)
+	}
+	want := money.Amount(0)
+	for range ids {
+		want = want.Add(initial)
+	}
+	f.assertBalances(t, ids, want.Sub(withdrawn))
+}
+
+func TestAccountServiceConcurrentWithdrawalsDoNotOverdraw(t *testing.T) {
+	db := openTestDB(t)
+	f := newConcurrencyFixture(t, db)
+
+	const workers = 20
+	userID, ids := f.openAccounts(t, db, 1, money.MustParse("100.00"))
+	amount := money.MustParse("10.00")
+	db.SetMaxOpenConns(workers)
+
+	var wg sync.WaitGroup
+	results := make([]error, workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			results[w] = f.accounts.Withdraw(context.Background(), userID, ids[0], amount)
+		}(w)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range results {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, ErrInsufficientFunds):
+			t.Errorf("withdrawal failed: %v", err)
+		}
+	}
+	if succeeded != 10 {
+		t.Errorf("%d withdrawals succeeded, want exactly 10", succeeded)
+	}
+	f.assertBalances(t, ids, 0)
+}
+
+func TestAccountServiceOppositeTransfersDoNotDeadlock(t *testing.T) {
+	db := openTestDB(t)
+	f := newConcurrencyFixture(t, db)
+
+	const (
+		workers    = 8
+		operations = 25
+	)
+	initial := money.MustParse("1000.00")
+	userID, ids := f.openAccounts(t, db, 2, initial)
+	db.SetMaxOpenConns(workers)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*operations)
+	for w := 0; w < workers; w++ {
+		// Половина горутин переводит A→B, половина B→A: без упорядоченной блокировки
+		// счетов такие транзакции взаимно блокируются
+		from, to := ids[0], ids[1]
+		if w%2 == 1 {
+			from, to = to, from
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < operations; i++ {
+				err := f.accounts.Transfer(context.Background(), userID, from, to, money.MustParse("1.00"))
+				if err != nil && !errors.Is(err, ErrInsufficientFunds) {
+					errs <- err
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("transfer failed: %v", err)
+	}
+	f.assertBalances(t, ids, initial.Add(initial))
+}
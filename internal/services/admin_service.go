@@ -0,0 +1,467 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/money"
+	"github.com/bank-service/internal/repositories"
+)
+
+const (
+	DefaultUserSearchLimit = 20
+	MaxUserSearchLimit     = 100
+)
+
+// Действия сотрудников, записываемые в журнал bank.admin_actions
+const (
+	AdminActionFreezeAccount      = "freeze_account"
+	AdminActionUnfreezeAccount    = "unfreeze_account"
+	AdminActionReverseTransaction = "reverse_transaction"
+	AdminActionSetRole            = "set_role"
+	AdminActionRevealCard         = "reveal_card"
+	AdminActionSetLimit           = "set_limit"
+	AdminActionRemoveLimit        = "remove_limit"
+)
+
+type adminService struct {
+	userRepo        repositories.UserRepository
+	accountRepo     repositories.AccountRepository
+	transactionRepo repositories.TransactionRepository
+	holdRepo        repositories.HoldRepository
+	ledgerRepo      repositories.LedgerRepository
+	tokenRepo       repositories.TokenRepository
+	actionRepo      repositories.AdminActionRepository
+	accountService  AccountService
+	cardService     CardService
+	limitService    LimitService
+	ledgerService   LedgerService
+	db              *sql.DB
+}
+
+func NewAdminService(userRepo repositories.UserRepository, accountRepo repositories.AccountRepository, transactionRepo repositories.TransactionRepository, holdRepo repositories.HoldRepository, ledgerRepo repositories.LedgerRepository, tokenRepo repositories.TokenRepository, actionRepo repositories.AdminActionRepository, accountService AccountService, cardService CardService, limitService LimitService, ledgerService LedgerService, db *sql.DB) AdminService {
+	return &adminService{
+		userRepo:        userRepo,
+		accountRepo:     accountRepo,
+		transactionRepo: transactionRepo,
+		holdRepo:        holdRepo,
+		ledgerRepo:      ledgerRepo,
+		tokenRepo:       tokenRepo,
+		actionRepo:      actionRepo,
+		accountService:  accountService,
+		cardService:     cardService,
+		limitService:    limitService,
+		ledgerService:   ledgerService,
+		db:              db,
+	}
+}
+
+func (s *adminService) SearchUsers(ctx context.Context, query string, limit int) ([]*models.User, error) {
+	query = strings.TrimSpace(query)
+	if len(query) < 2 {
+		return nil, errors.New("search query must be at least 2 characters long")
+	}
+	if limit <= 0 {
+		limit = DefaultUserSearchLimit
+	}
+	if limit > MaxUserSearchLimit {
+		limit = MaxUserSearchLimit
+	}
+	return s.userRepo.Search(ctx, query, limit)
+}
+
+func (s *adminService) GetUser(ctx context.Context, userID int64) (*models.User, []*models.Account, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user == nil {
+		return nil, nil, errors.New("user not found")
+	}
+	accounts, err := s.accountService.GetAccounts(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, accounts, nil
+}
+
+func (s *adminService) GetAccount(ctx context.Context, accountID int64) (*models.Account, error) {
+	account, err := s.accountRepo.FindByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, errors.New("account not found")
+	}
+	account.Holds, err = s.holdRepo.FindActiveByAccountID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+func (s *adminService) GetAccountTransactions(ctx context.Context, accountID int64, filter models.TransactionFilter) (*models.TransactionPage, error) {
+	account, err := s.accountRepo.FindByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, errors.New("account not found")
+	}
+	// Сотрудник смотрит историю от имени владельца счёта
+	return s.accountService.GetTransactions(ctx, accountID, account.UserID, filter)
+}
+
+func (s *adminService) FreezeAccount(ctx context.Context, actorID, accountID int64, reason string) (*models.Account, error) {
+	return s.setAccountStatus(ctx, actorID, accountID, models.AccountStatusFrozen, AdminActionFreezeAccount, reason)
+}
+
+func (s *adminService) UnfreezeAccount(ctx context.Context, actorID, accountID int64, reason string) (*models.Account, error) {
+	return s.setAccountStatus(ctx, actorID, accountID, models.AccountStatusActive, AdminActionUnfreezeAccount, reason)
+}
+
+// setAccountStatus меняет статус счёта и записывает действие в журнал в одной транзакции
+func (s *adminService) setAccountStatus(ctx context.Context, actorID, accountID int64, status, action, reason string) (*models.Account, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("reason is required")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	account, err := s.accountRepo.FindByIDForUpdate(ctx, tx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, errors.New("account not found")
+	}
+	if account.Status == status {
+		return nil, errors.New("account is already " + status)
+	}
+
+	if err := s.accountRepo.SetStatus(ctx, tx, accountID, status); err != nil {
+		return nil, err
+	}
+	err = s.actionRepo.Create(ctx, tx, &models.AdminAction{
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: "account",
+		TargetID:   accountID,
+		Details:    reason,
+		CreatedAt:  time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	account.Status = status
+	return account, nil
+}
+
+// ReverseTransaction сторнирует проводку, породившую операцию: в журнал добавляется
+// проводка с противоположными разносками, а по каждому затронутому счёту — операция типа reversal.
+// Исходные записи не изменяются. Сторнировать можно только один раз, сторно не сторнируется.
+func (s *adminService) ReverseTransaction(ctx context.Context, actorID, transactionID int64, reason string) ([]*models.Transaction, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("reason is required")
+	}
+
+	original, err := s.transactionRepo.FindByID(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if original == nil {
+		return nil, errors.New("transaction not found")
+	}
+	if original.EntryID == 0 {
+		return nil, errors.New("transaction has no journal entry and cannot be reversed")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Блокировка проводки не даёт двум сотрудникам сторнировать её одновременно
+	entry, err := s.ledgerRepo.FindEntryForUpdate(ctx, tx, original.EntryID)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, errors.New("journal entry not found")
+	}
+	if entry.ReversesEntryID != 0 {
+		return nil, errors.New("reversal cannot be reversed")
+	}
+	reversalID, err := s.ledgerRepo.FindReversalID(ctx, tx, entry.ID)
+	if err != nil {
+		return nil, err
+	}
+	if reversalID != 0 {
+		return nil, errors.New("transaction is already reversed")
+	}
+
+	// Счета блокируются до проверки остатка, как и при обычном переводе
+	var accountIDs []int64
+	for _, posting := range entry.Postings {
+		if posting.AccountID != 0 {
+			accountIDs = append(accountIDs, posting.AccountID)
+		}
+	}
+	locked, err := s.accountRepo.LockByIDs(ctx, tx, accountIDs...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Списание при сторно не должно задевать средства, заблокированные холдами
+	reversal := &models.JournalEntry{
+		Type:            models.TransactionTypeReversal,
+		Description:     "Reversal of entry " + strconv.FormatInt(entry.ID, 10) + ": " + reason,
+		ReversesEntryID: entry.ID,
+		CreatedAt:       time.Now(),
+	}
+	for _, posting := range entry.Postings {
+		if posting.AccountID != 0 {
+			account, ok := locked[posting.AccountID]
+			if !ok {
+				return nil, errors.New("account not found")
+			}
+			account.AvailableBalance = account.AvailableBalance.Sub(posting.Amount)
+		}
+		reversal.Postings = append(reversal.Postings, &models.Posting{
+			AccountID:     posting.AccountID,
+			SystemAccount: posting.SystemAccount,
+			Amount:        posting.Amount.Neg(),
+			Currency:      posting.Currency,
+		})
+	}
+	for _, account := range locked {
+		if account.AvailableBalance.IsNegative() {
+			return nil, errors.New("insufficient funds to reverse transaction")
+		}
+	}
+
+	if err := s.ledgerService.Post(ctx, tx, reversal); err != nil {
+		return nil, err
+	}
+
+	originals, err := s.transactionRepo.FindByEntryID(ctx, tx, entry.ID)
+	if err != nil {
+		return nil, err
+	}
+	reversals := make([]*models.Transaction, 0, len(originals))
+	for _, transaction := range originals {
+		reversed := &models.Transaction{
+			AccountID:    transaction.AccountID,
+			Amount:       transaction.Amount.Neg(),
+			Type:         models.TransactionTypeReversal,
+			Description:  "Reversal of transaction " + strconv.FormatInt(transaction.ID, 10),
+			EntryID:      reversal.ID,
+			ExchangeRate: transaction.ExchangeRate,
+			CreatedAt:    time.Now(),
+		}
+		if err := s.transactionRepo.Create(ctx, tx, reversed); err != nil {
+			return nil, err
+		}
+		reversals = append(reversals, reversed)
+	}
+
+	err = s.actionRepo.Create(ctx, tx, &models.AdminAction{
+		ActorID:    actorID,
+		Action:     AdminActionReverseTransaction,
+		TargetType: "transaction",
+		TargetID:   transactionID,
+		Details:    reason,
+		CreatedAt:  time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return reversals, nil
+}
+
+// SetUserRole назначает пользователю роль. Действующие сессии пользователя отзываются,
+// чтобы токены со старой ролью перестали приниматься сразу, а не по истечении срока.
+// Нулевой actorID означает назначение из командной строки.
+func (s *adminService) SetUserRole(ctx context.Context, actorID, userID int64, role string) (*models.User, error) {
+	if !models.IsRole(role) {
+		return nil, errors.New("unknown role: " + role)
+	}
+	if actorID != 0 && actorID == userID {
+		return nil, errors.New("cannot change own role")
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := s.userRepo.UpdateRole(ctx, tx, userID, role); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+	now := time.Now()
+	if err := s.tokenRepo.RevokeUserFamilies(ctx, tx, userID, "role changed", now); err != nil {
+		return nil, err
+	}
+	err = s.actionRepo.Create(ctx, tx, &models.AdminAction{
+		ActorID:    actorID,
+		Action:     AdminActionSetRole,
+		TargetType: "user",
+		TargetID:   userID,
+		Details:    user.Role + " -> " + role,
+		CreatedAt:  now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	user.Role = role
+	return user, nil
+}
+
+// RevealCard раскрывает полный номер и срок действия карты. Запись в журнал действий
+// делается до расшифровки: раскрытие без следа в журнале невозможно.
+func (s *adminService) RevealCard(ctx context.Context, actorID, cardID int64, reason string) (*models.Card, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("reason is required")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = s.actionRepo.Create(ctx, tx, &models.AdminAction{
+		ActorID:    actorID,
+		Action:     AdminActionRevealCard,
+		TargetType: "card",
+		TargetID:   cardID,
+		Details:    reason,
+		CreatedAt:  time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.cardService.RevealCard(ctx, cardID)
+}
+
+// GetLimits возвращает действующие лимиты карты, счёта или пользователя
+func (s *adminService) GetLimits(ctx context.Context, scope string, scopeID int64) ([]*models.EffectiveLimit, error) {
+	return s.limitService.GetLimits(ctx, scope, scopeID)
+}
+
+// SetLimit устанавливает лимит банка для карты, счёта или пользователя вместо лимита по умолчанию
+func (s *adminService) SetLimit(ctx context.Context, actorID int64, limit *models.SpendingLimit, reason string) (*models.SpendingLimit, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("reason is required")
+	}
+	limit.Source = models.LimitSourceBank
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := s.limitService.SetLimit(ctx, tx, limit); err != nil {
+		return nil, err
+	}
+	err = s.actionRepo.Create(ctx, tx, &models.AdminAction{
+		ActorID:    actorID,
+		Action:     AdminActionSetLimit,
+		TargetType: limit.Scope,
+		TargetID:   limit.ScopeID,
+		Details:    limitDetails(limit.LimitKey, limit.MaxAmount, limit.MaxCount) + ": " + reason,
+		CreatedAt:  limit.UpdatedAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return limit, nil
+}
+
+// RemoveLimit удаляет лимит банка; снова действует лимит по умолчанию из конфигурации
+func (s *adminService) RemoveLimit(ctx context.Context, actorID int64, key models.LimitKey, reason string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return errors.New("reason is required")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.limitService.RemoveLimit(ctx, tx, key, models.LimitSourceBank); err != nil {
+		return err
+	}
+	err = s.actionRepo.Create(ctx, tx, &models.AdminAction{
+		ActorID:    actorID,
+		Action:     AdminActionRemoveLimit,
+		TargetType: key.Scope,
+		TargetID:   key.ScopeID,
+		Details:    limitDetails(key, nil, nil) + ": " + reason,
+		CreatedAt:  time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// limitDetails описывает лимит для журнала действий: "daily card_purchase RUB amount=1000.00 count=5"
+func limitDetails(key models.LimitKey, maxAmount *money.Amount, maxCount *int) string {
+	details := key.Period + " " + key.Operation + " " + string(key.Currency)
+	if maxAmount != nil {
+		details += " amount=" + maxAmount.String()
+	}
+	if maxCount != nil {
+		details += " count=" + strconv.Itoa(*maxCount)
+	}
+	return details
+}
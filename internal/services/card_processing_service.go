@@ -0,0 +1,470 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/bank-service/internal/iso8583"
+	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/money"
+	"github.com/bank-service/internal/repositories"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// cardProcessingService обрабатывает сообщения карточного процессинга: проверяет карту,
+// блокирует (авторизация) или списывает (финансовая операция) средства и отменяет их.
+// Отказ — не ошибка: он возвращается кодом ответа ISO 8583 и сохраняется вместе с сообщением.
+type cardProcessingService struct {
+	authRepo        repositories.CardAuthorizationRepository
+	cardRepo        repositories.CardRepository
+	accountRepo     repositories.AccountRepository
+	holdRepo        repositories.HoldRepository
+	ledgerRepo      repositories.LedgerRepository
+	transactionRepo repositories.TransactionRepository
+	ledgerService   LedgerService
+	limitService    LimitService
+	hmacSecret      string
+	db              *sql.DB
+}
+
+func NewCardProcessingService(authRepo repositories.CardAuthorizationRepository, cardRepo repositories.CardRepository, accountRepo repositories.AccountRepository, holdRepo repositories.HoldRepository, ledgerRepo repositories.LedgerRepository, transactionRepo repositories.TransactionRepository, ledgerService LedgerService, limitService LimitService, hmacSecret string, db *sql.DB) CardProcessingService {
+	return &cardProcessingService{
+		authRepo:        authRepo,
+		cardRepo:        cardRepo,
+		accountRepo:     accountRepo,
+		holdRepo:        holdRepo,
+		ledgerRepo:      ledgerRepo,
+		transactionRepo: transactionRepo,
+		ledgerService:   ledgerService,
+		limitService:    limitService,
+		hmacSecret:      hmacSecret,
+		db:              db,
+	}
+}
+
+// Authorize обрабатывает авторизацию (холд) или финансовую операцию (списание)
+func (s *cardProcessingService) Authorize(ctx context.Context, req *models.CardAuthorizationRequest) (*models.CardAuthorization, error) {
+	if req.Operation != models.CardOperationAuthorization && req.Operation != models.CardOperationFinancial {
+		return nil, fmt.Errorf("unsupported card operation %q", req.Operation)
+	}
+	// Повтор сообщения получает сохранённый ответ и не блокирует средства второй раз
+	existing, err := s.authRepo.FindByKey(ctx, req.CardMessageKey)
+	if err != nil || existing != nil {
+		return existing, err
+	}
+
+	now := time.Now()
+	auth := &models.CardAuthorization{
+		CardMessageKey: req.CardMessageKey,
+		RRN:            req.RRN,
+		Amount:         req.Amount,
+		CurrencyCode:   req.CurrencyCode,
+		Merchant:       req.Merchant,
+		CreatedAt:      now,
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	card, code, err := s.verifyCard(ctx, req, now)
+	if err != nil {
+		return nil, err
+	}
+	if card != nil {
+		auth.CardID = card.ID
+	}
+	if code != iso8583.ResponseApproved {
+		return s.decline(ctx, tx, auth, code)
+	}
+	if !req.Amount.IsPositive() {
+		return s.decline(ctx, tx, auth, iso8583.ResponseInvalidAmount)
+	}
+	currency, err := money.CurrencyByNumericCode(req.CurrencyCode)
+	if err != nil {
+		return s.decline(ctx, tx, auth, iso8583.ResponseInvalidTransaction)
+	}
+
+	// Блокировка счёта сериализует операции по карте с остальными списаниями
+	account, err := s.accountRepo.FindByIDForUpdate(ctx, tx, card.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case account == nil:
+		return s.decline(ctx, tx, auth, iso8583.ResponseDoNotHonor)
+	case account.IsFrozen():
+		return s.decline(ctx, tx, auth, iso8583.ResponseRestrictedCard)
+	case account.Currency != currency:
+		// Конвертация валюты операции не поддерживается симулятором
+		return s.decline(ctx, tx, auth, iso8583.ResponseInvalidTransaction)
+	case account.AvailableBalance.Cmp(req.Amount) < 0:
+		return s.decline(ctx, tx, auth, iso8583.ResponseInsufficientFunds)
+	}
+	err = s.limitService.Consume(ctx, tx, &models.LimitUsage{
+		Operation: models.LimitOperationCardPurchase,
+		CardID:    card.ID,
+		AccountID: account.ID,
+		UserID:    account.UserID,
+		Currency:  account.Currency,
+		Amount:    req.Amount,
+		At:        now,
+	})
+	var limitErr *LimitError
+	if errors.As(err, &limitErr) {
+		if limitErr.Count {
+			return s.decline(ctx, tx, auth, iso8583.ResponseExceedsCountLimit)
+		}
+		return s.decline(ctx, tx, auth, iso8583.ResponseExceedsAmountLimit)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	description := "Card purchase"
+	if req.Merchant != "" {
+		description += " at " + req.Merchant
+	}
+	if req.Operation == models.CardOperationAuthorization {
+		hold := &models.Hold{
+			AccountID:   account.ID,
+			CardID:      card.ID,
+			Amount:      req.Amount,
+			Status:      models.HoldStatusActive,
+			Description: description,
+			ExpiresAt:   now.Add(DefaultHoldTTL),
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		if err := s.holdRepo.Create(ctx, tx, hold); err != nil {
+			return nil, err
+		}
+		auth.HoldID = hold.ID
+	} else {
+		entry, err := s.debit(ctx, tx, account, req.Amount, description)
+		if err != nil {
+			return nil, err
+		}
+		auth.EntryID = entry.ID
+	}
+
+	auth.ResponseCode = iso8583.ResponseApproved
+	if auth.ApprovalCode, err = generateApprovalCode(); err != nil {
+		return nil, err
+	}
+	return s.save(ctx, tx, auth)
+}
+
+// verifyCard находит карту по HMAC номера и проверяет её статус, срок действия и CVV2.
+// Возвращает код ответа; карта возвращается, если она найдена.
+func (s *cardProcessingService) verifyCard(ctx context.Context, req *models.CardAuthorizationRequest, now time.Time) (*models.Card, string, error) {
+	card, err := s.cardRepo.FindByHMAC(ctx, cardNumberHMAC(s.hmacSecret, req.CardNumber))
+	if err != nil {
+		return nil, "", err
+	}
+	if card == nil {
+		return nil, iso8583.ResponseInvalidCardNumber, nil
+	}
+
+	switch card.Status {
+	case models.CardStatusLost:
+		return card, iso8583.ResponseLostCard, nil
+	case models.CardStatusStolen:
+		return card, iso8583.ResponseStolenCard, nil
+	case models.CardStatusExpired:
+		return card, iso8583.ResponseExpiredCard, nil
+	case models.CardStatusActive:
+	default:
+		return card, iso8583.ResponseRestrictedCard, nil
+	}
+	if !card.IsUsable(now) {
+		return card, iso8583.ResponseExpiredCard, nil
+	}
+
+	// Срок действия из поля 14 должен совпадать с выпущенным
+	if req.ExpiryDate != "" && !card.ExpiresAt.IsZero() {
+		expiresAt, err := models.CardExpiresAt(req.ExpiryDate[2:] + "/" + req.ExpiryDate[:2])
+		if err != nil || !expiresAt.Equal(card.ExpiresAt) {
+			return card, iso8583.ResponseExpiredCard, nil
+		}
+	}
+	if req.CVV != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(card.CVV), []byte(req.CVV)); err != nil {
+			return card, iso8583.ResponseCVVMismatch, nil
+		}
+	}
+	return card, iso8583.ResponseApproved, nil
+}
+
+// debit списывает сумму покупки со счёта карты на счёт расчётов с платёжной системой
+func (s *cardProcessingService) debit(ctx context.Context, tx *sql.Tx, account *models.Account, amount money.Amount, description string) (*models.JournalEntry, error) {
+	entry := &models.JournalEntry{
+		Type:        models.TransactionTypeCardPurchase,
+		Description: description,
+		Postings: []*models.Posting{
+			{AccountID: account.ID, Amount: amount.Neg(), Currency: account.Currency},
+			{SystemAccount: models.SystemAccountCardSettlement, Amount: amount, Currency: account.Currency},
+		},
+		CreatedAt: time.Now(),
+	}
+	if err := s.ledgerService.Post(ctx, tx, entry); err != nil {
+		return nil, err
+	}
+	transaction := &models.Transaction{
+		AccountID:   account.ID,
+		Amount:      amount.Neg(),
+		Type:        models.TransactionTypeCardPurchase,
+		Description: description,
+		EntryID:     entry.ID,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.transactionRepo.Create(ctx, tx, transaction); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Reverse отменяет авторизацию (снимает холд) или финансовую операцию (сторнирует списание).
+// Отмена уже отменённой или отклонённой операции подтверждается без изменений.
+func (s *cardProcessingService) Reverse(ctx context.Context, req *models.CardAuthorizationRequest) (*models.CardAuthorization, error) {
+	if req.Original == nil {
+		return nil, errors.New("original message is required")
+	}
+	existing, err := s.authRepo.FindByKey(ctx, req.CardMessageKey)
+	if err != nil || existing != nil {
+		return existing, err
+	}
+
+	now := time.Now()
+	auth := &models.CardAuthorization{
+		CardMessageKey: req.CardMessageKey,
+		RRN:            req.RRN,
+		Amount:         req.Amount,
+		CurrencyCode:   req.CurrencyCode,
+		Merchant:       req.Merchant,
+		CreatedAt:      now,
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	original, err := s.authRepo.FindByKeyForUpdate(ctx, tx, *req.Original)
+	if err != nil {
+		return nil, err
+	}
+	if original == nil {
+		return s.decline(ctx, tx, auth, iso8583.ResponseOriginalNotFound)
+	}
+	auth.CardID = original.CardID
+	auth.OriginalID = original.ID
+	// Частичная отмена не поддерживается
+	if !req.Amount.IsZero() && req.Amount.Cmp(original.Amount) != 0 {
+		return s.decline(ctx, tx, auth, iso8583.ResponseInvalidAmount)
+	}
+
+	if original.ResponseCode == iso8583.ResponseApproved && !original.Reversed {
+		// Холд, снятый или истёкший раньше, уже вернул сумму в лимиты
+		releaseLimits := true
+		if original.HoldID != 0 {
+			code, released, err := s.releaseHold(ctx, tx, original.HoldID)
+			if err != nil {
+				return nil, err
+			}
+			if code != iso8583.ResponseApproved {
+				return s.decline(ctx, tx, auth, code)
+			}
+			releaseLimits = released
+		}
+		if original.EntryID != 0 {
+			auth.EntryID, err = s.reverseEntry(ctx, tx, original.EntryID)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if releaseLimits {
+			if err := s.releaseLimits(ctx, tx, original); err != nil {
+				return nil, err
+			}
+		}
+		if err := s.authRepo.MarkReversed(ctx, tx, original.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	auth.ResponseCode = iso8583.ResponseApproved
+	return s.save(ctx, tx, auth)
+}
+
+// releaseLimits возвращает отменённую операцию в лимиты карты, счёта и пользователя
+func (s *cardProcessingService) releaseLimits(ctx context.Context, tx *sql.Tx, original *models.CardAuthorization) error {
+	card, err := s.cardRepo.FindByID(ctx, original.CardID)
+	if err != nil {
+		return err
+	}
+	if card == nil {
+		return errors.New("card not found")
+	}
+	account, err := s.accountRepo.FindByID(ctx, card.AccountID)
+	if err != nil {
+		return err
+	}
+	if account == nil {
+		return errors.New("account not found")
+	}
+	return s.limitService.Release(ctx, tx, &models.LimitUsage{
+		Operation: models.LimitOperationCardPurchase,
+		CardID:    card.ID,
+		AccountID: account.ID,
+		UserID:    account.UserID,
+		Currency:  account.Currency,
+		Amount:    original.Amount,
+		At:        original.CreatedAt,
+	})
+}
+
+// releaseHold снимает холд авторизации и сообщает, был ли он снят этой отменой.
+// Подтверждённый (списанный) холд отменить нельзя; снятый или истёкший холд уже не блокирует
+// средства и не расходует лимиты, и отмена подтверждается без изменений. Холд, срок которого
+// прошёл, но который ещё не переведён в expired, снимается здесь же.
+func (s *cardProcessingService) releaseHold(ctx context.Context, tx *sql.Tx, holdID int64) (string, bool, error) {
+	hold, err := s.holdRepo.FindByID(ctx, holdID)
+	if err != nil {
+		return "", false, err
+	}
+	if hold == nil {
+		return iso8583.ResponseOriginalNotFound, false, nil
+	}
+	// Счёт блокируется раньше холда, как и при подтверждении холда
+	if _, err := s.accountRepo.FindByIDForUpdate(ctx, tx, hold.AccountID); err != nil {
+		return "", false, err
+	}
+	hold, err = s.holdRepo.FindByIDForUpdate(ctx, tx, holdID)
+	if err != nil {
+		return "", false, err
+	}
+	if hold.Status == models.HoldStatusCaptured {
+		return iso8583.ResponseInvalidTransaction, false, nil
+	}
+	if hold.Status != models.HoldStatusActive {
+		return iso8583.ResponseApproved, false, nil
+	}
+
+	hold.Status = models.HoldStatusReleased
+	hold.UpdatedAt = time.Now()
+	if err := s.holdRepo.Update(ctx, tx, hold); err != nil {
+		return "", false, err
+	}
+	return iso8583.ResponseApproved, true, nil
+}
+
+// reverseEntry сторнирует проводку финансовой операции: средства возвращаются на счёт карты
+func (s *cardProcessingService) reverseEntry(ctx context.Context, tx *sql.Tx, entryID int64) (int64, error) {
+	entry, err := s.ledgerRepo.FindEntryForUpdate(ctx, tx, entryID)
+	if err != nil {
+		return 0, err
+	}
+	if entry == nil {
+		return 0, errors.New("journal entry not found")
+	}
+	// Списание могли уже сторнировать вручную через /admin; повторно средства не возвращаются
+	reversalID, err := s.ledgerRepo.FindReversalID(ctx, tx, entry.ID)
+	if err != nil {
+		return 0, err
+	}
+	if reversalID != 0 {
+		return reversalID, nil
+	}
+
+	var accountIDs []int64
+	for _, posting := range entry.Postings {
+		if posting.AccountID != 0 {
+			accountIDs = append(accountIDs, posting.AccountID)
+		}
+	}
+	if _, err := s.accountRepo.LockByIDs(ctx, tx, accountIDs...); err != nil {
+		return 0, err
+	}
+
+	reversal := &models.JournalEntry{
+		Type:            models.TransactionTypeReversal,
+		Description:     "Reversal of card purchase " + strconv.FormatInt(entry.ID, 10),
+		ReversesEntryID: entry.ID,
+		CreatedAt:       time.Now(),
+	}
+	for _, posting := range entry.Postings {
+		reversal.Postings = append(reversal.Postings, &models.Posting{
+			AccountID:     posting.AccountID,
+			SystemAccount: posting.SystemAccount,
+			Amount:        posting.Amount.Neg(),
+			Currency:      posting.Currency,
+		})
+	}
+	if err := s.ledgerService.Post(ctx, tx, reversal); err != nil {
+		return 0, err
+	}
+
+	originals, err := s.transactionRepo.FindByEntryID(ctx, tx, entry.ID)
+	if err != nil {
+		return 0, err
+	}
+	for _, transaction := range originals {
+		reversed := &models.Transaction{
+			AccountID:   transaction.AccountID,
+			Amount:      transaction.Amount.Neg(),
+			Type:        models.TransactionTypeReversal,
+			Description: "Reversal of transaction " + strconv.FormatInt(transaction.ID, 10),
+			EntryID:     reversal.ID,
+			CreatedAt:   time.Now(),
+		}
+		if err := s.transactionRepo.Create(ctx, tx, reversed); err != nil {
+			return 0, err
+		}
+	}
+	return reversal.ID, nil
+}
+
+// decline сохраняет отказ. Отказ принимается до любых изменений средств,
+// поэтому в транзакции остаются только блокировки строк.
+func (s *cardProcessingService) decline(ctx context.Context, tx *sql.Tx, auth *models.CardAuthorization, code string) (*models.CardAuthorization, error) {
+	auth.ResponseCode = code
+	return s.save(ctx, tx, auth)
+}
+
+// save записывает сообщение и фиксирует транзакцию. Если параллельно уже обработан
+// повтор того же сообщения, транзакция откатывается и возвращается его ответ.
+func (s *cardProcessingService) save(ctx context.Context, tx *sql.Tx, auth *models.CardAuthorization) (*models.CardAuthorization, error) {
+	created, err := s.authRepo.Create(ctx, tx, auth)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		if err := tx.Rollback(); err != nil {
+			return nil, err
+		}
+		return s.authRepo.FindByKey(ctx, auth.CardMessageKey)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return auth, nil
+}
+
+// generateApprovalCode возвращает шестизначный код авторизации (поле 38)
+func generateApprovalCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
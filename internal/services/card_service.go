@@ -3,84 +3,372 @@ package services
 import (
 	"context"
 	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha256"
+	"database/sql"
 	"encoding/hex"
 	"errors"
+	"fmt"
+	"math/big"
+	"strings"
 	"time"
 
+	"github.com/bank-service/internal/envelope"
 	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/money"
+	"github.com/bank-service/internal/pan"
+	"github.com/bank-service/internal/policy"
 	"github.com/bank-service/internal/repositories"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// legacyCardBatchSize — сколько незашифрованных карт обрабатывается за один запрос к базе
+const legacyCardBatchSize = 100
+
+// maxCardNumberAttempts — сколько раз генерировать номер, если он совпал с уже выпущенным
+const maxCardNumberAttempts = 10
+
+// CardProduct — карточный продукт: из каких BIN выпускаются номера и сколько действует карта
+type CardProduct struct {
+	BINRanges      []pan.Range
+	ValidityMonths int
+}
+
+// Имена полей служат дополнительными данными шифрования: шифротекст номера
+// не расшифруется как срок действия и наоборот
+var (
+	cardNumberAAD = []byte("card_number")
+	expiryDateAAD = []byte("expiry_date")
+)
+
 type cardService struct {
-	cardRepo    repositories.CardRepository
-	accountRepo repositories.AccountRepository
-	hmacSecret  string
+	cardRepo       repositories.CardRepository
+	policy         policy.Policy
+	holdService    HoldService
+	keyRing        *envelope.KeyRing
+	products       map[string]CardProduct
+	defaultProduct string
+	hmacSecret     string
+	db             *sql.DB
 }
 
-func NewCardService(cardRepo repositories.CardRepository, accountRepo repositories.AccountRepository, hmacSecret string) CardService {
+func NewCardService(cardRepo repositories.CardRepository, policy policy.Policy, holdService HoldService, keyRing *envelope.KeyRing, products map[string]CardProduct, defaultProduct string, hmacSecret string, db *sql.DB) CardService {
 	return &cardService{
-		cardRepo:    cardRepo,
-		accountRepo: accountRepo,
-		hmacSecret:  hmacSecret,
+		cardRepo:       cardRepo,
+		policy:         policy,
+		holdService:    holdService,
+		keyRing:        keyRing,
+		products:       products,
+		defaultProduct: defaultProduct,
+		hmacSecret:     hmacSecret,
+		db:             db,
 	}
 }
 
-func (s *cardService) CreateCard(ctx context.Context, accountID int64, cardNumber, expiryDate, cvv string) (*models.Card, error) {
-	// Проверяем существование счёта
-	account, err := s.accountRepo.FindByID(ctx, accountID)
+// CreateCard выпускает карту продукта product (пустой — продукт по умолчанию) к счёту пользователя.
+// Номер, срок действия и CVV генерируются сервисом; CVV возвращается открытым только здесь,
+// в базе хранится лишь его хеш.
+func (s *cardService) CreateCard(ctx context.Context, userID, accountID int64, product string) (*models.Card, string, error) {
+	// Карту можно выпустить только к своему счёту
+	if _, err := s.policy.Account(ctx, userID, accountID); err != nil {
+		return nil, "", err
+	}
+
+	if product == "" {
+		product = s.defaultProduct
+	}
+	card, cvv, err := s.newCard(ctx, accountID, product)
+	if err != nil {
+		return nil, "", err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
-	if account == nil {
-		return nil, errors.New("account not found")
+	defer tx.Rollback()
+
+	if err := s.saveIssued(ctx, tx, card, userID, models.CardReasonIssued); err != nil {
+		return nil, "", err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, "", err
+	}
+	return card, cvv, nil
+}
+
+// newCard генерирует реквизиты новой карты продукта и шифрует их; карта ещё не сохранена
+func (s *cardService) newCard(ctx context.Context, accountID int64, product string) (*models.Card, string, error) {
+	cardProduct, ok := s.products[product]
+	if !ok {
+		return nil, "", errors.New("unknown card product")
 	}
 
-	// Создаём карту
+	// Генерируем номер, которого ещё нет среди выпущенных карт
+	cardNumber, mac, err := s.generateCardNumber(ctx, cardProduct)
+	if err != nil {
+		return nil, "", err
+	}
+	cvv, err := generateCVV()
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Карта действует до конца месяца, отстоящего от месяца выпуска на срок продукта
+	now := time.Now()
+	expiry := time.Date(now.Year(), now.Month()+time.Month(cardProduct.ValidityMonths), 1, 0, 0, 0, 0, time.UTC)
+
 	card := &models.Card{
 		AccountID:  accountID,
+		Product:    product,
+		Status:     models.CardStatusActive,
 		CardNumber: cardNumber,
-		ExpiryDate: expiryDate,
+		ExpiryDate: expiry.Format("01/06"),
 		CVV:        cvv,
-		CreatedAt:  time.Now(),
-		UpdatedAt:  time.Now(),
+		HMAC:       mac,
+		CreatedAt:  now,
+		UpdatedAt:  now,
 	}
 
 	// Валидируем карту
 	if err := card.Validate(); err != nil {
-		return nil, err
+		return nil, "", err
+	}
+	card.ExpiresAt, err = models.CardExpiresAt(card.ExpiryDate)
+	if err != nil {
+		return nil, "", err
 	}
-
-	// Вычисляем HMAC для card_number + expiry_date
-	hmacData := card.CardNumber + card.ExpiryDate
-	mac := hmac.New(sha256.New, []byte(s.hmacSecret))
-	mac.Write([]byte(hmacData))
-	card.HMAC = hex.EncodeToString(mac.Sum(nil))
 
 	// Хешируем CVV
 	hashedCVV, err := bcrypt.GenerateFromPassword([]byte(cvv), bcrypt.DefaultCost)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	card.CVV = string(hashedCVV)
 
-	// Сохраняем карту
-	if err := s.cardRepo.Create(ctx, card); err != nil {
+	// Номер и срок действия попадают в базу только зашифрованными
+	card.MaskedNumber = models.MaskCardNumber(card.CardNumber)
+	if err := s.encrypt(card); err != nil {
+		return nil, "", err
+	}
+	return card, cvv, nil
+}
+
+// saveIssued сохраняет выпущенную карту вместе с первой записью журнала статусов.
+// Уникальный индекс по hmac защищает от одновременного выпуска одного номера.
+func (s *cardService) saveIssued(ctx context.Context, tx *sql.Tx, card *models.Card, actorID int64, reason string) error {
+	if err := s.cardRepo.Create(ctx, tx, card); err != nil {
+		return err
+	}
+	return s.cardRepo.CreateStatusChange(ctx, tx, &models.CardStatusChange{
+		CardID:    card.ID,
+		ToStatus:  card.Status,
+		ActorID:   actorID,
+		Reason:    reason,
+		CreatedAt: card.CreatedAt,
+	})
+}
+
+func (s *cardService) BlockCard(ctx context.Context, userID, cardID int64, reason string) (*models.Card, error) {
+	return s.changeStatus(ctx, userID, cardID, models.CardStatusBlocked, reason)
+}
+
+// UnblockCard снимает блокировку; утерянную, украденную или истёкшую карту разблокировать нельзя
+func (s *cardService) UnblockCard(ctx context.Context, userID, cardID int64, reason string) (*models.Card, error) {
+	return s.changeStatus(ctx, userID, cardID, models.CardStatusActive, reason)
+}
+
+// ReportLost отмечает карту утерянной или украденной; дальше её можно только перевыпустить
+func (s *cardService) ReportLost(ctx context.Context, userID, cardID int64, stolen bool, reason string) (*models.Card, error) {
+	status := models.CardStatusLost
+	if stolen {
+		status = models.CardStatusStolen
+	}
+	return s.changeStatus(ctx, userID, cardID, status, reason)
+}
+
+func (s *cardService) CloseCard(ctx context.Context, userID, cardID int64, reason string) (*models.Card, error) {
+	return s.changeStatus(ctx, userID, cardID, models.CardStatusClosed, reason)
+}
+
+// changeStatus переводит карту пользователя в новый статус по правилам models.Card.CanTransitionTo
+func (s *cardService) changeStatus(ctx context.Context, userID, cardID int64, status, reason string) (*models.Card, error) {
+	if _, err := s.policy.Card(ctx, userID, cardID); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
 
+	// Блокируем карту, чтобы параллельные запросы не перевели её в несовместимые статусы
+	card, err := s.cardRepo.FindByIDForUpdate(ctx, tx, cardID)
+	if err != nil {
+		return nil, err
+	}
+	if card == nil {
+		return nil, errors.New("card not found")
+	}
+	if !card.CanTransitionTo(status) {
+		return nil, fmt.Errorf("card in status %s cannot be changed to %s", card.Status, status)
+	}
+	if err := s.transition(ctx, tx, card, status, userID, strings.TrimSpace(reason)); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
 	return card, nil
 }
 
-func (s *cardService) GetCards(ctx context.Context, accountID int64) ([]*models.Card, error) {
-	// Проверяем существование счёта
-	account, err := s.accountRepo.FindByID(ctx, accountID)
+// transition меняет статус заблокированной карты и записывает смену в журнал
+func (s *cardService) transition(ctx context.Context, tx *sql.Tx, card *models.Card, status string, actorID int64, reason string) error {
+	now := time.Now()
+	if err := s.cardRepo.UpdateStatus(ctx, tx, card.ID, status, now); err != nil {
+		return err
+	}
+	change := &models.CardStatusChange{
+		CardID:     card.ID,
+		FromStatus: card.Status,
+		ToStatus:   status,
+		ActorID:    actorID,
+		Reason:     reason,
+		CreatedAt:  now,
+	}
+	if err := s.cardRepo.CreateStatusChange(ctx, tx, change); err != nil {
+		return err
+	}
+	card.Status = status
+	card.UpdatedAt = now
+	return nil
+}
+
+// ReissueCard выпускает карту с новым номером взамен прежней и закрывает прежнюю.
+// Продукт сохраняется, если он ещё выпускается, иначе используется продукт по умолчанию.
+func (s *cardService) ReissueCard(ctx context.Context, userID, cardID int64) (*models.Card, string, error) {
+	old, err := s.policy.Card(ctx, userID, cardID)
+	if err != nil {
+		return nil, "", err
+	}
+	product := old.Product
+	if _, ok := s.products[product]; !ok {
+		product = s.defaultProduct
+	}
+	card, cvv, err := s.newCard(ctx, old.AccountID, product)
+	if err != nil {
+		return nil, "", err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
+		return nil, "", err
+	}
+	defer tx.Rollback()
+
+	old, err = s.cardRepo.FindByIDForUpdate(ctx, tx, cardID)
+	if err != nil {
+		return nil, "", err
+	}
+	if old == nil {
+		return nil, "", errors.New("card not found")
+	}
+	// Закрытую карту перевыпустить нельзя: в том числе это не даёт перевыпустить карту дважды
+	if !old.CanTransitionTo(models.CardStatusClosed) {
+		return nil, "", fmt.Errorf("card in status %s cannot be reissued", old.Status)
+	}
+
+	card.ReissuedFromID = old.ID
+	if err := s.saveIssued(ctx, tx, card, userID, models.CardReasonReissued); err != nil {
+		return nil, "", err
+	}
+	if err := s.transition(ctx, tx, old, models.CardStatusClosed, userID, models.CardReasonReissued); err != nil {
+		return nil, "", err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, "", err
+	}
+	return card, cvv, nil
+}
+
+func (s *cardService) GetStatusHistory(ctx context.Context, userID, cardID int64) ([]*models.CardStatusChange, error) {
+	if _, err := s.policy.Card(ctx, userID, cardID); err != nil {
 		return nil, err
 	}
-	if account == nil {
-		return nil, errors.New("account not found")
+	return s.cardRepo.FindStatusHistory(ctx, cardID)
+}
+
+// ExpireCards переводит в статус expired карты с истёкшим сроком действия. Заодно заполняет
+// срок окончания действия у карт, выпущенных до его учёта: он хранится только зашифрованным.
+func (s *cardService) ExpireCards(ctx context.Context) (int64, error) {
+	var afterID int64
+	for {
+		cards, err := s.cardRepo.FindWithoutExpiry(ctx, afterID, legacyCardBatchSize)
+		if err != nil {
+			return 0, err
+		}
+		for _, card := range cards {
+			afterID = card.ID
+			if err := s.decrypt(card); err != nil {
+				return 0, err
+			}
+			expiresAt, err := models.CardExpiresAt(card.ExpiryDate)
+			if err != nil {
+				// Срок в неверном формате остаётся пустым: такая карта не истекает автоматически
+				continue
+			}
+			if err := s.cardRepo.SetExpiresAt(ctx, card.ID, expiresAt); err != nil {
+				return 0, err
+			}
+		}
+		if len(cards) < legacyCardBatchSize {
+			break
+		}
+	}
+	return s.cardRepo.ExpireDue(ctx, time.Now())
+}
+
+// generateCardNumber генерирует номер из BIN продукта и проверяет его уникальность по HMAC
+func (s *cardService) generateCardNumber(ctx context.Context, product CardProduct) (string, string, error) {
+	for attempt := 0; attempt < maxCardNumberAttempts; attempt++ {
+		cardNumber, err := pan.Generate(product.BINRanges)
+		if err != nil {
+			return "", "", err
+		}
+		mac := cardNumberHMAC(s.hmacSecret, cardNumber)
+		exists, err := s.cardRepo.ExistsByHMAC(ctx, mac)
+		if err != nil {
+			return "", "", err
+		}
+		if !exists {
+			return cardNumber, mac, nil
+		}
+	}
+	return "", "", errors.New("failed to generate a unique card number")
+}
+
+// generateCVV возвращает случайный трёхзначный код
+func generateCVV() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%03d", n.Int64()), nil
+}
+
+// cardNumberHMAC вычисляет HMAC номера карты: по нему карта ищется и проверяется
+// на уникальность без расшифровки реквизитов
+func cardNumberHMAC(secret, cardNumber string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(cardNumber))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *cardService) GetCards(ctx context.Context, userID, accountID int64) ([]*models.Card, error) {
+	// Карты видны только владельцу счёта
+	if _, err := s.policy.Account(ctx, userID, accountID); err != nil {
+		return nil, err
 	}
 
 	// Получаем карты
@@ -90,3 +378,132 @@ func (s *cardService) GetCards(ctx context.Context, accountID int64) ([]*models.
 	}
 	return cards, nil
 }
+
+// Authorize авторизует покупку по карте: средства блокируются холдом на счёте карты
+// и списываются позже при подтверждении (capture) холда
+func (s *cardService) Authorize(ctx context.Context, cardID, userID int64, amount money.Amount, merchant string) (*models.Hold, error) {
+	// Проверяем, что карта выпущена к счёту пользователя
+	card, err := s.policy.Card(ctx, userID, cardID)
+	if err != nil {
+		return nil, err
+	}
+	if !card.IsUsable(time.Now()) {
+		return nil, errors.New("card is not active")
+	}
+
+	description := "Card purchase"
+	if merchant != "" {
+		description += " at " + merchant
+	}
+	return s.holdService.PlaceHold(ctx, card.AccountID, card.ID, amount, description, DefaultHoldTTL)
+}
+
+// RevealCard расшифровывает номер и срок действия карты. Проверка прав
+// и запись в журнал действий — на стороне вызывающего.
+func (s *cardService) RevealCard(ctx context.Context, cardID int64) (*models.Card, error) {
+	card, err := s.cardRepo.FindByID(ctx, cardID)
+	if err != nil {
+		return nil, err
+	}
+	if card == nil {
+		return nil, errors.New("card not found")
+	}
+	if err := s.decrypt(card); err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+// EncryptLegacyCards шифрует реквизиты карт, сохранённых открыто до перехода на шифрование
+func (s *cardService) EncryptLegacyCards(ctx context.Context) (int64, error) {
+	var encrypted int64
+	for {
+		cards, err := s.cardRepo.FindUnencrypted(ctx, legacyCardBatchSize)
+		if err != nil {
+			return encrypted, err
+		}
+		for _, card := range cards {
+			if err := s.encrypt(card); err != nil {
+				return encrypted, err
+			}
+			if err := s.cardRepo.SaveEncrypted(ctx, card); err != nil {
+				return encrypted, err
+			}
+			encrypted++
+		}
+		if len(cards) < legacyCardBatchSize {
+			return encrypted, nil
+		}
+	}
+}
+
+// RehashLegacyCards вычисляет HMAC номера для карт, у которых он не заполнен после перехода
+// на поиск карт по номеру. Карты с номером, совпавшим с уже учтённой картой, пропускаются.
+func (s *cardService) RehashLegacyCards(ctx context.Context) (int64, int64, error) {
+	var rehashed, duplicates, afterID int64
+	for {
+		cards, err := s.cardRepo.FindWithoutHMAC(ctx, afterID, legacyCardBatchSize)
+		if err != nil {
+			return rehashed, duplicates, err
+		}
+		for _, card := range cards {
+			afterID = card.ID
+			if err := s.decrypt(card); err != nil {
+				return rehashed, duplicates, err
+			}
+			ok, err := s.cardRepo.SetHMAC(ctx, card.ID, cardNumberHMAC(s.hmacSecret, card.CardNumber))
+			if err != nil {
+				return rehashed, duplicates, err
+			}
+			if ok {
+				rehashed++
+			} else {
+				duplicates++
+			}
+		}
+		if len(cards) < legacyCardBatchSize {
+			return rehashed, duplicates, nil
+		}
+	}
+}
+
+// encrypt шифрует номер и срок действия карты новым ключом данных
+func (s *cardService) encrypt(card *models.Card) error {
+	dataKey, err := s.keyRing.NewDataKey()
+	if err != nil {
+		return err
+	}
+	cardNumber, err := dataKey.Encrypt([]byte(card.CardNumber), cardNumberAAD)
+	if err != nil {
+		return err
+	}
+	expiryDate, err := dataKey.Encrypt([]byte(card.ExpiryDate), expiryDateAAD)
+	if err != nil {
+		return err
+	}
+	card.Encrypted = models.EncryptedCardData{
+		KeyID:      dataKey.KeyID,
+		DataKey:    dataKey.Wrapped,
+		CardNumber: cardNumber,
+		ExpiryDate: expiryDate,
+	}
+	return nil
+}
+
+func (s *cardService) decrypt(card *models.Card) error {
+	dataKey, err := s.keyRing.OpenDataKey(card.Encrypted.KeyID, card.Encrypted.DataKey)
+	if err != nil {
+		return err
+	}
+	cardNumber, err := dataKey.Decrypt(card.Encrypted.CardNumber, cardNumberAAD)
+	if err != nil {
+		return err
+	}
+	expiryDate, err := dataKey.Decrypt(card.Encrypted.ExpiryDate, expiryDateAAD)
+	if err != nil {
+		return err
+	}
+	card.CardNumber = string(cardNumber)
+	card.ExpiryDate = string(expiryDate)
+	return nil
+}
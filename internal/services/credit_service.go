@@ -2,27 +2,63 @@ package services
 
 import (
 	"context"
+	"database/sql"
 	"errors"
-	"math"
+	"fmt"
+	"math/big"
+	"strconv"
 	"time"
 
+	"github.com/bank-service/internal/amortization"
 	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/money"
+	"github.com/bank-service/internal/policy"
 	"github.com/bank-service/internal/repositories"
 )
 
+// ErrCreditRepaid — по кредиту не осталось непогашенных платежей
+var ErrCreditRepaid = errors.New("credit is already repaid")
+
+// dueCreditBatchSize — сколько кредитов с наступившими платежами выбирается за один запрос к базе
+const dueCreditBatchSize = 100
+
+// OverduePolicy — условия обслуживания просроченных платежей
+type OverduePolicy struct {
+	// PenaltyFee — штраф, начисляемый один раз на каждый просроченный платёж
+	PenaltyFee money.Amount
+	// PenaltyDailyRate — пени за день как доля просроченной суммы, до применения предела закона
+	PenaltyDailyRate *big.Rat
+	// DefaultAfterDays — через сколько дней просрочки кредит становится дефолтным
+	DefaultAfterDays int
+}
+
 type creditService struct {
-	creditRepo repositories.CreditRepository
-	userRepo   repositories.UserRepository
+	creditRepo     repositories.CreditRepository
+	userRepo       repositories.UserRepository
+	accountService AccountService
+	policy         policy.Policy
+	overdue        OverduePolicy
+	db             *sql.DB
 }
 
-func NewCreditService(creditRepo repositories.CreditRepository, userRepo repositories.UserRepository) CreditService {
+func NewCreditService(creditRepo repositories.CreditRepository, userRepo repositories.UserRepository, accountService AccountService, policy policy.Policy, overdue OverduePolicy, db *sql.DB) CreditService {
+	if overdue.PenaltyDailyRate == nil {
+		overdue.PenaltyDailyRate = new(big.Rat)
+	}
 	return &creditService{
-		creditRepo: creditRepo,
-		userRepo:   userRepo,
+		creditRepo:     creditRepo,
+		userRepo:       userRepo,
+		accountService: accountService,
+		policy:         policy,
+		overdue:        overdue,
+		db:             db,
 	}
 }
 
-func (s *creditService) CreateCredit(ctx context.Context, userID int64, amount, interestRate float64, termMonths int) (*models.Credit, error) {
+// CreateCredit оформляет кредит с графиком типа scheduleType (по умолчанию аннуитетным) и процентами
+// по конвенции dayCount (по умолчанию 30/360) и в той же транзакции зачисляет его сумму на счёт
+// accountID, с которого затем списываются платежи
+func (s *creditService) CreateCredit(ctx context.Context, userID, accountID int64, amount money.Amount, interestRate float64, termMonths int, scheduleType, dayCount string) (*models.Credit, error) {
 	// Проверяем, существует ли пользователь
 	user, err := s.userRepo.FindByID(ctx, userID)
 	if err != nil {
@@ -32,53 +68,89 @@ func (s *creditService) CreateCredit(ctx context.Context, userID int64, amount,
 		return nil, errors.New("user not found")
 	}
 
+	// Кредит выдаётся только на собственный действующий счёт
+	account, err := s.policy.Account(ctx, userID, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account.IsFrozen() {
+		return nil, ErrAccountFrozen
+	}
+
 	// Создаём кредит
 	credit := &models.Credit{
 		UserID:       userID,
+		AccountID:    accountID,
 		Amount:       amount,
+		Currency:     account.Currency,
 		InterestRate: interestRate,
 		TermMonths:   termMonths,
+		ScheduleType: scheduleType,
+		DayCount:     dayCount,
+		Status:       models.CreditStatusCurrent,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 	}
 
+	if credit.ScheduleType == "" {
+		credit.ScheduleType = models.ScheduleTypeAnnuity
+	}
+	if credit.DayCount == "" {
+		credit.DayCount = models.DayCount30360
+	}
+
 	// Валидируем кредит
 	if err := credit.Validate(); err != nil {
 		return nil, err
 	}
+	generator, err := amortization.GeneratorFor(credit.ScheduleType)
+	if err != nil {
+		return nil, err
+	}
 
-	// Сохраняем кредит
-	if err := s.creditRepo.CreateCredit(ctx, credit); err != nil {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
 
-	// Вычисляем аннуитетный платёж
-	monthlyRate := interestRate / 100 / 12
-	annuityFactor := (monthlyRate * math.Pow(1+monthlyRate, float64(termMonths))) / (math.Pow(1+monthlyRate, float64(termMonths)) - 1)
-	monthlyPayment := amount * annuityFactor
+	// Выдача: кредит клиентского счёта, дебет счёта выданных кредитов
+	disbursement, err := s.accountService.PostOperation(ctx, tx, &models.AccountOperation{
+		AccountID:   accountID,
+		Type:        models.TransactionTypeCreditDisbursement,
+		Description: "Credit disbursement",
+		Amount:      amount,
+		Counterparts: []*models.Posting{
+			{SystemAccount: models.SystemAccountCreditIssuance, Amount: amount.Neg()},
+		},
+		CreatedAt: credit.CreatedAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	credit.DisbursementEntryID = disbursement.EntryID
 
-	// Создаём график платежей
-	currentDate := time.Now().AddDate(0, 1, 0) // Первый платёж через месяц
-	for i := 0; i < termMonths; i++ {
-		paymentSchedule := &models.PaymentSchedule{
-			CreditID:    credit.ID,
-			PaymentDate: currentDate.AddDate(0, i, 0),
-			Amount:      math.Round(monthlyPayment*100) / 100,
-			Paid:        false,
-			Penalty:     0.0,
-			CreatedAt:   time.Now(),
-			UpdatedAt:   time.Now(),
-		}
+	// Сохраняем кредит
+	if err := s.creditRepo.CreateCredit(ctx, tx, credit); err != nil {
+		return nil, err
+	}
 
+	// Создаём график платежей, первый платёж через месяц
+	start := creditDate(credit.CreatedAt)
+	schedules := generator.Generate(amount, creditTerms(credit), start, amortization.MonthlyDates(start, termMonths))
+	for _, paymentSchedule := range schedules {
+		paymentSchedule.CreditID = credit.ID
 		if err := paymentSchedule.Validate(); err != nil {
 			return nil, err
 		}
-
-		if err := s.creditRepo.CreatePaymentSchedule(ctx, paymentSchedule); err != nil {
+		if err := s.creditRepo.CreatePaymentSchedule(ctx, tx, paymentSchedule); err != nil {
 			return nil, err
 		}
 	}
 
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
 	return credit, nil
 }
 
@@ -100,27 +172,737 @@ func (s *creditService) GetCredits(ctx context.Context, userID int64) ([]*models
 	return credits, nil
 }
 
-func (s *creditService) GetPaymentSchedules(ctx context.Context, creditID, userID int64) ([]*models.PaymentSchedule, error) {
+// GetPaymentSchedules возвращает действующий график, а с includeSuperseded — также платежи
+// графиков, заменённых после досрочных погашений
+func (s *creditService) GetPaymentSchedules(ctx context.Context, creditID, userID int64, includeSuperseded bool) ([]*models.PaymentSchedule, error) {
 	// Проверяем, существует ли кредит и принадлежит ли он пользователю
-	credits, err := s.creditRepo.FindByUserID(ctx, userID)
+	if _, err := s.policy.Credit(ctx, userID, creditID); err != nil {
+		return nil, err
+	}
+
+	// Получаем график платежей
+	schedules, err := s.creditRepo.FindPaymentSchedulesByCreditID(ctx, creditID, includeSuperseded)
+	if err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// Repay списывает со счёта кредита ближайший непогашенный платёж по графику вместе
+// с начисленной по нему неустойкой, не дожидаясь даты платежа
+func (s *creditService) Repay(ctx context.Context, userID, creditID int64) (*models.CreditPayment, error) {
+	if _, err := s.policy.Credit(ctx, userID, creditID); err != nil {
+		return nil, err
+	}
+	return s.repay(ctx, creditID, models.CreditPaymentSourceManual, false)
+}
+
+func (s *creditService) GetPayments(ctx context.Context, userID, creditID int64) ([]*models.CreditPayment, error) {
+	if _, err := s.policy.Credit(ctx, userID, creditID); err != nil {
+		return nil, err
+	}
+	return s.creditRepo.FindPaymentsByCreditID(ctx, creditID)
+}
+
+// GetSummary возвращает сводку по кредиту: внесённые платежи, остаток долга, проценты за весь срок
+// и полную стоимость кредита с учётом досрочных погашений
+func (s *creditService) GetSummary(ctx context.Context, userID, creditID int64) (*models.CreditSummary, error) {
+	credit, err := s.policy.Credit(ctx, userID, creditID)
 	if err != nil {
 		return nil, err
 	}
-	var creditExists bool
-	for _, credit := range credits {
-		if credit.ID == creditID {
-			creditExists = true
-			break
+	payments, err := s.creditRepo.FindPaymentsByCreditID(ctx, creditID)
+	if err != nil {
+		return nil, err
+	}
+	schedules, err := s.creditRepo.FindPaymentSchedulesByCreditID(ctx, creditID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &models.CreditSummary{
+		CreditID:     credit.ID,
+		Currency:     credit.Currency,
+		ScheduleType: credit.ScheduleType,
+		DayCount:     credit.DayCount,
+		InterestRate: credit.InterestRate,
+		Principal:    credit.Amount,
+		Payments:     len(payments),
+	}
+	// Денежные потоки для ПСК: выдача, внесённые платежи и оставшиеся платежи по графику.
+	// Неустойка в полную стоимость кредита не входит.
+	flows := []amortization.CashFlow{{Date: creditDate(credit.CreatedAt), Amount: credit.Amount.Neg()}}
+	for _, payment := range payments {
+		summary.PaidPrincipal = summary.PaidPrincipal.Add(payment.Principal)
+		summary.PaidInterest = summary.PaidInterest.Add(payment.Interest)
+		summary.PaidPenalty = summary.PaidPenalty.Add(payment.Penalty)
+		flows = append(flows, amortization.CashFlow{Date: creditDate(payment.CreatedAt), Amount: payment.Principal.Add(payment.Interest)})
+		if payment.CreatedAt.After(summary.MaturityDate) {
+			summary.MaturityDate = creditDate(payment.CreatedAt)
 		}
 	}
-	if !creditExists {
-		return nil, errors.New("credit not found or unauthorized")
+	summary.TotalInterest = summary.PaidInterest
+	for _, schedule := range schedules {
+		if schedule.PaymentDate.After(summary.MaturityDate) {
+			summary.MaturityDate = creditDate(schedule.PaymentDate)
+		}
+		if schedule.Paid {
+			continue
+		}
+		summary.OutstandingPrincipal = summary.OutstandingPrincipal.Add(schedule.Principal)
+		summary.TotalInterest = summary.TotalInterest.Add(schedule.Interest)
+		summary.RemainingPayments++
+		flows = append(flows, amortization.CashFlow{Date: creditDate(schedule.PaymentDate), Amount: schedule.Amount})
 	}
+	summary.TotalPayments = summary.PaidPrincipal.Add(summary.OutstandingPrincipal).Add(summary.TotalInterest)
+	summary.FullCost = summary.TotalPayments.Sub(credit.Amount)
+	summary.FullCostRate = amortization.FullCostRate(flows)
+	return summary, nil
+}
 
-	// Получаем график платежей
-	schedules, err := s.creditRepo.FindPaymentSchedulesByCreditID(ctx, creditID)
+func (s *creditService) GetPenalties(ctx context.Context, userID, creditID int64) ([]*models.CreditPenalty, error) {
+	if _, err := s.policy.Credit(ctx, userID, creditID); err != nil {
+		return nil, err
+	}
+	return s.creditRepo.FindPenaltiesByCreditID(ctx, creditID)
+}
+
+func (s *creditService) GetStatusHistory(ctx context.Context, userID, creditID int64) ([]*models.CreditStatusChange, error) {
+	if _, err := s.policy.Credit(ctx, userID, creditID); err != nil {
+		return nil, err
+	}
+	return s.creditRepo.FindStatusHistory(ctx, creditID)
+}
+
+// CollectDuePayments списывает наступившие и просроченные платежи по всем кредитам. Если на счёте
+// не хватает средств или он заморожен, платёж остаётся непогашенным до следующего запуска.
+// Ошибка по одному кредиту не останавливает обработку остальных: ошибки возвращаются вместе
+// с количеством погашенных платежей.
+func (s *creditService) CollectDuePayments(ctx context.Context) (int64, error) {
+	today := creditDate(time.Now())
+	var collected, afterID int64
+	var failures []error
+	for {
+		creditIDs, err := s.creditRepo.FindWithDuePayments(ctx, today, afterID, dueCreditBatchSize)
+		if err != nil {
+			return collected, errors.Join(append(failures, err)...)
+		}
+		for _, creditID := range creditIDs {
+			afterID = creditID
+			// Погашаем все наступившие платежи по очереди, начиная с самого раннего
+			for {
+				payment, err := s.repay(ctx, creditID, models.CreditPaymentSourceAuto, true)
+				if errors.Is(err, ErrInsufficientFunds) || errors.Is(err, ErrAccountFrozen) || errors.Is(err, ErrCreditRepaid) {
+					break
+				}
+				if err != nil {
+					failures = append(failures, fmt.Errorf("credit %d: %w", creditID, err))
+					break
+				}
+				if payment == nil {
+					break
+				}
+				collected++
+			}
+		}
+		if len(creditIDs) < dueCreditBatchSize {
+			return collected, errors.Join(failures...)
+		}
+	}
+}
+
+// ProcessOverdue начисляет неустойку по всем просроченным платежам по сегодняшний день
+// включительно и переводит кредиты с просрочкой в статусы overdue и defaulted. Как и при
+// автосписании, ошибка по одному кредиту не останавливает обработку остальных.
+// Возвращает количество начислений.
+func (s *creditService) ProcessOverdue(ctx context.Context) (int64, error) {
+	today := creditDate(time.Now())
+	var accrued, afterID int64
+	var failures []error
+	for {
+		// Просрочен платёж, не погашенный до конца дня платежа
+		creditIDs, err := s.creditRepo.FindWithDuePayments(ctx, today.AddDate(0, 0, -1), afterID, dueCreditBatchSize)
+		if err != nil {
+			return accrued, errors.Join(append(failures, err)...)
+		}
+		for _, creditID := range creditIDs {
+			afterID = creditID
+			count, err := s.processOverdue(ctx, creditID, today)
+			if err != nil {
+				failures = append(failures, fmt.Errorf("credit %d: %w", creditID, err))
+				continue
+			}
+			accrued += count
+		}
+		if len(creditIDs) < dueCreditBatchSize {
+			return accrued, errors.Join(failures...)
+		}
+	}
+}
+
+func (s *creditService) processOverdue(ctx context.Context, creditID int64, today time.Time) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	credit, err := s.creditRepo.FindByIDForUpdate(ctx, tx, creditID)
+	if err != nil {
+		return 0, err
+	}
+	if credit == nil || credit.Status == models.CreditStatusClosed {
+		return 0, nil
+	}
+	accrued, err := s.accruePenalties(ctx, tx, credit, today)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.refreshStatus(ctx, tx, credit, today); err != nil {
+		return 0, err
+	}
+	return accrued, tx.Commit()
+}
+
+// repay погашает самый ранний непогашенный платёж по кредиту вместе с неустойкой, предварительно
+// доначислив её по сегодняшний день. При dueOnly платёж с датой позже сегодняшней не погашается
+// и возвращается nil. Списание разносится на основной долг, проценты и неустойку,
+// после чего пересчитывается статус кредита.
+func (s *creditService) repay(ctx context.Context, creditID int64, source string, dueOnly bool) (*models.CreditPayment, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
-	return schedules, nil
+	defer tx.Rollback()
+
+	// Блокировка кредита упорядочивает ручные платежи, автосписание и начисление неустойки
+	credit, err := s.lockRepayable(ctx, tx, creditID)
+	if err != nil {
+		return nil, err
+	}
+
+	today := creditDate(time.Now())
+	if _, err := s.accruePenalties(ctx, tx, credit, today); err != nil {
+		return nil, err
+	}
+	schedule, err := s.creditRepo.FindNextUnpaidSchedule(ctx, tx, creditID)
+	if err != nil {
+		return nil, err
+	}
+	if schedule == nil && dueOnly {
+		return nil, nil
+	}
+	if schedule == nil {
+		return nil, ErrCreditRepaid
+	}
+	if dueOnly && creditDate(schedule.PaymentDate).After(today) {
+		return nil, nil
+	}
+
+	now := time.Now()
+	payment := &models.CreditPayment{
+		CreditID:   credit.ID,
+		ScheduleID: schedule.ID,
+		Kind:       models.CreditPaymentKindScheduled,
+		AccountID:  credit.AccountID,
+		Amount:     schedule.Amount.Add(schedule.Penalty),
+		Principal:  schedule.Principal,
+		Interest:   schedule.Interest,
+		Penalty:    schedule.Penalty,
+		Source:     source,
+		CreatedAt:  now,
+	}
+	if err := s.postPayment(ctx, tx, credit, payment, "repayment"); err != nil {
+		return nil, err
+	}
+	if err := s.creditRepo.MarkSchedulePaid(ctx, tx, schedule.ID, now); err != nil {
+		return nil, err
+	}
+	if err := s.refreshStatus(ctx, tx, credit, today); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// accruePenalties начисляет по просроченным платежам кредита штраф и пени за каждый день
+// просрочки по today включительно, продолжая с последнего начисления. Вызывающий должен
+// держать блокировку кредита. Возвращает количество начислений.
+func (s *creditService) accruePenalties(ctx context.Context, tx *sql.Tx, credit *models.Credit, today time.Time) (int64, error) {
+	schedules, err := s.creditRepo.FindOverdueSchedules(ctx, tx, credit.ID, today)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	var accrued int64
+	for _, schedule := range schedules {
+		penalties, err := s.pendingPenalties(ctx, tx, credit, schedule, today)
+		if err != nil {
+			return accrued, err
+		}
+
+		var total money.Amount
+		for _, penalty := range penalties {
+			penalty.CreatedAt = now
+			if err := s.creditRepo.CreatePenalty(ctx, tx, penalty); err != nil {
+				return accrued, err
+			}
+			total = total.Add(penalty.Amount)
+			accrued++
+		}
+		if total.IsPositive() {
+			if err := s.creditRepo.AddSchedulePenalty(ctx, tx, schedule.ID, total, now); err != nil {
+				return accrued, err
+			}
+		}
+	}
+	return accrued, nil
+}
+
+// pendingPenalties возвращает ещё не начисленную неустойку по платежу schedule за дни просрочки
+// по until включительно, не сохраняя её
+func (s *creditService) pendingPenalties(ctx context.Context, tx *sql.Tx, credit *models.Credit, schedule *models.PaymentSchedule, until time.Time) ([]*models.CreditPenalty, error) {
+	firstOverdueDay := creditDate(schedule.PaymentDate).AddDate(0, 0, 1)
+	if firstOverdueDay.After(until) {
+		return nil, nil
+	}
+	lastDaily, hasFee, err := s.creditRepo.FindPenaltyState(ctx, tx, schedule.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var penalties []*models.CreditPenalty
+	if !hasFee && s.overdue.PenaltyFee.IsPositive() {
+		penalties = append(penalties, &models.CreditPenalty{
+			CreditID:    credit.ID,
+			ScheduleID:  schedule.ID,
+			Kind:        models.PenaltyKindFee,
+			AccrualDate: firstOverdueDay,
+			Base:        schedule.Amount,
+			Amount:      s.overdue.PenaltyFee,
+		})
+	}
+	if s.overdue.PenaltyDailyRate.Sign() > 0 {
+		day := firstOverdueDay
+		if !lastDaily.IsZero() {
+			day = creditDate(lastDaily).AddDate(0, 0, 1)
+		}
+		for ; !day.After(until); day = day.AddDate(0, 0, 1) {
+			rate := s.overdue.PenaltyDailyRate
+			if limit := penaltyDailyLimit(credit, day); rate.Cmp(limit) > 0 {
+				rate = limit
+			}
+			amount := schedule.Amount.MulRat(rate, money.HalfUp)
+			if !amount.IsPositive() {
+				continue
+			}
+			percent, _ := new(big.Rat).Mul(rate, big.NewRat(100, 1)).Float64()
+			penalties = append(penalties, &models.CreditPenalty{
+				CreditID:    credit.ID,
+				ScheduleID:  schedule.ID,
+				Kind:        models.PenaltyKindDaily,
+				AccrualDate: day,
+				Base:        schedule.Amount,
+				Rate:        percent,
+				Amount:      amount,
+			})
+		}
+	}
+	return penalties, nil
+}
+
+// penaltyDailyLimit возвращает предельную ставку пени за день по закону о потребительском
+// кредите: 20% годовых, если по кредиту начисляются проценты, иначе 0,1% в день
+func penaltyDailyLimit(credit *models.Credit, day time.Time) *big.Rat {
+	if credit.InterestRate > 0 {
+		daysInYear := time.Date(day.Year(), time.December, 31, 0, 0, 0, 0, time.UTC).YearDay()
+		return big.NewRat(20, 100*int64(daysInYear))
+	}
+	return big.NewRat(1, 1000)
+}
+
+// refreshStatus переводит кредит в статус по самому раннему непогашенному платежу:
+// closed — платежей не осталось, current — просрочки нет, overdue — платёж просрочен,
+// defaulted — просрочка достигла DefaultAfterDays дней. Дефолт снимается только полным
+// погашением. Смена статуса записывается в журнал.
+func (s *creditService) refreshStatus(ctx context.Context, tx *sql.Tx, credit *models.Credit, today time.Time) error {
+	next, err := s.creditRepo.FindNextUnpaidSchedule(ctx, tx, credit.ID)
+	if err != nil {
+		return err
+	}
+
+	status, reason := credit.Status, ""
+	switch {
+	case next == nil:
+		status, reason = models.CreditStatusClosed, "all payments made"
+	case credit.Status == models.CreditStatusDefaulted:
+	case creditDate(next.PaymentDate).Before(today):
+		days := daysBetween(creditDate(next.PaymentDate), today)
+		status = models.CreditStatusOverdue
+		if days >= s.overdue.DefaultAfterDays {
+			status = models.CreditStatusDefaulted
+		}
+		reason = "payment due " + next.PaymentDate.Format("2006-01-02") + " is overdue by " + strconv.Itoa(days) + " days"
+	default:
+		status, reason = models.CreditStatusCurrent, "overdue payments made"
+	}
+	if status == credit.Status {
+		return nil
+	}
+
+	now := time.Now()
+	if err := s.creditRepo.UpdateStatus(ctx, tx, credit.ID, status, now); err != nil {
+		return err
+	}
+	err = s.creditRepo.CreateStatusChange(ctx, tx, &models.CreditStatusChange{
+		CreditID:   credit.ID,
+		FromStatus: credit.Status,
+		ToStatus:   status,
+		Reason:     reason,
+		CreatedAt:  now,
+	})
+	if err != nil {
+		return err
+	}
+	credit.Status = status
+	return nil
+}
+
+// GetPayoffQuote рассчитывает сумму полного досрочного погашения на дату date (по умолчанию —
+// сегодня). Неустойка по просроченным платежам учитывается по эту дату включительно.
+func (s *creditService) GetPayoffQuote(ctx context.Context, userID, creditID int64, date time.Time) (*models.PayoffQuote, error) {
+	credit, err := s.policy.Credit(ctx, userID, creditID)
+	if err != nil {
+		return nil, err
+	}
+	today := creditDate(time.Now())
+	if date.IsZero() {
+		date = today
+	}
+	date = creditDate(date)
+	if date.Before(today) {
+		return nil, errors.New("payoff date must not be in the past")
+	}
+	if credit.Status == models.CreditStatusClosed {
+		return nil, ErrCreditRepaid
+	}
+
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	plan, err := s.calculatePayoff(ctx, tx, credit, date)
+	if err != nil {
+		return nil, err
+	}
+	return plan.quote, nil
+}
+
+// PayOff полностью погашает кредит сегодняшним днём на сумму, которую вернул бы GetPayoffQuote:
+// наступившие платежи отмечаются оплаченными, будущие исключаются из графика
+func (s *creditService) PayOff(ctx context.Context, userID, creditID int64) (*models.CreditPayment, error) {
+	if _, err := s.policy.Credit(ctx, userID, creditID); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	credit, err := s.lockRepayable(ctx, tx, creditID)
+	if err != nil {
+		return nil, err
+	}
+	today := creditDate(time.Now())
+	if _, err := s.accruePenalties(ctx, tx, credit, today); err != nil {
+		return nil, err
+	}
+	plan, err := s.calculatePayoff(ctx, tx, credit, today)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	payment := &models.CreditPayment{
+		CreditID:  credit.ID,
+		Kind:      models.CreditPaymentKindPayoff,
+		AccountID: credit.AccountID,
+		Amount:    plan.quote.Total,
+		Principal: plan.quote.Principal,
+		Interest:  plan.quote.Interest,
+		Penalty:   plan.quote.Penalty,
+		Source:    models.CreditPaymentSourceManual,
+		CreatedAt: now,
+	}
+	if err := s.postPayment(ctx, tx, credit, payment, "payoff"); err != nil {
+		return nil, err
+	}
+	for _, schedule := range plan.due {
+		if err := s.creditRepo.MarkSchedulePaid(ctx, tx, schedule.ID, now); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.creditRepo.SupersedeUnpaidSchedules(ctx, tx, credit.ID, now); err != nil {
+		return nil, err
+	}
+	if err := s.refreshStatus(ctx, tx, credit, today); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// Prepay частично досрочно погашает кредит сегодняшним днём. Из суммы сначала погашаются
+// проценты, начисленные с начала текущего периода, остаток идёт в основной долг. Оставшиеся
+// платежи заменяются новым графиком: при reduce_term сохраняется размер платежа и сокращается
+// срок, при reduce_payment сохраняется срок и уменьшается платёж. Прежний график сохраняется
+// как история. Досрочно погашать можно, только если нет наступивших неоплаченных платежей.
+func (s *creditService) Prepay(ctx context.Context, userID, creditID int64, amount money.Amount, mode string) (*models.CreditPayment, []*models.PaymentSchedule, error) {
+	if !amount.IsPositive() {
+		return nil, nil, errors.New("amount must be positive")
+	}
+	if mode != models.PrepaymentReduceTerm && mode != models.PrepaymentReducePayment {
+		return nil, nil, errors.New("mode must be reduce_term or reduce_payment")
+	}
+	credit, err := s.policy.Credit(ctx, userID, creditID)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Платёж по графику только из процентов не зависит от срока, поэтому сокращать нечего
+	if mode == models.PrepaymentReduceTerm && credit.ScheduleType == models.ScheduleTypeInterestOnly {
+		return nil, nil, errors.New("interest_only credits support only reduce_payment prepayment")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	credit, err = s.lockRepayable(ctx, tx, creditID)
+	if err != nil {
+		return nil, nil, err
+	}
+	today := creditDate(time.Now())
+	due, err := s.creditRepo.FindOverdueSchedules(ctx, tx, credit.ID, today.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(due) > 0 {
+		return nil, nil, errors.New("due payments must be repaid before prepayment")
+	}
+	schedules, err := s.creditRepo.FindUnpaidSchedules(ctx, tx, credit.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(schedules) == 0 {
+		return nil, nil, ErrCreditRepaid
+	}
+
+	// Текущий период — период ближайшего платежа; проценты за истёкшие дни гасятся первыми
+	current := schedules[0]
+	var balance money.Amount
+	for _, schedule := range schedules {
+		balance = balance.Add(schedule.Principal)
+	}
+	terms := creditTerms(credit)
+	interest := accruedInterest(terms, current, today)
+	if amount.Cmp(interest) <= 0 {
+		return nil, nil, errors.New("prepayment must exceed accrued interest of " + interest.String())
+	}
+	principal := amount.Sub(interest)
+	if principal.Cmp(balance) >= 0 {
+		return nil, nil, errors.New("prepayment covers the whole debt, use payoff instead")
+	}
+	remaining := balance.Sub(principal)
+
+	// Новый график того же типа сохраняет даты платежей. Срок при reduce_term — наименьшее число
+	// платежей, при котором регулярный платёж не превышает прежний. Проценты за оставшиеся дни
+	// текущего периода начисляются на уменьшенный долг.
+	generator, err := amortization.GeneratorFor(credit.ScheduleType)
+	if err != nil {
+		return nil, nil, err
+	}
+	count := len(schedules)
+	if mode == models.PrepaymentReduceTerm {
+		installment := generator.Installment(balance, terms, count)
+		for n := 1; n < count; n++ {
+			if generator.Installment(remaining, terms, n).Cmp(installment) <= 0 {
+				count = n
+				break
+			}
+		}
+	}
+	dates := make([]time.Time, count)
+	for i := range dates {
+		dates[i] = creditDate(schedules[i].PaymentDate)
+	}
+	regenerated := generator.Generate(remaining, terms, today, dates)
+
+	now := time.Now()
+	payment := &models.CreditPayment{
+		CreditID:  credit.ID,
+		Kind:      models.CreditPaymentKindPrepayment,
+		AccountID: credit.AccountID,
+		Amount:    amount,
+		Principal: principal,
+		Interest:  interest,
+		Source:    models.CreditPaymentSourceManual,
+		CreatedAt: now,
+	}
+	if err := s.postPayment(ctx, tx, credit, payment, "prepayment"); err != nil {
+		return nil, nil, err
+	}
+	if err := s.creditRepo.SupersedeUnpaidSchedules(ctx, tx, credit.ID, now); err != nil {
+		return nil, nil, err
+	}
+	for _, schedule := range regenerated {
+		schedule.CreditID = credit.ID
+		if err := schedule.Validate(); err != nil {
+			return nil, nil, err
+		}
+		if err := s.creditRepo.CreatePaymentSchedule(ctx, tx, schedule); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+	return payment, regenerated, nil
+}
+
+// payoffPlan — расчёт полного досрочного погашения
+type payoffPlan struct {
+	quote *models.PayoffQuote
+	// due — наступившие к дате погашения платежи, они гасятся полностью вместе с неустойкой
+	due []*models.PaymentSchedule
+}
+
+// calculatePayoff рассчитывает полное погашение на дату date: наступившие платежи с неустойкой,
+// включая ещё не начисленную, основной долг будущих платежей и проценты за истёкшие дни
+// текущего периода
+func (s *creditService) calculatePayoff(ctx context.Context, tx *sql.Tx, credit *models.Credit, date time.Time) (*payoffPlan, error) {
+	schedules, err := s.creditRepo.FindUnpaidSchedules(ctx, tx, credit.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(schedules) == 0 {
+		return nil, ErrCreditRepaid
+	}
+
+	terms := creditTerms(credit)
+	plan := &payoffPlan{quote: &models.PayoffQuote{CreditID: credit.ID, Date: date}}
+	quote := plan.quote
+	for _, schedule := range schedules {
+		quote.Principal = quote.Principal.Add(schedule.Principal)
+		if creditDate(schedule.PaymentDate).After(date) {
+			quote.Interest = quote.Interest.Add(accruedInterest(terms, schedule, date))
+			continue
+		}
+
+		quote.Interest = quote.Interest.Add(schedule.Interest)
+		penalties, err := s.pendingPenalties(ctx, tx, credit, schedule, date)
+		if err != nil {
+			return nil, err
+		}
+		quote.Penalty = quote.Penalty.Add(schedule.Penalty)
+		for _, penalty := range penalties {
+			quote.Penalty = quote.Penalty.Add(penalty.Amount)
+		}
+		plan.due = append(plan.due, schedule)
+	}
+	quote.Total = quote.Principal.Add(quote.Interest).Add(quote.Penalty)
+	return plan, nil
+}
+
+// lockRepayable блокирует кредит, по которому можно вносить платежи
+func (s *creditService) lockRepayable(ctx context.Context, tx *sql.Tx, creditID int64) (*models.Credit, error) {
+	credit, err := s.creditRepo.FindByIDForUpdate(ctx, tx, creditID)
+	if err != nil {
+		return nil, err
+	}
+	if credit == nil {
+		return nil, errors.New("credit not found")
+	}
+	if credit.AccountID == 0 {
+		return nil, errors.New("credit has no repayment account")
+	}
+	if credit.Status == models.CreditStatusClosed {
+		return nil, ErrCreditRepaid
+	}
+	return credit, nil
+}
+
+// postPayment списывает платёж со счёта кредита и сохраняет его: дебет клиентского счёта,
+// кредит счетов выданных кредитов, процентного дохода и дохода от неустойки
+func (s *creditService) postPayment(ctx context.Context, tx *sql.Tx, credit *models.Credit, payment *models.CreditPayment, kind string) error {
+	var counterparts []*models.Posting
+	if payment.Principal.IsPositive() {
+		counterparts = append(counterparts, &models.Posting{SystemAccount: models.SystemAccountCreditIssuance, Amount: payment.Principal})
+	}
+	if payment.Interest.IsPositive() {
+		counterparts = append(counterparts, &models.Posting{SystemAccount: models.SystemAccountCreditInterest, Amount: payment.Interest})
+	}
+	if payment.Penalty.IsPositive() {
+		counterparts = append(counterparts, &models.Posting{SystemAccount: models.SystemAccountCreditPenalty, Amount: payment.Penalty})
+	}
+	transaction, err := s.accountService.PostOperation(ctx, tx, &models.AccountOperation{
+		AccountID:    credit.AccountID,
+		Type:         models.TransactionTypeCreditRepayment,
+		Description:  "Credit #" + strconv.FormatInt(credit.ID, 10) + " " + kind,
+		Amount:       payment.Amount.Neg(),
+		Counterparts: counterparts,
+		CreatedAt:    payment.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+	payment.EntryID = transaction.EntryID
+	return s.creditRepo.CreatePayment(ctx, tx, payment)
+}
+
+// creditTerms возвращает условия начисления процентов по кредиту
+func creditTerms(credit *models.Credit) amortization.Terms {
+	return amortization.NewTerms(credit.InterestRate, credit.DayCount)
+}
+
+// accruedInterest возвращает проценты, начисленные по условиям terms на долг перед платежом
+// schedule с начала его периода по date; они не превышают процентов самого платежа
+func accruedInterest(terms amortization.Terms, schedule *models.PaymentSchedule, date time.Time) money.Amount {
+	start := creditDate(schedule.PeriodStart)
+	if !date.After(start) {
+		return 0
+	}
+	if !date.Before(creditDate(schedule.PaymentDate)) {
+		return schedule.Interest
+	}
+	interest := terms.Interest(schedule.Principal.Add(schedule.RemainingPrincipal), start, date)
+	if interest.Cmp(schedule.Interest) > 0 {
+		return schedule.Interest
+	}
+	return interest
+}
+
+// daysBetween возвращает число календарных дней от from до to
+func daysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours() / 24)
+}
+
+// creditDate возвращает календарный день по UTC, в котором наступает момент t.
+// Даты платежей и начислений неустойки сравниваются только как календарные дни.
+func creditDate(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
 }
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/money"
+	"github.com/bank-service/internal/repositories"
+)
+
+// fakeCreditRepository хранит кредиты и графики в памяти. Методы, не нужные тестам,
+// достаются от встроенного интерфейса и паникуют при вызове.
+type fakeCreditRepository struct {
+	repositories.CreditRepository
+	credits   map[int64]*models.Credit
+	schedules []*models.PaymentSchedule
+	payments  []*models.CreditPayment
+}
+
+func (r *fakeCreditRepository) FindWithDuePayments(_ context.Context, date time.Time, afterID int64, limit int) ([]int64, error) {
+	due := make(map[int64]bool)
+	for _, schedule := range r.schedules {
+		credit := r.credits[schedule.CreditID]
+		if !schedule.Paid && !schedule.PaymentDate.After(date) && credit.Status != models.CreditStatusClosed && credit.ID > afterID {
+			due[credit.ID] = true
+		}
+	}
+	var ids []int64
+	for id := range due {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	return ids, nil
+}
+
+func (r *fakeCreditRepository) FindByIDForUpdate(_ context.Context, _ *sql.Tx, id int64) (*models.Credit, error) {
+	return r.credits[id], nil
+}
+
+func (r *fakeCreditRepository) FindOverdueSchedules(_ context.Context, _ *sql.Tx, creditID int64, date time.Time) ([]*models.PaymentSchedule, error) {
+	var overdue []*models.PaymentSchedule
+	for _, schedule := range r.schedules {
+		if schedule.CreditID == creditID && !schedule.Paid && schedule.PaymentDate.Before(date) {
+			overdue = append(overdue, schedule)
+		}
+	}
+	return overdue, nil
+}
+
+func (r *fakeCreditRepository) FindNextUnpaidSchedule(_ context.Context, _ *sql.Tx, creditID int64) (*models.PaymentSchedule, error) {
+	for _, schedule := range r.schedules {
+		if schedule.CreditID == creditID && !schedule.Paid {
+			return schedule, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeCreditRepository) MarkSchedulePaid(_ context.Context, _ *sql.Tx, id int64, paidAt time.Time) error {
+	for _, schedule := range r.schedules {
+		if schedule.ID == id {
+			schedule.Paid = true
+			schedule.PaidAt = &paidAt
+		}
+	}
+	return nil
+}
+
+func (r *fakeCreditRepository) CreatePayment(_ context.Context, _ *sql.Tx, payment *models.CreditPayment) error {
+	payment.ID = int64(len(r.payments) + 1)
+	r.payments = append(r.payments, payment)
+	return nil
+}
+
+func (r *fakeCreditRepository) UpdateStatus(_ context.Context, _ *sql.Tx, id int64, status string, _ time.Time) error {
+	r.credits[id].Status = status
+	return nil
+}
+
+func (r *fakeCreditRepository) CreateStatusChange(context.Context, *sql.Tx, *models.CreditStatusChange) error {
+	return nil
+}
+
+// fakeAccountService проводит операции по счетам без проверок; по счетам из failing
+// возвращает заданную ошибку
+type fakeAccountService struct {
+	AccountService
+	failing    map[int64]error
+	operations []*models.AccountOperation
+}
+
+func (s *fakeAccountService) PostOperation(_ context.Context, _ *sql.Tx, op *models.AccountOperation) (*models.Transaction, error) {
+	if err := s.failing[op.AccountID]; err != nil {
+		return nil, err
+	}
+	s.operations = append(s.operations, op)
+	return &models.Transaction{AccountID: op.AccountID, Amount: op.Amount, EntryID: int64(len(s.operations))}, nil
+}
+
+func newCollectorFixture(t *testing.T) (*creditService, *fakeCreditRepository, *fakeAccountService) {
+	t.Helper()
+	today := creditDate(time.Now())
+	repo := &fakeCreditRepository{
+		credits: map[int64]*models.Credit{
+			1: {ID: 1, AccountID: 10, Status: models.CreditStatusCurrent},
+			2: {ID: 2, AccountID: 20, Status: models.CreditStatusCurrent},
+		},
+		schedules: []*models.PaymentSchedule{
+			// У первого кредита остался только последний платёж
+			{ID: 1, CreditID: 1, PaymentDate: today, Amount: money.MustParse("100.00"), Principal: money.MustParse("100.00")},
+			{ID: 2, CreditID: 2, PaymentDate: today, Amount: money.MustParse("60.00"), Principal: money.MustParse("50.00"), Interest: money.MustParse("10.00")},
+			{ID: 3, CreditID: 2, PaymentDate: today.AddDate(0, 1, 0), Amount: money.MustParse("55.00"), Principal: money.MustParse("50.00"), Interest: money.MustParse("5.00")},
+		},
+	}
+	accounts := &fakeAccountService{failing: make(map[int64]error)}
+	service := NewCreditService(repo, nil, accounts, nil, OverduePolicy{}, openFakeDB(t)).(*creditService)
+	return service, repo, accounts
+}
+
+func TestCollectDuePaymentsContinuesAfterFinalInstallment(t *testing.T) {
+	service, repo, accounts := newCollectorFixture(t)
+
+	collected, err := service.CollectDuePayments(context.Background())
+	if err != nil {
+		t.Fatalf("CollectDuePayments: %v", err)
+	}
+	if collected != 2 {
+		t.Fatalf("collected %d payments, want 2", collected)
+	}
+	if status := repo.credits[1].Status; status != models.CreditStatusClosed {
+		t.Errorf("credit 1 status = %s, want closed", status)
+	}
+	if !repo.schedules[1].Paid {
+		t.Error("due payment of credit 2 was not collected")
+	}
+	if repo.schedules[2].Paid {
+		t.Error("future payment of credit 2 was collected")
+	}
+	if len(accounts.operations) != 2 || accounts.operations[1].Amount != money.MustParse("-60.00") {
+		t.Errorf("unexpected account operations: %+v", accounts.operations)
+	}
+}
+
+func TestCollectDuePaymentsContinuesAfterCreditFailure(t *testing.T) {
+	service, repo, accounts := newCollectorFixture(t)
+	failure := errors.New("connection reset")
+	accounts.failing[10] = failure
+	accounts.failing[20] = nil
+
+	collected, err := service.CollectDuePayments(context.Background())
+	if !errors.Is(err, failure) {
+		t.Fatalf("error = %v, want it to wrap %v", err, failure)
+	}
+	if collected != 1 {
+		t.Fatalf("collected %d payments, want 1", collected)
+	}
+	if repo.schedules[0].Paid {
+		t.Error("failed payment of credit 1 is marked paid")
+	}
+	if !repo.schedules[1].Paid {
+		t.Error("credit 2 was skipped after the failure of credit 1")
+	}
+}
+
+func TestCollectDuePaymentsLeavesUnfundedCredits(t *testing.T) {
+	service, repo, accounts := newCollectorFixture(t)
+	accounts.failing[10] = ErrInsufficientFunds
+
+	collected, err := service.CollectDuePayments(context.Background())
+	if err != nil {
+		t.Fatalf("CollectDuePayments: %v", err)
+	}
+	if collected != 1 || repo.schedules[0].Paid || !repo.schedules[1].Paid {
+		t.Errorf("collected %d, paid %v/%v; want only credit 2 collected", collected, repo.schedules[0].Paid, repo.schedules[1].Paid)
+	}
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+// fakeDriver — драйвер базы без хранения данных: он открывает и завершает транзакции,
+// которые сервисы передают в поддельные репозитории. Запросы через него не выполняются.
+type fakeDriver struct{}
+
+type fakeConn struct{}
+
+type fakeTx struct{}
+
+func init() {
+	sql.Register("services-fake", fakeDriver{})
+}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{}, nil }
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fake database does not execute queries")
+}
+func (fakeConn) Close() error              { return nil }
+func (fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+// openFakeDB возвращает *sql.DB, транзакции которого ничего не делают
+func openFakeDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("services-fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
@@ -0,0 +1,296 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/money"
+	"github.com/bank-service/internal/repositories"
+)
+
+// DefaultHoldTTL — срок, по истечении которого неподтверждённый холд снимается автоматически
+const DefaultHoldTTL = 7 * 24 * time.Hour
+
+type holdService struct {
+	holdRepo        repositories.HoldRepository
+	accountRepo     repositories.AccountRepository
+	transactionRepo repositories.TransactionRepository
+	ledgerService   LedgerService
+	limitService    LimitService
+	db              *sql.DB
+}
+
+func NewHoldService(holdRepo repositories.HoldRepository, accountRepo repositories.AccountRepository, transactionRepo repositories.TransactionRepository, ledgerService LedgerService, limitService LimitService, db *sql.DB) HoldService {
+	return &holdService{
+		holdRepo:        holdRepo,
+		accountRepo:     accountRepo,
+		transactionRepo: transactionRepo,
+		ledgerService:   ledgerService,
+		limitService:    limitService,
+		db:              db,
+	}
+}
+
+func (s *holdService) PlaceHold(ctx context.Context, accountID, cardID int64, amount money.Amount, description string, ttl time.Duration) (*models.Hold, error) {
+	if !amount.IsPositive() {
+		return nil, errors.New("amount must be positive")
+	}
+	if ttl <= 0 {
+		ttl = DefaultHoldTTL
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Блокировка счёта сериализует размещение холдов и списания по нему
+	account, err := s.accountRepo.FindByIDForUpdate(ctx, tx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, errors.New("account not found")
+	}
+	if account.IsFrozen() {
+		return nil, ErrAccountFrozen
+	}
+	if account.AvailableBalance.Cmp(amount) < 0 {
+		return nil, ErrInsufficientFunds
+	}
+
+	now := time.Now()
+	hold := &models.Hold{
+		AccountID:   accountID,
+		CardID:      cardID,
+		Amount:      amount,
+		Status:      models.HoldStatusActive,
+		Description: description,
+		ExpiresAt:   now.Add(ttl),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := hold.Validate(); err != nil {
+		return nil, err
+	}
+	// Холд по карте — авторизация покупки, без карты — будущее снятие наличных:
+	// он расходует лимиты покупок по карте или снятий со счёта
+	if err := s.limitService.Consume(ctx, tx, holdUsage(hold, account, amount)); err != nil {
+		return nil, err
+	}
+	if err := s.holdRepo.Create(ctx, tx, hold); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+func (s *holdService) CaptureHold(ctx context.Context, holdID int64, amount money.Amount) (*models.Hold, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	hold, account, err := s.lockHold(ctx, tx, holdID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Без суммы подтверждается весь холд; частичное подтверждение снимает остаток блокировки
+	if amount.IsZero() {
+		amount = hold.Amount
+	}
+	if !amount.IsPositive() {
+		return nil, errors.New("amount must be positive")
+	}
+	if amount.Cmp(hold.Amount) > 0 {
+		return nil, errors.New("capture amount exceeds hold amount")
+	}
+
+	transactionType := models.TransactionTypeHoldCapture
+	settlementAccount := models.SystemAccountCashOut
+	if hold.CardID != 0 {
+		transactionType = models.TransactionTypeCardPurchase
+		settlementAccount = models.SystemAccountCardSettlement
+	}
+	description := hold.Description
+	if description == "" {
+		description = "Capture of hold " + strconv.FormatInt(hold.ID, 10)
+	}
+
+	entry := &models.JournalEntry{
+		Type:        transactionType,
+		Description: description,
+		Postings: []*models.Posting{
+			{AccountID: account.ID, Amount: amount.Neg(), Currency: account.Currency},
+			{SystemAccount: settlementAccount, Amount: amount, Currency: account.Currency},
+		},
+		CreatedAt: time.Now(),
+	}
+	if err := s.ledgerService.Post(ctx, tx, entry); err != nil {
+		return nil, err
+	}
+
+	transaction := &models.Transaction{
+		AccountID:   account.ID,
+		Amount:      amount.Neg(),
+		Type:        transactionType,
+		Description: description,
+		EntryID:     entry.ID,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.transactionRepo.Create(ctx, tx, transaction); err != nil {
+		return nil, err
+	}
+
+	hold.CapturedAmount = amount
+	hold.Status = models.HoldStatusCaptured
+	hold.UpdatedAt = time.Now()
+	if err := s.holdRepo.Update(ctx, tx, hold); err != nil {
+		return nil, err
+	}
+	// Остаток частично подтверждённого холда возвращается в лимиты; операция при этом
+	// состоялась и остаётся в счётчике количества
+	if rest := hold.Amount.Sub(amount); rest.IsPositive() {
+		if err := s.limitService.ReleaseAmount(ctx, tx, holdUsage(hold, account, rest)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+func (s *holdService) ReleaseHold(ctx context.Context, holdID int64) (*models.Hold, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	hold, account, err := s.lockHold(ctx, tx, holdID)
+	if err != nil {
+		return nil, err
+	}
+
+	hold.Status = models.HoldStatusReleased
+	hold.UpdatedAt = time.Now()
+	if err := s.holdRepo.Update(ctx, tx, hold); err != nil {
+		return nil, err
+	}
+	if err := s.limitService.Release(ctx, tx, holdUsage(hold, account, hold.Amount)); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+// lockHold блокирует счёт и холд (в этом порядке, как и при списаниях) и проверяет, что холд действует
+func (s *holdService) lockHold(ctx context.Context, tx *sql.Tx, holdID int64) (*models.Hold, *models.Account, error) {
+	hold, err := s.holdRepo.FindByID(ctx, holdID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if hold == nil {
+		return nil, nil, errors.New("hold not found")
+	}
+
+	account, err := s.accountRepo.FindByIDForUpdate(ctx, tx, hold.AccountID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if account == nil {
+		return nil, nil, errors.New("account not found")
+	}
+
+	hold, err = s.holdRepo.FindByIDForUpdate(ctx, tx, holdID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if hold == nil {
+		return nil, nil, errors.New("hold not found")
+	}
+	if !hold.IsActive(time.Now()) {
+		return nil, nil, errors.New("hold is not active")
+	}
+	return hold, account, nil
+}
+
+func (s *holdService) GetHold(ctx context.Context, holdID int64) (*models.Hold, error) {
+	hold, err := s.holdRepo.FindByID(ctx, holdID)
+	if err != nil {
+		return nil, err
+	}
+	if hold == nil {
+		return nil, errors.New("hold not found")
+	}
+	return hold, nil
+}
+
+// ExpireHolds переводит просроченные холды в статус expired и возвращает их суммы в лимиты
+func (s *holdService) ExpireHolds(ctx context.Context) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	holds, err := s.holdRepo.ExpireDue(ctx, tx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	accounts := make(map[int64]*models.Account)
+	for _, hold := range holds {
+		account, ok := accounts[hold.AccountID]
+		if !ok {
+			account, err = s.accountRepo.FindByID(ctx, hold.AccountID)
+			if err != nil {
+				return 0, err
+			}
+			if account == nil {
+				return 0, errors.New("account not found")
+			}
+			accounts[hold.AccountID] = account
+		}
+		if err := s.limitService.Release(ctx, tx, holdUsage(hold, account, hold.Amount)); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return int64(len(holds)), nil
+}
+
+// holdUsage описывает сумму amount холда для учёта в лимитах. Расход относится к периоду,
+// в котором холд был размещён.
+func holdUsage(hold *models.Hold, account *models.Account, amount money.Amount) *models.LimitUsage {
+	operation := models.LimitOperationWithdrawal
+	if hold.CardID != 0 {
+		operation = models.LimitOperationCardPurchase
+	}
+	return &models.LimitUsage{
+		Operation: operation,
+		CardID:    hold.CardID,
+		AccountID: account.ID,
+		UserID:    account.UserID,
+		Currency:  account.Currency,
+		Amount:    amount,
+		At:        hold.CreatedAt,
+	}
+}
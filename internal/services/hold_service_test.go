@@ -0,0 +1,317 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/money"
+	"github.com/bank-service/internal/policy"
+	"github.com/bank-service/internal/repositories"
+)
+
+const (
+	holdTestUserID    int64 = 1
+	holdTestAccountID int64 = 10
+	holdTestCardID    int64 = 20
+)
+
+// fakeHoldAccountRepository хранит счета в памяти
+type fakeHoldAccountRepository struct {
+	repositories.AccountRepository
+	accounts map[int64]*models.Account
+}
+
+func (r *fakeHoldAccountRepository) FindByID(_ context.Context, id int64) (*models.Account, error) {
+	return r.accounts[id], nil
+}
+
+func (r *fakeHoldAccountRepository) FindByIDForUpdate(_ context.Context, _ *sql.Tx, id int64) (*models.Account, error) {
+	return r.accounts[id], nil
+}
+
+func (r *fakeHoldAccountRepository) AddToBalance(_ context.Context, _ *sql.Tx, id int64, delta money.Amount) (money.Amount, error) {
+	account, ok := r.accounts[id]
+	if !ok {
+		return 0, sql.ErrNoRows
+	}
+	account.Balance = account.Balance.Add(delta)
+	return account.Balance, nil
+}
+
+// fakeCardRepository отдаёт карты по идентификатору
+type fakeCardRepository struct {
+	repositories.CardRepository
+	cards map[int64]*models.Card
+}
+
+func (r *fakeCardRepository) FindByID(_ context.Context, id int64) (*models.Card, error) {
+	return r.cards[id], nil
+}
+
+// fakeHoldRepository хранит холды в памяти
+type fakeHoldRepository struct {
+	repositories.HoldRepository
+	holds map[int64]*models.Hold
+}
+
+func (r *fakeHoldRepository) Create(_ context.Context, _ *sql.Tx, hold *models.Hold) error {
+	hold.ID = int64(len(r.holds) + 1)
+	stored := *hold
+	r.holds[hold.ID] = &stored
+	return nil
+}
+
+func (r *fakeHoldRepository) FindByID(_ context.Context, id int64) (*models.Hold, error) {
+	hold, ok := r.holds[id]
+	if !ok {
+		return nil, nil
+	}
+	found := *hold
+	return &found, nil
+}
+
+func (r *fakeHoldRepository) FindByIDForUpdate(ctx context.Context, _ *sql.Tx, id int64) (*models.Hold, error) {
+	return r.FindByID(ctx, id)
+}
+
+func (r *fakeHoldRepository) Update(_ context.Context, _ *sql.Tx, hold *models.Hold) error {
+	stored := *hold
+	r.holds[hold.ID] = &stored
+	return nil
+}
+
+func (r *fakeHoldRepository) ExpireDue(_ context.Context, _ *sql.Tx, now time.Time) ([]*models.Hold, error) {
+	var expired []*models.Hold
+	for _, hold := range r.holds {
+		if hold.Status == models.HoldStatusActive && !hold.ExpiresAt.After(now) {
+			hold.Status = models.HoldStatusExpired
+			found := *hold
+			expired = append(expired, &found)
+		}
+	}
+	return expired, nil
+}
+
+// fakeLimitRepository ведёт счётчики расхода лимитов в памяти; сохранённых лимитов нет,
+// действуют лимиты по умолчанию
+type fakeLimitRepository struct {
+	repositories.LimitRepository
+	usage map[models.LimitKey]map[time.Time]limitCounter
+}
+
+type limitCounter struct {
+	amount money.Amount
+	count  int
+}
+
+func (r *fakeLimitRepository) FindForUsage(context.Context, *sql.Tx, *models.LimitUsage) ([]*models.SpendingLimit, error) {
+	return nil, nil
+}
+
+func (r *fakeLimitRepository) FindByScope(context.Context, string, int64) ([]*models.SpendingLimit, error) {
+	return nil, nil
+}
+
+func (r *fakeLimitRepository) LockUsage(ctx context.Context, _ *sql.Tx, key models.LimitKey, periodStart time.Time) (money.Amount, int, error) {
+	return r.FindUsage(ctx, key, periodStart)
+}
+
+func (r *fakeLimitRepository) AddUsage(_ context.Context, _ *sql.Tx, key models.LimitKey, periodStart time.Time, amount money.Amount, count int) error {
+	if r.usage[key] == nil {
+		r.usage[key] = make(map[time.Time]limitCounter)
+	}
+	counter := r.usage[key][periodStart]
+	counter.amount = counter.amount.Add(amount)
+	counter.count += count
+	r.usage[key][periodStart] = counter
+	return nil
+}
+
+func (r *fakeLimitRepository) FindUsage(_ context.Context, key models.LimitKey, periodStart time.Time) (money.Amount, int, error) {
+	counter := r.usage[key][periodStart]
+	return counter.amount, counter.count, nil
+}
+
+// fakeTransactionRepository принимает выписку операции без сохранения
+type fakeTransactionRepository struct {
+	repositories.TransactionRepository
+}
+
+func (fakeTransactionRepository) Create(context.Context, *sql.Tx, *models.Transaction) error {
+	return nil
+}
+
+type holdFixture struct {
+	holds       HoldService
+	limits      LimitService
+	holdRepo    *fakeHoldRepository
+	accountRepo *fakeHoldAccountRepository
+}
+
+// newHoldFixture собирает сервис холдов над счётом с остатком 1000.00 и картой к нему.
+// Лимиты по умолчанию: покупки по карте и снятия со счёта — до 500.00 в день.
+func newHoldFixture(t *testing.T) *holdFixture {
+	t.Helper()
+	accountRepo := &fakeHoldAccountRepository{accounts: map[int64]*models.Account{
+		holdTestAccountID: {
+			ID:               holdTestAccountID,
+			UserID:           holdTestUserID,
+			Balance:          money.MustParse("1000.00"),
+			AvailableBalance: money.MustParse("1000.00"),
+			Currency:         money.RUB,
+			Status:           models.AccountStatusActive,
+		},
+	}}
+	cardRepo := &fakeCardRepository{cards: map[int64]*models.Card{
+		holdTestCardID: {ID: holdTestCardID, AccountID: holdTestAccountID},
+	}}
+	holdRepo := &fakeHoldRepository{holds: make(map[int64]*models.Hold)}
+	ownership := policy.New(accountRepo, cardRepo, nil, holdRepo)
+
+	dailyMax := money.MustParse("500.00")
+	defaults := []*models.SpendingLimit{
+		{LimitKey: models.LimitKey{Scope: models.LimitScopeCard, Operation: models.LimitOperationCardPurchase, Period: models.LimitPeriodDaily, Currency: money.RUB}, MaxAmount: &dailyMax, Source: models.LimitSourceBank},
+		{LimitKey: models.LimitKey{Scope: models.LimitScopeAccount, Operation: models.LimitOperationWithdrawal, Period: models.LimitPeriodDaily, Currency: money.RUB}, MaxAmount: &dailyMax, Source: models.LimitSourceBank},
+	}
+	db := openFakeDB(t)
+	limits := NewLimitService(&fakeLimitRepository{usage: make(map[models.LimitKey]map[time.Time]limitCounter)}, cardRepo, accountRepo, nil, ownership, defaults, db)
+	ledger := NewLedgerService(&fakeLedgerRepository{}, accountRepo)
+	holds := NewHoldService(holdRepo, accountRepo, fakeTransactionRepository{}, ledger, limits, db)
+	return &holdFixture{holds: holds, limits: limits, holdRepo: holdRepo, accountRepo: accountRepo}
+}
+
+// dailyUsage возвращает расход дневного лимита операции из действующих лимитов
+func dailyUsage(t *testing.T, limits []*models.EffectiveLimit, operation string) (money.Amount, int) {
+	t.Helper()
+	for _, limit := range limits {
+		if limit.Operation == operation && limit.Period == models.LimitPeriodDaily {
+			return limit.UsedAmount, limit.UsedCount
+		}
+	}
+	t.Fatalf("no daily %s limit in %v", operation, limits)
+	return 0, 0
+}
+
+func (f *holdFixture) cardUsage(t *testing.T) (money.Amount, int) {
+	t.Helper()
+	limits, err := f.limits.GetCardLimits(context.Background(), holdTestUserID, holdTestCardID)
+	if err != nil {
+		t.Fatalf("GetCardLimits: %v", err)
+	}
+	return dailyUsage(t, limits, models.LimitOperationCardPurchase)
+}
+
+func (f *holdFixture) withdrawalUsage(t *testing.T) (money.Amount, int) {
+	t.Helper()
+	limits, err := f.limits.GetAccountLimits(context.Background(), holdTestUserID, holdTestAccountID)
+	if err != nil {
+		t.Fatalf("GetAccountLimits: %v", err)
+	}
+	return dailyUsage(t, limits, models.LimitOperationWithdrawal)
+}
+
+func TestPlaceHoldReturnsAccountErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		account *models.Account
+		want    error
+	}{
+		{
+			name:    "frozen account",
+			account: &models.Account{ID: holdTestAccountID, Status: models.AccountStatusFrozen, AvailableBalance: money.MustParse("100.00")},
+			want:    ErrAccountFrozen,
+		},
+		{
+			name:    "insufficient funds",
+			account: &models.Account{ID: holdTestAccountID, Status: models.AccountStatusActive, AvailableBalance: money.MustParse("9.99")},
+			want:    ErrInsufficientFunds,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newHoldFixture(t)
+			f.accountRepo.accounts[holdTestAccountID] = tt.account
+
+			_, err := f.holds.PlaceHold(context.Background(), holdTestAccountID, 0, money.MustParse("10.00"), "purchase", 0)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("PlaceHold() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReleaseHoldReturnsCardLimits(t *testing.T) {
+	f := newHoldFixture(t)
+	ctx := context.Background()
+
+	hold, err := f.holds.PlaceHold(ctx, holdTestAccountID, holdTestCardID, money.MustParse("300.00"), "purchase", 0)
+	if err != nil {
+		t.Fatalf("PlaceHold: %v", err)
+	}
+	if used, count := f.cardUsage(t); used != money.MustParse("300.00") || count != 1 {
+		t.Fatalf("usage after hold = %s in %d operations, want 300.00 in 1", used, count)
+	}
+
+	if _, err := f.holds.ReleaseHold(ctx, hold.ID); err != nil {
+		t.Fatalf("ReleaseHold: %v", err)
+	}
+	if used, count := f.cardUsage(t); !used.IsZero() || count != 0 {
+		t.Errorf("usage after release = %s in %d operations, want zero", used, count)
+	}
+}
+
+func TestExpireHoldsReturnsCardLimits(t *testing.T) {
+	f := newHoldFixture(t)
+	ctx := context.Background()
+
+	if _, err := f.holds.PlaceHold(ctx, holdTestAccountID, holdTestCardID, money.MustParse("300.00"), "purchase", time.Nanosecond); err != nil {
+		t.Fatalf("PlaceHold: %v", err)
+	}
+	expired, err := f.holds.ExpireHolds(ctx)
+	if err != nil {
+		t.Fatalf("ExpireHolds: %v", err)
+	}
+	if expired != 1 {
+		t.Fatalf("%d holds expired, want 1", expired)
+	}
+	if used, count := f.cardUsage(t); !used.IsZero() || count != 0 {
+		t.Errorf("usage after expiry = %s in %d operations, want zero", used, count)
+	}
+}
+
+func TestPartialCaptureReturnsRestOfCardLimit(t *testing.T) {
+	f := newHoldFixture(t)
+	ctx := context.Background()
+
+	hold, err := f.holds.PlaceHold(ctx, holdTestAccountID, holdTestCardID, money.MustParse("300.00"), "purchase", 0)
+	if err != nil {
+		t.Fatalf("PlaceHold: %v", err)
+	}
+	if _, err := f.holds.CaptureHold(ctx, hold.ID, money.MustParse("120.00")); err != nil {
+		t.Fatalf("CaptureHold: %v", err)
+	}
+	if used, count := f.cardUsage(t); used != money.MustParse("120.00") || count != 1 {
+		t.Errorf("usage after partial capture = %s in %d operations, want 120.00 in 1", used, count)
+	}
+}
+
+func TestPlaceHoldWithoutCardConsumesWithdrawalLimit(t *testing.T) {
+	f := newHoldFixture(t)
+	ctx := context.Background()
+
+	if _, err := f.holds.PlaceHold(ctx, holdTestAccountID, 0, money.MustParse("400.00"), "cash", 0); err != nil {
+		t.Fatalf("PlaceHold: %v", err)
+	}
+	if used, count := f.withdrawalUsage(t); used != money.MustParse("400.00") || count != 1 {
+		t.Errorf("withdrawal usage = %s in %d operations, want 400.00 in 1", used, count)
+	}
+
+	_, err := f.holds.PlaceHold(ctx, holdTestAccountID, 0, money.MustParse("200.00"), "cash", 0)
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("second hold error = %v, want %v", err, ErrLimitExceeded)
+	}
+}
@@ -2,36 +2,125 @@ package services
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
+	"github.com/bank-service/internal/iso20022"
 	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/money"
 )
 
 // UserService определяет методы для работы с пользователями
 type UserService interface {
 	Register(ctx context.Context, username, email, password string) (*models.User, error)
-	Login(ctx context.Context, email, password string) (string, error)
+	Login(ctx context.Context, email, password string) (*models.TokenPair, error)
+	RefreshTokens(ctx context.Context, refreshToken string) (*models.TokenPair, error)
+	Logout(ctx context.Context, userID int64, jti, sessionID string, expiresAt time.Time) error
+	PurgeExpiredTokens(ctx context.Context) (int64, error)
 	GetProfile(ctx context.Context, userID int64) (*models.User, error)
 }
 
 // AccountService определяет методы для работы со счетами
 type AccountService interface {
-	CreateAccount(ctx context.Context, userID int64) (*models.Account, error)
+	CreateAccount(ctx context.Context, userID int64, currency money.Currency) (*models.Account, error)
 	GetAccounts(ctx context.Context, userID int64) ([]*models.Account, error)
-	Deposit(ctx context.Context, accountID int64, amount float64) error
-	Withdraw(ctx context.Context, accountID int64, amount float64) error
-	Transfer(ctx context.Context, fromAccountID, toAccountID int64, amount float64) error
-	GetTransactions(ctx context.Context, accountID, userID int64) ([]*models.Transaction, error)
+	Deposit(ctx context.Context, userID, accountID int64, amount money.Amount) error
+	Withdraw(ctx context.Context, userID, accountID int64, amount money.Amount) error
+	Transfer(ctx context.Context, userID, fromAccountID, toAccountID int64, amount money.Amount) error
+	PostOperation(ctx context.Context, tx *sql.Tx, op *models.AccountOperation) (*models.Transaction, error)
+	GetTransactions(ctx context.Context, accountID, userID int64, filter models.TransactionFilter) (*models.TransactionPage, error)
+	GetStatement(ctx context.Context, accountID, userID int64, from, to time.Time) (*models.Statement, error)
 }
 
 // CardService определяет методы для работы с картами
 type CardService interface {
-	CreateCard(ctx context.Context, accountID int64, cardNumber, expiryDate, cvv string) (*models.Card, error)
-	GetCards(ctx context.Context, accountID int64) ([]*models.Card, error)
+	CreateCard(ctx context.Context, userID, accountID int64, product string) (*models.Card, string, error)
+	GetCards(ctx context.Context, userID, accountID int64) ([]*models.Card, error)
+	Authorize(ctx context.Context, cardID, userID int64, amount money.Amount, merchant string) (*models.Hold, error)
+	RevealCard(ctx context.Context, cardID int64) (*models.Card, error)
+	EncryptLegacyCards(ctx context.Context) (int64, error)
+	RehashLegacyCards(ctx context.Context) (int64, int64, error)
+	BlockCard(ctx context.Context, userID, cardID int64, reason string) (*models.Card, error)
+	UnblockCard(ctx context.Context, userID, cardID int64, reason string) (*models.Card, error)
+	ReportLost(ctx context.Context, userID, cardID int64, stolen bool, reason string) (*models.Card, error)
+	CloseCard(ctx context.Context, userID, cardID int64, reason string) (*models.Card, error)
+	ReissueCard(ctx context.Context, userID, cardID int64) (*models.Card, string, error)
+	GetStatusHistory(ctx context.Context, userID, cardID int64) ([]*models.CardStatusChange, error)
+	ExpireCards(ctx context.Context) (int64, error)
+}
+
+// CardProcessingService обрабатывает операции по картам, поступающие от процессинга (ISO 8583)
+type CardProcessingService interface {
+	Authorize(ctx context.Context, req *models.CardAuthorizationRequest) (*models.CardAuthorization, error)
+	Reverse(ctx context.Context, req *models.CardAuthorizationRequest) (*models.CardAuthorization, error)
+}
+
+// LimitService проверяет и учитывает лимиты расходных операций и управляет ими
+type LimitService interface {
+	Consume(ctx context.Context, tx *sql.Tx, usage *models.LimitUsage) error
+	Release(ctx context.Context, tx *sql.Tx, usage *models.LimitUsage) error
+	ReleaseAmount(ctx context.Context, tx *sql.Tx, usage *models.LimitUsage) error
+	GetCardLimits(ctx context.Context, userID, cardID int64) ([]*models.EffectiveLimit, error)
+	GetAccountLimits(ctx context.Context, userID, accountID int64) ([]*models.EffectiveLimit, error)
+	GetLimits(ctx context.Context, scope string, scopeID int64) ([]*models.EffectiveLimit, error)
+	SetCardLimit(ctx context.Context, userID, cardID int64, limit *models.SpendingLimit) (*models.SpendingLimit, error)
+	RemoveCardLimit(ctx context.Context, userID, cardID int64, operation, period string) error
+	SetLimit(ctx context.Context, tx *sql.Tx, limit *models.SpendingLimit) error
+	RemoveLimit(ctx context.Context, tx *sql.Tx, key models.LimitKey, source string) error
 }
 
 // CreditService определяет методы для работы с кредитами
 type CreditService interface {
-	CreateCredit(ctx context.Context, userID int64, amount, interestRate float64, termMonths int) (*models.Credit, error)
+	CreateCredit(ctx context.Context, userID, accountID int64, amount money.Amount, interestRate float64, termMonths int, scheduleType, dayCount string) (*models.Credit, error)
 	GetCredits(ctx context.Context, userID int64) ([]*models.Credit, error)
-	GetPaymentSchedules(ctx context.Context, creditID, userID int64) ([]*models.PaymentSchedule, error)
+	GetPaymentSchedules(ctx context.Context, creditID, userID int64, includeSuperseded bool) ([]*models.PaymentSchedule, error)
+	Repay(ctx context.Context, userID, creditID int64) (*models.CreditPayment, error)
+	GetPayoffQuote(ctx context.Context, userID, creditID int64, date time.Time) (*models.PayoffQuote, error)
+	PayOff(ctx context.Context, userID, creditID int64) (*models.CreditPayment, error)
+	Prepay(ctx context.Context, userID, creditID int64, amount money.Amount, mode string) (*models.CreditPayment, []*models.PaymentSchedule, error)
+	GetPayments(ctx context.Context, userID, creditID int64) ([]*models.CreditPayment, error)
+	GetSummary(ctx context.Context, userID, creditID int64) (*models.CreditSummary, error)
+	GetPenalties(ctx context.Context, userID, creditID int64) ([]*models.CreditPenalty, error)
+	GetStatusHistory(ctx context.Context, userID, creditID int64) ([]*models.CreditStatusChange, error)
+	CollectDuePayments(ctx context.Context) (int64, error)
+	ProcessOverdue(ctx context.Context) (int64, error)
+}
+
+// LedgerService определяет методы для работы с журналом двойной записи
+type LedgerService interface {
+	Post(ctx context.Context, tx *sql.Tx, entry *models.JournalEntry) error
+	VerifyBooks(ctx context.Context) error
+}
+
+// HoldService определяет методы для блокировки средств и их последующего списания.
+// Владельца счёта сервис не проверяет: его вызывают процессинг карт, фоновые задачи
+// и HoldHandler, который проверяет доступ через policy.Account и policy.Hold.
+type HoldService interface {
+	PlaceHold(ctx context.Context, accountID, cardID int64, amount money.Amount, description string, ttl time.Duration) (*models.Hold, error)
+	CaptureHold(ctx context.Context, holdID int64, amount money.Amount) (*models.Hold, error)
+	ReleaseHold(ctx context.Context, holdID int64) (*models.Hold, error)
+	GetHold(ctx context.Context, holdID int64) (*models.Hold, error)
+	ExpireHolds(ctx context.Context) (int64, error)
+}
+
+// PaymentService определяет методы обмена платёжными документами ISO 20022
+type PaymentService interface {
+	ExportStatement(ctx context.Context, accountID, userID int64, from, to time.Time) (*iso20022.Camt053Document, error)
+	ImportCreditTransfers(ctx context.Context, userID int64, document *iso20022.Pain001Document) (*iso20022.Pain002Document, error)
+}
+
+// AdminService определяет операции сотрудников банка над любыми пользователями и счетами
+type AdminService interface {
+	SearchUsers(ctx context.Context, query string, limit int) ([]*models.User, error)
+	GetUser(ctx context.Context, userID int64) (*models.User, []*models.Account, error)
+	GetAccount(ctx context.Context, accountID int64) (*models.Account, error)
+	GetAccountTransactions(ctx context.Context, accountID int64, filter models.TransactionFilter) (*models.TransactionPage, error)
+	FreezeAccount(ctx context.Context, actorID, accountID int64, reason string) (*models.Account, error)
+	UnfreezeAccount(ctx context.Context, actorID, accountID int64, reason string) (*models.Account, error)
+	ReverseTransaction(ctx context.Context, actorID, transactionID int64, reason string) ([]*models.Transaction, error)
+	SetUserRole(ctx context.Context, actorID, userID int64, role string) (*models.User, error)
+	RevealCard(ctx context.Context, actorID, cardID int64, reason string) (*models.Card, error)
+	GetLimits(ctx context.Context, scope string, scopeID int64) ([]*models.EffectiveLimit, error)
+	SetLimit(ctx context.Context, actorID int64, limit *models.SpendingLimit, reason string) (*models.SpendingLimit, error)
+	RemoveLimit(ctx context.Context, actorID int64, key models.LimitKey, reason string) error
 }
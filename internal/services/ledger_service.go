@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/repositories"
+)
+
+type ledgerService struct {
+	ledgerRepo  repositories.LedgerRepository
+	accountRepo repositories.AccountRepository
+}
+
+func NewLedgerService(ledgerRepo repositories.LedgerRepository, accountRepo repositories.AccountRepository) LedgerService {
+	return &ledgerService{
+		ledgerRepo:  ledgerRepo,
+		accountRepo: accountRepo,
+	}
+}
+
+func (s *ledgerService) Post(ctx context.Context, tx *sql.Tx, entry *models.JournalEntry) error {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	// Несбалансированная проводка не должна попасть в журнал: сумма разносок
+	// проверяется отдельно по каждой валюте. Полная сверка остатков с историей
+	// разносок выполняется в VerifyBooks, а не в каждой пишущей транзакции
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.ledgerRepo.CreateEntry(ctx, tx, entry); err != nil {
+		return err
+	}
+
+	// Остаток клиентского счёта изменяется только разносками журнала
+	for _, posting := range entry.Postings {
+		if posting.AccountID == 0 {
+			continue
+		}
+		_, err := s.accountRepo.AddToBalance(ctx, tx, posting.AccountID, posting.Amount)
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("account not found")
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *ledgerService) VerifyBooks(ctx context.Context) error {
+	unbalanced, err := s.ledgerRepo.FindUnbalancedEntryIDs(ctx)
+	if err != nil {
+		return err
+	}
+	if len(unbalanced) > 0 {
+		return fmt.Errorf("unbalanced journal entries: %v", unbalanced)
+	}
+
+	mismatched, err := s.ledgerRepo.FindMismatchedAccountIDs(ctx)
+	if err != nil {
+		return err
+	}
+	if len(mismatched) > 0 {
+		return fmt.Errorf("account balances do not match postings: %v", mismatched)
+	}
+	return nil
+}
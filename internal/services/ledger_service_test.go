@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/money"
+	"github.com/bank-service/internal/repositories"
+)
+
+// fakeLedgerRepository запоминает записанные проводки. Чтение истории разносок
+// достаётся от встроенного интерфейса и паникует: Post не должен к ней обращаться.
+type fakeLedgerRepository struct {
+	repositories.LedgerRepository
+	entries []*models.JournalEntry
+}
+
+func (r *fakeLedgerRepository) CreateEntry(_ context.Context, _ *sql.Tx, entry *models.JournalEntry) error {
+	entry.ID = int64(len(r.entries) + 1)
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+// fakeBalanceRepository хранит остатки счетов в памяти
+type fakeBalanceRepository struct {
+	repositories.AccountRepository
+	balances map[int64]money.Amount
+}
+
+func (r *fakeBalanceRepository) AddToBalance(_ context.Context, _ *sql.Tx, accountID int64, delta money.Amount) (money.Amount, error) {
+	balance, ok := r.balances[accountID]
+	if !ok {
+		return 0, sql.ErrNoRows
+	}
+	r.balances[accountID] = balance.Add(delta)
+	return r.balances[accountID], nil
+}
+
+func TestLedgerPostUpdatesBalancesWithoutReadingHistory(t *testing.T) {
+	ledgerRepo := &fakeLedgerRepository{}
+	accountRepo := &fakeBalanceRepository{balances: map[int64]money.Amount{1: money.MustParse("5.00")}}
+	ledger := NewLedgerService(ledgerRepo, accountRepo)
+
+	amount := money.MustParse("10.00")
+	entry := &models.JournalEntry{
+		Type: models.TransactionTypeDeposit,
+		Postings: []*models.Posting{
+			{AccountID: 1, Amount: amount, Currency: money.RUB},
+			{SystemAccount: models.SystemAccountCashIn, Amount: amount.Neg(), Currency: money.RUB},
+		},
+	}
+	if err := ledger.Post(context.Background(), nil, entry); err != nil {
+		t.Fatalf("post: %v", err)
+	}
+
+	if len(ledgerRepo.entries) != 1 {
+		t.Fatalf("%d entries written, want 1", len(ledgerRepo.entries))
+	}
+	if got, want := accountRepo.balances[1], money.MustParse("15.00"); got != want {
+		t.Errorf("balance = %s, want %s", got, want)
+	}
+}
+
+func TestLedgerPostRejectsEntryUnbalancedInOneCurrency(t *testing.T) {
+	ledgerRepo := &fakeLedgerRepository{}
+	accountRepo := &fakeBalanceRepository{balances: map[int64]money.Amount{1: 0, 2: 0}}
+	ledger := NewLedgerService(ledgerRepo, accountRepo)
+
+	// Общая сумма разносок равна нулю, но по каждой валюте проводка не сходится
+	amount := money.MustParse("10.00")
+	entry := &models.JournalEntry{
+		Type: models.TransactionTypeTransfer,
+		Postings: []*models.Posting{
+			{AccountID: 1, Amount: amount, Currency: money.RUB},
+			{AccountID: 2, Amount: amount.Neg(), Currency: money.USD},
+		},
+	}
+	if err := ledger.Post(context.Background(), nil, entry); err == nil {
+		t.Fatal("unbalanced entry was posted")
+	}
+
+	if len(ledgerRepo.entries) != 0 {
+		t.Error("unbalanced entry was written to the journal")
+	}
+	if accountRepo.balances[1] != 0 || accountRepo.balances[2] != 0 {
+		t.Errorf("balances changed: %v", accountRepo.balances)
+	}
+}
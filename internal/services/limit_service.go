@@ -0,0 +1,410 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/money"
+	"github.com/bank-service/internal/policy"
+	"github.com/bank-service/internal/repositories"
+)
+
+var ErrLimitExceeded = errors.New("spending limit exceeded")
+
+// LimitError — отказ в операции из-за лимита. Сравнивается с ErrLimitExceeded через errors.Is.
+type LimitError struct {
+	Limit *models.EffectiveLimit
+	// Count сообщает, что превышено количество операций, а не сумма
+	Count bool
+}
+
+func (e *LimitError) Error() string {
+	kind := "amount"
+	if e.Count {
+		kind = "count"
+	}
+	return fmt.Sprintf("%s %s %s limit for %s exceeded", e.Limit.Period, e.Limit.Scope, kind, e.Limit.Operation)
+}
+
+func (e *LimitError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+var (
+	limitScopes     = []string{models.LimitScopeCard, models.LimitScopeAccount, models.LimitScopeUser}
+	limitOperations = []string{models.LimitOperationWithdrawal, models.LimitOperationTransfer, models.LimitOperationCardPurchase}
+	limitPeriods    = []string{models.LimitPeriodDaily, models.LimitPeriodMonthly}
+)
+
+// limitService проверяет и учитывает лимиты расходных операций. Действующий лимит — минимум
+// из лимита банка (переопределение для карты, счёта или пользователя, а при его отсутствии —
+// лимит по умолчанию из конфигурации) и лимита клиента.
+//
+// Операция учитывается в счётчиках в транзакции самой операции, поэтому откат операции
+// откатывает и расход лимита. Расход уменьшается при отмене операции процессингом, при снятии
+// и истечении холда, а подтверждение холда на меньшую сумму возвращает неизрасходованный остаток.
+type limitService struct {
+	limitRepo   repositories.LimitRepository
+	cardRepo    repositories.CardRepository
+	accountRepo repositories.AccountRepository
+	userRepo    repositories.UserRepository
+	policy      policy.Policy
+	defaults    []*models.SpendingLimit
+	db          *sql.DB
+}
+
+func NewLimitService(limitRepo repositories.LimitRepository, cardRepo repositories.CardRepository, accountRepo repositories.AccountRepository, userRepo repositories.UserRepository, policy policy.Policy, defaults []*models.SpendingLimit, db *sql.DB) LimitService {
+	return &limitService{
+		limitRepo:   limitRepo,
+		cardRepo:    cardRepo,
+		accountRepo: accountRepo,
+		userRepo:    userRepo,
+		policy:      policy,
+		defaults:    defaults,
+		db:          db,
+	}
+}
+
+// Consume проверяет операцию по лимитам карты, счёта и пользователя и учитывает её.
+// Сначала блокируются и проверяются все счётчики, и только затем они увеличиваются:
+// при превышении лимита транзакция вызывающего не содержит изменений и её можно
+// использовать дальше (например, чтобы сохранить отказ). Счётчики блокируются в одном
+// порядке — карта, счёт, пользователь — чтобы параллельные операции не взаимоблокировались.
+func (s *limitService) Consume(ctx context.Context, tx *sql.Tx, usage *models.LimitUsage) error {
+	limits, err := s.limitRepo.FindForUsage(ctx, tx, usage)
+	if err != nil {
+		return err
+	}
+	keys := usageKeys(usage)
+	for _, key := range keys {
+		usedAmount, usedCount, err := s.limitRepo.LockUsage(ctx, tx, key, models.LimitPeriodStart(key.Period, usage.At))
+		if err != nil {
+			return err
+		}
+		limit := s.effective(key, limits)
+		if limit.MaxCount != nil && usedCount+1 > *limit.MaxCount {
+			return &LimitError{Limit: limit, Count: true}
+		}
+		if limit.MaxAmount != nil && usedAmount.Add(usage.Amount).Cmp(*limit.MaxAmount) > 0 {
+			return &LimitError{Limit: limit}
+		}
+	}
+	for _, key := range keys {
+		if err := s.limitRepo.AddUsage(ctx, tx, key, models.LimitPeriodStart(key.Period, usage.At), usage.Amount, 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Release возвращает в лимиты отменённую операцию. Расход уменьшается в периоде,
+// в котором операция была проведена (usage.At).
+func (s *limitService) Release(ctx context.Context, tx *sql.Tx, usage *models.LimitUsage) error {
+	return s.release(ctx, tx, usage, 1)
+}
+
+// ReleaseAmount возвращает в лимиты сумму usage.Amount, не уменьшая количество операций:
+// операция состоялась, но на меньшую сумму, чем была учтена
+func (s *limitService) ReleaseAmount(ctx context.Context, tx *sql.Tx, usage *models.LimitUsage) error {
+	return s.release(ctx, tx, usage, 0)
+}
+
+func (s *limitService) release(ctx context.Context, tx *sql.Tx, usage *models.LimitUsage, count int) error {
+	for _, key := range usageKeys(usage) {
+		if err := s.limitRepo.AddUsage(ctx, tx, key, models.LimitPeriodStart(key.Period, usage.At), usage.Amount.Neg(), -count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// usageKeys возвращает счётчики, в которых учитывается операция
+func usageKeys(usage *models.LimitUsage) []models.LimitKey {
+	var keys []models.LimitKey
+	for _, scope := range limitScopes {
+		var scopeID int64
+		switch scope {
+		case models.LimitScopeCard:
+			scopeID = usage.CardID
+		case models.LimitScopeAccount:
+			scopeID = usage.AccountID
+		case models.LimitScopeUser:
+			scopeID = usage.UserID
+		}
+		if scopeID == 0 {
+			continue
+		}
+		for _, period := range limitPeriods {
+			keys = append(keys, models.LimitKey{
+				Scope:     scope,
+				ScopeID:   scopeID,
+				Operation: usage.Operation,
+				Period:    period,
+				Currency:  usage.Currency,
+			})
+		}
+	}
+	return keys
+}
+
+// effective вычисляет действующий лимит по ключу из лимитов, сохранённых для карты, счёта и пользователя
+func (s *limitService) effective(key models.LimitKey, limits []*models.SpendingLimit) *models.EffectiveLimit {
+	var bank, customer *models.SpendingLimit
+	for _, limit := range s.defaults {
+		if limit.Scope == key.Scope && limit.Operation == key.Operation && limit.Period == key.Period && limit.Currency == key.Currency {
+			bank = limit
+		}
+	}
+	for _, limit := range limits {
+		if limit.LimitKey != key {
+			continue
+		}
+		if limit.Source == models.LimitSourceCustomer {
+			customer = limit
+		} else {
+			bank = limit
+		}
+	}
+
+	effective := &models.EffectiveLimit{LimitKey: key}
+	if bank != nil {
+		effective.MaxAmount = bank.MaxAmount
+		effective.MaxCount = bank.MaxCount
+	}
+	if customer != nil {
+		if customer.MaxAmount != nil && (effective.MaxAmount == nil || customer.MaxAmount.Cmp(*effective.MaxAmount) < 0) {
+			effective.MaxAmount = customer.MaxAmount
+			effective.CustomerSet = true
+		}
+		if customer.MaxCount != nil && (effective.MaxCount == nil || *customer.MaxCount < *effective.MaxCount) {
+			effective.MaxCount = customer.MaxCount
+			effective.CustomerSet = true
+		}
+	}
+	return effective
+}
+
+// GetCardLimits возвращает действующие лимиты карты пользователя
+func (s *limitService) GetCardLimits(ctx context.Context, userID, cardID int64) ([]*models.EffectiveLimit, error) {
+	if _, err := s.policy.Card(ctx, userID, cardID); err != nil {
+		return nil, err
+	}
+	return s.GetLimits(ctx, models.LimitScopeCard, cardID)
+}
+
+// GetAccountLimits возвращает действующие лимиты счёта пользователя
+func (s *limitService) GetAccountLimits(ctx context.Context, userID, accountID int64) ([]*models.EffectiveLimit, error) {
+	if _, err := s.policy.Account(ctx, userID, accountID); err != nil {
+		return nil, err
+	}
+	return s.GetLimits(ctx, models.LimitScopeAccount, accountID)
+}
+
+// GetLimits возвращает действующие лимиты карты, счёта или пользователя и их расход
+// в текущем периоде. Проверка прав — на стороне вызывающего.
+func (s *limitService) GetLimits(ctx context.Context, scope string, scopeID int64) ([]*models.EffectiveLimit, error) {
+	currencies, err := s.scopeCurrencies(ctx, scope, scopeID)
+	if err != nil {
+		return nil, err
+	}
+	limits, err := s.limitRepo.FindByScope(ctx, scope, scopeID)
+	if err != nil {
+		return nil, err
+	}
+	// У пользователя могут быть лимиты в валютах, в которых у него пока нет счетов
+	for _, limit := range limits {
+		if !containsCurrency(currencies, limit.Currency) {
+			currencies = append(currencies, limit.Currency)
+		}
+	}
+
+	now := time.Now()
+	var effective []*models.EffectiveLimit
+	for _, currency := range currencies {
+		for _, operation := range limitOperations {
+			for _, period := range limitPeriods {
+				key := models.LimitKey{Scope: scope, ScopeID: scopeID, Operation: operation, Period: period, Currency: currency}
+				limit := s.effective(key, limits)
+				if limit.MaxAmount == nil && limit.MaxCount == nil {
+					continue
+				}
+				limit.PeriodStart = models.LimitPeriodStart(period, now)
+				limit.UsedAmount, limit.UsedCount, err = s.limitRepo.FindUsage(ctx, key, limit.PeriodStart)
+				if err != nil {
+					return nil, err
+				}
+				effective = append(effective, limit)
+			}
+		}
+	}
+	return effective, nil
+}
+
+// scopeCurrencies возвращает валюты, в которых действуют лимиты карты, счёта или пользователя
+func (s *limitService) scopeCurrencies(ctx context.Context, scope string, scopeID int64) ([]money.Currency, error) {
+	switch scope {
+	case models.LimitScopeCard, models.LimitScopeAccount:
+		account, err := s.scopeAccount(ctx, scope, scopeID)
+		if err != nil {
+			return nil, err
+		}
+		return []money.Currency{account.Currency}, nil
+	case models.LimitScopeUser:
+		user, err := s.userRepo.FindByID(ctx, scopeID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, errors.New("user not found")
+		}
+		accounts, err := s.accountRepo.FindByUserID(ctx, scopeID)
+		if err != nil {
+			return nil, err
+		}
+		var currencies []money.Currency
+		for _, account := range accounts {
+			if !containsCurrency(currencies, account.Currency) {
+				currencies = append(currencies, account.Currency)
+			}
+		}
+		return currencies, nil
+	}
+	return nil, errors.New("invalid limit scope")
+}
+
+// scopeAccount возвращает счёт карты или сам счёт
+func (s *limitService) scopeAccount(ctx context.Context, scope string, scopeID int64) (*models.Account, error) {
+	accountID := scopeID
+	if scope == models.LimitScopeCard {
+		card, err := s.cardRepo.FindByID(ctx, scopeID)
+		if err != nil {
+			return nil, err
+		}
+		if card == nil {
+			return nil, errors.New("card not found")
+		}
+		accountID = card.AccountID
+	}
+	account, err := s.accountRepo.FindByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, errors.New("account not found")
+	}
+	return account, nil
+}
+
+func containsCurrency(currencies []money.Currency, currency money.Currency) bool {
+	for _, c := range currencies {
+		if c == currency {
+			return true
+		}
+	}
+	return false
+}
+
+// SetCardLimit устанавливает собственный лимит клиента по карте. Лимит клиента может
+// только ужесточить лимит банка: действует меньшее из значений.
+func (s *limitService) SetCardLimit(ctx context.Context, userID, cardID int64, limit *models.SpendingLimit) (*models.SpendingLimit, error) {
+	if _, err := s.policy.Card(ctx, userID, cardID); err != nil {
+		return nil, err
+	}
+	limit.Scope = models.LimitScopeCard
+	limit.ScopeID = cardID
+	limit.Source = models.LimitSourceCustomer
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := s.SetLimit(ctx, tx, limit); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return limit, nil
+}
+
+// RemoveCardLimit удаляет собственный лимит клиента по карте; лимит банка продолжает действовать
+func (s *limitService) RemoveCardLimit(ctx context.Context, userID, cardID int64, operation, period string) error {
+	if _, err := s.policy.Card(ctx, userID, cardID); err != nil {
+		return err
+	}
+	key := models.LimitKey{Scope: models.LimitScopeCard, ScopeID: cardID, Operation: operation, Period: period}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.RemoveLimit(ctx, tx, key, models.LimitSourceCustomer); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetLimit сохраняет лимит в транзакции вызывающего. Валюта лимита карты и счёта — валюта
+// счёта; валюта лимита пользователя по умолчанию RUB.
+func (s *limitService) SetLimit(ctx context.Context, tx *sql.Tx, limit *models.SpendingLimit) error {
+	if err := s.resolveCurrency(ctx, &limit.LimitKey); err != nil {
+		return err
+	}
+	if err := limit.Validate(); err != nil {
+		return err
+	}
+	limit.UpdatedAt = time.Now()
+	return s.limitRepo.Upsert(ctx, tx, limit)
+}
+
+// RemoveLimit удаляет лимит с ключом key из источника source в транзакции вызывающего
+func (s *limitService) RemoveLimit(ctx context.Context, tx *sql.Tx, key models.LimitKey, source string) error {
+	if err := s.resolveCurrency(ctx, &key); err != nil {
+		return err
+	}
+	deleted, err := s.limitRepo.Delete(ctx, tx, key, source)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errors.New("limit not found")
+	}
+	return nil
+}
+
+// resolveCurrency проверяет, что карта, счёт или пользователь существуют, и заполняет валюту ключа
+func (s *limitService) resolveCurrency(ctx context.Context, key *models.LimitKey) error {
+	switch key.Scope {
+	case models.LimitScopeCard, models.LimitScopeAccount:
+		account, err := s.scopeAccount(ctx, key.Scope, key.ScopeID)
+		if err != nil {
+			return err
+		}
+		if key.Currency != "" && key.Currency != account.Currency {
+			return errors.New("limit currency must match the account currency")
+		}
+		key.Currency = account.Currency
+	case models.LimitScopeUser:
+		user, err := s.userRepo.FindByID(ctx, key.ScopeID)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return errors.New("user not found")
+		}
+		if key.Currency == "" {
+			key.Currency = money.RUB
+		}
+	default:
+		return errors.New("invalid limit scope")
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bank-service/internal/exchange"
+	"github.com/bank-service/internal/iso20022"
+	"github.com/bank-service/internal/money"
+	"github.com/bank-service/internal/policy"
+)
+
+// ErrInvalidPaymentFile — документ не прошёл проверку по схеме и отклонён целиком
+var ErrInvalidPaymentFile = errors.New("payment file does not conform to the schema")
+
+type paymentService struct {
+	accountService AccountService
+	policy         policy.Policy
+}
+
+func NewPaymentService(accountService AccountService, policy policy.Policy) PaymentService {
+	return &paymentService{
+		accountService: accountService,
+		policy:         policy,
+	}
+}
+
+func (s *paymentService) ExportStatement(ctx context.Context, accountID, userID int64, from, to time.Time) (*iso20022.Camt053Document, error) {
+	statement, err := s.accountService.GetStatement(ctx, accountID, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	messageID := "STMT-" + strconv.FormatInt(accountID, 10) + "-" + strconv.FormatInt(statement.GeneratedAt.UnixNano(), 36)
+	return iso20022.NewCamt053(statement, messageID), nil
+}
+
+// ImportCreditTransfers исполняет поручения pain.001 переводами между счетами банка.
+// Каждое поручение исполняется отдельной транзакцией через AccountService.Transfer,
+// поэтому отказ по одному поручению не отменяет остальные; итог по каждому
+// возвращается в отчёте pain.002. Сумма поручения должна быть в валюте счёта плательщика,
+// отложенное исполнение не поддерживается — поручения с будущей датой отклоняются.
+func (s *paymentService) ImportCreditTransfers(ctx context.Context, userID int64, document *iso20022.Pain001Document) (*iso20022.Pain002Document, error) {
+	now := time.Now()
+	report := iso20022.NewPain002(document, "PSR-"+strconv.FormatInt(now.UnixNano(), 36), now)
+
+	if violations := document.Validate(); len(violations) > 0 {
+		report.Reject(iso20022.ReasonInvalidFileFormat, violations...)
+		return report, ErrInvalidPaymentFile
+	}
+
+	today := now.Format("2006-01-02")
+	for i := range document.Initiation.PaymentInfos {
+		info := &document.Initiation.PaymentInfos[i]
+		status := report.AddPaymentInfo(info.ID)
+
+		// Причина отказа, общая для всех поручений блока
+		rejectCode, rejectInfo := "", ""
+		executionDate, _ := info.ExecutionDate()
+		debtorID, ok := info.DebtorAccount.AccountID()
+		var currency money.Currency
+		switch {
+		case executionDate.Format("2006-01-02") > today:
+			rejectCode, rejectInfo = iso20022.ReasonInvalidDate, "future-dated payments are not supported"
+		case !ok:
+			rejectCode, rejectInfo = iso20022.ReasonInvalidDebtorAccount, "debtor account must be identified by Othr/Id"
+		default:
+			account, err := s.policy.Account(ctx, userID, debtorID)
+			switch {
+			case errors.Is(err, policy.ErrNotFound):
+				rejectCode, rejectInfo = iso20022.ReasonInvalidDebtorAccount, err.Error()
+			case err != nil:
+				return nil, err
+			default:
+				currency = account.Currency
+			}
+		}
+
+		for j := range info.Transactions {
+			transaction := &info.Transactions[j]
+			if rejectCode != "" {
+				status.RejectTransaction(transaction, rejectCode, rejectInfo)
+				continue
+			}
+			creditorID, ok := transaction.CreditorAccount.AccountID()
+			if !ok {
+				status.RejectTransaction(transaction, iso20022.ReasonInvalidCreditorAccount, "creditor account must be identified by Othr/Id")
+				continue
+			}
+			instructed := transaction.Amount.Instructed
+			if money.Currency(instructed.Currency) != currency {
+				status.RejectTransaction(transaction, iso20022.ReasonNotAllowedCurrency, "instructed amount must be in the debtor account currency")
+				continue
+			}
+			amount, err := money.Parse(strings.TrimSpace(instructed.Value))
+			if err != nil {
+				status.RejectTransaction(transaction, iso20022.ReasonInvalidAmount, err.Error())
+				continue
+			}
+
+			if err := s.accountService.Transfer(ctx, userID, debtorID, creditorID, amount); err != nil {
+				status.RejectTransaction(transaction, transferRejectReason(err), err.Error())
+				continue
+			}
+			status.Accept(transaction)
+		}
+	}
+
+	report.Finalize()
+	return report, nil
+}
+
+// transferRejectReason сопоставляет ошибку перевода с кодом причины отказа ISO 20022
+func transferRejectReason(err error) string {
+	switch {
+	case errors.Is(err, exchange.ErrRateNotFound):
+		return iso20022.ReasonIncorrectCurrency
+	case errors.Is(err, ErrInsufficientFunds):
+		return iso20022.ReasonInsufficientFunds
+	case errors.Is(err, ErrAccountFrozen):
+		return iso20022.ReasonBlockedAccount
+	}
+	switch err.Error() {
+	case "source account not found":
+		return iso20022.ReasonInvalidDebtorAccount
+	case "destination account not found", "cannot transfer to the same account":
+		return iso20022.ReasonInvalidCreditorAccount
+	case "amount must be positive", "amount is too small to convert":
+		return iso20022.ReasonInvalidAmount
+	}
+	return iso20022.ReasonNarrative
+}
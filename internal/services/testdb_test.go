@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bank-service/internal/migrator"
+	"github.com/bank-service/internal/models"
+	"github.com/bank-service/internal/repositories"
+	"github.com/bank-service/migrations"
+	_ "github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// testDatabaseDSNEnv — переменная окружения со строкой подключения к тестовой базе PostgreSQL.
+// Тесты с базой пропускаются, если она не задана, например:
+//
+//	BANK_TEST_DATABASE_DSN="host=localhost port=5436 user=test password=test dbname=bank_test sslmode=disable" go test ./...
+const testDatabaseDSNEnv = "BANK_TEST_DATABASE_DSN"
+
+// openTestDB подключается к тестовой базе и применяет к ней миграции
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv(testDatabaseDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set, skipping database test", testDatabaseDSNEnv)
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		t.Fatalf("connect to database: %v", err)
+	}
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	m, err := migrator.New(db, migrations.FS, logger)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("apply migrations: %v", err)
+	}
+	return db
+}
+
+// createTestUser создаёт отдельного пользователя для теста, чтобы данные разных
+// запусков не пересекались
+func createTestUser(t *testing.T, db *sql.DB) *models.User {
+	t.Helper()
+	suffix := time.Now().UnixNano()
+	user := &models.User{
+		Username:  fmt.Sprintf("test_%d", suffix),
+		Email:     fmt.Sprintf("test_%d@example.com", suffix),
+		Password:  "not-a-real-password",
+		Role:      models.RoleCustomer,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := repositories.NewUserRepository(db).Create(context.Background(), user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return user
+}
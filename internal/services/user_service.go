@@ -2,6 +2,11 @@ package services
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"time"
 
@@ -11,15 +16,28 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected, session revoked")
+)
+
 type userService struct {
-	userRepo  repositories.UserRepository
-	jwtSecret string
+	userRepo        repositories.UserRepository
+	tokenRepo       repositories.TokenRepository
+	jwtSecret       string
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
+	db              *sql.DB
 }
 
-func NewUserService(userRepo repositories.UserRepository, jwtSecret string) UserService {
+func NewUserService(userRepo repositories.UserRepository, tokenRepo repositories.TokenRepository, jwtSecret string, accessTokenTTL, refreshTokenTTL time.Duration, db *sql.DB) UserService {
 	return &userService{
-		userRepo:  userRepo,
-		jwtSecret: jwtSecret,
+		userRepo:        userRepo,
+		tokenRepo:       tokenRepo,
+		jwtSecret:       jwtSecret,
+		accessTokenTTL:  accessTokenTTL,
+		refreshTokenTTL: refreshTokenTTL,
+		db:              db,
 	}
 }
 
@@ -44,6 +62,7 @@ func (s *userService) Register(ctx context.Context, username, email, password st
 		Username:  username,
 		Email:     email,
 		Password:  string(hashedPassword),
+		Role:      models.RoleCustomer,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
@@ -61,32 +80,182 @@ func (s *userService) Register(ctx context.Context, username, email, password st
 	return user, nil
 }
 
-func (s *userService) Login(ctx context.Context, email, password string) (string, error) {
+func (s *userService) Login(ctx context.Context, email, password string) (*models.TokenPair, error) {
 	// Находим пользователя по email
 	user, err := s.userRepo.FindByEmail(ctx, email)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	if user == nil {
-		return "", errors.New("invalid email or password")
+		return nil, errors.New("invalid email or password")
 	}
 
 	// Проверяем пароль
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return "", errors.New("invalid email or password")
+		return nil, errors.New("invalid email or password")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Каждый вход открывает новую сессию — семейство refresh-токенов
+	familyID, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	family := &models.TokenFamily{
+		ID:        familyID,
+		UserID:    user.ID,
+		CreatedAt: time.Now(),
+	}
+	if err := s.tokenRepo.CreateFamily(ctx, tx, family); err != nil {
+		return nil, err
+	}
+
+	pair, err := s.issueTokens(ctx, tx, user, family.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// RefreshTokens обменивает refresh-токен на новую пару токенов той же сессии.
+// Использованный токен больше не принимается; его повторное предъявление означает,
+// что токен мог быть украден, поэтому сессия отзывается целиком вместе с выданными в ней токенами.
+func (s *userService) RefreshTokens(ctx context.Context, refreshToken string) (*models.TokenPair, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	token, err := s.tokenRepo.FindRefreshTokenForUpdate(ctx, tx, hashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if token == nil || token.FamilyRevokedAt != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	now := time.Now()
+	if token.UsedAt != nil {
+		if err := s.tokenRepo.RevokeFamily(ctx, tx, token.FamilyID, "refresh token reuse", now); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+	if !now.Before(token.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if err := s.tokenRepo.MarkRefreshTokenUsed(ctx, tx, token.ID, now); err != nil {
+		return nil, err
+	}
+	// Роль берётся из базы, а не из прежнего токена: её могли изменить за время сессии
+	user, err := s.userRepo.FindByID(ctx, token.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	pair, err := s.issueTokens(ctx, tx, user, token.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// Logout завершает сессию: отзывает её refresh-токены и текущий access-токен
+func (s *userService) Logout(ctx context.Context, userID int64, jti, sessionID string, expiresAt time.Time) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.tokenRepo.RevokeFamily(ctx, tx, sessionID, "logout", time.Now()); err != nil {
+		return err
+	}
+	if err := s.tokenRepo.RevokeAccessToken(ctx, tx, jti, userID, expiresAt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *userService) PurgeExpiredTokens(ctx context.Context) (int64, error) {
+	return s.tokenRepo.DeleteExpired(ctx, time.Now())
+}
+
+// issueTokens выдаёт короткоживущий access-токен и новый refresh-токен сессии
+func (s *userService) issueTokens(ctx context.Context, tx *sql.Tx, user *models.User, familyID string) (*models.TokenPair, error) {
+	now := time.Now()
+	jti, err := randomToken(16)
+	if err != nil {
+		return nil, err
 	}
 
-	// Генерируем JWT
+	// Генерируем JWT; jti и sid позволяют отозвать токен до истечения срока
+	accessExpiresAt := now.Add(s.accessTokenTTL)
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"user_id": user.ID,
-		"exp":     time.Now().Add(time.Hour * 24).Unix(),
+		"role":    user.Role,
+		"jti":     jti,
+		"sid":     familyID,
+		"iat":     now.Unix(),
+		"exp":     accessExpiresAt.Unix(),
 	})
-	tokenString, err := token.SignedString([]byte(s.jwtSecret))
+	accessToken, err := token.SignedString([]byte(s.jwtSecret))
 	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	err = s.tokenRepo.CreateRefreshToken(ctx, tx, &models.RefreshToken{
+		FamilyID:  familyID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: now.Add(s.refreshTokenTTL),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TokenPair{
+		AccessToken:     accessToken,
+		AccessExpiresAt: accessExpiresAt,
+		RefreshToken:    refreshToken,
+	}, nil
+}
+
+// randomToken возвращает n случайных байт в base64url без дополнения
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
 		return "", err
 	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
 
-	return tokenString, nil
+// hashToken вычисляет хеш refresh-токена для хранения в базе
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
 func (s *userService) GetProfile(ctx context.Context, userID int64) (*models.User, error) {
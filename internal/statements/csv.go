@@ -0,0 +1,47 @@
+package statements
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/bank-service/internal/models"
+)
+
+type csvRenderer struct{}
+
+func (csvRenderer) ContentType() string   { return "text/csv; charset=utf-8" }
+func (csvRenderer) FileExtension() string { return "csv" }
+
+// Render записывает выписку в CSV: строка входящего остатка, операции
+// с нарастающим остатком и строка исходящего остатка
+func (csvRenderer) Render(w io.Writer, statement *models.Statement) error {
+	writer := csv.NewWriter(w)
+
+	currency := statement.Account.Currency.String()
+	records := [][]string{
+		{"date", "id", "type", "description", "amount", "currency", "balance"},
+		{statement.From.Format(time.RFC3339), "", "opening_balance", "Opening balance", "", currency, statement.OpeningBalance.String()},
+	}
+
+	balance := statement.OpeningBalance
+	for _, transaction := range statement.Transactions {
+		balance = balance.Add(transaction.Amount)
+		records = append(records, []string{
+			transaction.CreatedAt.Format(time.RFC3339),
+			strconv.FormatInt(transaction.ID, 10),
+			transaction.Type,
+			transaction.Description,
+			transaction.Amount.String(),
+			currency,
+			balance.String(),
+		})
+	}
+	records = append(records, []string{statement.To.Format(time.RFC3339), "", "closing_balance", "Closing balance", "", currency, statement.ClosingBalance.String()})
+
+	if err := writer.WriteAll(records); err != nil {
+		return err
+	}
+	return writer.Error()
+}
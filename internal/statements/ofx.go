@@ -0,0 +1,143 @@
+package statements
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/bank-service/internal/models"
+)
+
+type ofxRenderer struct{}
+
+func (ofxRenderer) ContentType() string   { return "application/x-ofx" }
+func (ofxRenderer) FileExtension() string { return "ofx" }
+
+const ofxHeader = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+`
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxTransaction struct {
+	Type     string `xml:"TRNTYPE"`
+	Posted   string `xml:"DTPOSTED"`
+	Amount   string `xml:"TRNAMT"`
+	FITID    string `xml:"FITID"`
+	Name     string `xml:"NAME,omitempty"`
+	Memo     string `xml:"MEMO,omitempty"`
+	Currency string `xml:"CURRENCY>CURSYM,omitempty"`
+}
+
+type ofxBalance struct {
+	Amount string `xml:"BALAMT"`
+	AsOf   string `xml:"DTASOF"`
+}
+
+type ofxDocument struct {
+	XMLName xml.Name `xml:"OFX"`
+	SignOn  struct {
+		Status   ofxStatus `xml:"STATUS"`
+		Server   string    `xml:"DTSERVER"`
+		Language string    `xml:"LANGUAGE"`
+	} `xml:"SIGNONMSGSRSV1>SONRS"`
+	Statement struct {
+		TransactionUID string    `xml:"TRNUID"`
+		Status         ofxStatus `xml:"STATUS"`
+		Response       struct {
+			Currency string `xml:"CURDEF"`
+			Account  struct {
+				BankID    string `xml:"BANKID"`
+				AccountID string `xml:"ACCTID"`
+				Type      string `xml:"ACCTTYPE"`
+			} `xml:"BANKACCTFROM"`
+			TransactionList struct {
+				Start        string           `xml:"DTSTART"`
+				End          string           `xml:"DTEND"`
+				Transactions []ofxTransaction `xml:"STMTTRN"`
+			} `xml:"BANKTRANLIST"`
+			LedgerBalance ofxBalance `xml:"LEDGERBAL"`
+		} `xml:"STMTRS"`
+	} `xml:"BANKMSGSRSV1>STMTTRNRS"`
+}
+
+// ofxTime форматирует время в формате OFX (YYYYMMDDHHMMSS.XXX[смещение:зона]) в UTC
+func ofxTime(t time.Time) string {
+	return t.UTC().Format("20060102150405.000") + "[0:GMT]"
+}
+
+// ofxTransactionType сопоставляет тип операции с TRNTYPE из спецификации OFX
+func ofxTransactionType(transaction *models.Transaction) string {
+	switch transaction.Type {
+	case models.TransactionTypeDeposit:
+		return "DEP"
+	case models.TransactionTypeTransferIn, models.TransactionTypeTransferOut:
+		return "XFER"
+	case models.TransactionTypeCardPurchase:
+		return "POS"
+	case models.TransactionTypeWithdrawal:
+		return "CASH"
+	}
+	if transaction.Amount.IsNegative() {
+		return "DEBIT"
+	}
+	return "CREDIT"
+}
+
+// Render записывает выписку в формате OFX 2.2 (STMTRS банковского счёта)
+func (ofxRenderer) Render(w io.Writer, statement *models.Statement) error {
+	var doc ofxDocument
+	doc.SignOn.Status = ofxStatus{Code: 0, Severity: "INFO"}
+	doc.SignOn.Server = ofxTime(statement.GeneratedAt)
+	doc.SignOn.Language = "ENG"
+
+	accountID := strconv.FormatInt(statement.Account.ID, 10)
+	doc.Statement.TransactionUID = accountID + "-" + strconv.FormatInt(statement.GeneratedAt.Unix(), 10)
+	doc.Statement.Status = ofxStatus{Code: 0, Severity: "INFO"}
+
+	resp := &doc.Statement.Response
+	resp.Currency = statement.Account.Currency.String()
+	resp.Account.BankID = BankID
+	resp.Account.AccountID = accountID
+	resp.Account.Type = "CHECKING"
+	resp.TransactionList.Start = ofxTime(statement.From)
+	resp.TransactionList.End = ofxTime(statement.To)
+	for _, transaction := range statement.Transactions {
+		resp.TransactionList.Transactions = append(resp.TransactionList.Transactions, ofxTransaction{
+			Type:   ofxTransactionType(transaction),
+			Posted: ofxTime(transaction.CreatedAt),
+			Amount: transaction.Amount.String(),
+			FITID:  strconv.FormatInt(transaction.ID, 10),
+			Name:   truncate(transaction.Description, 32),
+			Memo:   transaction.Description,
+		})
+	}
+	resp.LedgerBalance = ofxBalance{
+		Amount: statement.ClosingBalance.String(),
+		AsOf:   ofxTime(statement.To),
+	}
+
+	if _, err := io.WriteString(w, ofxHeader); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// truncate обрезает строку до n символов (NAME в OFX ограничен 32 символами)
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
@@ -0,0 +1,156 @@
+package statements
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bank-service/internal/models"
+)
+
+type pdfRenderer struct{}
+
+func (pdfRenderer) ContentType() string   { return "application/pdf" }
+func (pdfRenderer) FileExtension() string { return "pdf" }
+
+// Геометрия страницы A4 в пунктах
+const (
+	pdfPageWidth    = 595
+	pdfPageHeight   = 842
+	pdfMargin       = 50
+	pdfFontSize     = 9
+	pdfLineHeight   = 13
+	pdfLinesPerPage = (pdfPageHeight - 2*pdfMargin) / pdfLineHeight
+)
+
+// Render формирует выписку в виде PDF 1.4 со стандартным шрифтом Helvetica.
+// Документ собирается вручную, без внешних зависимостей: заголовок,
+// таблица операций с постраничной разбивкой, итоги и исходящий остаток.
+func (pdfRenderer) Render(w io.Writer, statement *models.Statement) error {
+	currency := statement.Account.Currency.String()
+	lines := []string{
+		"Account statement",
+		"",
+		"Account: " + strconv.FormatInt(statement.Account.ID, 10) + " (" + currency + ")",
+		"Period: " + statement.From.Format(time.RFC3339) + " - " + statement.To.Format(time.RFC3339),
+		"Generated: " + statement.GeneratedAt.Format(time.RFC3339),
+		"",
+		"Opening balance: " + statement.OpeningBalance.String() + " " + currency,
+		"",
+		fmt.Sprintf("%-20s %-10s %-14s %14s  %s", "Date", "ID", "Type", "Amount", "Description"),
+	}
+	for _, transaction := range statement.Transactions {
+		lines = append(lines, fmt.Sprintf("%-20s %-10d %-14s %14s  %s",
+			transaction.CreatedAt.Format("2006-01-02 15:04:05"),
+			transaction.ID,
+			transaction.Type,
+			transaction.Amount.String(),
+			truncate(transaction.Description, 40),
+		))
+	}
+	lines = append(lines,
+		"",
+		"Total credits: "+statement.TotalCredits.String()+" "+currency,
+		"Total debits: "+statement.TotalDebits.String()+" "+currency,
+		"Closing balance: "+statement.ClosingBalance.String()+" "+currency,
+	)
+
+	var pages [][]string
+	for len(lines) > pdfLinesPerPage {
+		pages = append(pages, lines[:pdfLinesPerPage])
+		lines = lines[pdfLinesPerPage:]
+	}
+	pages = append(pages, lines)
+
+	doc := &pdfDocument{}
+	// Объекты 1 и 2 — каталог и дерево страниц, 3 — шрифт; страницы и их содержимое идут следом
+	catalog := doc.reserve()
+	pagesObject := doc.reserve()
+	font := doc.reserve()
+
+	var kids []string
+	for i, page := range pages {
+		var content bytes.Buffer
+		content.WriteString("BT\n/F1 " + strconv.Itoa(pdfFontSize) + " Tf\n")
+		content.WriteString(strconv.Itoa(pdfLineHeight) + " TL\n")
+		content.WriteString(strconv.Itoa(pdfMargin) + " " + strconv.Itoa(pdfPageHeight-pdfMargin) + " Td\n")
+		for _, line := range page {
+			content.WriteString("(" + pdfEscape(line) + ") Tj T*\n")
+		}
+		content.WriteString("ET\n")
+		footer := fmt.Sprintf("Page %d of %d", i+1, len(pages))
+		content.WriteString(fmt.Sprintf("BT\n/F1 %d Tf\n%d %d Td\n(%s) Tj\nET\n", pdfFontSize, pdfPageWidth-pdfMargin-60, pdfMargin/2, pdfEscape(footer)))
+
+		contentObject := doc.add(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+		pageObject := doc.add(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>",
+			pagesObject, pdfPageWidth, pdfPageHeight, font, contentObject))
+		kids = append(kids, strconv.Itoa(pageObject)+" 0 R")
+	}
+
+	doc.set(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesObject))
+	doc.set(pagesObject, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+	doc.set(font, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+
+	_, err := doc.WriteTo(w, catalog)
+	return err
+}
+
+// pdfDocument накапливает пронумерованные объекты PDF и записывает их с таблицей ссылок
+type pdfDocument struct {
+	objects []string
+}
+
+func (d *pdfDocument) reserve() int {
+	d.objects = append(d.objects, "")
+	return len(d.objects)
+}
+
+func (d *pdfDocument) add(body string) int {
+	d.objects = append(d.objects, body)
+	return len(d.objects)
+}
+
+func (d *pdfDocument) set(number int, body string) {
+	d.objects[number-1] = body
+}
+
+func (d *pdfDocument) WriteTo(w io.Writer, root int) (int64, error) {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	offsets := make([]int, len(d.objects))
+	for i, body := range d.objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, body)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(d.objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(d.objects)+1, root, xref)
+
+	return buf.WriteTo(w)
+}
+
+// pdfEscape экранирует строку для литерала PDF. Стандартный шрифт не содержит
+// кириллицы, поэтому символы вне ASCII заменяются на '?'.
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,34 @@
+package statements
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/bank-service/internal/models"
+)
+
+var ErrUnknownFormat = errors.New("unknown statement format")
+
+// Renderer формирует выписку в конкретном формате
+type Renderer interface {
+	Render(w io.Writer, statement *models.Statement) error
+	ContentType() string
+	FileExtension() string
+}
+
+// BankID — идентификатор банка, указываемый в машиночитаемых выписках
+const BankID = "BANKSERVICE"
+
+// RendererFor возвращает формирователь выписки для формата csv, ofx или pdf
+func RendererFor(format string) (Renderer, error) {
+	switch strings.ToLower(format) {
+	case "csv":
+		return csvRenderer{}, nil
+	case "ofx":
+		return ofxRenderer{}, nil
+	case "pdf":
+		return pdfRenderer{}, nil
+	}
+	return nil, ErrUnknownFormat
+}
@@ -0,0 +1,7 @@
+-- Схема bank не удаляется: в ней хранится таблица schema_migrations
+DROP TABLE IF EXISTS bank.payment_schedules;
+DROP TABLE IF EXISTS bank.credits;
+DROP TABLE IF EXISTS bank.transactions;
+DROP TABLE IF EXISTS bank.cards;
+DROP TABLE IF EXISTS bank.accounts;
+DROP TABLE IF EXISTS bank.users;
@@ -1,9 +1,7 @@
 CREATE SCHEMA IF NOT EXISTS bank;
 
-CREATE EXTENSION IF NOT EXISTS pgcrypto;
-
 -- Таблица пользователей
-CREATE TABLE users (
+CREATE TABLE IF NOT EXISTS bank.users (
     id BIGSERIAL PRIMARY KEY,
     username VARCHAR(50) UNIQUE NOT NULL,
     email VARCHAR(255) UNIQUE NOT NULL,
@@ -13,9 +11,9 @@ CREATE TABLE users (
 );
 
 -- Таблица счетов
-CREATE TABLE accounts (
+CREATE TABLE IF NOT EXISTS bank.accounts (
     id BIGSERIAL PRIMARY KEY,
-    user_id BIGINT REFERENCES users(id) ON DELETE CASCADE,
+    user_id BIGINT REFERENCES bank.users(id) ON DELETE CASCADE,
     balance NUMERIC(15, 2) DEFAULT 0.0,
     currency VARCHAR(3) DEFAULT 'RUB',
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
@@ -23,11 +21,11 @@ CREATE TABLE accounts (
 );
 
 -- Таблица карт
-CREATE TABLE cards (
+CREATE TABLE IF NOT EXISTS bank.cards (
     id BIGSERIAL PRIMARY KEY,
-    account_id BIGINT REFERENCES accounts(id) ON DELETE CASCADE,
-    card_number TEXT NOT NULL, -- Зашифровано с помощью PGP
-    expiry_date TEXT NOT NULL, -- Зашифровано с помощью PGP
+    account_id BIGINT REFERENCES bank.accounts(id) ON DELETE CASCADE,
+    card_number TEXT NOT NULL,
+    expiry_date TEXT NOT NULL,
     cvv TEXT NOT NULL, -- Хешировано с помощью bcrypt
     hmac TEXT NOT NULL, -- HMAC для проверки целостности
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
@@ -35,19 +33,19 @@ CREATE TABLE cards (
 );
 
 -- Таблица транзакций
-CREATE TABLE transactions (
+CREATE TABLE IF NOT EXISTS bank.transactions (
     id BIGSERIAL PRIMARY KEY,
-    account_id BIGINT REFERENCES accounts(id) ON DELETE CASCADE,
+    account_id BIGINT REFERENCES bank.accounts(id) ON DELETE CASCADE,
     amount NUMERIC(15, 2) NOT NULL,
-    type VARCHAR(50) NOT NULL, -- deposit, withdrawal, transfer
+    type VARCHAR(50) NOT NULL,
     description TEXT,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
 
 -- Таблица кредитов
-CREATE TABLE credits (
+CREATE TABLE IF NOT EXISTS bank.credits (
     id BIGSERIAL PRIMARY KEY,
-    user_id BIGINT REFERENCES users(id) ON DELETE CASCADE,
+    user_id BIGINT REFERENCES bank.users(id) ON DELETE CASCADE,
     amount NUMERIC(15, 2) NOT NULL,
     interest_rate NUMERIC(5, 2) NOT NULL,
     term_months INTEGER NOT NULL,
@@ -56,13 +54,13 @@ CREATE TABLE credits (
 );
 
 -- Таблица графика платежей
-CREATE TABLE payment_schedules (
+CREATE TABLE IF NOT EXISTS bank.payment_schedules (
     id BIGSERIAL PRIMARY KEY,
-    credit_id BIGINT REFERENCES credits(id) ON DELETE CASCADE,
+    credit_id BIGINT REFERENCES bank.credits(id) ON DELETE CASCADE,
     payment_date DATE NOT NULL,
     amount NUMERIC(15, 2) NOT NULL,
     paid BOOLEAN DEFAULT FALSE,
     penalty NUMERIC(15, 2) DEFAULT 0.0,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
-);
\ No newline at end of file
+);
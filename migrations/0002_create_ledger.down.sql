@@ -0,0 +1,3 @@
+ALTER TABLE bank.transactions DROP COLUMN IF EXISTS entry_id;
+DROP TABLE IF EXISTS bank.postings;
+DROP TABLE IF EXISTS bank.journal_entries;
@@ -0,0 +1,46 @@
+-- Журнал двойной записи: проводки каждой записи в сумме по валюте дают ноль
+CREATE TABLE IF NOT EXISTS bank.journal_entries (
+    id BIGSERIAL PRIMARY KEY,
+    type VARCHAR(50) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Проводка относится либо к клиентскому счёту, либо к системному счёту банка
+CREATE TABLE IF NOT EXISTS bank.postings (
+    id BIGSERIAL PRIMARY KEY,
+    entry_id BIGINT NOT NULL REFERENCES bank.journal_entries(id),
+    account_id BIGINT REFERENCES bank.accounts(id),
+    system_account VARCHAR(50),
+    amount NUMERIC(15, 2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((account_id IS NULL) <> (system_account IS NULL))
+);
+CREATE INDEX IF NOT EXISTS postings_entry_id_idx ON bank.postings (entry_id);
+CREATE INDEX IF NOT EXISTS postings_account_id_idx ON bank.postings (account_id);
+
+ALTER TABLE bank.transactions
+ADD COLUMN IF NOT EXISTS entry_id BIGINT REFERENCES bank.journal_entries(id);
+
+-- Остатки, появившиеся до введения журнала, оформляются входящими проводками
+DO $$
+DECLARE
+    acc RECORD;
+    new_entry_id BIGINT;
+BEGIN
+    FOR acc IN
+        SELECT a.id, a.balance, a.currency
+        FROM bank.accounts a
+        WHERE a.balance <> 0
+        AND NOT EXISTS (SELECT 1 FROM bank.postings p WHERE p.account_id = a.id)
+    LOOP
+        INSERT INTO bank.journal_entries (type, description)
+        VALUES ('opening_balance', 'Opening balance for account ' || acc.id)
+        RETURNING id INTO new_entry_id;
+        INSERT INTO bank.postings (entry_id, account_id, amount, currency)
+        VALUES (new_entry_id, acc.id, acc.balance, acc.currency);
+        INSERT INTO bank.postings (entry_id, system_account, amount, currency)
+        VALUES (new_entry_id, 'opening-balance', -acc.balance, acc.currency);
+    END LOOP;
+END $$;
@@ -0,0 +1,14 @@
+-- Ключи идемпотентности и сохранённые ответы на денежные операции
+CREATE TABLE IF NOT EXISTS bank.idempotency_keys (
+    user_id BIGINT REFERENCES bank.users(id) ON DELETE CASCADE,
+    key VARCHAR(255) NOT NULL,
+    request_method VARCHAR(10) NOT NULL,
+    request_path TEXT NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,
+    status_code INTEGER,
+    response_body BYTEA,
+    response_content_type TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    PRIMARY KEY (user_id, key)
+);
@@ -0,0 +1 @@
+ALTER TABLE bank.transactions DROP COLUMN IF EXISTS exchange_rate;
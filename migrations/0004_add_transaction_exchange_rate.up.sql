@@ -0,0 +1,3 @@
+-- Курс пересчёта, применённый к обеим частям межвалютного перевода
+ALTER TABLE bank.transactions
+ADD COLUMN IF NOT EXISTS exchange_rate NUMERIC(18, 8);
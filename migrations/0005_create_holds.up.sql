@@ -0,0 +1,14 @@
+-- Холды резервируют средства до подтверждения или отмены операции
+CREATE TABLE IF NOT EXISTS bank.holds (
+    id BIGSERIAL PRIMARY KEY,
+    account_id BIGINT NOT NULL REFERENCES bank.accounts(id) ON DELETE CASCADE,
+    card_id BIGINT REFERENCES bank.cards(id) ON DELETE SET NULL,
+    amount NUMERIC(15, 2) NOT NULL CHECK (amount > 0),
+    captured_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    description TEXT,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS holds_active_account_id_idx ON bank.holds (account_id) WHERE status = 'active';
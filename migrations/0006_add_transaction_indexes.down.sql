@@ -0,0 +1,5 @@
+-- Расширение pg_trgm оставляем: оно может использоваться вне схемы bank
+DROP INDEX IF EXISTS bank.transactions_description_trgm_idx;
+DROP INDEX IF EXISTS bank.transactions_account_abs_amount_idx;
+DROP INDEX IF EXISTS bank.transactions_account_type_created_idx;
+DROP INDEX IF EXISTS bank.transactions_account_created_idx;
@@ -0,0 +1,6 @@
+-- Индексы для постраничной выборки истории операций и фильтров
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS transactions_account_created_idx ON bank.transactions (account_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS transactions_account_type_created_idx ON bank.transactions (account_id, type, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS transactions_account_abs_amount_idx ON bank.transactions (account_id, ABS(amount));
+CREATE INDEX IF NOT EXISTS transactions_description_trgm_idx ON bank.transactions USING GIN (description gin_trgm_ops);
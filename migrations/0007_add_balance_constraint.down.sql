@@ -0,0 +1 @@
+ALTER TABLE bank.accounts DROP CONSTRAINT IF EXISTS accounts_balance_non_negative;
@@ -0,0 +1,8 @@
+-- Защита от отрицательного остатка на уровне базы, даже если проверка в коде будет обойдена
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'accounts_balance_non_negative') THEN
+        ALTER TABLE bank.accounts
+        ADD CONSTRAINT accounts_balance_non_negative CHECK (balance >= 0) NOT VALID;
+    END IF;
+END $$;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS bank.revoked_access_tokens;
+DROP TABLE IF EXISTS bank.refresh_tokens;
+DROP TABLE IF EXISTS bank.token_families;
@@ -0,0 +1,29 @@
+-- Сессии пользователей: семейства refresh-токенов, выданных при одном входе
+CREATE TABLE IF NOT EXISTS bank.token_families (
+    id VARCHAR(64) PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES bank.users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    revoke_reason TEXT
+);
+
+-- Refresh-токены хранятся только в виде SHA-256 хеша
+CREATE TABLE IF NOT EXISTS bank.refresh_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    family_id VARCHAR(64) NOT NULL REFERENCES bank.token_families(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS refresh_tokens_family_id_idx ON bank.refresh_tokens (family_id);
+CREATE INDEX IF NOT EXISTS refresh_tokens_expires_at_idx ON bank.refresh_tokens (expires_at);
+
+-- Отозванные access-токены (jti) до истечения их срока действия
+CREATE TABLE IF NOT EXISTS bank.revoked_access_tokens (
+    jti VARCHAR(64) PRIMARY KEY,
+    user_id BIGINT REFERENCES bank.users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS revoked_access_tokens_expires_at_idx ON bank.revoked_access_tokens (expires_at);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS bank.users_email_trgm_idx;
+DROP INDEX IF EXISTS bank.users_username_trgm_idx;
+DROP TABLE IF EXISTS bank.admin_actions;
+ALTER TABLE bank.journal_entries DROP COLUMN IF EXISTS reverses_entry_id;
+ALTER TABLE bank.accounts DROP COLUMN IF EXISTS status;
+ALTER TABLE bank.users DROP COLUMN IF EXISTS role;
@@ -0,0 +1,29 @@
+-- Роль пользователя: customer, operator, admin или auditor
+ALTER TABLE bank.users
+ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'customer'
+CHECK (role IN ('customer', 'operator', 'admin', 'auditor'));
+
+-- Статус счёта: списания с замороженного счёта запрещены
+ALTER TABLE bank.accounts
+ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'active'
+CHECK (status IN ('active', 'frozen'));
+
+-- Сторнирующая проводка ссылается на исходную; уникальность не даёт сторнировать дважды
+ALTER TABLE bank.journal_entries
+ADD COLUMN IF NOT EXISTS reverses_entry_id BIGINT UNIQUE REFERENCES bank.journal_entries(id);
+
+-- Журнал действий сотрудников банка; actor_id пуст для действий из командной строки
+CREATE TABLE IF NOT EXISTS bank.admin_actions (
+    id BIGSERIAL PRIMARY KEY,
+    actor_id BIGINT REFERENCES bank.users(id),
+    action VARCHAR(50) NOT NULL,
+    target_type VARCHAR(50) NOT NULL,
+    target_id BIGINT NOT NULL,
+    details TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS admin_actions_target_idx ON bank.admin_actions (target_type, target_id);
+
+-- Поиск пользователей по подстроке имени и email
+CREATE INDEX IF NOT EXISTS users_username_trgm_idx ON bank.users USING GIN (username gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS users_email_trgm_idx ON bank.users USING GIN (email gin_trgm_ops);
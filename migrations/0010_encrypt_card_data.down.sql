@@ -0,0 +1,18 @@
+-- Зашифрованные реквизиты нельзя расшифровать средствами базы, поэтому откат возможен,
+-- только пока ни одна карта не зашифрована
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM bank.cards WHERE card_number_enc IS NOT NULL) THEN
+        RAISE EXCEPTION 'bank.cards contains encrypted card data, migration cannot be reverted';
+    END IF;
+END $$;
+
+ALTER TABLE bank.cards DROP CONSTRAINT IF EXISTS cards_card_data_check;
+ALTER TABLE bank.cards ALTER COLUMN expiry_date SET NOT NULL;
+ALTER TABLE bank.cards ALTER COLUMN card_number SET NOT NULL;
+ALTER TABLE bank.cards
+DROP COLUMN IF EXISTS key_id,
+DROP COLUMN IF EXISTS data_key,
+DROP COLUMN IF EXISTS expiry_date_enc,
+DROP COLUMN IF EXISTS card_number_enc,
+DROP COLUMN IF EXISTS masked_number;
@@ -0,0 +1,24 @@
+-- Номер и срок действия карты хранятся зашифрованными конвертом: data_key — ключ данных,
+-- зашифрованный ключом key_id из файла ключей. Открытые колонки остаются только у карт,
+-- выпущенных до этой миграции; сервис шифрует их при запуске и очищает открытые значения.
+ALTER TABLE bank.cards
+ADD COLUMN IF NOT EXISTS masked_number TEXT,
+ADD COLUMN IF NOT EXISTS card_number_enc BYTEA,
+ADD COLUMN IF NOT EXISTS expiry_date_enc BYTEA,
+ADD COLUMN IF NOT EXISTS data_key BYTEA,
+ADD COLUMN IF NOT EXISTS key_id TEXT;
+
+ALTER TABLE bank.cards ALTER COLUMN card_number DROP NOT NULL;
+ALTER TABLE bank.cards ALTER COLUMN expiry_date DROP NOT NULL;
+
+UPDATE bank.cards
+SET masked_number = LEFT(card_number, 4) || ' **** **** ' || RIGHT(card_number, 4)
+WHERE masked_number IS NULL;
+ALTER TABLE bank.cards ALTER COLUMN masked_number SET NOT NULL;
+
+-- Реквизиты карты хранятся либо открыто (до шифрования), либо зашифрованными, но не одновременно
+ALTER TABLE bank.cards ADD CONSTRAINT cards_card_data_check CHECK (
+    (card_number_enc IS NOT NULL AND expiry_date_enc IS NOT NULL AND data_key IS NOT NULL AND key_id IS NOT NULL
+        AND card_number IS NULL AND expiry_date IS NULL)
+    OR (card_number_enc IS NULL AND card_number IS NOT NULL AND expiry_date IS NOT NULL)
+);
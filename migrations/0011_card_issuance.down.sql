@@ -0,0 +1,5 @@
+-- Пересчитанный hmac не совпадает с прежней схемой, но проверить его всё равно нечем
+DROP INDEX IF EXISTS bank.cards_hmac_idx;
+UPDATE bank.cards SET hmac = '' WHERE hmac IS NULL;
+ALTER TABLE bank.cards ALTER COLUMN hmac SET NOT NULL;
+ALTER TABLE bank.cards DROP COLUMN IF EXISTS product;
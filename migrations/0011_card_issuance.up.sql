@@ -0,0 +1,10 @@
+-- Карты выпускает сервис: у каждой карты есть продукт, а hmac вычисляется по одному
+-- номеру карты и служит для проверки его уникальности без расшифровки.
+ALTER TABLE bank.cards ADD COLUMN IF NOT EXISTS product TEXT NOT NULL DEFAULT 'legacy';
+ALTER TABLE bank.cards ALTER COLUMN product DROP DEFAULT;
+
+-- Прежний hmac считался по номеру вместе со сроком действия; сервис пересчитывает его
+-- при запуске. Совпадающие номера ранее заведённых карт остаются без hmac.
+ALTER TABLE bank.cards ALTER COLUMN hmac DROP NOT NULL;
+UPDATE bank.cards SET hmac = NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS cards_hmac_idx ON bank.cards (hmac);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS bank.card_status_history;
+DROP INDEX IF EXISTS bank.cards_expiry_idx;
+ALTER TABLE bank.cards
+DROP COLUMN IF EXISTS reissued_from_id,
+DROP COLUMN IF EXISTS expires_at,
+DROP COLUMN IF EXISTS status;
@@ -0,0 +1,22 @@
+-- Статус карты и срок окончания действия. expires_at ранее выпущенных карт заполняет
+-- фоновая задача истечения карт: срок действия хранится зашифрованным.
+ALTER TABLE bank.cards
+ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'active'
+CHECK (status IN ('active', 'blocked', 'lost', 'stolen', 'expired', 'closed')),
+ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP WITH TIME ZONE,
+-- Перевыпущенная карта ссылается на прежнюю; уникальность не даёт перевыпустить карту дважды
+ADD COLUMN IF NOT EXISTS reissued_from_id BIGINT UNIQUE REFERENCES bank.cards(id);
+
+CREATE INDEX IF NOT EXISTS cards_expiry_idx ON bank.cards (expires_at) WHERE status IN ('active', 'blocked');
+
+-- Журнал смены статусов карты; actor_id пуст для действий системы (например, истечения срока)
+CREATE TABLE IF NOT EXISTS bank.card_status_history (
+    id BIGSERIAL PRIMARY KEY,
+    card_id BIGINT NOT NULL REFERENCES bank.cards(id) ON DELETE CASCADE,
+    from_status VARCHAR(20),
+    to_status VARCHAR(20) NOT NULL,
+    actor_id BIGINT REFERENCES bank.users(id),
+    reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS card_status_history_card_idx ON bank.card_status_history (card_id, created_at);
@@ -0,0 +1,23 @@
+-- Сообщения карточного процессинга (симулятор ISO 8583) и ответы на них.
+-- Ключ повтора — операция, терминал, STAN и время передачи из поля 7.
+CREATE TABLE IF NOT EXISTS bank.card_authorizations (
+    id BIGSERIAL PRIMARY KEY,
+    operation VARCHAR(20) NOT NULL CHECK (operation IN ('authorization', 'financial', 'reversal')),
+    terminal_id VARCHAR(8) NOT NULL,
+    stan VARCHAR(6) NOT NULL,
+    transmitted_at VARCHAR(10) NOT NULL,
+    card_id BIGINT REFERENCES bank.cards(id) ON DELETE SET NULL,
+    rrn VARCHAR(12),
+    amount NUMERIC(15, 2) NOT NULL,
+    currency_code VARCHAR(3) NOT NULL,
+    merchant TEXT,
+    response_code VARCHAR(2) NOT NULL,
+    approval_code VARCHAR(6),
+    hold_id BIGINT REFERENCES bank.holds(id),
+    entry_id BIGINT REFERENCES bank.journal_entries(id),
+    original_id BIGINT REFERENCES bank.card_authorizations(id),
+    reversed BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (operation, terminal_id, stan, transmitted_at)
+);
+CREATE INDEX IF NOT EXISTS card_authorizations_card_idx ON bank.card_authorizations (card_id, created_at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS bank.limit_usage;
+DROP TABLE IF EXISTS bank.spending_limits;
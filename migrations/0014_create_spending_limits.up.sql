@@ -0,0 +1,32 @@
+-- Лимиты расходных операций. Лимиты банка из конфигурации здесь не хранятся:
+-- в таблице только переопределения для конкретных карт, счетов и пользователей
+-- и собственные лимиты клиентов по картам.
+CREATE TABLE IF NOT EXISTS bank.spending_limits (
+    id BIGSERIAL PRIMARY KEY,
+    scope VARCHAR(10) NOT NULL CHECK (scope IN ('card', 'account', 'user')),
+    scope_id BIGINT NOT NULL,
+    operation VARCHAR(20) NOT NULL CHECK (operation IN ('withdrawal', 'transfer', 'card_purchase')),
+    period VARCHAR(10) NOT NULL CHECK (period IN ('daily', 'monthly')),
+    currency VARCHAR(3) NOT NULL,
+    max_amount NUMERIC(15, 2) CHECK (max_amount >= 0),
+    max_count INTEGER CHECK (max_count >= 0),
+    source VARCHAR(10) NOT NULL CHECK (source IN ('bank', 'customer')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (max_amount IS NOT NULL OR max_count IS NOT NULL),
+    UNIQUE (scope, scope_id, operation, period, currency, source)
+);
+
+-- Израсходованная часть лимитов по периодам. Счётчики ведутся для каждой операции,
+-- даже если лимит не установлен, чтобы лимит, заданный посреди периода, учитывал уже проведённые операции.
+CREATE TABLE IF NOT EXISTS bank.limit_usage (
+    scope VARCHAR(10) NOT NULL,
+    scope_id BIGINT NOT NULL,
+    operation VARCHAR(20) NOT NULL,
+    period VARCHAR(10) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    period_start DATE NOT NULL,
+    amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    count INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (scope, scope_id, operation, period, currency, period_start)
+);
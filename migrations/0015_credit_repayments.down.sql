@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS bank.credit_payments;
+DROP INDEX IF EXISTS bank.payment_schedules_due_idx;
+ALTER TABLE bank.payment_schedules ALTER COLUMN paid DROP NOT NULL;
+ALTER TABLE bank.payment_schedules DROP COLUMN IF EXISTS paid_at;
+ALTER TABLE bank.payment_schedules DROP COLUMN IF EXISTS interest;
+ALTER TABLE bank.payment_schedules DROP COLUMN IF EXISTS principal;
+ALTER TABLE bank.credits DROP COLUMN IF EXISTS disbursement_entry_id;
+ALTER TABLE bank.credits DROP COLUMN IF EXISTS status;
+ALTER TABLE bank.credits DROP COLUMN IF EXISTS currency;
+ALTER TABLE bank.credits DROP COLUMN IF EXISTS account_id;
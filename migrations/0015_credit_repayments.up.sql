@@ -0,0 +1,63 @@
+-- Кредит выдаётся на счёт клиента и погашается списаниями с него же.
+-- У кредитов, выданных до этого, счёта нет: они не выдавались и не погашаются автоматически.
+ALTER TABLE bank.credits ADD COLUMN IF NOT EXISTS account_id BIGINT REFERENCES bank.accounts(id);
+ALTER TABLE bank.credits ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'RUB';
+ALTER TABLE bank.credits ALTER COLUMN currency DROP DEFAULT;
+ALTER TABLE bank.credits ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'current'
+    CONSTRAINT credits_status_check CHECK (status IN ('current', 'closed'));
+ALTER TABLE bank.credits ADD COLUMN IF NOT EXISTS disbursement_entry_id BIGINT REFERENCES bank.journal_entries(id);
+
+-- Разбивка платежа на основной долг и проценты и момент погашения
+ALTER TABLE bank.payment_schedules ADD COLUMN IF NOT EXISTS principal NUMERIC(15, 2);
+ALTER TABLE bank.payment_schedules ADD COLUMN IF NOT EXISTS interest NUMERIC(15, 2);
+ALTER TABLE bank.payment_schedules ADD COLUMN IF NOT EXISTS paid_at TIMESTAMP WITH TIME ZONE;
+
+-- Разбивка существующих графиков: проценты начисляются на остаток долга по месячной ставке,
+-- последний платёж гасит весь оставшийся долг
+WITH RECURSIVE ordered AS (
+    SELECT ps.id, ps.credit_id, ps.amount, c.amount AS credit_amount, c.interest_rate / 1200 AS rate,
+        ROW_NUMBER() OVER (PARTITION BY ps.credit_id ORDER BY ps.payment_date, ps.id) AS n,
+        COUNT(*) OVER (PARTITION BY ps.credit_id) AS total
+    FROM bank.payment_schedules ps
+    JOIN bank.credits c ON c.id = ps.credit_id
+    WHERE ps.principal IS NULL
+),
+split AS (
+    SELECT o.id, o.credit_id, o.n, o.amount, o.credit_amount AS balance,
+        CASE WHEN o.n = o.total THEN LEAST(o.credit_amount, o.amount)
+            ELSE GREATEST(LEAST(o.amount - ROUND(o.credit_amount * o.rate, 2), o.credit_amount), 0) END AS principal
+    FROM ordered o
+    WHERE o.n = 1
+    UNION ALL
+    SELECT o.id, o.credit_id, o.n, o.amount, s.balance - s.principal,
+        CASE WHEN o.n = o.total THEN LEAST(s.balance - s.principal, o.amount)
+            ELSE GREATEST(LEAST(o.amount - ROUND((s.balance - s.principal) * o.rate, 2), s.balance - s.principal), 0) END
+    FROM split s
+    JOIN ordered o ON o.credit_id = s.credit_id AND o.n = s.n + 1
+)
+UPDATE bank.payment_schedules ps
+SET principal = s.principal, interest = s.amount - s.principal
+FROM split s
+WHERE ps.id = s.id;
+
+UPDATE bank.payment_schedules SET principal = amount, interest = 0 WHERE principal IS NULL;
+UPDATE bank.payment_schedules SET paid = FALSE WHERE paid IS NULL;
+ALTER TABLE bank.payment_schedules ALTER COLUMN principal SET NOT NULL;
+ALTER TABLE bank.payment_schedules ALTER COLUMN interest SET NOT NULL;
+ALTER TABLE bank.payment_schedules ALTER COLUMN paid SET NOT NULL;
+CREATE INDEX IF NOT EXISTS payment_schedules_due_idx ON bank.payment_schedules (payment_date) WHERE NOT paid;
+
+-- Платежи по кредитам: списанная сумма и её разбивка
+CREATE TABLE IF NOT EXISTS bank.credit_payments (
+    id BIGSERIAL PRIMARY KEY,
+    credit_id BIGINT NOT NULL REFERENCES bank.credits(id) ON DELETE CASCADE,
+    schedule_id BIGINT REFERENCES bank.payment_schedules(id),
+    account_id BIGINT NOT NULL REFERENCES bank.accounts(id),
+    amount NUMERIC(15, 2) NOT NULL CHECK (amount > 0),
+    principal NUMERIC(15, 2) NOT NULL,
+    interest NUMERIC(15, 2) NOT NULL,
+    entry_id BIGINT NOT NULL REFERENCES bank.journal_entries(id),
+    source VARCHAR(10) NOT NULL CHECK (source IN ('manual', 'auto')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS credit_payments_credit_idx ON bank.credit_payments (credit_id, created_at);
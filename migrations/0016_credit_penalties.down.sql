@@ -0,0 +1,7 @@
+ALTER TABLE bank.credit_payments DROP COLUMN IF EXISTS penalty;
+DROP TABLE IF EXISTS bank.credit_penalties;
+ALTER TABLE bank.payment_schedules ALTER COLUMN penalty DROP NOT NULL;
+DROP TABLE IF EXISTS bank.credit_status_history;
+UPDATE bank.credits SET status = 'current' WHERE status IN ('overdue', 'defaulted');
+ALTER TABLE bank.credits DROP CONSTRAINT IF EXISTS credits_status_check;
+ALTER TABLE bank.credits ADD CONSTRAINT credits_status_check CHECK (status IN ('current', 'closed'));
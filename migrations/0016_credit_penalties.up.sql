@@ -0,0 +1,38 @@
+-- Просроченный и дефолтный кредиты
+ALTER TABLE bank.credits DROP CONSTRAINT IF EXISTS credits_status_check;
+ALTER TABLE bank.credits ADD CONSTRAINT credits_status_check
+    CHECK (status IN ('current', 'overdue', 'defaulted', 'closed'));
+
+-- Журнал смены статусов кредита; статусы меняет только фоновая обработка и погашение
+CREATE TABLE IF NOT EXISTS bank.credit_status_history (
+    id BIGSERIAL PRIMARY KEY,
+    credit_id BIGINT NOT NULL REFERENCES bank.credits(id) ON DELETE CASCADE,
+    from_status VARCHAR(20),
+    to_status VARCHAR(20) NOT NULL,
+    reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS credit_status_history_credit_idx ON bank.credit_status_history (credit_id, created_at);
+
+UPDATE bank.payment_schedules SET penalty = 0 WHERE penalty IS NULL;
+ALTER TABLE bank.payment_schedules ALTER COLUMN penalty SET NOT NULL;
+
+-- Начисления неустойки по просроченным платежам: разовый штраф (fee) и пени за каждый
+-- день просрочки (daily). Уникальность не даёт начислить пени за один день дважды.
+CREATE TABLE IF NOT EXISTS bank.credit_penalties (
+    id BIGSERIAL PRIMARY KEY,
+    credit_id BIGINT NOT NULL REFERENCES bank.credits(id) ON DELETE CASCADE,
+    schedule_id BIGINT NOT NULL REFERENCES bank.payment_schedules(id) ON DELETE CASCADE,
+    kind VARCHAR(10) NOT NULL CHECK (kind IN ('fee', 'daily')),
+    accrual_date DATE NOT NULL,
+    -- base — просроченная сумма, rate — ставка пени в процентах за день
+    base NUMERIC(15, 2) NOT NULL,
+    rate NUMERIC(12, 8),
+    amount NUMERIC(15, 2) NOT NULL CHECK (amount > 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (schedule_id, kind, accrual_date)
+);
+CREATE INDEX IF NOT EXISTS credit_penalties_credit_idx ON bank.credit_penalties (credit_id, accrual_date);
+
+-- Неустойка, погашенная вместе с платежом
+ALTER TABLE bank.credit_payments ADD COLUMN IF NOT EXISTS penalty NUMERIC(15, 2) NOT NULL DEFAULT 0;
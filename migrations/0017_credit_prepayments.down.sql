@@ -0,0 +1,21 @@
+-- Прежние графики без отметки о вытеснении нельзя отличить от действующего, поэтому удаляются.
+-- Откат возможен, только пока на вытесненные платежи не ссылаются платежи и неустойки по кредитам
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1
+        FROM bank.payment_schedules s
+        WHERE s.superseded_at IS NOT NULL
+          AND (EXISTS (SELECT 1 FROM bank.credit_payments p WHERE p.schedule_id = s.id)
+               OR EXISTS (SELECT 1 FROM bank.credit_penalties n WHERE n.schedule_id = s.id))
+    ) THEN
+        RAISE EXCEPTION 'bank.credit_payments or bank.credit_penalties reference superseded payment schedules, migration cannot be reverted';
+    END IF;
+END $$;
+
+ALTER TABLE bank.credit_payments DROP COLUMN IF EXISTS kind;
+DROP INDEX IF EXISTS bank.payment_schedules_due_idx;
+DELETE FROM bank.payment_schedules WHERE superseded_at IS NOT NULL;
+ALTER TABLE bank.payment_schedules DROP COLUMN IF EXISTS superseded_at;
+ALTER TABLE bank.payment_schedules DROP COLUMN IF EXISTS period_start;
+CREATE INDEX IF NOT EXISTS payment_schedules_due_idx ON bank.payment_schedules (payment_date) WHERE NOT paid;
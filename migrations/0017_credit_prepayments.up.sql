@@ -0,0 +1,28 @@
+-- Начало процентного периода платежа и вытеснение платежей новым графиком.
+-- При досрочном погашении неоплаченные платежи не удаляются, а помечаются superseded_at,
+-- чтобы сохранить историю прежних графиков.
+ALTER TABLE bank.payment_schedules ADD COLUMN IF NOT EXISTS period_start DATE;
+ALTER TABLE bank.payment_schedules ADD COLUMN IF NOT EXISTS superseded_at TIMESTAMP WITH TIME ZONE;
+
+-- Период платежа начинается в дату предыдущего платежа, первый — в день выдачи кредита
+UPDATE bank.payment_schedules ps
+SET period_start = p.period_start
+FROM (
+    SELECT s.id, COALESCE(
+        LAG(s.payment_date) OVER (PARTITION BY s.credit_id ORDER BY s.payment_date, s.id),
+        c.created_at::DATE
+    ) AS period_start
+    FROM bank.payment_schedules s
+    JOIN bank.credits c ON c.id = s.credit_id
+) p
+WHERE ps.id = p.id AND ps.period_start IS NULL;
+UPDATE bank.payment_schedules SET period_start = payment_date - INTERVAL '1 month' WHERE period_start IS NULL;
+ALTER TABLE bank.payment_schedules ALTER COLUMN period_start SET NOT NULL;
+
+DROP INDEX IF EXISTS bank.payment_schedules_due_idx;
+CREATE INDEX IF NOT EXISTS payment_schedules_due_idx ON bank.payment_schedules (payment_date)
+    WHERE NOT paid AND superseded_at IS NULL;
+
+-- Вид платежа: по графику, частичное или полное досрочное погашение
+ALTER TABLE bank.credit_payments ADD COLUMN IF NOT EXISTS kind VARCHAR(20) NOT NULL DEFAULT 'scheduled'
+    CHECK (kind IN ('scheduled', 'prepayment', 'payoff'));
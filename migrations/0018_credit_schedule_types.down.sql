@@ -0,0 +1 @@
+ALTER TABLE bank.credits DROP COLUMN IF EXISTS schedule_type;
@@ -0,0 +1,4 @@
+-- Тип графика платежей по кредиту. Прежние кредиты выдавались только с аннуитетным графиком.
+ALTER TABLE bank.credits ADD COLUMN IF NOT EXISTS schedule_type VARCHAR(20) NOT NULL DEFAULT 'annuity'
+    CONSTRAINT credits_schedule_type_check
+    CHECK (schedule_type IN ('annuity', 'differentiated', 'interest_only', 'zero_interest'));
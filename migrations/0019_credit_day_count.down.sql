@@ -0,0 +1,2 @@
+ALTER TABLE bank.payment_schedules DROP COLUMN IF EXISTS remaining_principal;
+ALTER TABLE bank.credits DROP COLUMN IF EXISTS day_count;
@@ -0,0 +1,19 @@
+-- Конвенция подсчёта дней, по которой начисляются проценты. Прежние графики строились
+-- по месячной ставке, что совпадает с 30/360 для полных месяцев.
+ALTER TABLE bank.credits ADD COLUMN IF NOT EXISTS day_count VARCHAR(20) NOT NULL DEFAULT '30/360'
+    CONSTRAINT credits_day_count_check CHECK (day_count IN ('actual/365', 'actual/actual', '30/360'));
+
+-- Остаток основного долга после платежа: сумма основного долга последующих платежей того же графика
+ALTER TABLE bank.payment_schedules ADD COLUMN IF NOT EXISTS remaining_principal NUMERIC(15, 2);
+UPDATE bank.payment_schedules ps
+SET remaining_principal = r.remaining_principal
+FROM (
+    SELECT id, COALESCE(SUM(principal) OVER (
+        PARTITION BY credit_id, superseded_at
+        ORDER BY payment_date DESC, id DESC
+        ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING
+    ), 0) AS remaining_principal
+    FROM bank.payment_schedules
+) r
+WHERE ps.id = r.id AND ps.remaining_principal IS NULL;
+ALTER TABLE bank.payment_schedules ALTER COLUMN remaining_principal SET NOT NULL;
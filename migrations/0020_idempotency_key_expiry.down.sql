@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS bank.idempotency_keys_expires_at_idx;
+ALTER TABLE bank.idempotency_keys DROP COLUMN IF EXISTS expires_at;
@@ -0,0 +1,10 @@
+-- Срок хранения ключа идемпотентности. Незавершённый ключ истекает через таймаут обработки,
+-- чтобы запрос, прерванный падением процесса, можно было повторить; завершённый — через
+-- срок хранения ответа. Истёкшие ключи удаляются фоновой задачей.
+ALTER TABLE bank.idempotency_keys ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP WITH TIME ZONE;
+UPDATE bank.idempotency_keys
+SET expires_at = COALESCE(completed_at + INTERVAL '24 hours', created_at + INTERVAL '5 minutes')
+WHERE expires_at IS NULL;
+ALTER TABLE bank.idempotency_keys ALTER COLUMN expires_at SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at_idx ON bank.idempotency_keys (expires_at);
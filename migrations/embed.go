@@ -0,0 +1,9 @@
+// Package migrations содержит SQL-миграции схемы bank.
+// Файлы именуются NNNN_описание.up.sql и NNNN_описание.down.sql
+// и встраиваются в бинарный файл, поэтому миграции всегда соответствуют версии кода.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS